@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+
+	"github.com/consensys/zslbox/snark"
+	"github.com/consensys/zslbox/zsl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// KeySetAdminServer implements KeySetAdmin server interface as defined in zslbox.proto
+type KeySetAdminServer struct {
+	keySets *snark.KeySets
+}
+
+// NewKeySetAdminServer returns a new KeySetAdmin server managing keySets
+func NewKeySetAdminServer(keySets *snark.KeySets) *KeySetAdminServer {
+	return &KeySetAdminServer{keySets: keySets}
+}
+
+// AddKeySet loads the existing key set of a key directory of the server and makes it the active key set
+// once its keys are loaded
+func (server *KeySetAdminServer) AddKeySet(ctx context.Context, request *zsl.AddKeySetRequest) (*zsl.KeySet, error) {
+	system := snark.PPZKSNARK
+	if request.ProvingSystem == zsl.ProvingSystem_GROTH16 {
+		system = snark.Groth16
+	}
+	keySet, err := server.keySets.Add(ctx, request.KeyDir, uint(request.TreeDepth), system)
+	if err != nil {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "couldn't load key set: %v", err)
+	}
+	toReturn := exportKeySet(keySet, true)
+	log.Infow("AddKeySet", "keyDir", request.KeyDir, "provingSystem", system, "treeDepth", toReturn.TreeDepth, "keyId", toReturn.KeyId)
+	return toReturn, nil
+}
+
+// RetireKeySet unloads a key set, but the active one
+func (server *KeySetAdminServer) RetireKeySet(ctx context.Context, request *zsl.KeySetRequest) (*zsl.KeySet, error) {
+	keySet, err := server.keySets.Retire(request.KeyId)
+	if err != nil {
+		return nil, snarkError("RetireKeySet", err)
+	}
+	log.Infow("RetireKeySet", "keyDir", keySet.KeyDir, "keyId", request.KeyId)
+	return exportKeySet(keySet, false), nil
+}
+
+// ListKeySets returns the loaded key sets, the active one last
+func (server *KeySetAdminServer) ListKeySets(context.Context, *zsl.Void) (*zsl.KeySetList, error) {
+	keySets := server.keySets.List()
+	toReturn := &zsl.KeySetList{}
+	for i, keySet := range keySets {
+		toReturn.KeySets = append(toReturn.KeySets, exportKeySet(keySet, i == len(keySets)-1))
+	}
+	return toReturn, nil
+}
+
+// exportKeySet returns the description of keySet; its key ID is empty while its keys are generating
+func exportKeySet(keySet *snark.KeySet, active bool) *zsl.KeySet {
+	id, _ := keySet.ID()
+	return &zsl.KeySet{
+		KeyId:         id,
+		KeyDir:        keySet.KeyDir,
+		ProvingSystem: provingSystem(keySet),
+		Active:        active,
+		TreeDepth:     uint32(keySet.Backend.Status().TreeDepth),
+	}
+}
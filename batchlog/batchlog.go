@@ -0,0 +1,165 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package batchlog appends batches of fixed size records to a file, all or none: each batch is written
+// with a single write and synced, and a partial batch, from an interrupted write, is truncated when the
+// file is opened. It persists the nullifier registry and the commitment tree.
+package batchlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// ErrHeaderMismatch is returned when opening a log whose header isn't the expected one
+	ErrHeaderMismatch = errors.New("batchlog: unexpected header")
+	// ErrInvalidRecord is returned when appending a record of the wrong size
+	ErrInvalidRecord = errors.New("batchlog: invalid record size")
+)
+
+// Log is an append-only file: a header, then batches of records, each one its number of records (4 bytes,
+// little endian) || its records. It isn't safe for concurrent use.
+type Log struct {
+	file       *os.File
+	recordSize int
+	size       int64 // of the header and the complete batches
+	broken     error // set when a failed append couldn't be truncated
+}
+
+// Open opens (or creates, writing header) the log of records of recordSize bytes in the file at path,
+// and calls read with the records of each complete batch, in order. It returns ErrHeaderMismatch if the
+// file doesn't start with header, and an error if read does.
+func Open(path string, header []byte, recordSize int, read func(records [][]byte) error) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{file: file, recordSize: recordSize}
+	if err := l.read(header, read); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := l.truncate(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// read reads the header and the complete batches of the file, writing the header of an empty file
+func (l *Log) read(header []byte, read func(records [][]byte) error) error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < int64(len(header)) {
+		// new file, or interrupted while writing its header
+		if err := l.file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := l.file.WriteAt(header, 0); err != nil {
+			return err
+		}
+		if err := l.file.Sync(); err != nil {
+			return err
+		}
+		l.size = int64(len(header))
+		return nil
+	}
+	r := bufio.NewReader(l.file)
+	fileHeader := make([]byte, len(header))
+	if _, err := io.ReadFull(r, fileHeader); err != nil {
+		return err
+	}
+	if !bytes.Equal(fileHeader, header) {
+		return ErrHeaderMismatch
+	}
+	l.size = int64(len(header))
+
+	count := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, count); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		batchSize := int64(binary.LittleEndian.Uint32(count)) * int64(l.recordSize)
+		if l.size+int64(len(count))+batchSize > info.Size() {
+			return nil
+		}
+		batch := make([]byte, batchSize)
+		if _, err := io.ReadFull(r, batch); err != nil {
+			return err
+		}
+		records := make([][]byte, 0, len(batch)/l.recordSize)
+		for i := 0; i < len(batch); i += l.recordSize {
+			records = append(records, batch[i:i+l.recordSize])
+		}
+		if err := read(records); err != nil {
+			return fmt.Errorf("%s is corrupted: %v in the batch at offset %d", l.file.Name(), err, l.size)
+		}
+		l.size += int64(len(count) + len(batch))
+	}
+}
+
+// truncate truncates the file to its header and complete batches, and moves to its end
+func (l *Log) truncate() error {
+	if err := l.file.Truncate(l.size); err != nil {
+		return err
+	}
+	_, err := l.file.Seek(l.size, io.SeekStart)
+	return err
+}
+
+// Append appends a batch of records, and syncs the file. If it fails, none of the records is in the
+// file; if the partial batch can't be truncated, the log is broken: Append returns the truncation error,
+// and fails from then on.
+func (l *Log) Append(records [][]byte) error {
+	if l.broken != nil {
+		return l.broken
+	}
+	batch := make([]byte, 4, 4+len(records)*l.recordSize)
+	binary.LittleEndian.PutUint32(batch, uint32(len(records)))
+	for _, record := range records {
+		if len(record) != l.recordSize {
+			return ErrInvalidRecord
+		}
+		batch = append(batch, record...)
+	}
+	_, err := l.file.Write(batch)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// drop what was written of the batch
+		if truncateErr := l.truncate(); truncateErr != nil {
+			l.broken = fmt.Errorf("batchlog: %s is broken, a failed append (%v) couldn't be truncated: %v", l.file.Name(), err, truncateErr)
+			return l.broken
+		}
+		return err
+	}
+	l.size += int64(len(batch))
+	return nil
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	return l.file.Close()
+}
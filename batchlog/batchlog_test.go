@@ -0,0 +1,98 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batchlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func open(t *testing.T, path string, header []byte) (*Log, [][]byte) {
+	var records [][]byte
+	l, err := Open(path, header, 2, func(batch [][]byte) error {
+		records = append(records, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, records
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zslbatchlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	header := []byte("zsl")
+
+	l, _ := open(t, path, header)
+	if err := l.Append([][]byte{{1, 2}, {3, 4}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append([][]byte{{5, 6}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append([][]byte{{7}}); err != ErrInvalidRecord {
+		t.Fatal("expected ErrInvalidRecord, got", err)
+	}
+	l.Close()
+
+	// the records are read back, in order
+	l, records := open(t, path, header)
+	if !bytes.Equal(bytes.Join(records, nil), []byte{1, 2, 3, 4, 5, 6}) {
+		t.Fatal("unexpected records", records)
+	}
+	l.Close()
+	if _, err := Open(path, []byte("zsm"), 2, func([][]byte) error { return nil }); err != ErrHeaderMismatch {
+		t.Fatal("expected ErrHeaderMismatch, got", err)
+	}
+
+	// an interrupted batch is dropped, and the next ones are read
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+	l, records = open(t, path, header)
+	if len(records) != 2 {
+		t.Fatal("expected 2 records, got", records)
+	}
+	if err := l.Append([][]byte{{8, 9}}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	l, records = open(t, path, header)
+	if !bytes.Equal(bytes.Join(records, nil), []byte{1, 2, 3, 4, 8, 9}) {
+		t.Fatal("unexpected records", records)
+	}
+
+	// a failed append that can't be truncated breaks the log
+	l.file.Close()
+	err = l.Append([][]byte{{10, 11}})
+	if err == nil {
+		t.Fatal("append to a closed file should fail")
+	}
+	if err2 := l.Append([][]byte{{10, 11}}); err2 != err {
+		t.Fatal("a broken log should refuse appends, got", err2)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// zslceremony runs a multi-party setup ceremony of the ZSL circuits keys (see snark.Ceremony).
+//
+// The coordinator starts the ceremony (init), each participant contributes to the powers of tau (contribute),
+// the coordinator moves to the circuit secrets phase (next), each participant contributes again, and the
+// coordinator writes the keys (finalize). Anyone can check the transcript (verify).
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/consensys/zslbox/snark"
+	"github.com/consensys/zslbox/zsl"
+)
+
+// -------------------------------------------------------------------------------------------------
+// flags
+var (
+	fDir         = flag.String("dir", "ceremony", "ceremony directory")
+	fTreeDepth   = flag.Uint("tree_depth", zsl.TreeDepth, "Merkle tree depth of the circuits (init)")
+	fParticipant = flag.String("name", "", "participant name (contribute)")
+	fKeyDir      = flag.String("key_dir", "keys", "directory of the proving and verifying keys (finalize)")
+)
+
+const usage = `usage: zslceremony [flags] command
+
+commands:
+  init        start a ceremony in -dir (needs the libzsl backend to export the circuits)
+  contribute  contribute random secrets to the current phase as -name
+  next        move from the powers of tau to the circuit secrets phase
+  finalize    write the keys and their manifest to -key_dir
+  verify      check all the contributions (and the keys, once finalized)
+
+flags:
+`
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flag.Arg(0) == "init" {
+		ceremony, err := initCeremony()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, c := range snark.Circuits {
+			info := ceremony.Circuits[c.String()]
+			log.Printf("%s: %d constraints, %d powers of tau", c, info.Constraints, info.DomainSize)
+		}
+		return
+	}
+
+	ceremony, err := snark.OpenCeremony(*fDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch flag.Arg(0) {
+	case "contribute":
+		if *fParticipant == "" {
+			log.Fatal("missing participant -name")
+		}
+		log.Printf("contributing to the %s phase, this may take a while", ceremony.Phase)
+		if _, err := ceremony.Contribute(*fParticipant, rand.Reader); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("contribution %d recorded in %s; destroy this machine's memory and pass the directory on", len(ceremony.Contributions), *fDir)
+	case "next":
+		if err := ceremony.NextPhase(); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("ceremony moved to the %s phase", ceremony.Phase)
+	case "finalize":
+		if err := os.MkdirAll(*fKeyDir, 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := ceremony.Finalize(*fKeyDir); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("keys written to %s", *fKeyDir)
+	case "verify":
+		if err := ceremony.Verify(); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%d contributions verified (%s phase)", len(ceremony.Contributions), ceremony.Phase)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// initCeremony starts a ceremony with the constraint systems exported by the libzsl backend
+func initCeremony() (*snark.Ceremony, error) {
+	backend, err := snark.Get("libzsl")
+	if err != nil {
+		return nil, err
+	}
+	exporter, ok := backend.(snark.ConstraintSystemExporter)
+	if !ok {
+		return nil, fmt.Errorf("the libzsl backend can't export constraint systems")
+	}
+	constraintSystems := make(map[snark.Circuit][]byte)
+	for _, c := range snark.Circuits {
+		if constraintSystems[c], err = exporter.ConstraintSystem(*fTreeDepth, c); err != nil {
+			return nil, err
+		}
+	}
+	return snark.NewCeremony(*fDir, *fTreeDepth, constraintSystems)
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// zslsolidity generates the Solidity verifier contracts of the ZSL circuits (see verifier.Solidity), from a
+// key set written by ZSLBox or zslceremony: one <Circuit>Verifier.sol file per circuit.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/zslbox/snark"
+	"github.com/consensys/zslbox/verifier"
+	"github.com/consensys/zslbox/zsl"
+)
+
+// -------------------------------------------------------------------------------------------------
+// flags
+var (
+	fKeyDir = flag.String("key_dir", "keys", "directory of the proving and verifying keys, and their manifest")
+	fOut    = flag.String("out", ".", "output directory of the Solidity contracts")
+)
+
+var circuits = map[snark.Circuit]zsl.Circuit{
+	snark.Shielding:   zsl.Circuit_SHIELDING,
+	snark.Unshielding: zsl.Circuit_UNSHIELDING,
+	snark.Transfer:    zsl.Circuit_TRANSFER,
+	snark.Transfer1x1: zsl.Circuit_TRANSFER_1X1,
+	snark.Transfer1x2: zsl.Circuit_TRANSFER_1X2,
+	snark.Transfer4x2: zsl.Circuit_TRANSFER_4X2,
+	snark.Transfer4x4: zsl.Circuit_TRANSFER_4X4,
+
+	snark.PublicTransfer:    zsl.Circuit_PUBLIC_TRANSFER,
+	snark.PublicTransfer1x1: zsl.Circuit_PUBLIC_TRANSFER_1X1,
+	snark.PublicTransfer1x2: zsl.Circuit_PUBLIC_TRANSFER_1X2,
+	snark.PublicTransfer4x2: zsl.Circuit_PUBLIC_TRANSFER_4X2,
+	snark.PublicTransfer4x4: zsl.Circuit_PUBLIC_TRANSFER_4X4,
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Parse()
+
+	manifest, err := snark.ReadManifest(*fKeyDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(*fOut, 0755); err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range snark.Circuits {
+		vk, err := verifyingKey(c, manifest)
+		if err != nil {
+			log.Fatalf("%s: %v", c, err)
+		}
+		source, err := verifier.Solidity(vk)
+		if err != nil {
+			log.Fatalf("%s: %v", c, err)
+		}
+		path := filepath.Join(*fOut, verifier.ContractName(vk.Circuit)+".sol")
+		if err := ioutil.WriteFile(path, source, 0644); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: %s (%s)", c, path, manifest.ProvingSystem)
+	}
+}
+
+// verifyingKey returns the verifying key of circuit, checked against the manifest, with the alpha and beta
+// points of its proving key for Groth16 key sets
+func verifyingKey(circuit snark.Circuit, manifest *snark.Manifest) (*zsl.VerifyingKey, error) {
+	pkPath, vkPath := snark.KeyFiles(*fKeyDir, circuit)
+	raw, err := ioutil.ReadFile(vkPath)
+	if err != nil {
+		return nil, err
+	}
+	keys, ok := manifest.Circuits[circuit.String()]
+	if !ok || keys.VerifyingKey != snark.Fingerprint(raw) {
+		return nil, fmt.Errorf("%s doesn't match the key set manifest", vkPath)
+	}
+	vk, err := snark.ExportVerifyingKey(raw, manifest.ProvingSystem)
+	if err != nil {
+		return nil, err
+	}
+	vk.Circuit = circuits[circuit]
+	if manifest.ProvingSystem != snark.Groth16 {
+		return vk, nil
+	}
+	pk, err := os.Open(pkPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pk.Close()
+	if err := snark.ExportGroth16AlphaBeta(bufio.NewReader(pk), vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commitment keeps the note commitments of a ledger in a zsl.Tree shared by its clients, so that
+// light clients can obtain the root and the authentication paths of their notes from a trusted box rather
+// than each keeping a copy of the tree.
+//
+// A Tree is kept in memory, or in memory and in an append-only file (OpenTree).
+package commitment
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/consensys/zslbox/batchlog"
+	"github.com/consensys/zslbox/zsl"
+)
+
+// ErrInvalidCommitment is returned for a commitment that isn't zsl.HashSize bytes
+var ErrInvalidCommitment = errors.New("invalid commitment size")
+
+// New returns the commitments of hashes, or ErrInvalidCommitment if one isn't zsl.HashSize bytes
+func New(hashes ...[]byte) ([]zsl.Hash, error) {
+	toReturn := make([]zsl.Hash, len(hashes))
+	for i, h := range hashes {
+		if len(h) != zsl.HashSize {
+			return nil, ErrInvalidCommitment
+		}
+		toReturn[i] = zsl.NewHash(h)
+	}
+	return toReturn, nil
+}
+
+// Tree is a zsl.Tree of commitments, optionally persisted in a file. It is safe for concurrent use.
+type Tree struct {
+	lock sync.RWMutex
+	tree *zsl.Tree
+	log  *batchlog.Log // nil if the tree is in memory only
+}
+
+// NewTree returns an empty tree of depth, in memory
+func NewTree(depth uint) *Tree {
+	return &Tree{tree: zsl.NewTree(depth)}
+}
+
+// OpenTree opens (or creates) the tree of depth in the file at path (a batchlog.Log), and reads its
+// commitments. The file starts with the depth of the tree (4 bytes, little endian), then each Append adds
+// a batch of commitments. A partial batch at the end of the file, from an interrupted Append, is
+// truncated: none of its commitments was appended.
+func OpenTree(depth uint, path string) (*Tree, error) {
+	t := &Tree{tree: zsl.NewTree(depth)}
+	header := make([]byte, 4)
+	binary.LittleEndian.PutUint32(header, uint32(depth))
+	var err error
+	t.log, err = batchlog.Open(path, header, zsl.HashSize, func(records [][]byte) error {
+		for _, record := range records {
+			if _, err := t.tree.AddCommitment(zsl.NewHash(record)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == batchlog.ErrHeaderMismatch {
+		return nil, fmt.Errorf("%s isn't a tree of depth %d", path, depth)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Append appends commitments to the tree, all or none: if one is already in the tree (or twice in
+// commitments) it returns zsl.ErrCommitmentExists, if they don't fit in the tree zsl.ErrTreeFull. It
+// returns the new root and size of the tree, the first commitment having index size-len(commitments).
+func (t *Tree) Append(commitments []zsl.Hash) (zsl.Hash, uint, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	batch := make(map[zsl.Hash]bool, len(commitments))
+	for _, cm := range commitments {
+		if batch[cm] || t.tree.Contains(cm) {
+			return zsl.Hash{}, 0, zsl.ErrCommitmentExists
+		}
+		batch[cm] = true
+	}
+	if uint(len(commitments)) > t.tree.Capacity()-t.tree.Size() {
+		return zsl.Hash{}, 0, zsl.ErrTreeFull
+	}
+	if t.log != nil {
+		records := make([][]byte, len(commitments))
+		for i := range commitments {
+			records[i] = commitments[i][:]
+		}
+		if err := t.log.Append(records); err != nil {
+			return zsl.Hash{}, 0, err
+		}
+	}
+	for _, cm := range commitments {
+		if _, err := t.tree.AddCommitment(cm); err != nil {
+			// unreachable: the commitments were checked above
+			panic(err)
+		}
+	}
+	return t.tree.Root(), t.tree.Size(), nil
+}
+
+// Depth returns the depth of the tree
+func (t *Tree) Depth() uint {
+	return t.tree.Depth()
+}
+
+// Root returns the root and the size of the tree
+func (t *Tree) Root() (zsl.Hash, uint) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.tree.Root(), t.tree.Size()
+}
+
+// RootAt returns the root of the tree when it had its first size commitments, or
+// zsl.ErrCommitmentNotFound if it has less
+func (t *Tree) RootAt(size uint) (zsl.Hash, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.tree.RootAt(size)
+}
+
+// Witness is the authentication path of a commitment, from leaf to root
+type Witness struct {
+	Index      uint
+	Commitment zsl.Hash
+	Path       [][]byte
+	Root       zsl.Hash // of the tree of Size commitments the path leads to
+	Size       uint
+}
+
+// Witness returns the witness of commitment, or zsl.ErrCommitmentNotFound
+func (t *Tree) Witness(commitment zsl.Hash) (*Witness, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	index, path, err := t.tree.GetWitnesses(commitment)
+	if err != nil {
+		return nil, err
+	}
+	return &Witness{Index: index, Commitment: commitment, Path: path, Root: t.tree.Root(), Size: t.tree.Size()}, nil
+}
+
+// WitnessAt returns the witness of the commitment of index, or zsl.ErrCommitmentNotFound
+func (t *Tree) WitnessAt(index uint) (*Witness, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	commitment, err := t.tree.Commitment(index)
+	if err != nil {
+		return nil, err
+	}
+	path, err := t.tree.GetWitnessesAt(index)
+	if err != nil {
+		return nil, err
+	}
+	return &Witness{Index: index, Commitment: commitment, Path: path, Root: t.tree.Root(), Size: t.tree.Size()}, nil
+}
+
+// Close closes the file of the tree
+func (t *Tree) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.log == nil {
+		return nil
+	}
+	return t.log.Close()
+}
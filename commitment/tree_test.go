@@ -0,0 +1,156 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commitment
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+func randomCommitments(n int) []zsl.Hash {
+	toReturn := make([]zsl.Hash, n)
+	for i := range toReturn {
+		toReturn[i] = zsl.NewHash(zsl.RandomBytes(zsl.HashSize))
+	}
+	return toReturn
+}
+
+func TestAppend(t *testing.T) {
+	tree := NewTree(2) // maxElements = 2^2 = 4
+	commitments := randomCommitments(3)
+
+	root, size, err := tree.Append(commitments[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 2 {
+		t.Fatal("expected size 2, got", size)
+	}
+
+	// an append is all or none
+	if _, _, err := tree.Append([]zsl.Hash{commitments[2], commitments[0]}); err != zsl.ErrCommitmentExists {
+		t.Fatal("expected ErrCommitmentExists, got", err)
+	}
+	if _, _, err := tree.Append([]zsl.Hash{commitments[2], commitments[2]}); err != zsl.ErrCommitmentExists {
+		t.Fatal("expected ErrCommitmentExists, got", err)
+	}
+	if _, _, err := tree.Append(randomCommitments(3)); err != zsl.ErrTreeFull {
+		t.Fatal("expected ErrTreeFull, got", err)
+	}
+	if r, s := tree.Root(); r != root || s != 2 {
+		t.Fatal("commitments of a failed append were appended")
+	}
+
+	if _, _, err := tree.Append(commitments[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if rootAt, err := tree.RootAt(2); err != nil || rootAt != root {
+		t.Fatal("unexpected root at size 2", err)
+	}
+
+	// witnesses by commitment and by index match, and lead to the root
+	root, _ = tree.Root()
+	witness, err := tree.Witness(commitments[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessAt, err := tree.WitnessAt(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if witness.Index != 1 || witnessAt.Commitment != commitments[1] || witness.Root != root || witness.Size != 3 {
+		t.Fatal("unexpected witness", witness, witnessAt)
+	}
+	for i := range witness.Path {
+		if !bytes.Equal(witness.Path[i], witnessAt.Path[i]) {
+			t.Fatal("witnesses by index and by commitment don't match")
+		}
+	}
+	if _, err := tree.WitnessAt(3); err != zsl.ErrCommitmentNotFound {
+		t.Fatal("expected ErrCommitmentNotFound, got", err)
+	}
+}
+
+func TestOpenTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zslcommitments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "commitments")
+
+	tree, err := OpenTree(4, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitments := randomCommitments(5)
+	if _, _, err := tree.Append(commitments[:3]); err != nil {
+		t.Fatal(err)
+	}
+	root, _, err := tree.Append(commitments[3:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the commitments are read back, in order
+	tree, err = OpenTree(4, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, s := tree.Root(); r != root || s != 5 {
+		t.Fatal("commitments not read back", s)
+	}
+	tree.Close()
+
+	if _, err := OpenTree(5, path); err == nil {
+		t.Fatal("opened a tree of depth 4 with depth 5")
+	}
+
+	// an interrupted batch is dropped, and the next ones are read
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+	tree, err = OpenTree(4, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, s := tree.Root(); s != 3 {
+		t.Fatal("expected 3 commitments, got", s)
+	}
+	if _, _, err := tree.Append(commitments[3:]); err != nil {
+		t.Fatal(err)
+	}
+	tree.Close()
+	tree, err = OpenTree(4, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if r, s := tree.Root(); r != root || s != 5 {
+		t.Fatal("commitments appended after an interrupted batch not read back", s)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/consensys/zslbox/commitment"
+	"github.com/consensys/zslbox/zsl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// CommitmentTreeServer implements CommitmentTree server interface as defined in zslbox.proto
+type CommitmentTreeServer struct {
+	tree *commitment.Tree
+	// appends is true if AppendCommitments is served (on the admin address): any client could fill the tree
+	appends bool
+}
+
+// NewCommitmentTreeServer returns a new CommitmentTree server of tree, serving AppendCommitments if appends
+// is true (PermissionDenied otherwise)
+func NewCommitmentTreeServer(tree *commitment.Tree, appends bool) *CommitmentTreeServer {
+	return &CommitmentTreeServer{tree: tree, appends: appends}
+}
+
+// AppendCommitments appends commitments to the tree, all or none
+func (server *CommitmentTreeServer) AppendCommitments(ctx context.Context, request *zsl.Commitments) (*zsl.TreeState, error) {
+	if !server.appends {
+		return nil, grpc.Errorf(codes.PermissionDenied, "AppendCommitments is only served on the admin address (-admin)")
+	}
+	commitments, err := commitment.New(request.Commitments...)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	root, size, err := server.tree.Append(commitments)
+	if err != nil {
+		return nil, treeError(err)
+	}
+	toReturn := server.treeState(root, size)
+	log.Debugw("AppendCommitments",
+		"commitments", hexes(request.Commitments),
+		"root", hex.EncodeToString(toReturn.Root),
+		"size", toReturn.Size,
+	)
+	return toReturn, nil
+}
+
+// GetRoot returns the root of the tree
+func (server *CommitmentTreeServer) GetRoot(ctx context.Context, request *zsl.Void) (*zsl.TreeState, error) {
+	return server.treeState(server.tree.Root()), nil
+}
+
+// GetRootAt returns the root of the tree when it had its first request.Size commitments
+func (server *CommitmentTreeServer) GetRootAt(ctx context.Context, request *zsl.RootAtRequest) (*zsl.TreeState, error) {
+	root, err := server.tree.RootAt(uint(request.Size))
+	if err != nil {
+		return nil, treeError(err)
+	}
+	return server.treeState(root, uint(request.Size)), nil
+}
+
+// GetWitness returns the tree index and tree path of a commitment, by commitment if set, by tree index otherwise
+func (server *CommitmentTreeServer) GetWitness(ctx context.Context, request *zsl.WitnessRequest) (*zsl.Witness, error) {
+	var witness *commitment.Witness
+	if len(request.Commitment) != 0 {
+		commitments, err := commitment.New(request.Commitment)
+		if err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		witness, err = server.tree.Witness(commitments[0])
+		if err != nil {
+			return nil, treeError(err)
+		}
+	} else {
+		var err error
+		witness, err = server.tree.WitnessAt(uint(request.TreeIndex))
+		if err != nil {
+			return nil, treeError(err)
+		}
+	}
+	return &zsl.Witness{
+		TreeIndex:  uint64(witness.Index),
+		TreePath:   witness.Path,
+		Commitment: witness.Commitment[:],
+		Root:       witness.Root[:],
+		Size:       uint64(witness.Size),
+	}, nil
+}
+
+func (server *CommitmentTreeServer) treeState(root zsl.Hash, size uint) *zsl.TreeState {
+	return &zsl.TreeState{Root: root[:], Size: uint64(size), Depth: uint64(server.tree.Depth())}
+}
+
+// treeError maps an error of the commitment tree to a gRPC error
+func treeError(err error) error {
+	switch err {
+	case zsl.ErrCommitmentExists:
+		return grpc.Errorf(codes.AlreadyExists, "%v", err)
+	case zsl.ErrCommitmentNotFound:
+		return grpc.Errorf(codes.NotFound, "%v", err)
+	case zsl.ErrTreeFull:
+		return grpc.Errorf(codes.ResourceExhausted, "%v", err)
+	}
+	log.Errorw("commitment tree failed", "err", err)
+	return grpc.Errorf(codes.Internal, "%v", err)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/consensys/zslbox/snark"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthPollInterval is the interval at which the active key set status is reported to the health service
+const healthPollInterval = time.Second
+
+// zslboxService is the health service name reporting if all circuits are ready;
+// each circuit also has its own service name (shielding, unshielding, transfer, transfer_1x1, ...)
+const zslboxService = "zsl.ZSLBox"
+
+// watchHealth periodically reports the state of the active key set to the grpc.health.v1 server
+func watchHealth(keySets *snark.KeySets, healthServer *health.Server) {
+	for {
+		status := keySets.Status()
+		for _, c := range snark.Circuits {
+			healthServer.SetServingStatus(c.String(), servingStatus(status.Keys[c] == snark.KeysReady))
+		}
+		healthServer.SetServingStatus(zslboxService, servingStatus(status.Ready()))
+		time.Sleep(healthPollInterval)
+	}
+}
+
+func servingStatus(ready bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ready {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// healthzHandler answers 200 as long as the process serves HTTP requests (liveness)
+func healthzHandler(resp http.ResponseWriter, req *http.Request) {
+	fmt.Fprintln(resp, "ok")
+}
+
+// readyzHandler answers 200 once the keys of all circuits of the active key set are loaded, 503 otherwise
+// (readiness). The body lists the state of each circuit's keys.
+func readyzHandler(keySets *snark.KeySets) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		status := keySets.Status()
+		if !status.Ready() {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+		}
+		for _, c := range snark.Circuits {
+			fmt.Fprintf(resp, "%s: %s\n", c, status.Keys[c])
+		}
+	}
+}
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"runtime"
 
+	"github.com/consensys/zslbox/commitment"
+	"github.com/consensys/zslbox/nullifier"
 	"github.com/consensys/zslbox/snark"
 	"github.com/consensys/zslbox/zsl"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // -------------------------------------------------------------------------------------------------
@@ -20,6 +28,26 @@ var (
 	fKeyFile   = flag.String("key_file", "server.key", "TLS key file")
 	fHTTPPort  = flag.Int("http", 9001, "gRPC server http port")
 	fHTTPSPort = flag.Int("https", 9000, "gRPC server https port")
+
+	fKeyDir        = flag.String("key_dir", defaultKeyDir(), "directory of the proving and verifying keys (env ZSLBOX_KEY_DIR)")
+	fSnarkBackend  = flag.String("snark_backend", snark.DefaultBackend(), fmt.Sprintf("snark backend %v", snark.Backends()))
+	fTreeDepth     = flag.Uint("tree_depth", zsl.TreeDepth, "Merkle tree depth of generated keys (existing keys use the tree depth of their manifest)")
+	fProvingSystem = flag.String("proving_system", snark.PPZKSNARK.String(), fmt.Sprintf("proving system of the keys %v", snark.ProvingSystems))
+	fSnarkWorkers  = flag.Int("snark_workers", 0, "number of snark worker processes, restarted if they crash (0: prove in process)")
+	fSnarkWorker   = flag.Bool("snark_worker", false, "run as a snark worker process (started by zslbox when snark_workers > 0)")
+	fAdmin         = flag.Bool("admin", false, "serve the KeySetAdmin service, to add and retire key sets, and AppendCommitments on admin_addr")
+	fAdminAddr     = flag.String("admin_addr", "localhost:9002", "gRPC (TLS) address of the admin services, keep it local")
+
+	fNullifierStore  = flag.String("nullifier_store", "", "store of the NullifierRegistry service, memory or file (empty: no registry)")
+	fNullifierFile   = flag.String("nullifier_file", "nullifiers.log", "file of the file nullifier store")
+	fCheckNullifiers = flag.Bool("check_nullifiers", false, "reject the proofs of nullifiers already seen in verifications, and record the nullifiers of the valid ones (requires nullifier_store)")
+
+	fCommitmentTree     = flag.String("commitment_tree", "", "store of the CommitmentTree service, memory or file (empty: no tree)")
+	fCommitmentTreeFile = flag.String("commitment_tree_file", "commitments.log", "file of the file commitment tree")
+
+	fMaxJobs   = flag.Int("max_jobs", runtime.NumCPU(), "maximum number of concurrent proofs and verifications")
+	fMaxProofs = flag.Int("max_proofs", 1, "maximum number of concurrent proofs per circuit (0: no limit but max_jobs)")
+	fQueueSize = flag.Int("queue_size", 64, "maximum number of requests waiting for a prover or verifier")
 )
 
 // -------------------------------------------------------------------------------------------------
@@ -52,29 +80,87 @@ func main() {
 	defer log.Warn("stopping zslbox")
 	defer logger.Sync() // flushes buffer, if any
 
-	// Init snark module (will create params if mounted volume on /keys doesn't exist. )
-	log.Info("initializing libzsl")
-	snark.Init(zsl.TreeDepth, "/keys")
-
 	// Parse flags
 	flag.Parse()
 
+	// Init snark backend (will create params if absent from key directory)
+	backend, err := snark.Get(*fSnarkBackend)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *fSnarkWorker {
+		if err := snark.ServeWorker(backend); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	provingSystem, err := snark.ParseProvingSystem(*fProvingSystem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Infow("initializing snark backend", "backend", *fSnarkBackend, "keyDir", *fKeyDir, "provingSystem", provingSystem)
+	keySets := snark.NewKeySets(*fTreeDepth, keySetBackends(backend))
+	keySet, err := keySets.Load(*fKeyDir, 0, provingSystem)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// nullifier registry
+	registry, err := newNullifierRegistry()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var checkedNullifiers *nullifier.Registry
+	if *fCheckNullifiers {
+		if registry == nil {
+			log.Fatal("check_nullifiers requires a nullifier_store")
+		}
+		checkedNullifiers = registry
+	}
+
+	// commitment tree, of the tree depth of the keys
+	tree, err := newCommitmentTree(keySet.Backend.Status().TreeDepth)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// init gRPC server
 	grpcServer := grpc.NewServer()
-	zsl.RegisterZSLBoxServer(grpcServer, NewZSLServer())
+	zsl.RegisterZSLBoxServer(grpcServer, NewZSLServer(keySets, newScheduler(), checkedNullifiers))
+	if registry != nil {
+		zsl.RegisterNullifierRegistryServer(grpcServer, NewNullifierRegistryServer(registry))
+	}
+	if tree != nil {
+		zsl.RegisterCommitmentTreeServer(grpcServer, NewCommitmentTreeServer(tree, false))
+	}
+
+	// admin gRPC server, on its own listener: any client of the public ones could rotate keys or fill the tree
+	if *fAdmin {
+		if err := serveAdmin(keySets, tree); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// grpc.health.v1 service, reporting per circuit key loading state
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go watchHealth(keySets, healthServer)
 
 	wrappedServer := grpcweb.WrapServer(grpcServer, grpcweb.WithWebsockets(true))
-	handler := func(resp http.ResponseWriter, req *http.Request) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/healthz", healthzHandler)
+	handler.HandleFunc("/readyz", readyzHandler(keySets))
+	handler.HandleFunc("/", func(resp http.ResponseWriter, req *http.Request) {
 		wrappedServer.ServeHTTP(resp, req)
-	}
+	})
 
 	httpServer := http.Server{
 		Addr:    fmt.Sprintf(":%d", *fHTTPPort),
-		Handler: http.HandlerFunc(handler),
+		Handler: handler,
 	}
 	httpsServer := http.Server{
 		Addr:    fmt.Sprintf(":%d", *fHTTPSPort),
-		Handler: http.HandlerFunc(handler),
+		Handler: handler,
 	}
 
 	log.Info("starting grpc server")
@@ -85,6 +171,129 @@ func main() {
 	log.Fatal(httpsServer.ListenAndServeTLS(*fCertFile, *fKeyFile))
 }
 
+// serveAdmin serves the KeySetAdmin service, and the CommitmentTree service with AppendCommitments if tree
+// isn't nil, on admin_addr
+func serveAdmin(keySets *snark.KeySets, tree *commitment.Tree) error {
+	creds, err := credentials.NewServerTLSFromFile(*fCertFile, *fKeyFile)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", *fAdminAddr)
+	if err != nil {
+		return err
+	}
+	adminServer := grpc.NewServer(grpc.Creds(creds))
+	zsl.RegisterKeySetAdminServer(adminServer, NewKeySetAdminServer(keySets))
+	if tree != nil {
+		zsl.RegisterCommitmentTreeServer(adminServer, NewCommitmentTreeServer(tree, true))
+	}
+	log.Infow("starting admin grpc server", "addr", *fAdminAddr)
+	go func() {
+		log.Fatal(adminServer.Serve(listener))
+	}()
+	return nil
+}
+
+// keySetBackends returns the function creating the backend of a new key set: a pool of worker processes
+// if snark_workers is set, backend for the first key set otherwise, and a new instance of it for the
+// others if it supports it
+func keySetBackends(backend snark.Backend) func() (snark.Backend, error) {
+	first := true
+	return func() (snark.Backend, error) {
+		if *fSnarkWorkers > 0 {
+			return newPool()
+		}
+		if first {
+			first = false
+			return backend, nil
+		}
+		if instancer, ok := backend.(snark.Instancer); ok {
+			return instancer.NewInstance(), nil
+		}
+		return nil, fmt.Errorf("the %s backend loads one key set per process, set snark_workers to load more", *fSnarkBackend)
+	}
+}
+
+// newPool returns a pool of snark worker processes configured from the flags, running this executable
+func newPool() (*snark.Pool, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	log.Infow("snark worker pool", "workers", *fSnarkWorkers)
+	return snark.NewPool(snark.PoolConfig{
+		Command: executable,
+		Args:    []string{"-snark_worker", "-snark_backend", *fSnarkBackend},
+		Workers: *fSnarkWorkers,
+	}), nil
+}
+
+// newScheduler returns a snark scheduler configured from the flags
+func newScheduler() *snark.Scheduler {
+	config := snark.SchedulerConfig{
+		MaxJobs:   *fMaxJobs,
+		MaxProofs: make(map[snark.Circuit]int),
+		QueueSize: *fQueueSize,
+	}
+	if *fMaxProofs > 0 {
+		for _, c := range snark.Circuits {
+			config.MaxProofs[c] = *fMaxProofs
+		}
+	}
+	log.Infow("snark scheduler", "maxJobs", config.MaxJobs, "maxProofs", config.MaxProofs, "queueSize", config.QueueSize)
+	return snark.NewScheduler(config)
+}
+
+// newNullifierRegistry returns the nullifier registry of the nullifier_store flag, nil if it's empty
+func newNullifierRegistry() (*nullifier.Registry, error) {
+	var store nullifier.Store
+	switch *fNullifierStore {
+	case "":
+		return nil, nil
+	case "memory":
+		store = nullifier.NewMemoryStore()
+	case "file":
+		fileStore, err := nullifier.OpenFileStore(*fNullifierFile)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+	default:
+		return nil, fmt.Errorf("unknown nullifier store %q (supported: memory, file)", *fNullifierStore)
+	}
+	log.Infow("nullifier registry", "store", *fNullifierStore, "checkNullifiers", *fCheckNullifiers)
+	return nullifier.NewRegistry(store), nil
+}
+
+// newCommitmentTree returns the commitment tree of depth of the commitment_tree flag, nil if it's empty
+func newCommitmentTree(depth uint) (*commitment.Tree, error) {
+	var tree *commitment.Tree
+	switch *fCommitmentTree {
+	case "":
+		return nil, nil
+	case "memory":
+		tree = commitment.NewTree(depth)
+	case "file":
+		var err error
+		if tree, err = commitment.OpenTree(depth, *fCommitmentTreeFile); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown commitment tree %q (supported: memory, file)", *fCommitmentTree)
+	}
+	root, size := tree.Root()
+	log.Infow("commitment tree", "store", *fCommitmentTree, "depth", depth, "size", size, "root", hex.EncodeToString(root[:]))
+	return tree, nil
+}
+
+// defaultKeyDir returns ZSLBOX_KEY_DIR if set, /keys otherwise
+func defaultKeyDir() string {
+	if keyDir := os.Getenv("ZSLBOX_KEY_DIR"); keyDir != "" {
+		return keyDir
+	}
+	return "/keys"
+}
+
 func newZapConfig() zap.Config {
 	return zap.Config{
 		Level:       zap.NewAtomicLevelAt(zap.DebugLevel),
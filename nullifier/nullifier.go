@@ -0,0 +1,155 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nullifier records the nullifiers of accepted proofs in a Registry, so that a note can't be spent
+// twice (its spend nullifier was seen) and a rho can't be reused (its send nullifier was seen).
+//
+// The nullifiers are kept in a pluggable Store: MemoryStore, or FileStore on disk.
+package nullifier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+var (
+	// ErrSeen is returned when a nullifier was already seen
+	ErrSeen = errors.New("nullifier already seen")
+	// ErrInvalidNullifier is returned for a nullifier that isn't zsl.HashSize bytes
+	ErrInvalidNullifier = errors.New("invalid nullifier size")
+)
+
+// Kind is the kind of a nullifier
+type Kind byte
+
+const (
+	// Spend nullifiers, SHA256(0x01 || rho || sk), are revealed when a note is spent
+	Spend Kind = iota
+	// Send nullifiers, SHA256(0x00 || rho), are revealed when a note is created
+	Send
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Spend:
+		return "spend"
+	case Send:
+		return "send"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Nullifier is a spend or send nullifier
+type Nullifier struct {
+	Kind Kind
+	Hash zsl.Hash
+}
+
+// New returns the nullifiers of kind of hashes, or ErrInvalidNullifier if one isn't zsl.HashSize bytes
+func New(kind Kind, hashes ...[]byte) ([]Nullifier, error) {
+	toReturn := make([]Nullifier, len(hashes))
+	for i, h := range hashes {
+		if len(h) != zsl.HashSize {
+			return nil, ErrInvalidNullifier
+		}
+		toReturn[i] = Nullifier{Kind: kind, Hash: zsl.NewHash(h)}
+	}
+	return toReturn, nil
+}
+
+// Registry records the nullifiers of accepted proofs in a Store. It is safe for concurrent use.
+type Registry struct {
+	lock  sync.Mutex
+	store Store
+}
+
+// NewRegistry returns a registry of the nullifiers of store
+func NewRegistry(store Store) *Registry {
+	return &Registry{store: store}
+}
+
+// Check returns true for each nullifier already seen
+func (r *Registry) Check(nullifiers []Nullifier) ([]bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.check(nullifiers)
+}
+
+// Commit records nullifiers, all or none: if one was already seen, or is twice in nullifiers, it records
+// none and returns ErrSeen. It returns true for each nullifier seen before the commit (or earlier in
+// nullifiers), checked in the same critical section as the commit.
+func (r *Registry) Commit(nullifiers []Nullifier) ([]bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	seen, err := r.check(nullifiers)
+	if err != nil {
+		return nil, err
+	}
+	batch := make(map[Nullifier]bool, len(nullifiers))
+	for i, n := range nullifiers {
+		if batch[n] {
+			seen[i] = true
+		}
+		batch[n] = true
+	}
+	for _, s := range seen {
+		if s {
+			return seen, ErrSeen
+		}
+	}
+	return seen, r.store.Add(nullifiers)
+}
+
+// Verify returns ErrSeen if one of nullifiers was already seen, the result of verify otherwise, recording
+// nullifiers if it's true. verify runs without holding the registry: of concurrent verifications of the
+// same nullifier, the first to commit is valid, the others return ErrSeen.
+func (r *Registry) Verify(nullifiers []Nullifier, verify func() bool) (bool, error) {
+	seen, err := r.Check(nullifiers)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range seen {
+		if s {
+			return false, ErrSeen
+		}
+	}
+	if !verify() {
+		return false, nil
+	}
+	if _, err := r.Commit(nullifiers); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Close closes the store of the registry
+func (r *Registry) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.store.Close()
+}
+
+func (r *Registry) check(nullifiers []Nullifier) ([]bool, error) {
+	toReturn := make([]bool, len(nullifiers))
+	for i, n := range nullifiers {
+		var err error
+		if toReturn[i], err = r.store.Contains(n); err != nil {
+			return nil, err
+		}
+	}
+	return toReturn, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nullifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+func randomNullifiers(t *testing.T, kind Kind, n int) []Nullifier {
+	hashes := make([][]byte, n)
+	for i := range hashes {
+		hashes[i] = zsl.RandomBytes(zsl.HashSize)
+	}
+	toReturn, err := New(kind, hashes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return toReturn
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(NewMemoryStore())
+	spend, send := randomNullifiers(t, Spend, 2), randomNullifiers(t, Send, 2)
+	if _, err := registry.Commit(append(spend, send...)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same hash of another kind is another nullifier
+	other := []Nullifier{{Kind: Send, Hash: spend[0].Hash}, randomNullifiers(t, Spend, 1)[0]}
+	seen, err := registry.Check(append(other, spend[1], send[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen[0] || seen[1] || !seen[2] || !seen[3] {
+		t.Fatal("unexpected seen nullifiers", seen)
+	}
+
+	// a commit is all or none
+	if seen, err := registry.Commit(append(other, send[1])); err != ErrSeen || seen[0] || seen[1] || !seen[2] {
+		t.Fatal("expected ErrSeen for the last nullifier, got", seen, err)
+	}
+	if seen, err := registry.Commit([]Nullifier{other[0], other[0]}); err != ErrSeen || seen[0] || !seen[1] {
+		t.Fatal("expected ErrSeen for the duplicate nullifier, got", seen, err)
+	}
+	if seen, err := registry.Check(other); err != nil || seen[0] || seen[1] {
+		t.Fatal("nullifiers of a failed commit were recorded", seen, err)
+	}
+
+	if _, err := New(Spend, make([]byte, zsl.HashSize-1)); err != ErrInvalidNullifier {
+		t.Fatal("expected ErrInvalidNullifier, got", err)
+	}
+}
+
+func TestRegistryVerify(t *testing.T) {
+	registry := NewRegistry(NewMemoryStore())
+	nullifiers := randomNullifiers(t, Spend, 2)
+
+	// the nullifiers of invalid proofs aren't recorded
+	valid, err := registry.Verify(nullifiers, func() bool { return false })
+	if err != nil || valid {
+		t.Fatal("expected invalid proof, got", valid, err)
+	}
+	valid, err = registry.Verify(nullifiers, func() bool { return true })
+	if err != nil || !valid {
+		t.Fatal("expected valid proof, got", valid, err)
+	}
+	called := false
+	if _, err := registry.Verify(nullifiers[1:], func() bool { called = true; return true }); err != ErrSeen {
+		t.Fatal("expected ErrSeen, got", err)
+	}
+	if called {
+		t.Fatal("proof of seen nullifiers was verified")
+	}
+
+	// of concurrent verifications of the same nullifier, one is valid
+	nullifiers = randomNullifiers(t, Send, 1)
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	nbValid := 0
+	start := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			valid, err := registry.Verify(nullifiers, func() bool { <-start; return true })
+			if err != nil && err != ErrSeen {
+				t.Error(err)
+			}
+			if valid {
+				lock.Lock()
+				nbValid++
+				lock.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if nbValid != 1 {
+		t.Fatal("expected 1 valid verification, got", nbValid)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zslnullifiers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nullifiers")
+
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spend, send := randomNullifiers(t, Spend, 3), randomNullifiers(t, Send, 2)
+	if err := store.Add(spend); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(send); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the nullifiers are read back
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 5 {
+		t.Fatal("expected 5 nullifiers, got", store.Len())
+	}
+	for _, n := range append(spend, send...) {
+		if ok, err := store.Contains(n); err != nil || !ok {
+			t.Fatal("nullifier not read back", n, err)
+		}
+	}
+	store.Close()
+
+	// an interrupted batch is dropped, and the next ones are read
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 3 {
+		t.Fatal("expected 3 nullifiers, got", store.Len())
+	}
+	if ok, _ := store.Contains(send[0]); ok {
+		t.Fatal("nullifier of an interrupted batch was read")
+	}
+	if err := store.Add(send[:1]); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	store, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if ok, _ := store.Contains(send[0]); !ok || store.Len() != 4 {
+		t.Fatal("nullifier added after an interrupted batch not read back")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nullifier
+
+import (
+	"fmt"
+
+	"github.com/consensys/zslbox/batchlog"
+	"github.com/consensys/zslbox/zsl"
+)
+
+// Store stores the nullifiers of a Registry. Stores needn't be safe for concurrent use: the Registry
+// serializes the calls.
+type Store interface {
+	// Contains returns true if nullifier is in the store
+	Contains(nullifier Nullifier) (bool, error)
+	// Add adds nullifiers to the store, all or none; persistent stores return once they are on disk
+	Add(nullifiers []Nullifier) error
+	Close() error
+}
+
+// MemoryStore is a Store in memory, lost when the process exits
+type MemoryStore struct {
+	nullifiers map[Nullifier]struct{}
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nullifiers: make(map[Nullifier]struct{})}
+}
+
+// Contains implements Store
+func (s *MemoryStore) Contains(nullifier Nullifier) (bool, error) {
+	_, ok := s.nullifiers[nullifier]
+	return ok, nil
+}
+
+// Add implements Store
+func (s *MemoryStore) Add(nullifiers []Nullifier) error {
+	for _, n := range nullifiers {
+		s.nullifiers[n] = struct{}{}
+	}
+	return nil
+}
+
+// Len returns the number of nullifiers in the store
+func (s *MemoryStore) Len() int {
+	return len(s.nullifiers)
+}
+
+// Close implements Store
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// recordSize is the size of a nullifier in a FileStore: its kind and its hash
+const recordSize = 1 + zsl.HashSize
+
+// FileStore is a Store in an append-only file (a batchlog.Log), also kept in memory. Each Add appends a
+// batch of nullifiers (kind || hash), and syncs the file before returning.
+type FileStore struct {
+	log    *batchlog.Log
+	memory *MemoryStore
+}
+
+// OpenFileStore opens (or creates) the FileStore of the file at path, and reads its nullifiers. A partial
+// batch at the end of the file, from an interrupted Add, is truncated: none of its nullifiers was added.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{memory: NewMemoryStore()}
+	var err error
+	s.log, err = batchlog.Open(path, nil, recordSize, func(records [][]byte) error {
+		nullifiers := make([]Nullifier, len(records))
+		for i, record := range records {
+			kind := Kind(record[0])
+			if kind != Spend && kind != Send {
+				return fmt.Errorf("unknown nullifier kind %d", record[0])
+			}
+			nullifiers[i] = Nullifier{Kind: kind, Hash: zsl.NewHash(record[1:])}
+		}
+		return s.memory.Add(nullifiers)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Contains implements Store
+func (s *FileStore) Contains(nullifier Nullifier) (bool, error) {
+	return s.memory.Contains(nullifier)
+}
+
+// Add implements Store
+func (s *FileStore) Add(nullifiers []Nullifier) error {
+	records := make([][]byte, len(nullifiers))
+	for i, n := range nullifiers {
+		records[i] = append([]byte{byte(n.Kind)}, n.Hash[:]...)
+	}
+	if err := s.log.Append(records); err != nil {
+		return err
+	}
+	return s.memory.Add(nullifiers)
+}
+
+// Len returns the number of nullifiers in the store
+func (s *FileStore) Len() int {
+	return s.memory.Len()
+}
+
+// Close implements Store
+func (s *FileStore) Close() error {
+	return s.log.Close()
+}
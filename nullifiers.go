@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+
+	"github.com/consensys/zslbox/nullifier"
+	"github.com/consensys/zslbox/zsl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// NullifierRegistryServer implements NullifierRegistry server interface as defined in zslbox.proto
+type NullifierRegistryServer struct {
+	registry *nullifier.Registry
+}
+
+// NewNullifierRegistryServer returns a new NullifierRegistry server checking and recording nullifiers in
+// registry
+func NewNullifierRegistryServer(registry *nullifier.Registry) *NullifierRegistryServer {
+	return &NullifierRegistryServer{registry: registry}
+}
+
+// CheckNullifiers returns, for each nullifier, whether it was already seen
+func (server *NullifierRegistryServer) CheckNullifiers(ctx context.Context, request *zsl.Nullifiers) (*zsl.NullifiersResult, error) {
+	nullifiers, err := requestNullifiers(request.SpendNullifiers, request.SendNullifiers)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := server.registry.Check(nullifiers)
+	if err != nil {
+		return nil, registryError(err)
+	}
+	toReturn := nullifiersResult(len(request.SpendNullifiers), seen)
+	log.Debugw("CheckNullifiers",
+		"spendNullifiers", hexes(request.SpendNullifiers),
+		"sendNullifiers", hexes(request.SendNullifiers),
+		"spent", toReturn.Spent,
+		"used", toReturn.Used,
+	)
+	return toReturn, nil
+}
+
+// CommitNullifiers records the nullifiers, all or none: if one was already seen (or is twice in the
+// request), it records none and committed is false
+func (server *NullifierRegistryServer) CommitNullifiers(ctx context.Context, request *zsl.Nullifiers) (*zsl.NullifiersResult, error) {
+	nullifiers, err := requestNullifiers(request.SpendNullifiers, request.SendNullifiers)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := server.registry.Commit(nullifiers)
+	if err != nil && err != nullifier.ErrSeen {
+		return nil, registryError(err)
+	}
+	toReturn := nullifiersResult(len(request.SpendNullifiers), seen)
+	toReturn.Committed = err == nil
+	log.Debugw("CommitNullifiers",
+		"spendNullifiers", hexes(request.SpendNullifiers),
+		"sendNullifiers", hexes(request.SendNullifiers),
+		"spent", toReturn.Spent,
+		"used", toReturn.Used,
+		"committed", toReturn.Committed,
+	)
+	return toReturn, nil
+}
+
+// requestNullifiers returns the spend and send nullifiers of a request, or an InvalidArgument error if one
+// isn't 32 bytes
+func requestNullifiers(spendNullifiers [][]byte, sendNullifiers [][]byte) ([]nullifier.Nullifier, error) {
+	spend, err := nullifier.New(nullifier.Spend, spendNullifiers...)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	send, err := nullifier.New(nullifier.Send, sendNullifiers...)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return append(spend, send...), nil
+}
+
+// nullifiersResult returns the result of seen, the spend nullifiers first
+func nullifiersResult(nbSpendNullifiers int, seen []bool) *zsl.NullifiersResult {
+	return &zsl.NullifiersResult{Spent: seen[:nbSpendNullifiers], Used: seen[nbSpendNullifiers:]}
+}
+
+// registryError maps an error of the nullifier store to a gRPC error
+func registryError(err error) error {
+	log.Errorw("nullifier registry failed", "err", err)
+	return grpc.Errorf(codes.Internal, "%v", err)
+}
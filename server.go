@@ -5,8 +5,12 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
+	"strings"
 
+	"github.com/consensys/zslbox/nullifier"
 	"github.com/consensys/zslbox/snark"
+	"github.com/consensys/zslbox/verifier"
 	"github.com/consensys/zslbox/zsl"
 	"github.com/jpmorganchase/zsl-q/zsl-golang/zsl/sha256"
 	"google.golang.org/grpc"
@@ -15,22 +19,32 @@ import (
 
 // ZSLServer implements ZSLBox server interface as defined in zslbox.proto
 type ZSLServer struct {
+	keySets   *snark.KeySets
+	scheduler *snark.Scheduler
+	// nullifiers checks and records the nullifiers of the verified proofs, if not nil
+	nullifiers *nullifier.Registry
 }
 
-// NewZSLServer returns a new ZSL Server
-func NewZSLServer() *ZSLServer {
-	return &ZSLServer{}
+// NewZSLServer returns a new ZSL Server computing proofs with the active key set, and verifying them with
+// the key set of their key ID, within the limits of scheduler. If nullifiers isn't nil, verifications
+// reject the proofs of nullifiers already seen, and record the nullifiers of the valid ones.
+func NewZSLServer(keySets *snark.KeySets, scheduler *snark.Scheduler, nullifiers *nullifier.Registry) *ZSLServer {
+	return &ZSLServer{keySets: keySets, scheduler: scheduler, nullifiers: nullifiers}
 }
 
-// GetCommitment returns SHA256(note.Rho || note.Pk || note.Value)
-// where note.Value is in little endian byte order
+// GetCommitment returns SHA256(note.Rho || note.Pk || note.Value || note.Asset)
+// where note.Value is in little endian byte order and note.Asset is the default asset if empty
 func (server *ZSLServer) GetCommitment(ctx context.Context, note *zsl.Note) (*zsl.Bytes, error) {
 	log.Debugw("GetCommitment",
 		"note.Rho", hex.EncodeToString(note.Rho),
 		"note.Pk", hex.EncodeToString(note.Pk),
 		"note.Value", note.Value,
+		"note.Asset", hex.EncodeToString(note.Asset),
 	)
-	return &zsl.Bytes{Bytes: computeCommitment(note.Rho, note.Pk, note.Value)}, nil
+	if err := checkAssets(note.Asset); err != nil {
+		return nil, err
+	}
+	return &zsl.Bytes{Bytes: computeCommitment(note.Rho, note.Pk, note.Value, zsl.NoteAsset(note.Asset))}, nil
 }
 
 // GetSendNullifier returns SHA256(0x00 || note.Rho)
@@ -84,151 +98,672 @@ func (server *ZSLServer) CreateShielding(ctx context.Context, note *zsl.Note) (*
 		"note.Rho", hex.EncodeToString(note.Rho),
 		"note.Pk", hex.EncodeToString(note.Pk),
 		"note.Value", note.Value,
+		"note.Asset", hex.EncodeToString(note.Asset),
 	)
+	if err := checkAssets(note.Asset); err != nil {
+		return nil, err
+	}
 
+	keySet, err := server.keySet("")
+	if err != nil {
+		return nil, err
+	}
 	toReturn := &zsl.Shielding{}
-	toReturn.Snark = snark.ProveShielding(note.Rho, note.Pk, note.Value)
+	proof, err := server.scheduler.Prove(ctx, snark.Shielding, func() ([]byte, error) {
+		return snark.Prove(ctx, keySet.Backend, shieldingWitness(note))
+	})
+	if err != nil {
+		return nil, snarkError("CreateShielding", err)
+	}
+	toReturn.Snark = proof
+	toReturn.ProvingSystem = provingSystem(keySet)
+	if toReturn.KeyId, err = keyID(keySet); err != nil {
+		return nil, err
+	}
 	toReturn.SendNullifier = computeSendNullifier(note.Rho)
-	toReturn.Commitment = computeCommitment(note.Rho, note.Pk, note.Value)
+	toReturn.Commitment = computeCommitment(note.Rho, note.Pk, note.Value, zsl.NoteAsset(note.Asset))
 
 	return toReturn, nil
 }
 
-// CreateUnshielding computes a zkSNARK, nullifiers for given input, using Unshielding circuit
+// CreateUnshielding computes a zkSNARK, bound to shieldedInput.Binding, nullifiers for given input, using
+// Unshielding circuit
 func (server *ZSLServer) CreateUnshielding(ctx context.Context, shieldedInput *zsl.ShieldedInput) (*zsl.Unshielding, error) {
 	log.Debugw("CreateUnshielding",
 		"input.Rho", hex.EncodeToString(shieldedInput.Rho),
 		"input.Sk", hex.EncodeToString(shieldedInput.Sk),
 		"input.TreeIndex", shieldedInput.TreeIndex,
 		"input.Value", shieldedInput.Value,
+		"input.Asset", hex.EncodeToString(shieldedInput.Asset),
+		"input.Binding", hex.EncodeToString(shieldedInput.Binding),
 	)
+	if err := checkAssets(shieldedInput.Asset); err != nil {
+		return nil, err
+	}
+	if err := checkBinding(shieldedInput.Binding); err != nil {
+		return nil, err
+	}
 
 	// generate proof
+	keySet, err := server.keySet("")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTreePaths(keySet, shieldedInput); err != nil {
+		return nil, err
+	}
 	toReturn := &zsl.Unshielding{}
-	toReturn.Snark = snark.ProveUnshielding(shieldedInput.Rho, shieldedInput.Sk, shieldedInput.Value, shieldedInput.TreeIndex, shieldedInput.TreePath)
+	proof, err := server.scheduler.Prove(ctx, snark.Unshielding, func() ([]byte, error) {
+		return snark.Prove(ctx, keySet.Backend, unshieldingWitness(shieldedInput))
+	})
+	if err != nil {
+		return nil, snarkError("CreateUnshielding", err)
+	}
+	toReturn.Snark = proof
+	toReturn.ProvingSystem = provingSystem(keySet)
+	if toReturn.KeyId, err = keyID(keySet); err != nil {
+		return nil, err
+	}
 	toReturn.SendNullifier = computeSendNullifier(shieldedInput.Rho)
 	toReturn.SpendNullifier = computeSpendNullifier(shieldedInput.Rho, shieldedInput.Sk)
 
 	return toReturn, nil
 }
 
-// CreateShieldedTransfer takes 2 notes as inputs (known Sk) and 2 desired output notes.
-// It returns the zkSNARK, the spend nullifiers for the inputs, and the commitments & send nullifiers for outputs
+// CreateShieldedTransfer takes N notes as inputs (known Sk), M desired output notes, public values in and out
+// and a public fee.
+// It returns the zkSNARK, bound to request.Binding, the spend nullifiers for the inputs, and the commitments & send
+// nullifiers for outputs
 func (server *ZSLServer) CreateShieldedTransfer(ctx context.Context, request *zsl.ShieldedTransferRequest) (*zsl.ShieldedTransfer, error) {
-	log.Debug("CreateShieldedTransfer")
-	// check input size
-	if len(request.Inputs) != 2 || len(request.Outputs) != 2 {
-		return nil, grpc.Errorf(codes.InvalidArgument, "expecting 2 inputs and 2 outputs")
+	log.Debugw("CreateShieldedTransfer", "inputs", len(request.Inputs), "outputs", len(request.Outputs), "vpubIn", request.VpubIn, "vpubOut", request.VpubOut,
+		"fee", request.Fee, "binding", hex.EncodeToString(request.Binding))
+	// the numbers of inputs and outputs, and the public values and fee, select the circuit
+	circuit, err := snark.TransferCircuit(len(request.Inputs), len(request.Outputs), request.VpubIn != 0 || request.VpubOut != 0 || request.Fee != 0)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "no shielded transfer circuit of %d inputs and %d outputs (supported: %s)",
+			len(request.Inputs), len(request.Outputs), transferArities())
+	}
+	if err := checkBinding(request.Binding); err != nil {
+		return nil, err
+	}
+	for _, input := range request.Inputs {
+		if err := checkAssets(input.Asset); err != nil {
+			return nil, err
+		}
+	}
+	for _, output := range request.Outputs {
+		if err := checkAssets(output.Asset); err != nil {
+			return nil, err
+		}
 	}
 
+	keySet, err := server.keySet("")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTreePaths(keySet, request.Inputs...); err != nil {
+		return nil, err
+	}
+	witness := &snark.TransferWitness{VpubIn: request.VpubIn, VpubOut: request.VpubOut, Fee: request.Fee, Binding: zsl.ProofBinding(request.Binding)}
+	for _, input := range request.Inputs {
+		witness.Inputs = append(witness.Inputs, *unshieldingWitness(input))
+	}
+	for _, output := range request.Outputs {
+		witness.Outputs = append(witness.Outputs, *shieldingWitness(output))
+	}
 	toReturn := &zsl.ShieldedTransfer{}
-	toReturn.Snark = snark.ProveTransfer(
-		request.Inputs[0].Rho, request.Inputs[0].Sk, request.Inputs[0].Value, request.Inputs[0].TreeIndex, request.Inputs[0].TreePath,
-		request.Inputs[1].Rho, request.Inputs[1].Sk, request.Inputs[1].Value, request.Inputs[1].TreeIndex, request.Inputs[1].TreePath,
-		request.Outputs[0].Rho, request.Outputs[0].Pk, request.Outputs[0].Value,
-		request.Outputs[1].Rho, request.Outputs[1].Pk, request.Outputs[1].Value,
-	)
-
-	toReturn.SendNullifiers = [][]byte{
-		computeSendNullifier(request.Outputs[0].Rho),
-		computeSendNullifier(request.Outputs[1].Rho),
+	proof, err := server.scheduler.Prove(ctx, circuit, func() ([]byte, error) {
+		return snark.Prove(ctx, keySet.Backend, witness)
+	})
+	if err != nil {
+		return nil, snarkError("CreateShieldedTransfer", err)
 	}
-
-	toReturn.Commitments = [][]byte{
-		computeCommitment(request.Outputs[0].Rho, request.Outputs[0].Pk, request.Outputs[0].Value),
-		computeCommitment(request.Outputs[1].Rho, request.Outputs[1].Pk, request.Outputs[1].Value),
+	toReturn.Snark = proof
+	toReturn.ProvingSystem = provingSystem(keySet)
+	if toReturn.KeyId, err = keyID(keySet); err != nil {
+		return nil, err
 	}
 
-	toReturn.SpendNullifiers = [][]byte{
-		computeSpendNullifier(request.Inputs[0].Rho, request.Inputs[0].Sk),
-		computeSpendNullifier(request.Inputs[1].Rho, request.Inputs[1].Sk),
+	for _, output := range request.Outputs {
+		toReturn.SendNullifiers = append(toReturn.SendNullifiers, computeSendNullifier(output.Rho))
+		toReturn.Commitments = append(toReturn.Commitments, computeCommitment(output.Rho, output.Pk, output.Value, zsl.NoteAsset(output.Asset)))
+	}
+	for _, input := range request.Inputs {
+		toReturn.SpendNullifiers = append(toReturn.SpendNullifiers, computeSpendNullifier(input.Rho, input.Sk))
 	}
 
 	return toReturn, nil
 }
 
 // VerifyShielding ensures that the provided Shielding proof is valid. It takes as input the zkSNARK,
-// the send nullifier, commitment and value of the shielded note.
+// the send nullifier, commitment, value and asset of the shielded note.
 func (server *ZSLServer) VerifyShielding(ctx context.Context, request *zsl.VerifyShieldingRequest) (*zsl.Result, error) {
-	if len(request.Shielding.Snark) != zsl.ProofSize {
-		return nil, grpc.Errorf(codes.InvalidArgument, "proof size must be %d", zsl.ProofSize)
+	if err := checkAssets(request.Asset); err != nil {
+		return nil, err
+	}
+	keySet, err := server.keySet(request.Shielding.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProof(keySet, request.Shielding.Snark, request.Shielding.ProvingSystem); err != nil {
+		return nil, err
 	}
 
-	isValid := snark.VerifyShielding(request.Shielding.Snark, request.Shielding.SendNullifier, request.Shielding.Commitment, request.Value)
+	result, err := server.verify(ctx, "VerifyShielding", nil, [][]byte{request.Shielding.SendNullifier}, func() (bool, error) {
+		return snark.Verify(ctx, keySet.Backend, &snark.ShieldingVerification{
+			Proof:         request.Shielding.Snark,
+			SendNullifier: request.Shielding.SendNullifier,
+			Commitment:    request.Shielding.Commitment,
+			Value:         request.Value,
+			Asset:         zsl.NoteAsset(request.Asset),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	log.Debugw("VerifyShielding",
 		"snark", hex.EncodeToString(request.Shielding.Snark),
 		"sendNullifier", hex.EncodeToString(request.Shielding.SendNullifier),
 		"commitment", hex.EncodeToString(request.Shielding.Commitment),
 		"value", request.Value,
-		"valid", isValid,
+		"asset", hex.EncodeToString(request.Asset),
+		"valid", result.Result,
+		"message", result.Message,
 	)
 
-	return &zsl.Result{Result: isValid}, nil
+	return result, nil
 }
 
 // VerifyUnshielding ensures that the provided Unshielding proof is valid. It takes as input the zkSNARK,
-// the spend nullifier, the tree root and value of the shielded note.
+// the spend nullifier, the tree root, value and asset of the shielded note, and the binding of the proof.
 func (server *ZSLServer) VerifyUnshielding(ctx context.Context, request *zsl.VerifyUnshieldingRequest) (*zsl.Result, error) {
-	if len(request.Snark) != zsl.ProofSize {
-		return nil, grpc.Errorf(codes.InvalidArgument, "proof size must be %d", zsl.ProofSize)
+	if err := checkAssets(request.Asset); err != nil {
+		return nil, err
+	}
+	if err := checkBinding(request.Binding); err != nil {
+		return nil, err
+	}
+	keySet, err := server.keySet(request.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProof(keySet, request.Snark, request.ProvingSystem); err != nil {
+		return nil, err
 	}
 
-	isValid := snark.VerifyUnshielding(request.Snark, request.SpendNullifier, request.TreeRoot, request.Value)
+	result, err := server.verify(ctx, "VerifyUnshielding", [][]byte{request.SpendNullifier}, nil, func() (bool, error) {
+		return snark.Verify(ctx, keySet.Backend, &snark.UnshieldingVerification{
+			Proof:          request.Snark,
+			SpendNullifier: request.SpendNullifier,
+			TreeRoot:       request.TreeRoot,
+			Value:          request.Value,
+			Asset:          zsl.NoteAsset(request.Asset),
+			Binding:        zsl.ProofBinding(request.Binding),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	log.Debugw("VerifyUnshielding",
 		"snark", hex.EncodeToString(request.Snark),
 		"spendNullifier", hex.EncodeToString(request.SpendNullifier),
 		"treeRoot", hex.EncodeToString(request.TreeRoot),
 		"value", request.Value,
-		"valid", isValid,
+		"asset", hex.EncodeToString(request.Asset),
+		"binding", hex.EncodeToString(request.Binding),
+		"valid", result.Result,
+		"message", result.Message,
 	)
 
-	return &zsl.Result{Result: isValid}, nil
+	return result, nil
 }
 
 // VerifyShieldedTransfer ensures that the provided shielded transfer proof is valid.
-// It takes as input the zkSNARK, treeRoot, spend nullifiers for inputs and send nullifiers & commitments
-// for outputs
+// It takes as input the zkSNARK, treeRoot, spend nullifiers for inputs, send nullifiers & commitments
+// for outputs and the public values, with the asset of the notes, and the binding of the proof
 func (server *ZSLServer) VerifyShieldedTransfer(ctx context.Context, request *zsl.VerifyShieldedTransferRequest) (*zsl.Result, error) {
-	// check input size
-	if len(request.ShieldedTransfer.SendNullifiers) != 2 || len(request.ShieldedTransfer.SpendNullifiers) != 2 || len(request.ShieldedTransfer.Commitments) != 2 {
-		return nil, grpc.Errorf(codes.InvalidArgument, "expecting 2 spend/sent nullifiers and commitments")
+	// check input size: the numbers of nullifiers and commitments select the circuit
+	transfer := request.ShieldedTransfer
+	if !transferShape(transfer) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "expecting N spend nullifiers, M send nullifiers and M commitments (supported NxM: %s)", transferArities())
+	}
+	if err := checkAssets(request.Asset); err != nil {
+		return nil, err
+	}
+	if err := checkBinding(request.Binding); err != nil {
+		return nil, err
 	}
 
-	if len(request.ShieldedTransfer.Snark) != zsl.ProofSize {
-		return nil, grpc.Errorf(codes.InvalidArgument, "proof size must be %d", zsl.ProofSize)
+	keySet, err := server.keySet(request.ShieldedTransfer.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProof(keySet, request.ShieldedTransfer.Snark, request.ShieldedTransfer.ProvingSystem); err != nil {
+		return nil, err
 	}
 
-	isValid := snark.VerifyTransfer(request.ShieldedTransfer.Snark,
-		request.TreeRoot,
-		request.ShieldedTransfer.SpendNullifiers[0],
-		request.ShieldedTransfer.SpendNullifiers[1],
-		request.ShieldedTransfer.SendNullifiers[0],
-		request.ShieldedTransfer.SendNullifiers[1],
-		request.ShieldedTransfer.Commitments[0],
-		request.ShieldedTransfer.Commitments[1])
+	result, err := server.verify(ctx, "VerifyShieldedTransfer", transfer.SpendNullifiers, transfer.SendNullifiers, func() (bool, error) {
+		return snark.Verify(ctx, keySet.Backend, &snark.TransferVerification{
+			Proof:           transfer.Snark,
+			TreeRoot:        request.TreeRoot,
+			SpendNullifiers: transfer.SpendNullifiers,
+			SendNullifiers:  transfer.SendNullifiers,
+			Commitments:     transfer.Commitments,
+			VpubIn:          request.VpubIn,
+			VpubOut:         request.VpubOut,
+			Fee:             request.Fee,
+			Asset:           zsl.NoteAsset(request.Asset),
+			Binding:         zsl.ProofBinding(request.Binding),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	log.Debugw("VerifyShieldedTransfer",
 		"snark", hex.EncodeToString(request.ShieldedTransfer.Snark),
 		"treeRoot", hex.EncodeToString(request.TreeRoot),
-		"spend_nf_1", hex.EncodeToString(request.ShieldedTransfer.SpendNullifiers[0]),
-		"spend_nf_2", hex.EncodeToString(request.ShieldedTransfer.SpendNullifiers[1]),
-		"send_nf_1", hex.EncodeToString(request.ShieldedTransfer.SendNullifiers[0]),
-		"send_nf_2", hex.EncodeToString(request.ShieldedTransfer.SendNullifiers[1]),
-		"commitment_1", hex.EncodeToString(request.ShieldedTransfer.Commitments[0]),
-		"commitment_2", hex.EncodeToString(request.ShieldedTransfer.Commitments[1]),
-		"valid", isValid,
+		"spendNullifiers", hexes(transfer.SpendNullifiers),
+		"sendNullifiers", hexes(transfer.SendNullifiers),
+		"commitments", hexes(transfer.Commitments),
+		"vpubIn", request.VpubIn,
+		"vpubOut", request.VpubOut,
+		"fee", request.Fee,
+		"asset", hex.EncodeToString(request.Asset),
+		"binding", hex.EncodeToString(request.Binding),
+		"valid", result.Result,
+		"message", result.Message,
 	)
 
-	return &zsl.Result{Result: isValid}, nil
+	return result, nil
+}
+
+// VerifyBatch verifies many shielding, unshielding and shielded transfer proofs in parallel.
+// It returns one result per request, in the same order; malformed requests are invalid. With a nullifier
+// registry, the nullifiers of the valid proofs are recorded in the same order: of two proofs of the same
+// nullifier, the second is invalid.
+func (server *ZSLServer) VerifyBatch(ctx context.Context, request *zsl.VerifyBatchRequest) (*zsl.VerifyBatchResult, error) {
+	// verifications[i] is verified with keySets[i], and has the nullifiers nullifiers[i]
+	var verifications []snark.Verification
+	var keySets []*snark.KeySet
+	var nullifiers [][]nullifier.Nullifier
+	add := func(keyID string, system zsl.ProvingSystem, v snark.Verification, spendNullifiers [][]byte, sendNullifiers [][]byte, asset []byte, binding []byte) {
+		keySet, err := server.keySets.Get(keyID)
+		if err != nil || provingSystem(keySet) != system || checkAssets(asset) != nil || checkBinding(binding) != nil {
+			v = nil
+		}
+		n, err := requestNullifiers(spendNullifiers, sendNullifiers)
+		if err != nil && server.nullifiers != nil {
+			v = nil
+		}
+		verifications, keySets, nullifiers = append(verifications, v), append(keySets, keySet), append(nullifiers, n)
+	}
+	for _, r := range request.Shieldings {
+		if r.Shielding == nil {
+			add("", 0, nil, nil, nil, nil, nil)
+			continue
+		}
+		add(r.Shielding.KeyId, r.Shielding.ProvingSystem, &snark.ShieldingVerification{
+			Proof:         r.Shielding.Snark,
+			SendNullifier: r.Shielding.SendNullifier,
+			Commitment:    r.Shielding.Commitment,
+			Value:         r.Value,
+			Asset:         zsl.NoteAsset(r.Asset),
+		}, nil, [][]byte{r.Shielding.SendNullifier}, r.Asset, nil)
+	}
+	for _, r := range request.Unshieldings {
+		add(r.KeyId, r.ProvingSystem, &snark.UnshieldingVerification{
+			Proof:          r.Snark,
+			SpendNullifier: r.SpendNullifier,
+			TreeRoot:       r.TreeRoot,
+			Value:          r.Value,
+			Asset:          zsl.NoteAsset(r.Asset),
+			Binding:        zsl.ProofBinding(r.Binding),
+		}, [][]byte{r.SpendNullifier}, nil, r.Asset, r.Binding)
+	}
+	for _, r := range request.ShieldedTransfers {
+		transfer := r.ShieldedTransfer
+		if !transferShape(transfer) {
+			add("", 0, nil, nil, nil, nil, nil)
+			continue
+		}
+		add(transfer.KeyId, transfer.ProvingSystem, &snark.TransferVerification{
+			Proof:           transfer.Snark,
+			TreeRoot:        r.TreeRoot,
+			SpendNullifiers: transfer.SpendNullifiers,
+			SendNullifiers:  transfer.SendNullifiers,
+			Commitments:     transfer.Commitments,
+			VpubIn:          r.VpubIn,
+			VpubOut:         r.VpubOut,
+			Fee:             r.Fee,
+			Asset:           zsl.NoteAsset(r.Asset),
+			Binding:         zsl.ProofBinding(r.Binding),
+		}, transfer.SpendNullifiers, transfer.SendNullifiers, r.Asset, r.Binding)
+	}
+
+	// proofs of nullifiers already seen are invalid
+	if server.nullifiers != nil {
+		for i, n := range nullifiers {
+			if verifications[i] == nil {
+				continue
+			}
+			seen, err := server.nullifiers.Check(n)
+			if err != nil {
+				return nil, registryError(err)
+			}
+			for _, s := range seen {
+				if s {
+					verifications[i] = nil
+				}
+			}
+		}
+	}
+
+	// the batch is one job for the scheduler, on as many slots as it verifies proofs in parallel
+	var results []bool
+	_, err := server.scheduler.VerifyBatch(ctx, len(verifications), func(parallelism int) (bool, error) {
+		var err error
+		results, err = verifyBatch(ctx, keySets, verifications, parallelism)
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, snarkError("VerifyBatch", err)
+	}
+	if server.nullifiers != nil {
+		for i, valid := range results {
+			if !valid {
+				continue
+			}
+			if _, err := server.nullifiers.Commit(nullifiers[i]); err == nullifier.ErrSeen {
+				results[i] = false
+			} else if err != nil {
+				return nil, registryError(err)
+			}
+		}
+	}
+	nbShieldings, nbUnshieldings := len(request.Shieldings), len(request.Unshieldings)
+	toReturn := &zsl.VerifyBatchResult{
+		Shieldings:        results[:nbShieldings],
+		Unshieldings:      results[nbShieldings : nbShieldings+nbUnshieldings],
+		ShieldedTransfers: results[nbShieldings+nbUnshieldings:],
+	}
+
+	log.Debugw("VerifyBatch",
+		"shieldings", toReturn.Shieldings,
+		"unshieldings", toReturn.Unshieldings,
+		"shieldedTransfers", toReturn.ShieldedTransfers,
+	)
+
+	return toReturn, nil
+}
+
+// GetVerifyingKey returns the verifying key of a circuit, raw (libsnark serialization) and structured,
+// along with its fingerprint
+func (server *ZSLServer) GetVerifyingKey(ctx context.Context, request *zsl.VerifyingKeyRequest) (*zsl.VerifyingKey, error) {
+	log.Debugw("GetVerifyingKey", "circuit", request.Circuit)
+	circuit, ok := circuits[request.Circuit]
+	if !ok {
+		return nil, grpc.Errorf(codes.InvalidArgument, "unknown circuit %s", request.Circuit)
+	}
+
+	keySet, err := server.keySet(request.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := keySet.Backend.VerifyingKey(circuit)
+	if err != nil {
+		if err == snark.ErrKeysNotLoaded {
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, grpc.Errorf(codes.Internal, "couldn't read verifying key: %v", err)
+	}
+	toReturn, err := snark.ExportVerifyingKey(raw, keySet.Backend.Status().ProvingSystem)
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, "%v", err)
+	}
+	toReturn.Circuit = request.Circuit
+	toReturn.TreeDepth = uint32(keySet.Backend.Status().TreeDepth)
+	if toReturn.KeyId, err = keyID(keySet); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// GetPublicInputs returns the public inputs of a shielding, unshielding or shielded transfer, packed in
+// field elements as the circuit verifiers expect them
+func (server *ZSLServer) GetPublicInputs(ctx context.Context, request *zsl.PublicInputsRequest) (*zsl.PublicInputs, error) {
+	toReturn, err := verifier.PublicInputs(request)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	log.Debugw("GetPublicInputs", "circuit", toReturn.Circuit, "inputs", toReturn.Inputs)
+	return toReturn, nil
 }
 
 // -------------------------------------------------------------------------------------------------
 // Private functions
 
-// cm = SHA256(rho || pk || v) where v is in little endian byte order
-func computeCommitment(rho []byte, pk []byte, v uint64) []byte {
+// keySet returns the key set of key ID keyID (the active one if empty), or a NotFound error
+func (server *ZSLServer) keySet(keyID string) (*snark.KeySet, error) {
+	keySet, err := server.keySets.Get(keyID)
+	if err != nil {
+		return nil, snarkError("KeySets.Get", err)
+	}
+	return keySet, nil
+}
+
+// keyID returns the key ID of keySet, or a gRPC error if its keys aren't loaded
+func keyID(keySet *snark.KeySet) (string, error) {
+	id, err := keySet.ID()
+	if err == snark.ErrKeysNotLoaded {
+		return "", grpc.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	if err != nil {
+		return "", grpc.Errorf(codes.Internal, "couldn't compute key ID: %v", err)
+	}
+	return id, nil
+}
+
+// provingSystem returns the proving system of the keys of keySet
+func provingSystem(keySet *snark.KeySet) zsl.ProvingSystem {
+	if keySet.Backend.Status().ProvingSystem == snark.Groth16 {
+		return zsl.ProvingSystem_GROTH16
+	}
+	return zsl.ProvingSystem_PPZKSNARK
+}
+
+// checkAssets returns an InvalidArgument error if one of assets is neither empty nor zsl.HashSize bytes:
+// NoteAsset would hash it as is in the commitments
+func checkAssets(assets ...[]byte) error {
+	for _, asset := range assets {
+		if err := zsl.CheckAsset(asset); err != nil {
+			return grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+	}
+	return nil
+}
+
+// checkBinding returns an InvalidArgument error if binding is neither empty nor zsl.HashSize bytes
+func checkBinding(binding []byte) error {
+	if err := zsl.CheckBinding(binding); err != nil {
+		return grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return nil
+}
+
+// checkProof returns an InvalidArgument error if proof isn't a proof of the proving system of keySet
+func checkProof(keySet *snark.KeySet, proof []byte, system zsl.ProvingSystem) error {
+	if expected := provingSystem(keySet); system != expected {
+		return grpc.Errorf(codes.InvalidArgument, "proof is a %s proof, keys are %s keys", system, expected)
+	}
+	if size := keySet.Backend.Status().ProvingSystem.ProofSize(); len(proof) != size {
+		return grpc.Errorf(codes.InvalidArgument, "proof size must be %d", size)
+	}
+	return nil
+}
+
+// verify runs verify on the scheduler for op (the RPC), and returns its result. With a nullifier registry, the proof is
+// rejected (with a message) if one of its nullifiers was already seen, and its nullifiers are recorded if
+// it's valid.
+func (server *ZSLServer) verify(ctx context.Context, op string, spendNullifiers [][]byte, sendNullifiers [][]byte, verify func() (bool, error)) (*zsl.Result, error) {
+	if server.nullifiers == nil {
+		isValid, err := server.scheduler.Verify(ctx, verify)
+		if err != nil {
+			return nil, snarkError(op, err)
+		}
+		return &zsl.Result{Result: isValid}, nil
+	}
+
+	nullifiers, err := requestNullifiers(spendNullifiers, sendNullifiers)
+	if err != nil {
+		return nil, err
+	}
+	var verifyErr error
+	isValid, err := server.nullifiers.Verify(nullifiers, func() bool {
+		var isValid bool
+		isValid, verifyErr = server.scheduler.Verify(ctx, verify)
+		return isValid
+	})
+	if verifyErr != nil {
+		return nil, snarkError(op, verifyErr)
+	}
+	if err == nullifier.ErrSeen {
+		return &zsl.Result{Message: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, registryError(err)
+	}
+	return &zsl.Result{Result: isValid}, nil
+}
+
+// verifyBatch verifies verifications[i] with keySets[i], batching the verifications of each key set on up
+// to parallelism goroutines. It returns the error of a verification that failed, if any (see snark.Verify).
+func verifyBatch(ctx context.Context, keySets []*snark.KeySet, verifications []snark.Verification, parallelism int) ([]bool, error) {
+	results := make([]bool, len(verifications))
+	batches := make(map[*snark.KeySet][]int)
+	for i, keySet := range keySets {
+		if verifications[i] != nil {
+			batches[keySet] = append(batches[keySet], i)
+		}
+	}
+	for keySet, indexes := range batches {
+		batch := make([]snark.Verification, len(indexes))
+		for j, i := range indexes {
+			batch[j] = verifications[i]
+		}
+		batchResults, err := snark.VerifyBatch(ctx, keySet.Backend, batch, parallelism)
+		if err != nil {
+			return nil, err
+		}
+		for j, valid := range batchResults {
+			results[indexes[j]] = valid
+		}
+	}
+	return results, nil
+}
+
+// checkTreePaths returns an InvalidArgument error if the tree path of an input doesn't match the tree
+// depth of the circuits of keySet
+func checkTreePaths(keySet *snark.KeySet, inputs ...*zsl.ShieldedInput) error {
+	treeDepth := keySet.Backend.Status().TreeDepth
+	for _, input := range inputs {
+		if uint(len(input.TreePath)) != treeDepth {
+			return grpc.Errorf(codes.InvalidArgument, "expecting a tree path of %d nodes, got %d", treeDepth, len(input.TreePath))
+		}
+	}
+	return nil
+}
+
+// circuits maps the circuits of the API to the snark circuits
+var circuits = map[zsl.Circuit]snark.Circuit{
+	zsl.Circuit_SHIELDING:    snark.Shielding,
+	zsl.Circuit_UNSHIELDING:  snark.Unshielding,
+	zsl.Circuit_TRANSFER:     snark.Transfer,
+	zsl.Circuit_TRANSFER_1X1: snark.Transfer1x1,
+	zsl.Circuit_TRANSFER_1X2: snark.Transfer1x2,
+	zsl.Circuit_TRANSFER_4X2: snark.Transfer4x2,
+	zsl.Circuit_TRANSFER_4X4: snark.Transfer4x4,
+
+	zsl.Circuit_PUBLIC_TRANSFER:     snark.PublicTransfer,
+	zsl.Circuit_PUBLIC_TRANSFER_1X1: snark.PublicTransfer1x1,
+	zsl.Circuit_PUBLIC_TRANSFER_1X2: snark.PublicTransfer1x2,
+	zsl.Circuit_PUBLIC_TRANSFER_4X2: snark.PublicTransfer4x2,
+	zsl.Circuit_PUBLIC_TRANSFER_4X4: snark.PublicTransfer4x4,
+}
+
+// transferArities returns the supported numbers of inputs and outputs of shielded transfers (ex: 2x2, 1x1)
+func transferArities() string {
+	var arities []string
+	for _, c := range snark.Circuits {
+		if nbInputs, nbOutputs := c.Arity(); nbInputs > 0 && !c.PublicValues() {
+			arities = append(arities, fmt.Sprintf("%dx%d", nbInputs, nbOutputs))
+		}
+	}
+	return strings.Join(arities, ", ")
+}
+
+// transferShape returns true if transfer has the spend nullifiers, send nullifiers and commitments of a
+// shielded transfer circuit
+func transferShape(transfer *zsl.ShieldedTransfer) bool {
+	if transfer == nil || len(transfer.SendNullifiers) != len(transfer.Commitments) {
+		return false
+	}
+	_, err := snark.TransferCircuit(len(transfer.SpendNullifiers), len(transfer.Commitments), false)
+	return err == nil
+}
+
+// hexes returns the hex encoding of hashes, for logging
+func hexes(hashes [][]byte) []string {
+	toReturn := make([]string, len(hashes))
+	for i, h := range hashes {
+		toReturn[i] = hex.EncodeToString(h)
+	}
+	return toReturn
+}
+
+func shieldingWitness(note *zsl.Note) *snark.ShieldingWitness {
+	return &snark.ShieldingWitness{Rho: note.Rho, Pk: note.Pk, Value: note.Value, Asset: zsl.NoteAsset(note.Asset)}
+}
+
+func unshieldingWitness(input *zsl.ShieldedInput) *snark.UnshieldingWitness {
+	return &snark.UnshieldingWitness{
+		Rho:       input.Rho,
+		Sk:        input.Sk,
+		Value:     input.Value,
+		Asset:     zsl.NoteAsset(input.Asset),
+		TreeIndex: input.TreeIndex,
+		TreePath:  input.TreePath,
+		Binding:   zsl.ProofBinding(input.Binding),
+	}
+}
+
+// snarkError maps an error returned by the snark backend, scheduler or key sets during op (ex: the RPC) to a
+// gRPC error, and logs the unexpected ones
+func snarkError(op string, err error) error {
+	switch err {
+	case snark.ErrUnsatisfiedWitness, snark.ErrInvalidInputSize:
+		return grpc.Errorf(codes.InvalidArgument, "%v", err)
+	case snark.ErrKeysNotLoaded, snark.ErrActiveKeySet:
+		return grpc.Errorf(codes.FailedPrecondition, "%v", err)
+	case snark.ErrUnknownKeySet:
+		return grpc.Errorf(codes.NotFound, "%v", err)
+	case snark.ErrQueueFull:
+		return grpc.Errorf(codes.ResourceExhausted, "%v", err)
+	case snark.ErrWorkerFailed:
+		return grpc.Errorf(codes.Unavailable, "%v", err)
+	case context.DeadlineExceeded:
+		return grpc.Errorf(codes.DeadlineExceeded, "%v", err)
+	case context.Canceled:
+		return grpc.Errorf(codes.Canceled, "%v", err)
+	}
+	log.Errorw("snark operation failed", "op", op, "err", err)
+	return grpc.Errorf(codes.Internal, "%v", err)
+}
+
+// cm = SHA256(rho || pk || v || asset) where v is in little endian byte order
+func computeCommitment(rho []byte, pk []byte, v uint64, asset []byte) []byte {
 	vbuf := make([]byte, 8)
 	binary.LittleEndian.PutUint64(vbuf, v)
 
@@ -236,6 +771,7 @@ func computeCommitment(rho []byte, pk []byte, v uint64) []byte {
 	h.Write(rho)
 	h.Write(pk)
 	h.Write(vbuf)
+	h.Write(asset)
 	return h.Sum(nil)
 }
 
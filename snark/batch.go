@@ -0,0 +1,132 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Verification is a proof and its public inputs, to verify with VerifyBatch:
+// a ShieldingVerification, UnshieldingVerification or TransferVerification
+type Verification interface {
+	verify(backend Backend) bool
+}
+
+// ShieldingVerification holds the inputs of Backend.VerifyShielding
+type ShieldingVerification struct {
+	Proof         []byte
+	SendNullifier []byte
+	Commitment    []byte
+	Value         uint64
+	Asset         []byte
+}
+
+// UnshieldingVerification holds the inputs of Backend.VerifyUnshielding
+type UnshieldingVerification struct {
+	Proof          []byte
+	SpendNullifier []byte
+	TreeRoot       []byte
+	Value          uint64
+	Asset          []byte
+	Binding        []byte
+}
+
+// TransferVerification holds the inputs of Backend.VerifyTransferN
+type TransferVerification struct {
+	Proof           []byte
+	TreeRoot        []byte
+	SpendNullifiers [][]byte
+	SendNullifiers  [][]byte
+	Commitments     [][]byte
+	VpubIn          uint64
+	VpubOut         uint64
+	Fee             uint64
+	Asset           []byte // of the public values
+	Binding         []byte
+}
+
+func (v *ShieldingVerification) verify(backend Backend) bool {
+	return backend.VerifyShielding(v.Proof, v.SendNullifier, v.Commitment, v.Value, v.Asset)
+}
+
+func (v *UnshieldingVerification) verify(backend Backend) bool {
+	return backend.VerifyUnshielding(v.Proof, v.SpendNullifier, v.TreeRoot, v.Value, v.Asset, v.Binding)
+}
+
+func (v *TransferVerification) verify(backend Backend) bool {
+	return backend.VerifyTransferN(v.Proof, v.TreeRoot, v.SpendNullifiers, v.SendNullifiers, v.Commitments, v.VpubIn, v.VpubOut, v.Fee, v.Asset, v.Binding)
+}
+
+// ErrorVerifier is implemented by backends whose verifications can fail without the proof being
+// invalid (see Pool)
+type ErrorVerifier interface {
+	// VerifyWithError verifies v, or returns an error (ex: ErrWorkerFailed, or ctx.Err() if ctx is done
+	// first) if it couldn't
+	VerifyWithError(ctx context.Context, v Verification) (bool, error)
+}
+
+// Verify verifies v on backend. If backend is an ErrorVerifier, it returns the error of a verification
+// that failed, rather than an invalid proof, and stops it when ctx is done.
+func Verify(ctx context.Context, backend Backend, v Verification) (bool, error) {
+	if verifier, ok := backend.(ErrorVerifier); ok {
+		return verifier.VerifyWithError(ctx, v)
+	}
+	return v.verify(backend), nil
+}
+
+// VerifyBatch verifies the proofs on backend in parallel, on up to parallelism goroutines (runtime.NumCPU()
+// if 0, see Scheduler.VerifyBatch). results[i] is true if verifications[i] is valid; a nil verification is
+// invalid. err is the error of one of the verifications that failed (see Verify), if any.
+func VerifyBatch(ctx context.Context, backend Backend, verifications []Verification, parallelism int) (results []bool, err error) {
+	results = make([]bool, len(verifications))
+	nbWorkers := parallelism
+	if nbWorkers <= 0 {
+		nbWorkers = runtime.NumCPU()
+	}
+	if nbWorkers > len(verifications) {
+		nbWorkers = len(verifications)
+	}
+
+	jobs := make(chan int, len(verifications))
+	for i := range verifications {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	wg.Add(nbWorkers)
+	for w := 0; w < nbWorkers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if verifications[i] == nil {
+					continue
+				}
+				valid, verifyErr := Verify(ctx, backend, verifications[i])
+				results[i] = valid && verifyErr == nil
+				if verifyErr != nil {
+					errLock.Lock()
+					err = verifyErr
+					errLock.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results, err
+}
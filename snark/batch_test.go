@@ -0,0 +1,95 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+	"github.com/jpmorganchase/zsl-q/zsl-golang/zsl/sha256"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	backend := newMock(t)
+
+	// shieldings, every other one with a wrong value
+	var verifications []Verification
+	var expected []bool
+	for i := 0; i < 50; i++ {
+		rho, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+		proof, err := backend.ProveShielding(rho, pk, uint64(i), defaultAsset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := &ShieldingVerification{
+			Proof:         proof,
+			SendNullifier: mockSendNullifier(rho),
+			Commitment:    mockCommitment(rho, pk, uint64(i), defaultAsset),
+			Value:         uint64(i),
+			Asset:         defaultAsset,
+		}
+		if i%2 == 1 {
+			v.Value++
+		}
+		verifications = append(verifications, v)
+		expected = append(expected, i%2 == 0)
+	}
+
+	// a transfer of zero value inputs, an unshielding with a truncated proof and a nil verification
+	rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	pk := sha256.Sum256(sk)
+	treePath := make([][]byte, zsl.TreeDepth)
+	for i := range treePath {
+		treePath[i] = make([]byte, zsl.HashSize)
+	}
+	outRho1, outRho2 := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	proof, err := backend.ProveTransfer(rho, sk, 0, 0, treePath, outRho1, sk, 0, 0, treePath, outRho1, pk[:], 0, outRho2, pk[:], 0, defaultAsset, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifications = append(verifications, &TransferVerification{
+		Proof:           proof,
+		TreeRoot:        make([]byte, zsl.HashSize),
+		SpendNullifiers: [][]byte{mockSpendNullifier(rho, sk), mockSpendNullifier(outRho1, sk)},
+		SendNullifiers:  [][]byte{mockSendNullifier(outRho1), mockSendNullifier(outRho2)},
+		Commitments:     [][]byte{mockCommitment(outRho1, pk[:], 0, defaultAsset), mockCommitment(outRho2, pk[:], 0, defaultAsset)},
+		Binding:         defaultBinding,
+	})
+	expected = append(expected, true)
+	verifications = append(verifications, &UnshieldingVerification{
+		Proof:          proof[:zsl.ProofSize-1],
+		SpendNullifier: mockSpendNullifier(rho, sk),
+		TreeRoot:       make([]byte, zsl.HashSize),
+	}, nil)
+	expected = append(expected, false, false)
+
+	results, err := VerifyBatch(context.Background(), backend, verifications, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(expected) {
+		t.Fatal("expected", len(expected), "results, got", len(results))
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatal("verification", i, "should be", expected[i])
+		}
+	}
+
+	if results, _ := VerifyBatch(context.Background(), backend, nil, 0); len(results) != 0 {
+		t.Fatal("empty batch should have no results")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import "fmt"
+
+// Error is the error type returned by the prove functions of a Backend, and by the Scheduler
+type Error int
+
+const (
+	// ErrUnsatisfiedWitness is returned when the witness doesn't satisfy the circuit constraints
+	// (ex: unbalanced transfer, input notes not in the same tree)
+	ErrUnsatisfiedWitness Error = iota + 1
+	// ErrInvalidInputSize is returned when a rho, key or tree path has an unexpected size
+	ErrInvalidInputSize
+	// ErrKeysNotLoaded is returned when the backend wasn't initialized or the keys failed to load
+	ErrKeysNotLoaded
+	// ErrProver is returned when the prover failed for another reason
+	ErrProver
+	// ErrQueueFull is returned by the Scheduler when too many jobs are waiting
+	ErrQueueFull
+	// ErrWorkerFailed is returned by a Pool when the worker process running the job crashed
+	ErrWorkerFailed
+	// ErrUnknownKeySet is returned by KeySets when no loaded key set has the key ID
+	ErrUnknownKeySet
+	// ErrActiveKeySet is returned by KeySets.Retire for the active key set
+	ErrActiveKeySet
+)
+
+func (e Error) Error() string {
+	switch e {
+	case ErrUnsatisfiedWitness:
+		return "snark: witness doesn't satisfy the circuit constraints"
+	case ErrInvalidInputSize:
+		return "snark: invalid input size"
+	case ErrKeysNotLoaded:
+		return "snark: keys not loaded"
+	case ErrProver:
+		return "snark: prover failed"
+	case ErrQueueFull:
+		return "snark: queue full"
+	case ErrWorkerFailed:
+		return "snark: worker process failed"
+	case ErrUnknownKeySet:
+		return "snark: unknown key set"
+	case ErrActiveKeySet:
+		return "snark: the active key set can't be retired"
+	}
+	return fmt.Sprintf("snark: error %d", int(e))
+}
+
+// hashSize is the size of rho, keys and tree nodes
+const hashSize = 32
+
+// checkSizes returns ErrInvalidInputSize if one of the inputs isn't hashSize bytes long
+func checkSizes(inputs ...[]byte) error {
+	for _, input := range inputs {
+		if len(input) != hashSize {
+			return ErrInvalidInputSize
+		}
+	}
+	return nil
+}
+
+// checkTreePath returns ErrInvalidInputSize if treePath doesn't have treeDepth nodes of hashSize bytes
+func checkTreePath(treePath [][]byte, treeDepth uint) error {
+	if uint(len(treePath)) != treeDepth {
+		return ErrInvalidInputSize
+	}
+	return checkSizes(treePath...)
+}
@@ -0,0 +1,303 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// KeyID returns the identifier of the key set loaded by backend: the hex encoded SHA-256 of the
+// SHA-256 of the verifying keys of all circuits (see Fingerprint), concatenated in the order of Circuits.
+// Key sets differing by the keys of any circuit have different key IDs.
+func KeyID(backend Backend) (string, error) {
+	h := sha256.New()
+	for _, c := range Circuits {
+		vk, err := backend.VerifyingKey(c)
+		if err != nil {
+			return "", err
+		}
+		fingerprint := sha256.Sum256(vk)
+		h.Write(fingerprint[:])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Instancer is implemented by backends able to load several key sets in the same process, each one
+// with a new instance. libzsl isn't: its keys are global, load other key sets in a Pool.
+type Instancer interface {
+	NewInstance() Backend
+}
+
+// KeySet is a key set loaded by a Backend
+type KeySet struct {
+	KeyDir  string
+	Backend Backend
+
+	lock sync.Mutex
+	id   string
+}
+
+// ID returns the key ID of the key set (see KeyID), or ErrKeysNotLoaded while its keys are generating
+// (or if they failed to load)
+func (k *KeySet) ID() (string, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.id != "" {
+		return k.id, nil
+	}
+	for _, state := range k.Backend.Status().Keys {
+		if state == KeysGenerating || state == KeysFailed {
+			return "", ErrKeysNotLoaded
+		}
+	}
+	id, err := KeyID(k.Backend)
+	if err != nil {
+		return "", err
+	}
+	k.id = id
+	return id, nil
+}
+
+// KeySets holds the key sets loaded side by side, to rotate keys without making the proofs of the
+// previous ones unverifiable. New proofs use the active key set, the last one added; proofs of any
+// loaded key set are verified by selecting it with its key ID (see Get).
+type KeySets struct {
+	treeDepth  uint
+	newBackend func() (Backend, error)
+
+	loadLock sync.Mutex // held during Load and Add
+
+	lock sync.RWMutex
+	sets []*KeySet // in the order they were loaded, the last one is active
+}
+
+// NewKeySets returns an empty KeySets, loading the key sets with the backends returned by newBackend.
+// Keys generated without an explicit tree depth are for treeDepth.
+func NewKeySets(treeDepth uint, newBackend func() (Backend, error)) *KeySets {
+	return &KeySets{treeDepth: treeDepth, newBackend: newBackend}
+}
+
+const (
+	// maxTreeDepth is the maximum depth of the commitment tree: tree indexes are uint64
+	maxTreeDepth = 64
+	// keysPollInterval is the interval between status checks of a key set being added (see Add)
+	keysPollInterval = 100 * time.Millisecond
+)
+
+// Load initializes a new backend with the keys of system in keyDir (see Backend.Init), and makes it the
+// active key set right away, while its keys are loading or generating. The tree depth of the keys is the
+// one of their manifest, or treeDepth if not zero (the default tree depth otherwise) for new keys.
+// It's meant for the first key set, at startup: add the others with Add.
+func (s *KeySets) Load(keyDir string, treeDepth uint, system ProvingSystem) (*KeySet, error) {
+	if treeDepth == 0 {
+		treeDepth = s.treeDepth
+		if manifest, err := ReadManifest(keyDir); err == nil {
+			treeDepth = manifest.TreeDepth
+		}
+	}
+
+	s.loadLock.Lock()
+	defer s.loadLock.Unlock()
+	keySet, err := s.init(keyDir, treeDepth, system)
+	if err != nil {
+		return nil, err
+	}
+	s.activate(keySet)
+	return keySet, nil
+}
+
+// Add loads the existing keys of system in keyDir in a new backend, and makes it the active key set once
+// they are loaded. Unlike Load, it never generates keys: it fails if keyDir doesn't have the keys of all
+// circuits, listed in its manifest, so that a mistyped directory can't make keys of a one-party setup
+// active. Keys with another tree depth than treeDepth, if not zero, are rejected. Add fails if the key set
+// is already loaded, or if ctx is done before its keys are loaded.
+func (s *KeySets) Add(ctx context.Context, keyDir string, treeDepth uint, system ProvingSystem) (*KeySet, error) {
+	manifest, err := ReadManifest(keyDir)
+	if err != nil {
+		return nil, fmt.Errorf("snark: no key set in %s (keys are only generated at startup): %v", keyDir, err)
+	}
+	for _, c := range Circuits {
+		pkPath, vkPath := KeyFiles(keyDir, c)
+		if manifest.Circuits[c.String()] == nil || !fileExists(pkPath) || !fileExists(vkPath) {
+			return nil, fmt.Errorf("snark: key set in %s has no %s keys (keys are only generated at startup)", keyDir, c)
+		}
+	}
+	if treeDepth != 0 && treeDepth != manifest.TreeDepth {
+		return nil, fmt.Errorf("snark: keys in %s are for tree depth %d, expected %d", keyDir, manifest.TreeDepth, treeDepth)
+	}
+
+	s.loadLock.Lock()
+	defer s.loadLock.Unlock()
+	keySet, err := s.init(keyDir, manifest.TreeDepth, system)
+	if err != nil {
+		return nil, err
+	}
+	err = waitKeys(ctx, keySet)
+	if err == nil {
+		err = s.checkDuplicate(keySet)
+	}
+	if err != nil {
+		closeBackend(keySet.Backend)
+		return nil, err
+	}
+	s.activate(keySet)
+	return keySet, nil
+}
+
+// init returns the key set of a new backend initialized with the keys of keyDir (see Backend.Init)
+func (s *KeySets) init(keyDir string, treeDepth uint, system ProvingSystem) (*KeySet, error) {
+	if treeDepth == 0 || treeDepth > maxTreeDepth {
+		return nil, fmt.Errorf("snark: tree depth must be between 1 and %d, got %d", maxTreeDepth, treeDepth)
+	}
+	backend, err := s.newBackend()
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Init(treeDepth, keyDir, system); err != nil {
+		closeBackend(backend)
+		return nil, err
+	}
+	return &KeySet{KeyDir: keyDir, Backend: backend}, nil
+}
+
+// activate makes keySet the active key set
+func (s *KeySets) activate(keySet *KeySet) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sets = append(s.sets, keySet)
+}
+
+// waitKeys waits until the keys of keySet are loaded. It returns an error if they failed to load, or if ctx
+// is done first.
+func waitKeys(ctx context.Context, keySet *KeySet) error {
+	for {
+		status := keySet.Backend.Status()
+		if status.Ready() {
+			return nil
+		}
+		for _, c := range Circuits {
+			if status.Keys[c] == KeysFailed {
+				return fmt.Errorf("snark: %s keys in %s failed to load", c, keySet.KeyDir)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(keysPollInterval):
+		}
+	}
+}
+
+// checkDuplicate returns an error if the key set, whose keys are loaded, is already loaded
+func (s *KeySets) checkDuplicate(keySet *KeySet) error {
+	id, err := keySet.ID()
+	if err != nil {
+		return err
+	}
+	for _, loaded := range s.List() {
+		if loadedID, err := loaded.ID(); err == nil && loadedID == id {
+			return fmt.Errorf("snark: key set %s is already loaded from %s", id, loaded.KeyDir)
+		}
+	}
+	return nil
+}
+
+// Retire unloads the key set of key ID id: its proofs can't be verified anymore. The active key set
+// can't be retired (ErrActiveKeySet); load its replacement first.
+func (s *KeySets) Retire(id string) (*KeySet, error) {
+	keySet, err := s.remove(id)
+	if err != nil {
+		return nil, err
+	}
+	// closing a backend can take a while (ex: waiting for the workers of a Pool to exit), don't hold the lock
+	closeBackend(keySet.Backend)
+	return keySet, nil
+}
+
+// remove removes the key set of key ID id from the loaded ones, but the active one
+func (s *KeySets) remove(id string) (*KeySet, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, keySet := range s.sets {
+		if loadedID, err := keySet.ID(); err != nil || loadedID != id {
+			continue
+		}
+		if i == len(s.sets)-1 {
+			return nil, ErrActiveKeySet
+		}
+		s.sets = append(s.sets[:i], s.sets[i+1:]...)
+		return keySet, nil
+	}
+	return nil, ErrUnknownKeySet
+}
+
+// Get returns the key set of key ID id, or the active key set if id is empty.
+// It returns ErrUnknownKeySet if there is none.
+func (s *KeySets) Get(id string) (*KeySet, error) {
+	if id == "" {
+		if active := s.Active(); active != nil {
+			return active, nil
+		}
+		return nil, ErrUnknownKeySet
+	}
+	for _, keySet := range s.List() {
+		if loadedID, err := keySet.ID(); err == nil && loadedID == id {
+			return keySet, nil
+		}
+	}
+	return nil, ErrUnknownKeySet
+}
+
+// Active returns the active key set, nil if none is loaded
+func (s *KeySets) Active() *KeySet {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if len(s.sets) == 0 {
+		return nil
+	}
+	return s.sets[len(s.sets)-1]
+}
+
+// List returns the loaded key sets, in the order they were loaded: the last one is active
+func (s *KeySets) List() []*KeySet {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]*KeySet(nil), s.sets...)
+}
+
+// Status returns the status of the backend of the active key set
+func (s *KeySets) Status() Status {
+	if active := s.Active(); active != nil {
+		return active.Backend.Status()
+	}
+	status := Status{TreeDepth: s.treeDepth, Keys: make(map[Circuit]KeyState)}
+	for _, c := range Circuits {
+		status.Keys[c] = KeysUnloaded
+	}
+	return status
+}
+
+// closeBackend releases the resources of backend, if it holds any (ex: the workers of a Pool)
+func closeBackend(backend Backend) {
+	if closer, ok := backend.(io.Closer); ok {
+		closer.Close()
+	}
+}
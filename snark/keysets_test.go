@@ -0,0 +1,349 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+// writeKeySet writes (fake) keys of all circuits and their manifest, of tree depth treeDepth, in a new
+// temporary directory
+func writeKeySet(t *testing.T, treeDepth uint) string {
+	keyDir, err := ioutil.TempDir("", "zslbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range Circuits {
+		pkPath, vkPath := KeyFiles(keyDir, c)
+		for _, path := range []string{pkPath, vkPath} {
+			if err := ioutil.WriteFile(path, zsl.RandomBytes(32), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	manifest, err := NewManifest(keyDir, treeDepth, PPZKSNARK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Write(keyDir); err != nil {
+		t.Fatal(err)
+	}
+	return keyDir
+}
+
+// loadingMock is a mock whose keys are loading until loaded is closed
+type loadingMock struct {
+	*mock
+	loaded chan struct{}
+}
+
+func (m *loadingMock) Status() Status {
+	status := m.mock.Status()
+	select {
+	case <-m.loaded:
+	default:
+		for c := range status.Keys {
+			status.Keys[c] = KeysLoading
+		}
+	}
+	return status
+}
+
+func TestKeySets(t *testing.T) {
+	keySets := NewKeySets(zsl.TreeDepth, func() (Backend, error) { return &mock{}, nil })
+	if keySets.Active() != nil || keySets.Status().Ready() {
+		t.Fatal("no key set should be loaded")
+	}
+	if _, err := keySets.Get(""); err != ErrUnknownKeySet {
+		t.Fatal("expected ErrUnknownKeySet, got", err)
+	}
+
+	oldDir, rotatedDir := writeKeySet(t, zsl.TreeDepth), writeKeySet(t, zsl.TreeDepth)
+	defer os.RemoveAll(oldDir)
+	defer os.RemoveAll(rotatedDir)
+	old, err := keySets.Load(oldDir, 0, PPZKSNARK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID, err := old.ID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oldID) != 2*zsl.HashSize {
+		t.Fatal("unexpected key ID", oldID)
+	}
+	rho, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	proof, err := old.Backend.ProveShielding(rho, pk, 42, defaultAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotation: new proofs use the new key set, the old proofs still verify with the old one
+	rotated, err := keySets.Add(context.Background(), rotatedDir, 0, PPZKSNARK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedID, err := rotated.ID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotatedID == oldID {
+		t.Fatal("key sets of different directories should have different key IDs")
+	}
+	if active, _ := keySets.Get(""); active != rotated {
+		t.Fatal("the last loaded key set should be active")
+	}
+	keySet, err := keySets.Get(oldID)
+	if err != nil || keySet != old {
+		t.Fatal("couldn't get key set by key ID", err)
+	}
+	sendNullifier, cm := mockSendNullifier(rho), mockCommitment(rho, pk, 42, defaultAsset)
+	if !keySet.Backend.VerifyShielding(proof, sendNullifier, cm, 42, defaultAsset) {
+		t.Fatal("couldn't verify proof with its key set")
+	}
+	if rotated.Backend.VerifyShielding(proof, sendNullifier, cm, 42, defaultAsset) {
+		t.Fatal("proof verified with another key set")
+	}
+	if _, err := keySets.Add(context.Background(), oldDir, 0, PPZKSNARK); err == nil {
+		t.Fatal("Add should fail on a loaded key set")
+	}
+
+	// Add never generates keys
+	if _, err := keySets.Add(context.Background(), "keys-3", 0, PPZKSNARK); err == nil {
+		t.Fatal("Add should fail on a directory without keys")
+	}
+	incompleteDir := writeKeySet(t, zsl.TreeDepth)
+	defer os.RemoveAll(incompleteDir)
+	if err := os.Remove(filepath.Join(incompleteDir, Transfer.String()+".pk")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keySets.Add(context.Background(), incompleteDir, 0, PPZKSNARK); err == nil {
+		t.Fatal("Add should fail on a directory without the keys of a circuit")
+	}
+	if active := keySets.Active(); active != rotated || len(keySets.List()) != 2 {
+		t.Fatal("a failed Add changed the key sets")
+	}
+
+	if _, err := keySets.Retire(rotatedID); err != ErrActiveKeySet {
+		t.Fatal("expected ErrActiveKeySet, got", err)
+	}
+	if _, err := keySets.Retire(oldID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keySets.Get(oldID); err != ErrUnknownKeySet {
+		t.Fatal("expected ErrUnknownKeySet, got", err)
+	}
+	if _, err := keySets.Retire(oldID); err != ErrUnknownKeySet {
+		t.Fatal("expected ErrUnknownKeySet, got", err)
+	}
+	if list := keySets.List(); len(list) != 1 || list[0] != rotated {
+		t.Fatal("unexpected key sets", list)
+	}
+}
+
+func TestKeySetsTreeDepth(t *testing.T) {
+	keySets := NewKeySets(zsl.TreeDepth, func() (Backend, error) { return &mock{}, nil })
+
+	// existing keys: the tree depth of their manifest
+	keyDir, err := ioutil.TempDir("", "zslbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(keyDir)
+	if err := ioutil.WriteFile(filepath.Join(keyDir, ManifestFile), []byte(`{"circuitVersion": 1, "treeDepth": 16}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keySet, err := keySets.Load(keyDir, 0, PPZKSNARK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth := keySet.Backend.Status().TreeDepth; depth != 16 {
+		t.Fatal("expected the tree depth of the manifest, got", depth)
+	}
+
+	// added keys: the tree depth of their manifest, which must match the requested one
+	addedDir := writeKeySet(t, 8)
+	defer os.RemoveAll(addedDir)
+	if _, err := keySets.Add(context.Background(), addedDir, 16, PPZKSNARK); err == nil {
+		t.Fatal("Add should fail on keys of another tree depth")
+	}
+	if keySet, err = keySets.Add(context.Background(), addedDir, 0, PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	if depth := keySet.Backend.Status().TreeDepth; depth != 8 {
+		t.Fatal("expected the tree depth of the manifest, got", depth)
+	}
+
+	// new keys (at startup): the default tree depth, or the requested one
+	if keySet, err = keySets.Load("keys-1", 0, PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	if depth := keySet.Backend.Status().TreeDepth; depth != zsl.TreeDepth {
+		t.Fatal("expected the default tree depth, got", depth)
+	}
+	if keySet, err = keySets.Load("keys-2", 8, PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	if depth := keySet.Backend.Status().TreeDepth; depth != 8 {
+		t.Fatal("expected the requested tree depth, got", depth)
+	}
+	if _, err := keySets.Load("keys-3", 65, PPZKSNARK); err == nil {
+		t.Fatal("Load should fail on a tree depth over 64")
+	}
+}
+
+func TestKeySetsAddLoading(t *testing.T) {
+	backends := make(chan *loadingMock, 3)
+	keySets := NewKeySets(zsl.TreeDepth, func() (Backend, error) {
+		backend := &loadingMock{mock: &mock{}, loaded: make(chan struct{})}
+		backends <- backend
+		return backend, nil
+	})
+	first, err := keySets.Load("keys-1", 0, PPZKSNARK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close((<-backends).loaded)
+	keyDir := writeKeySet(t, zsl.TreeDepth)
+	defer os.RemoveAll(keyDir)
+
+	// a key set whose keys don't load in time isn't added
+	ctx, cancel := context.WithTimeout(context.Background(), 3*keysPollInterval)
+	defer cancel()
+	if _, err := keySets.Add(ctx, keyDir, 0, PPZKSNARK); err != context.DeadlineExceeded {
+		t.Fatal("expected context.DeadlineExceeded, got", err)
+	}
+	<-backends
+	if active := keySets.Active(); active != first || len(keySets.List()) != 1 {
+		t.Fatal("a key set was added before its keys were loaded")
+	}
+
+	// the added key set is only active once its keys are loaded
+	added := make(chan *KeySet)
+	go func() {
+		keySet, err := keySets.Add(context.Background(), keyDir, 0, PPZKSNARK)
+		if err != nil {
+			t.Error(err)
+		}
+		added <- keySet
+	}()
+	loading := <-backends
+	time.Sleep(3 * keysPollInterval)
+	if active := keySets.Active(); active != first {
+		t.Fatal("a key set was made active while its keys were loading")
+	}
+	close(loading.loaded)
+	keySet := <-added
+	if active := keySets.Active(); keySet == nil || active != keySet || !keySets.Status().Ready() {
+		t.Fatal("the added key set should be active once its keys are loaded")
+	}
+}
+
+// closingMock is a mock whose Close blocks until closed is closed
+type closingMock struct {
+	*mock
+	closing, closed chan struct{}
+}
+
+func (m *closingMock) Close() error {
+	close(m.closing)
+	<-m.closed
+	return nil
+}
+
+func TestKeySetsRetireClosing(t *testing.T) {
+	retired := &closingMock{mock: &mock{}, closing: make(chan struct{}), closed: make(chan struct{})}
+	backends := []Backend{retired, &mock{}}
+	keySets := NewKeySets(zsl.TreeDepth, func() (Backend, error) {
+		backend := backends[0]
+		backends = backends[1:]
+		return backend, nil
+	})
+	old, err := keySets.Load("keys-1", 0, PPZKSNARK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID, _ := old.ID()
+	keyDir := writeKeySet(t, zsl.TreeDepth)
+	defer os.RemoveAll(keyDir)
+	active, err := keySets.Add(context.Background(), keyDir, 0, PPZKSNARK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the key sets are available while the retired one's backend is closing
+	errs := make(chan error)
+	go func() {
+		_, err := keySets.Retire(oldID)
+		errs <- err
+	}()
+	<-retired.closing
+	if keySets.Active() != active || len(keySets.List()) != 1 {
+		t.Fatal("unexpected key sets while closing the retired one")
+	}
+	close(retired.closed)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	backend := newMock(t)
+	id, err := KeyID(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SHA256 of the verifying keys fingerprints
+	var fingerprints []byte
+	for _, c := range Circuits {
+		vk, _ := backend.VerifyingKey(c)
+		fingerprint := sha256.Sum256(vk)
+		fingerprints = append(fingerprints, fingerprint[:]...)
+	}
+	if expected := Fingerprint(fingerprints); id != expected {
+		t.Fatalf("key ID is %s, expected %s", id, expected)
+	}
+
+	// key sets differing by the keys of one circuit have different key IDs
+	otherID, err := KeyID(&otherKeyMock{mock: backend, circuit: Transfer4x4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherID == id {
+		t.Fatal("key sets with different transfer 4x4 keys have the same key ID")
+	}
+}
+
+// otherKeyMock is a mock with another verifying key for circuit
+type otherKeyMock struct {
+	*mock
+	circuit Circuit
+}
+
+func (m *otherKeyMock) VerifyingKey(circuit Circuit) ([]byte, error) {
+	vk, err := m.mock.VerifyingKey(circuit)
+	if err != nil || circuit != m.circuit {
+		return vk, err
+	}
+	return append(vk, 0), nil
+}
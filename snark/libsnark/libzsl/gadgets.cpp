@@ -375,6 +375,7 @@ public:
     }
 };
 
+// NoteCommitment computes SHA256(rho | pk | value | asset)
 template<typename FieldT>
 class NoteCommitment : gadget<FieldT> {
 private:
@@ -391,6 +392,7 @@ public:
 		pb_variable_array<FieldT> rho,
 		pb_variable_array<FieldT> pk,
 		pb_variable_array<FieldT> value,
+		pb_variable_array<FieldT> asset,
 		std::shared_ptr<digest_variable<FieldT>> result
 	) : gadget<FieldT>(pb) {
 		pb_linear_combination_array<FieldT> IV = SHA256_default_IV(pb);
@@ -409,6 +411,7 @@ public:
             *intermediate,
         ""));
 
+        // 104 bytes message: 24 bytes of padding, length 832 bits
         pb_variable_array<FieldT> length_padding =
             from_bits({
                 // padding
@@ -434,44 +437,13 @@ public:
                 0,0,0,0,0,0,0,0,
                 0,0,0,0,0,0,0,0,
                 0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,0,0,
-                0,0,0,0,0,0,1,0,
+                0,0,0,0,0,0,1,1,
                 0,1,0,0,0,0,0,0
 		}, ZERO);
 
         block2.reset(new block_variable<FieldT>(pb, {
             value,
+            asset,
             length_padding
         }, ""));
 
@@ -506,10 +478,12 @@ private:
 
     // SHA256(0x00 | rho)
     std::shared_ptr<digest_variable<FieldT>> send_nullifier;
-    // The note commitment: SHA256(rho | pk | value)
+    // The note commitment: SHA256(rho | pk | value | asset)
     std::shared_ptr<digest_variable<FieldT>> cm;
     // 64-bit value
     pb_variable_array<FieldT> value;
+    // asset identifier
+    std::shared_ptr<digest_variable<FieldT>> asset;
 
     // Aux inputs
     pb_variable<FieldT> ZERO;
@@ -538,6 +512,9 @@ public:
 	    	value.allocate(pb, 64, "");
 	    	zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), value.begin(), value.end());
 
+	    	asset.reset(new digest_variable<FieldT>(pb, 256, ""));
+	    	zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), asset->bits.begin(), asset->bits.end());
+
 	    	assert(zk_unpacked_inputs.size() == verifying_input_bit_size());
 
 	    	unpacker.reset(new multipacking_gadget<FieldT>(
@@ -554,7 +531,7 @@ public:
 	    rho.reset(new digest_variable<FieldT>(pb, 256, ""));
 	    pk.reset(new digest_variable<FieldT>(pb, 256, ""));
 
-	    cm_hasher.reset(new NoteCommitment<FieldT>(pb, ZERO, rho->bits, pk->bits, value, cm));
+	    cm_hasher.reset(new NoteCommitment<FieldT>(pb, ZERO, rho->bits, pk->bits, value, asset->bits, cm));
 	    nf_hasher.reset(new SendNullifier<FieldT>(pb, ZERO, rho->bits, send_nullifier));
     }
 
@@ -572,7 +549,8 @@ public:
     void generate_r1cs_witness(
         const std::vector<unsigned char>& witness_rho,
         const std::vector<unsigned char>& witness_pk,
-        uint64_t witness_value
+        uint64_t witness_value,
+        const std::vector<unsigned char>& witness_asset
     ) {
         this->pb.val(ZERO) = FieldT::zero();
 
@@ -591,6 +569,11 @@ public:
             uint64_to_bool_vector(witness_value)
         );
 
+        asset->bits.fill_with_bits(
+            this->pb,
+            convertBytesVectorToVector(witness_asset)
+        );
+
         cm_hasher->generate_r1cs_witness();
         nf_hasher->generate_r1cs_witness();
 
@@ -600,17 +583,20 @@ public:
     static r1cs_primary_input<FieldT> witness_map(
         const std::vector<unsigned char> &witness_nf,
         const std::vector<unsigned char> &witness_cm,
-        uint64_t witness_value
+        uint64_t witness_value,
+        const std::vector<unsigned char> &witness_asset
     ) {
         std::vector<bool> verify_inputs;
 
         std::vector<bool> nf_bits = convertBytesVectorToVector(witness_nf);
         std::vector<bool> cm_bits = convertBytesVectorToVector(witness_cm);
         std::vector<bool> value_bits = uint64_to_bool_vector(witness_value);
+        std::vector<bool> asset_bits = convertBytesVectorToVector(witness_asset);
 
         verify_inputs.insert(verify_inputs.end(), nf_bits.begin(), nf_bits.end());
         verify_inputs.insert(verify_inputs.end(), cm_bits.begin(), cm_bits.end());
         verify_inputs.insert(verify_inputs.end(), value_bits.begin(), value_bits.end());
+        verify_inputs.insert(verify_inputs.end(), asset_bits.begin(), asset_bits.end());
 
         assert(verify_inputs.size() == verifying_input_bit_size());
         auto verify_field_elements = libff::pack_bit_vector_into_field_element_vector<FieldT>(verify_inputs);
@@ -628,6 +614,7 @@ public:
         acc += 256; // the nullifier
         acc += 256; // the note commitment
         acc += 64; // the value of the note
+        acc += 256; // the asset of the note
 
         return acc;
     }
@@ -700,6 +687,11 @@ private:
 
     // 64-bit value
     pb_variable_array<FieldT> value;
+    // asset identifier
+    std::shared_ptr<digest_variable<FieldT>> asset;
+    // binding of the proof to its context (ex: recipient or transaction hash). It takes part in no constraint
+    // but, as a verifier input, the proof is only valid for it.
+    std::shared_ptr<digest_variable<FieldT>> binding;
 
     // Aux inputs
     pb_variable<FieldT> ZERO;
@@ -742,6 +734,12 @@ public:
             value.allocate(pb, 64, "");
             zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), value.begin(), value.end());
 
+            asset.reset(new digest_variable<FieldT>(pb, 256, ""));
+            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), asset->bits.begin(), asset->bits.end());
+
+            binding.reset(new digest_variable<FieldT>(pb, 256, ""));
+            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), binding->bits.begin(), binding->bits.end());
+
             assert(zk_unpacked_inputs.size() == verifying_input_bit_size());
 
             unpacker.reset(new multipacking_gadget<FieldT>(
@@ -761,7 +759,7 @@ public:
         cm.reset(new digest_variable<FieldT>(pb, 256, ""));
 
         key_hasher.reset(new KeyHasher<FieldT>(pb, ZERO, sk->bits, pk));
-        cm_hasher.reset(new NoteCommitment<FieldT>(pb, ZERO, rho->bits, pk->bits, value, cm));
+        cm_hasher.reset(new NoteCommitment<FieldT>(pb, ZERO, rho->bits, pk->bits, value, asset->bits, cm));
         nf_hasher.reset(new SpendNullifier<FieldT>(pb, ZERO, rho->bits, sk->bits, spend_nullifier));
         auto test = ONE;
         merkle_lookup.reset(new merkle_tree_gadget<FieldT>(pb, *cm, *anchor, test));
@@ -784,8 +782,10 @@ public:
         const std::vector<unsigned char>& witness_rho,
         const std::vector<unsigned char>& witness_sk,
         uint64_t witness_value,
+        const std::vector<unsigned char>& witness_asset,
         size_t path_index,
-        const std::vector<std::vector<bool>>& authentication_path
+        const std::vector<std::vector<bool>>& authentication_path,
+        const std::vector<unsigned char>& witness_binding
     ) {
         this->pb.val(ZERO) = FieldT::zero();
 
@@ -804,6 +804,16 @@ public:
             uint64_to_bool_vector(witness_value)
         );
 
+        asset->bits.fill_with_bits(
+            this->pb,
+            convertBytesVectorToVector(witness_asset)
+        );
+
+        binding->bits.fill_with_bits(
+            this->pb,
+            convertBytesVectorToVector(witness_binding)
+        );
+
         key_hasher->generate_r1cs_witness();
         cm_hasher->generate_r1cs_witness();
         nf_hasher->generate_r1cs_witness();
@@ -815,17 +825,23 @@ public:
     static r1cs_primary_input<FieldT> witness_map(
         const std::vector<unsigned char> &witness_nf,
         const std::vector<unsigned char> &witness_anchor,
-        uint64_t witness_value
+        uint64_t witness_value,
+        const std::vector<unsigned char> &witness_asset,
+        const std::vector<unsigned char> &witness_binding
     ) {
         std::vector<bool> verify_inputs;
 
         std::vector<bool> nf_bits = convertBytesVectorToVector(witness_nf);
         std::vector<bool> anchor_bits = convertBytesVectorToVector(witness_anchor);
         std::vector<bool> value_bits = uint64_to_bool_vector(witness_value);
+        std::vector<bool> asset_bits = convertBytesVectorToVector(witness_asset);
+        std::vector<bool> binding_bits = convertBytesVectorToVector(witness_binding);
 
         verify_inputs.insert(verify_inputs.end(), nf_bits.begin(), nf_bits.end());
         verify_inputs.insert(verify_inputs.end(), anchor_bits.begin(), anchor_bits.end());
         verify_inputs.insert(verify_inputs.end(), value_bits.begin(), value_bits.end());
+        verify_inputs.insert(verify_inputs.end(), asset_bits.begin(), asset_bits.end());
+        verify_inputs.insert(verify_inputs.end(), binding_bits.begin(), binding_bits.end());
 
         assert(verify_inputs.size() == verifying_input_bit_size());
         auto verify_field_elements = libff::pack_bit_vector_into_field_element_vector<FieldT>(verify_inputs);
@@ -843,6 +859,8 @@ public:
         acc += 256; // the nullifier
         acc += 256; // the anchor
         acc += 64; // the value of the note
+        acc += 256; // the asset of the note
+        acc += 256; // the binding
 
         return acc;
     }
@@ -872,9 +890,19 @@ linear_combination<FieldT> packed_addition(pb_variable_array<FieldT> input) {
     ));
 }
 
+// TransferCircuit spends n_inputs notes of the tree of root anchor and creates n_outputs notes of the same
+// total value. All its notes are of the same asset, so that the balance is per asset; the asset is private.
+// With public_values, the public values vpub_in and vpub_out, the fee, and their asset, are verifier inputs
+// too, and the balance is inputs + vpub_in = outputs + vpub_out + fee (as in the Sprout JoinSplit, with the
+// fee paid to whoever submits the transfer, ex: a relayer).
+// The last verifier input is the binding of the proof to its context (see UnshieldingCircuit).
 template<typename FieldT>
 class TransferCircuit : gadget<FieldT> {
 private:
+    size_t n_inputs;
+    size_t n_outputs;
+    bool public_values;
+
     // Verifier inputs
     pb_variable_array<FieldT> zk_packed_inputs;
     pb_variable_array<FieldT> zk_unpacked_inputs;
@@ -885,78 +913,96 @@ private:
 
     // Verifier inputs
     std::shared_ptr<digest_variable<FieldT>> anchor;
-    std::shared_ptr<digest_variable<FieldT>> spend_nullifier_input_1;
-    std::shared_ptr<digest_variable<FieldT>> spend_nullifier_input_2;
-    std::shared_ptr<digest_variable<FieldT>> send_nullifier_output_1;
-    std::shared_ptr<digest_variable<FieldT>> send_nullifier_output_2;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> spend_nullifier_input;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> send_nullifier_output;
+    pb_variable_array<FieldT> vpub_in;
+    pb_variable_array<FieldT> vpub_out;
+    pb_variable_array<FieldT> fee;
+
+    // asset of all the notes (verifier input with public values)
+    std::shared_ptr<digest_variable<FieldT>> asset;
+    // binding of the proof to its context (verifier input)
+    std::shared_ptr<digest_variable<FieldT>> binding;
 
     // Input stuff.
-    std::shared_ptr<digest_variable<FieldT>> input_sk_1;
-    std::shared_ptr<digest_variable<FieldT>> input_sk_2;
-    std::shared_ptr<digest_variable<FieldT>> input_pk_1;
-    std::shared_ptr<digest_variable<FieldT>> input_pk_2;
-    std::shared_ptr<KeyHasher<FieldT>> key_hasher_1;
-    std::shared_ptr<KeyHasher<FieldT>> key_hasher_2;
-    std::shared_ptr<digest_variable<FieldT>> input_rho_1;
-    std::shared_ptr<digest_variable<FieldT>> input_rho_2;
-    std::shared_ptr<SpendNullifier<FieldT>> input_nf_hasher_1;
-    std::shared_ptr<SpendNullifier<FieldT>> input_nf_hasher_2;
-    pb_variable_array<FieldT> input_value_1;
-    pb_variable_array<FieldT> input_value_2;
-    std::shared_ptr<digest_variable<FieldT>> input_cm_1;
-    std::shared_ptr<digest_variable<FieldT>> input_cm_2;
-    std::shared_ptr<NoteCommitment<FieldT>> input_cm_hasher_1;
-    std::shared_ptr<NoteCommitment<FieldT>> input_cm_hasher_2;
-    pb_variable<FieldT> enforce_input_1;
-    pb_variable<FieldT> enforce_input_2;
-    std::shared_ptr<merkle_tree_gadget<FieldT>> merkle_lookup_1;
-    std::shared_ptr<merkle_tree_gadget<FieldT>> merkle_lookup_2;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> input_sk;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> input_pk;
+    std::vector<std::shared_ptr<KeyHasher<FieldT>>> key_hasher;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> input_rho;
+    std::vector<std::shared_ptr<SpendNullifier<FieldT>>> input_nf_hasher;
+    std::vector<pb_variable_array<FieldT>> input_value;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> input_cm;
+    std::vector<std::shared_ptr<NoteCommitment<FieldT>>> input_cm_hasher;
+    std::vector<pb_variable<FieldT>> enforce_input;
+    std::vector<std::shared_ptr<merkle_tree_gadget<FieldT>>> merkle_lookup;
 
     // Output stuff.
-    std::shared_ptr<digest_variable<FieldT>> output_cm_1;
-    pb_variable_array<FieldT> output_value_1;
-    std::shared_ptr<digest_variable<FieldT>> output_rho_1;
-    std::shared_ptr<digest_variable<FieldT>> output_pk_1;
-    std::shared_ptr<NoteCommitment<FieldT>> output_cm_hasher_1;
-    std::shared_ptr<SendNullifier<FieldT>> output_nf_hasher_1;
-
-    std::shared_ptr<digest_variable<FieldT>> output_cm_2;
-    pb_variable_array<FieldT> output_value_2;
-    std::shared_ptr<digest_variable<FieldT>> output_rho_2;
-    std::shared_ptr<digest_variable<FieldT>> output_pk_2;
-    std::shared_ptr<NoteCommitment<FieldT>> output_cm_hasher_2;
-    std::shared_ptr<SendNullifier<FieldT>> output_nf_hasher_2;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> output_cm;
+    std::vector<pb_variable_array<FieldT>> output_value;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> output_rho;
+    std::vector<std::shared_ptr<digest_variable<FieldT>>> output_pk;
+    std::vector<std::shared_ptr<NoteCommitment<FieldT>>> output_cm_hasher;
+    std::vector<std::shared_ptr<SendNullifier<FieldT>>> output_nf_hasher;
+
+    // digests allocates n digest variables
+    static std::vector<std::shared_ptr<digest_variable<FieldT>>> digests(protoboard<FieldT> &pb, size_t n) {
+        std::vector<std::shared_ptr<digest_variable<FieldT>>> toReturn;
+        for (size_t i = 0; i < n; i++) {
+            toReturn.push_back(std::make_shared<digest_variable<FieldT>>(pb, 256, ""));
+        }
+        return toReturn;
+    }
+
+    // values allocates n 64-bit values
+    static std::vector<pb_variable_array<FieldT>> values(protoboard<FieldT> &pb, size_t n) {
+        std::vector<pb_variable_array<FieldT>> toReturn(n);
+        for (size_t i = 0; i < n; i++) {
+            toReturn[i].allocate(pb, 64, "");
+        }
+        return toReturn;
+    }
 
 public:
 
-    TransferCircuit(protoboard<FieldT> &pb) : gadget<FieldT>(pb) {
+    TransferCircuit(protoboard<FieldT> &pb, size_t n_inputs, size_t n_outputs, bool public_values) : gadget<FieldT>(pb), n_inputs(n_inputs), n_outputs(n_outputs), public_values(public_values) {
         // Inputs
         {
-            zk_packed_inputs.allocate(pb, verifying_field_element_size());
-            pb.set_input_sizes(verifying_field_element_size());
+            zk_packed_inputs.allocate(pb, verifying_field_element_size(n_inputs, n_outputs, public_values));
+            pb.set_input_sizes(verifying_field_element_size(n_inputs, n_outputs, public_values));
 
             anchor.reset(new digest_variable<FieldT>(pb, 256, ""));
             zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), anchor->bits.begin(), anchor->bits.end());
 
-            spend_nullifier_input_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), spend_nullifier_input_1->bits.begin(), spend_nullifier_input_1->bits.end());
-
-            spend_nullifier_input_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), spend_nullifier_input_2->bits.begin(), spend_nullifier_input_2->bits.end());
-
-            send_nullifier_output_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), send_nullifier_output_1->bits.begin(), send_nullifier_output_1->bits.end());
-
-            send_nullifier_output_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), send_nullifier_output_2->bits.begin(), send_nullifier_output_2->bits.end());
-
-            output_cm_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), output_cm_1->bits.begin(), output_cm_1->bits.end());
-
-            output_cm_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), output_cm_2->bits.begin(), output_cm_2->bits.end());
-
-            assert(zk_unpacked_inputs.size() == verifying_input_bit_size());
+            spend_nullifier_input = digests(pb, n_inputs);
+            for (auto& nf : spend_nullifier_input) {
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), nf->bits.begin(), nf->bits.end());
+            }
+
+            send_nullifier_output = digests(pb, n_outputs);
+            for (auto& nf : send_nullifier_output) {
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), nf->bits.begin(), nf->bits.end());
+            }
+
+            output_cm = digests(pb, n_outputs);
+            for (auto& cm : output_cm) {
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), cm->bits.begin(), cm->bits.end());
+            }
+
+            if (public_values) {
+                vpub_in.allocate(pb, 64, "");
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), vpub_in.begin(), vpub_in.end());
+                vpub_out.allocate(pb, 64, "");
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), vpub_out.begin(), vpub_out.end());
+                fee.allocate(pb, 64, "");
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), fee.begin(), fee.end());
+                asset.reset(new digest_variable<FieldT>(pb, 256, ""));
+                zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), asset->bits.begin(), asset->bits.end());
+            }
+
+            binding.reset(new digest_variable<FieldT>(pb, 256, ""));
+            zk_unpacked_inputs.insert(zk_unpacked_inputs.end(), binding->bits.begin(), binding->bits.end());
+
+            assert(zk_unpacked_inputs.size() == verifying_input_bit_size(n_inputs, n_outputs, public_values));
 
             unpacker.reset(new multipacking_gadget<FieldT>(
                 pb,
@@ -969,124 +1015,141 @@ public:
 
         // Aux
         ZERO.allocate(pb);
-        input_cm_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_cm_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_sk_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_sk_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_pk_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_pk_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_rho_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-        input_rho_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-        key_hasher_1.reset(new KeyHasher<FieldT>(pb, ZERO, input_sk_1->bits, input_pk_1));
-        key_hasher_2.reset(new KeyHasher<FieldT>(pb, ZERO, input_sk_2->bits, input_pk_2));
-        input_nf_hasher_1.reset(new SpendNullifier<FieldT>(pb, ZERO, input_rho_1->bits, input_sk_1->bits, spend_nullifier_input_1));
-        input_nf_hasher_2.reset(new SpendNullifier<FieldT>(pb, ZERO, input_rho_2->bits, input_sk_2->bits, spend_nullifier_input_2));
-
-        input_value_1.allocate(pb, 64, "");
-        input_value_2.allocate(pb, 64, "");
-
-        input_cm_hasher_1.reset(new NoteCommitment<FieldT>(pb, ZERO, input_rho_1->bits, input_pk_1->bits, input_value_1, input_cm_1));
-        input_cm_hasher_2.reset(new NoteCommitment<FieldT>(pb, ZERO, input_rho_2->bits, input_pk_2->bits, input_value_2, input_cm_2));
-
-        enforce_input_1.allocate(pb);
-        enforce_input_2.allocate(pb);
-        
-        merkle_lookup_1.reset(new merkle_tree_gadget<FieldT>(pb, *input_cm_1, *anchor, enforce_input_1));
-        merkle_lookup_2.reset(new merkle_tree_gadget<FieldT>(pb, *input_cm_2, *anchor, enforce_input_2));
-
-        output_value_1.allocate(pb, 64, "");
-        output_value_2.allocate(pb, 64, "");
-
-        output_rho_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-        output_rho_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-        output_pk_1.reset(new digest_variable<FieldT>(pb, 256, ""));
-        output_pk_2.reset(new digest_variable<FieldT>(pb, 256, ""));
-
-        output_cm_hasher_1.reset(new NoteCommitment<FieldT>(pb, ZERO, output_rho_1->bits, output_pk_1->bits, output_value_1, output_cm_1));
-        output_cm_hasher_2.reset(new NoteCommitment<FieldT>(pb, ZERO, output_rho_2->bits, output_pk_2->bits, output_value_2, output_cm_2));
-
-        output_nf_hasher_1.reset(new SendNullifier<FieldT>(pb, ZERO, output_rho_1->bits, send_nullifier_output_1));
-        output_nf_hasher_2.reset(new SendNullifier<FieldT>(pb, ZERO, output_rho_2->bits, send_nullifier_output_2));
+        if (!public_values) {
+            asset.reset(new digest_variable<FieldT>(pb, 256, ""));
+        }
+        input_cm = digests(pb, n_inputs);
+        input_sk = digests(pb, n_inputs);
+        input_pk = digests(pb, n_inputs);
+        input_rho = digests(pb, n_inputs);
+        for (size_t i = 0; i < n_inputs; i++) {
+            key_hasher.push_back(std::make_shared<KeyHasher<FieldT>>(pb, ZERO, input_sk[i]->bits, input_pk[i]));
+        }
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_nf_hasher.push_back(std::make_shared<SpendNullifier<FieldT>>(pb, ZERO, input_rho[i]->bits, input_sk[i]->bits, spend_nullifier_input[i]));
+        }
+
+        input_value = values(pb, n_inputs);
+
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_cm_hasher.push_back(std::make_shared<NoteCommitment<FieldT>>(pb, ZERO, input_rho[i]->bits, input_pk[i]->bits, input_value[i], asset->bits, input_cm[i]));
+        }
+
+        enforce_input.resize(n_inputs);
+        for (size_t i = 0; i < n_inputs; i++) {
+            enforce_input[i].allocate(pb);
+        }
+
+        for (size_t i = 0; i < n_inputs; i++) {
+            merkle_lookup.push_back(std::make_shared<merkle_tree_gadget<FieldT>>(pb, *input_cm[i], *anchor, enforce_input[i]));
+        }
+
+        output_value = values(pb, n_outputs);
+
+        output_rho = digests(pb, n_outputs);
+        output_pk = digests(pb, n_outputs);
+
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_cm_hasher.push_back(std::make_shared<NoteCommitment<FieldT>>(pb, ZERO, output_rho[j]->bits, output_pk[j]->bits, output_value[j], asset->bits, output_cm[j]));
+        }
+
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_nf_hasher.push_back(std::make_shared<SendNullifier<FieldT>>(pb, ZERO, output_rho[j]->bits, send_nullifier_output[j]));
+        }
     }
 
     void generate_r1cs_constraints() {
         unpacker->generate_r1cs_constraints(true);
         generate_r1cs_equals_const_constraint<FieldT>(this->pb, ZERO, FieldT::zero(), "ZERO");
 
-        input_sk_1->generate_r1cs_constraints();
-        input_sk_2->generate_r1cs_constraints();
-        input_rho_1->generate_r1cs_constraints();
-        input_rho_2->generate_r1cs_constraints();
-        key_hasher_1->generate_r1cs_constraints();
-        key_hasher_2->generate_r1cs_constraints();
-        input_nf_hasher_1->generate_r1cs_constraints();
-        input_nf_hasher_2->generate_r1cs_constraints();
-
-        for (size_t i = 0; i < 64; i++) {
-            generate_boolean_r1cs_constraint<FieldT>(
-                this->pb,
-                input_value_1[i],
-                ""
-            );
-            generate_boolean_r1cs_constraint<FieldT>(
-                this->pb,
-                input_value_2[i],
-                ""
-            );
+        // the unpacker enforces the bitness of the verifier inputs
+        if (!public_values) {
+            asset->generate_r1cs_constraints();
         }
 
-        input_cm_hasher_1->generate_r1cs_constraints();
-        input_cm_hasher_2->generate_r1cs_constraints();
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_sk[i]->generate_r1cs_constraints();
+        }
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_rho[i]->generate_r1cs_constraints();
+        }
+        for (size_t i = 0; i < n_inputs; i++) {
+            key_hasher[i]->generate_r1cs_constraints();
+        }
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_nf_hasher[i]->generate_r1cs_constraints();
+        }
 
-        generate_boolean_r1cs_constraint<FieldT>(this->pb, enforce_input_1, "");
+        for (size_t b = 0; b < 64; b++) {
+            for (size_t i = 0; i < n_inputs; i++) {
+                generate_boolean_r1cs_constraint<FieldT>(
+                    this->pb,
+                    input_value[i][b],
+                    ""
+                );
+            }
+        }
 
-        this->pb.add_r1cs_constraint(r1cs_constraint<FieldT>(
-                    packed_addition(input_value_1),
-                    (1 - enforce_input_1),
-                    0
-        ), "");
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_cm_hasher[i]->generate_r1cs_constraints();
+        }
 
-        generate_boolean_r1cs_constraint<FieldT>(this->pb, enforce_input_2, "");
+        for (size_t i = 0; i < n_inputs; i++) {
+            generate_boolean_r1cs_constraint<FieldT>(this->pb, enforce_input[i], "");
 
-        this->pb.add_r1cs_constraint(r1cs_constraint<FieldT>(
-                    packed_addition(input_value_2),
-                    (1 - enforce_input_2),
-                    0
-        ), "");
+            this->pb.add_r1cs_constraint(r1cs_constraint<FieldT>(
+                        packed_addition(input_value[i]),
+                        (1 - enforce_input[i]),
+                        0
+            ), "");
+        }
 
-        merkle_lookup_1->generate_r1cs_constraints();
-        merkle_lookup_2->generate_r1cs_constraints();
+        for (size_t i = 0; i < n_inputs; i++) {
+            merkle_lookup[i]->generate_r1cs_constraints();
+        }
 
-        for (size_t i = 0; i < 64; i++) {
-            generate_boolean_r1cs_constraint<FieldT>(
-                this->pb,
-                output_value_1[i],
-                ""
-            );
-            generate_boolean_r1cs_constraint<FieldT>(
-                this->pb,
-                output_value_2[i],
-                ""
-            );
+        for (size_t b = 0; b < 64; b++) {
+            for (size_t j = 0; j < n_outputs; j++) {
+                generate_boolean_r1cs_constraint<FieldT>(
+                    this->pb,
+                    output_value[j][b],
+                    ""
+                );
+            }
         }
 
-        output_rho_1->generate_r1cs_constraints();
-        output_rho_2->generate_r1cs_constraints();
-        output_pk_1->generate_r1cs_constraints();
-        output_pk_2->generate_r1cs_constraints();
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_rho[j]->generate_r1cs_constraints();
+        }
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_pk[j]->generate_r1cs_constraints();
+        }
 
-        output_cm_hasher_1->generate_r1cs_constraints();
-        output_cm_hasher_2->generate_r1cs_constraints();
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_cm_hasher[j]->generate_r1cs_constraints();
+        }
 
-        output_nf_hasher_1->generate_r1cs_constraints();
-        output_nf_hasher_2->generate_r1cs_constraints();
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_nf_hasher[j]->generate_r1cs_constraints();
+        }
 
         {
-            linear_combination<FieldT> left_side =
-                packed_addition(input_value_1) + packed_addition(input_value_2);
-
-            linear_combination<FieldT> right_side =
-                packed_addition(output_value_1) + packed_addition(output_value_2);
+            linear_combination<FieldT> left_side;
+            for (size_t i = 0; i < n_inputs; i++) {
+                left_side = left_side + packed_addition(input_value[i]);
+            }
+
+            linear_combination<FieldT> right_side;
+            for (size_t j = 0; j < n_outputs; j++) {
+                right_side = right_side + packed_addition(output_value[j]);
+            }
+
+            // the public values are bits of the verifier inputs, whose bitness the unpacker enforces
+            if (public_values) {
+                left_side = left_side + packed_addition(vpub_in);
+                right_side = right_side + packed_addition(vpub_out);
+                right_side = right_side + packed_addition(fee);
+            }
 
             // Ensure that both sides are equal
             this->pb.add_r1cs_constraint(r1cs_constraint<FieldT>(
@@ -1097,156 +1160,163 @@ public:
         }
     }
 
+    // the witness of the input i is witness_rho[i], witness_sk[i], witness_value[i], path_index[i] and
+    // authentication_path[i], the one of the output j output_witness_rho[j], output_witness_pk[j] and
+    // output_witness_value[j], the asset of all the notes witness_asset. witness_vpub_in, witness_vpub_out
+    // and witness_fee must be 0 without public values. witness_binding is the binding of the proof.
     void generate_r1cs_witness(
-        const std::vector<unsigned char>& witness_rho_1,
-        const std::vector<unsigned char>& witness_sk_1,
-        uint64_t witness_value_1,
-        size_t path_index_1,
-        const std::vector<std::vector<bool>>& authentication_path_1,
-        const std::vector<unsigned char>& witness_rho_2,
-        const std::vector<unsigned char>& witness_sk_2,
-        uint64_t witness_value_2,
-        size_t path_index_2,
-        const std::vector<std::vector<bool>>& authentication_path_2,
-        const std::vector<unsigned char>& output_witness_rho_1,
-        const std::vector<unsigned char>& output_witness_pk_1,
-        uint64_t output_witness_value_1,
-        const std::vector<unsigned char>& output_witness_rho_2,
-        const std::vector<unsigned char>& output_witness_pk_2,
-        uint64_t output_witness_value_2
+        const std::vector<std::vector<unsigned char>>& witness_rho,
+        const std::vector<std::vector<unsigned char>>& witness_sk,
+        const std::vector<uint64_t>& witness_value,
+        const std::vector<size_t>& path_index,
+        const std::vector<std::vector<std::vector<bool>>>& authentication_path,
+        const std::vector<std::vector<unsigned char>>& output_witness_rho,
+        const std::vector<std::vector<unsigned char>>& output_witness_pk,
+        const std::vector<uint64_t>& output_witness_value,
+        uint64_t witness_vpub_in,
+        uint64_t witness_vpub_out,
+        uint64_t witness_fee,
+        const std::vector<unsigned char>& witness_asset,
+        const std::vector<unsigned char>& witness_binding
     ) {
         this->pb.val(ZERO) = FieldT::zero();
 
-        this->pb.val(enforce_input_1) = (witness_value_1 != 0) ? FieldT::one() : FieldT::zero();
-        this->pb.val(enforce_input_2) = (witness_value_2 != 0) ? FieldT::one() : FieldT::zero();
-
-        input_rho_1->bits.fill_with_bits(
-            this->pb,
-            convertBytesVectorToVector(witness_rho_1)
-        );
-
-        input_sk_1->bits.fill_with_bits(
-            this->pb,
-            convertBytesVectorToVector(witness_sk_1)
-        );
-
-        input_value_1.fill_with_bits(
-            this->pb,
-            uint64_to_bool_vector(witness_value_1)
-        );
-
-        key_hasher_1->generate_r1cs_witness();
-        input_cm_hasher_1->generate_r1cs_witness();
-        input_nf_hasher_1->generate_r1cs_witness();
-        merkle_lookup_1->generate_r1cs_witness(path_index_1, authentication_path_1);
-
-        input_rho_2->bits.fill_with_bits(
+        asset->bits.fill_with_bits(
             this->pb,
-            convertBytesVectorToVector(witness_rho_2)
+            convertBytesVectorToVector(witness_asset)
         );
 
-        input_sk_2->bits.fill_with_bits(
+        binding->bits.fill_with_bits(
             this->pb,
-            convertBytesVectorToVector(witness_sk_2)
+            convertBytesVectorToVector(witness_binding)
         );
 
-        input_value_2.fill_with_bits(
-            this->pb,
-            uint64_to_bool_vector(witness_value_2)
-        );
+        if (public_values) {
+            vpub_in.fill_with_bits(
+                this->pb,
+                uint64_to_bool_vector(witness_vpub_in)
+            );
+            vpub_out.fill_with_bits(
+                this->pb,
+                uint64_to_bool_vector(witness_vpub_out)
+            );
+            fee.fill_with_bits(
+                this->pb,
+                uint64_to_bool_vector(witness_fee)
+            );
+        } else if (witness_vpub_in != 0 || witness_vpub_out != 0 || witness_fee != 0) {
+            throw std::invalid_argument("public values without public values circuit");
+        }
 
-        key_hasher_2->generate_r1cs_witness();
-        input_cm_hasher_2->generate_r1cs_witness();
-        input_nf_hasher_2->generate_r1cs_witness();
-        merkle_lookup_2->generate_r1cs_witness(path_index_2, authentication_path_2);
+        for (size_t i = 0; i < n_inputs; i++) {
+            this->pb.val(enforce_input[i]) = (witness_value[i] != 0) ? FieldT::one() : FieldT::zero();
+        }
 
-        output_rho_1->bits.fill_with_bits(
-            this->pb,
-            convertBytesVectorToVector(output_witness_rho_1)
-        );
+        for (size_t i = 0; i < n_inputs; i++) {
+            input_rho[i]->bits.fill_with_bits(
+                this->pb,
+                convertBytesVectorToVector(witness_rho[i])
+            );
 
-        output_pk_1->bits.fill_with_bits(
-            this->pb,
-            convertBytesVectorToVector(output_witness_pk_1)
-        );
+            input_sk[i]->bits.fill_with_bits(
+                this->pb,
+                convertBytesVectorToVector(witness_sk[i])
+            );
 
-        output_value_1.fill_with_bits(
-            this->pb,
-            uint64_to_bool_vector(output_witness_value_1)
-        );
+            input_value[i].fill_with_bits(
+                this->pb,
+                uint64_to_bool_vector(witness_value[i])
+            );
 
-        output_cm_hasher_1->generate_r1cs_witness();
-        output_nf_hasher_1->generate_r1cs_witness();
+            key_hasher[i]->generate_r1cs_witness();
+            input_cm_hasher[i]->generate_r1cs_witness();
+            input_nf_hasher[i]->generate_r1cs_witness();
+            merkle_lookup[i]->generate_r1cs_witness(path_index[i], authentication_path[i]);
+        }
 
-        output_rho_2->bits.fill_with_bits(
-            this->pb,
-            convertBytesVectorToVector(output_witness_rho_2)
-        );
+        for (size_t j = 0; j < n_outputs; j++) {
+            output_rho[j]->bits.fill_with_bits(
+                this->pb,
+                convertBytesVectorToVector(output_witness_rho[j])
+            );
 
-        output_pk_2->bits.fill_with_bits(
-            this->pb,
-            convertBytesVectorToVector(output_witness_pk_2)
-        );
+            output_pk[j]->bits.fill_with_bits(
+                this->pb,
+                convertBytesVectorToVector(output_witness_pk[j])
+            );
 
-        output_value_2.fill_with_bits(
-            this->pb,
-            uint64_to_bool_vector(output_witness_value_2)
-        );
+            output_value[j].fill_with_bits(
+                this->pb,
+                uint64_to_bool_vector(output_witness_value[j])
+            );
 
-        output_cm_hasher_2->generate_r1cs_witness();
-        output_nf_hasher_2->generate_r1cs_witness();
+            output_cm_hasher[j]->generate_r1cs_witness();
+            output_nf_hasher[j]->generate_r1cs_witness();
+        }
 
         unpacker->generate_r1cs_witness_from_bits();
     }
 
+    // witness_map packs the anchor, the input spend nullifiers, the output send nullifiers and the output
+    // commitments, then with public_values vpub_in, vpub_out, fee and asset, and last the binding
     static r1cs_primary_input<FieldT> witness_map(
         const std::vector<unsigned char> &witness_anchor,
-        const std::vector<unsigned char> &input_nf_1,
-        const std::vector<unsigned char> &input_nf_2,
-        const std::vector<unsigned char> &output_nf_1,
-        const std::vector<unsigned char> &output_nf_2,
-        const std::vector<unsigned char> &output_cm_1,
-        const std::vector<unsigned char> &output_cm_2
+        const std::vector<std::vector<unsigned char>> &input_nf,
+        const std::vector<std::vector<unsigned char>> &output_nf,
+        const std::vector<std::vector<unsigned char>> &output_cm,
+        bool public_values,
+        uint64_t vpub_in,
+        uint64_t vpub_out,
+        uint64_t fee,
+        const std::vector<unsigned char> &asset,
+        const std::vector<unsigned char> &binding
     )
     {
         std::vector<bool> verify_inputs;
 
         std::vector<bool> anchor_bits = convertBytesVectorToVector(witness_anchor);
-        std::vector<bool> input_nf1_bits = convertBytesVectorToVector(input_nf_1);
-        std::vector<bool> input_nf2_bits = convertBytesVectorToVector(input_nf_2);
-        std::vector<bool> output_nf1_bits = convertBytesVectorToVector(output_nf_1);
-        std::vector<bool> output_nf2_bits = convertBytesVectorToVector(output_nf_2);
-        std::vector<bool> output_cm1_bits = convertBytesVectorToVector(output_cm_1);
-        std::vector<bool> output_cm2_bits = convertBytesVectorToVector(output_cm_2);
-
         verify_inputs.insert(verify_inputs.end(), anchor_bits.begin(), anchor_bits.end());
-        verify_inputs.insert(verify_inputs.end(), input_nf1_bits.begin(), input_nf1_bits.end());
-        verify_inputs.insert(verify_inputs.end(), input_nf2_bits.begin(), input_nf2_bits.end());
-        verify_inputs.insert(verify_inputs.end(), output_nf1_bits.begin(), output_nf1_bits.end());
-        verify_inputs.insert(verify_inputs.end(), output_nf2_bits.begin(), output_nf2_bits.end());
-        verify_inputs.insert(verify_inputs.end(), output_cm1_bits.begin(), output_cm1_bits.end());
-        verify_inputs.insert(verify_inputs.end(), output_cm2_bits.begin(), output_cm2_bits.end());
+        for (auto hashes : {input_nf, output_nf, output_cm}) {
+            for (auto& h : hashes) {
+                std::vector<bool> bits = convertBytesVectorToVector(h);
+                verify_inputs.insert(verify_inputs.end(), bits.begin(), bits.end());
+            }
+        }
+        if (public_values) {
+            for (auto value : {vpub_in, vpub_out, fee}) {
+                std::vector<bool> value_bits = uint64_to_bool_vector(value);
+                verify_inputs.insert(verify_inputs.end(), value_bits.begin(), value_bits.end());
+            }
+            std::vector<bool> asset_bits = convertBytesVectorToVector(asset);
+            verify_inputs.insert(verify_inputs.end(), asset_bits.begin(), asset_bits.end());
+        }
+        std::vector<bool> binding_bits = convertBytesVectorToVector(binding);
+        verify_inputs.insert(verify_inputs.end(), binding_bits.begin(), binding_bits.end());
 
-        assert(verify_inputs.size() == verifying_input_bit_size());
+        assert(verify_inputs.size() == verifying_input_bit_size(input_nf.size(), output_cm.size(), public_values));
         auto verify_field_elements = libff::pack_bit_vector_into_field_element_vector<FieldT>(verify_inputs);
-        assert(verify_field_elements.size() == verifying_field_element_size());
+        assert(verify_field_elements.size() == verifying_field_element_size(input_nf.size(), output_cm.size(), public_values));
         return verify_field_elements;
     }
 
-    static size_t verifying_field_element_size() {
-        return libff::div_ceil(verifying_input_bit_size(), FieldT::capacity());
+    static size_t verifying_field_element_size(size_t n_inputs, size_t n_outputs, bool public_values) {
+        return libff::div_ceil(verifying_input_bit_size(n_inputs, n_outputs, public_values), FieldT::capacity());
     }
 
-    static size_t verifying_input_bit_size() {
+    static size_t verifying_input_bit_size(size_t n_inputs, size_t n_outputs, bool public_values) {
         size_t acc = 0;
 
         acc += 256; // the anchor
-        acc += 256; // input 1 nullifier
-        acc += 256; // input 2 nullifier
-        acc += 256; // output 1 nullifier
-        acc += 256; // output 2 nullifier
-        acc += 256; // output 1 commitment
-        acc += 256; // output 2 commitment
+        acc += 256 * n_inputs; // input nullifiers
+        acc += 256 * n_outputs; // output nullifiers
+        acc += 256 * n_outputs; // output commitments
+        if (public_values) {
+            acc += 64; // vpub_in
+            acc += 64; // vpub_out
+            acc += 64; // fee
+            acc += 256; // asset
+        }
+        acc += 256; // binding
 
         return acc;
     }
@@ -18,7 +18,9 @@
 #include <iostream>
 #include <libsnark/gadgetlib1/gadgets/basic_gadgets.hpp>
 #include <libsnark/zk_proof_systems/ppzksnark/r1cs_ppzksnark/r1cs_ppzksnark.hpp>
+#include <libsnark/zk_proof_systems/ppzksnark/r1cs_gg_ppzksnark/r1cs_gg_ppzksnark.hpp>
 #include <libsnark/common/default_types/r1cs_ppzksnark_pp.hpp>
+#include <libsnark/common/default_types/r1cs_gg_ppzksnark_pp.hpp>
 #include <libff/common/utils.hpp>
 #include <libff/common/profiling.hpp>
 #include <libff/algebra/fields/field_utils.hpp>
@@ -28,13 +30,36 @@ using namespace std;
 
 namespace zsl {
     size_t TREE_DEPTH = 29;
-
-    r1cs_ppzksnark_verification_key<default_r1cs_ppzksnark_pp> vkShielding;
-    r1cs_ppzksnark_verification_key<default_r1cs_ppzksnark_pp> vkUnshielding;
-    r1cs_ppzksnark_verification_key<default_r1cs_ppzksnark_pp> vkTransfer;
-    r1cs_ppzksnark_proving_key<default_r1cs_ppzksnark_pp> pkShielding;
-    r1cs_ppzksnark_proving_key<default_r1cs_ppzksnark_pp> pkUnshielding;
-    r1cs_ppzksnark_proving_key<default_r1cs_ppzksnark_pp> pkTransfer;
+    int PROVING_SYSTEM = ZSL_PPZKSNARK;
+
+    // keys of a circuit; only those of PROVING_SYSTEM are loaded
+    struct keys {
+        r1cs_ppzksnark_proving_key<default_r1cs_ppzksnark_pp> pk;
+        r1cs_ppzksnark_verification_key<default_r1cs_ppzksnark_pp> vk;
+        r1cs_gg_ppzksnark_proving_key<default_r1cs_gg_ppzksnark_pp> gg_pk;
+        r1cs_gg_ppzksnark_verification_key<default_r1cs_gg_ppzksnark_pp> gg_vk;
+    };
+
+    keys shielding;
+    keys unshielding;
+
+    // arities (inputs, outputs) of the shielded transfer circuits, and their keys, without and with public
+    // values
+    const size_t TRANSFER_ARITIES[][2] = {{1, 1}, {1, 2}, {2, 2}, {4, 2}, {4, 4}};
+    const size_t NB_TRANSFER_ARITIES = sizeof(TRANSFER_ARITIES) / sizeof(TRANSFER_ARITIES[0]);
+    keys transfer[NB_TRANSFER_ARITIES];
+    keys public_transfer[NB_TRANSFER_ARITIES];
+
+    // transfer_keys returns the keys of the transfer circuit of n_inputs inputs and n_outputs outputs, nullptr
+    // if there is none
+    keys *transfer_keys(size_t n_inputs, size_t n_outputs, bool public_values) {
+        for (size_t i = 0; i < NB_TRANSFER_ARITIES; i++) {
+            if (TRANSFER_ARITIES[i][0] == n_inputs && TRANSFER_ARITIES[i][1] == n_outputs) {
+                return public_values ? &public_transfer[i] : &transfer[i];
+            }
+        }
+        return nullptr;
+    }
 }
 
 #include "gadgets.cpp"
@@ -44,50 +69,146 @@ typedef libff::Fr<default_r1cs_ppzksnark_pp> FieldT;
 #include <fstream>
 
 
-void zsl_initialize(uint tree_depth)
+void zsl_initialize(uint tree_depth, int proving_system)
 {
     zsl::TREE_DEPTH = tree_depth;
+    zsl::PROVING_SYSTEM = proving_system;
     default_r1cs_ppzksnark_pp::init_public_params();
     libff::inhibit_profiling_info = true;
     libff::inhibit_profiling_counters = true;
 }
 
+size_t zsl_proof_size()
+{
+    return zsl::PROVING_SYSTEM == ZSL_GROTH16 ? ZSL_GROTH16_PROOF_SIZE : ZSL_PPZKSNARK_PROOF_SIZE;
+}
+
 
 template<typename T>
-void saveToFile(string path, T& obj) {
+bool saveToFile(string path, const T& obj) {
     stringstream ss;
     ss << obj;
     ofstream fh;
     fh.open(path, ios::binary);
+    if (!fh.is_open()) {
+        return false;
+    }
     ss.rdbuf()->pubseekpos(0, ios_base::out);
     fh << ss.rdbuf();
     fh.flush();
     fh.close();
+    return !fh.fail();
 }
 
 template<typename T>
-void loadFromFile(string path, T& objIn) {
+bool loadFromFile(string path, T& objIn) {
     stringstream ss;
     ifstream fh(path, ios::binary);
+    if (!fh.is_open()) {
+        return false;
+    }
     ss << fh.rdbuf();
     fh.close();
     ss.rdbuf()->pubseekpos(0, ios_base::in);
     ss >> objIn;
+    return !ss.fail();
+}
+
+// loadKeys loads the keys of the proving system of the key set
+int loadKeys(const char *pk_path, const char *vk_path, zsl::keys& keys) {
+    try {
+        bool loaded;
+        if (zsl::PROVING_SYSTEM == ZSL_GROTH16) {
+            loaded = loadFromFile(vk_path, keys.gg_vk) && loadFromFile(pk_path, keys.gg_pk);
+        } else {
+            loaded = loadFromFile(vk_path, keys.vk) && loadFromFile(pk_path, keys.pk);
+        }
+        return loaded ? ZSL_OK : ZSL_ERR_KEYS;
+    } catch (...) {
+        return ZSL_ERR_KEYS;
+    }
+}
+
+// paramgen generates and saves the keys of the constraint system, for the proving system of the key set
+void paramgen(const r1cs_constraint_system<FieldT>& constraint_system, const char *pk_path, const char *vk_path)
+{
+    cout << "Number of R1CS constraints: " << constraint_system.num_constraints() << endl;
+    if (zsl::PROVING_SYSTEM == ZSL_GROTH16) {
+        auto crs = r1cs_gg_ppzksnark_generator<default_r1cs_gg_ppzksnark_pp>(constraint_system);
+        saveToFile(pk_path, crs.pk);
+        saveToFile(vk_path, crs.vk);
+        return;
+    }
+    auto crs = r1cs_ppzksnark_generator<default_r1cs_ppzksnark_pp>(constraint_system);
+    saveToFile(pk_path, crs.pk);
+    saveToFile(vk_path, crs.vk);
+}
+
+// writeProof serializes proof to output_proof (zsl_proof_size() bytes)
+template<typename T>
+int writeProof(const T& proof, void *output_proof_ptr) {
+    unsigned char *output_proof = reinterpret_cast<unsigned char *>(output_proof_ptr);
+
+    stringstream proof_data;
+    proof_data << proof;
+    auto proof_str = proof_data.str();
+    if (proof_str.size() != zsl_proof_size()) {
+        return ZSL_ERR_PROVER;
+    }
+    for (size_t i = 0; i < proof_str.size(); i++) {
+        output_proof[i] = proof_str[i];
+    }
+    return ZSL_OK;
+}
+
+// prove computes the proof of the witness of pb, with the keys of the proving system of the key set
+int prove(const zsl::keys& keys, const protoboard<FieldT>& pb, void *output_proof_ptr) {
+    if (!pb.is_satisfied()) {
+        return ZSL_ERR_UNSATISFIED_WITNESS;
+    }
+    if (zsl::PROVING_SYSTEM == ZSL_GROTH16) {
+        auto proof = r1cs_gg_ppzksnark_prover<default_r1cs_gg_ppzksnark_pp>(keys.gg_pk, pb.primary_input(), pb.auxiliary_input());
+        return writeProof(proof, output_proof_ptr);
+    }
+    auto proof = r1cs_ppzksnark_prover<default_r1cs_ppzksnark_pp>(keys.pk, pb.primary_input(), pb.auxiliary_input());
+    return writeProof(proof, output_proof_ptr);
+}
+
+// readProof parses a serialized proof (zsl_proof_size() bytes)
+template<typename T>
+bool readProof(void *proof_ptr, T& proof) {
+    char *raw = reinterpret_cast<char *>(proof_ptr);
+    stringstream proof_data(string(raw, raw + zsl_proof_size()));
+    proof_data >> proof;
+    return !proof_data.fail();
+}
+
+// verify checks the proof of the primary input, with the keys of the proving system of the key set
+bool verify(const zsl::keys& keys, void *proof_ptr, const r1cs_primary_input<FieldT>& primary_input) {
+    if (zsl::PROVING_SYSTEM == ZSL_GROTH16) {
+        r1cs_gg_ppzksnark_proof<default_r1cs_gg_ppzksnark_pp> proof;
+        return readProof(proof_ptr, proof) &&
+            r1cs_gg_ppzksnark_verifier_strong_IC<default_r1cs_gg_ppzksnark_pp>(keys.gg_vk, primary_input, proof);
+    }
+    r1cs_ppzksnark_proof<default_r1cs_ppzksnark_pp> proof;
+    return readProof(proof_ptr, proof) &&
+        r1cs_ppzksnark_verifier_strong_IC<default_r1cs_ppzksnark_pp>(keys.vk, primary_input, proof);
 }
 
-void zsl_load_shielding_keys() {
-    loadFromFile("/keys/shielding.vk", zsl::vkShielding);
-    loadFromFile("/keys/shielding.pk", zsl::pkShielding);
+int zsl_load_shielding_keys(const char *pk_path, const char *vk_path) {
+    return loadKeys(pk_path, vk_path, zsl::shielding);
 }
 
-void zsl_load_unshielding_keys() {
-    loadFromFile("/keys/unshielding.vk", zsl::vkUnshielding);
-    loadFromFile("/keys/unshielding.pk", zsl::pkUnshielding);
+int zsl_load_unshielding_keys(const char *pk_path, const char *vk_path) {
+    return loadKeys(pk_path, vk_path, zsl::unshielding);
 }
 
-void zsl_load_transfer_keys() {
-    loadFromFile("/keys/transfer.vk", zsl::vkTransfer);
-    loadFromFile("/keys/transfer.pk", zsl::pkTransfer);
+int zsl_load_transfer_keys(size_t n_inputs, size_t n_outputs, bool public_values, const char *pk_path, const char *vk_path) {
+    zsl::keys *keys = zsl::transfer_keys(n_inputs, n_outputs, public_values);
+    if (keys == nullptr) {
+        return ZSL_ERR_KEYS;
+    }
+    return loadKeys(pk_path, vk_path, *keys);
 }
 
 
@@ -95,338 +216,314 @@ bool zsl_verify_shielding(
     void *proof_ptr,
     void *send_nf_ptr,
     void *cm_ptr,
-    uint64_t value
+    uint64_t value,
+    void *asset_ptr
 )
 {
     unsigned char *send_nf = reinterpret_cast<unsigned char *>(send_nf_ptr);
     unsigned char *cm = reinterpret_cast<unsigned char *>(cm_ptr);
-    unsigned char *proof = reinterpret_cast<unsigned char *>(proof_ptr);
-
-    vector<unsigned char> proof_v(proof, proof+584);
-
-    stringstream proof_data;
-    for (int i = 0; i < 584; i++) {
-        proof_data << proof_v[i];
-    }
-
-    assert(proof_data.str().size() == 584);
-
-    proof_data.rdbuf()->pubseekpos(0, ios_base::in);
-
-    r1cs_ppzksnark_proof<default_r1cs_ppzksnark_pp> proof_obj;
-    proof_data >> proof_obj;
+    unsigned char *asset = reinterpret_cast<unsigned char *>(asset_ptr);
 
     auto witness_map = ShieldingCircuit<FieldT>::witness_map(
         vector<unsigned char>(send_nf, send_nf+32),
         vector<unsigned char>(cm, cm+32),
-        value
+        value,
+        vector<unsigned char>(asset, asset+32)
     );
 
-    if (!r1cs_ppzksnark_verifier_strong_IC<default_r1cs_ppzksnark_pp>(zsl::vkShielding, witness_map, proof_obj)) {
-        return false;
-    } else {
-        return true;
-    }
+    return verify(zsl::shielding, proof_ptr, witness_map);
 }
 
 bool zsl_verify_unshielding(
     void *proof_ptr,
     void *spend_nf_ptr,
     void *rt_ptr,
-    uint64_t value
+    uint64_t value,
+    void *asset_ptr,
+    void *binding_ptr
 )
 {
     unsigned char *spend_nf = reinterpret_cast<unsigned char *>(spend_nf_ptr);
     unsigned char *rt = reinterpret_cast<unsigned char *>(rt_ptr);
-    unsigned char *proof = reinterpret_cast<unsigned char *>(proof_ptr);
-
-    vector<unsigned char> proof_v(proof, proof+584);
-
-    stringstream proof_data;
-    for (int i = 0; i < 584; i++) {
-        proof_data << proof_v[i];
-    }
-
-    assert(proof_data.str().size() == 584);
-
-    proof_data.rdbuf()->pubseekpos(0, ios_base::in);
-
-    r1cs_ppzksnark_proof<default_r1cs_ppzksnark_pp> proof_obj;
-    proof_data >> proof_obj;
+    unsigned char *asset = reinterpret_cast<unsigned char *>(asset_ptr);
+    unsigned char *binding = reinterpret_cast<unsigned char *>(binding_ptr);
 
     auto witness_map = UnshieldingCircuit<FieldT>::witness_map(
         vector<unsigned char>(spend_nf, spend_nf+32),
         vector<unsigned char>(rt, rt+32),
-        value
+        value,
+        vector<unsigned char>(asset, asset+32),
+        vector<unsigned char>(binding, binding+32)
     );
 
-
-    if (!r1cs_ppzksnark_verifier_strong_IC<default_r1cs_ppzksnark_pp>(zsl::vkUnshielding, witness_map, proof_obj)) {
-        return false;
-    } else {
-        return true;
-    }
+    return verify(zsl::unshielding, proof_ptr, witness_map);
 }
 
-void zsl_prove_unshielding(
+int zsl_prove_unshielding(
     void *rho_ptr,
     void *pk_ptr,
     uint64_t value,
+    void *asset_ptr,
     uint64_t tree_position,
     void *authentication_path_ptr,
+    void *binding_ptr,
     void *output_proof_ptr
 )
 {
-    unsigned char *rho = reinterpret_cast<unsigned char *>(rho_ptr);
-    unsigned char *pk = reinterpret_cast<unsigned char *>(pk_ptr);
-    unsigned char *output_proof = reinterpret_cast<unsigned char *>(output_proof_ptr);
-    unsigned char *authentication_path = reinterpret_cast<unsigned char *>(authentication_path_ptr);
-
-    protoboard<FieldT> pb;
-    UnshieldingCircuit<FieldT> g(pb);
-    g.generate_r1cs_constraints();
-
-    vector<vector<bool>> auth_path;
-    for (uint i = 0; i < zsl::TREE_DEPTH; i++) {
-        auth_path.push_back(convertBytesVectorToVector(vector<unsigned char>(authentication_path + i*32, authentication_path + i*32 + 32)));
-    }
-
-    reverse(begin(auth_path), end(auth_path));
-
-    g.generate_r1cs_witness(
-        vector<unsigned char>(rho, rho + 32),
-        vector<unsigned char>(pk, pk + 32),
-        value,
-        tree_position,
-        auth_path
-    );
-    // pb.get_constraint_system().swap_AB_if_beneficial(); //TODO check this modification.
-    assert(pb.is_satisfied());
-
-    auto proof = r1cs_ppzksnark_prover<default_r1cs_ppzksnark_pp>(zsl::pkUnshielding, pb.primary_input(), pb.auxiliary_input());
-
-    stringstream proof_data;
-    proof_data << proof;
-    auto proof_str = proof_data.str();
-    assert(proof_str.size() == 584);
-
-    for (int i = 0; i < 584; i++) {
-        output_proof[i] = proof_str[i];
+    try {
+        unsigned char *rho = reinterpret_cast<unsigned char *>(rho_ptr);
+        unsigned char *pk = reinterpret_cast<unsigned char *>(pk_ptr);
+        unsigned char *asset = reinterpret_cast<unsigned char *>(asset_ptr);
+        unsigned char *authentication_path = reinterpret_cast<unsigned char *>(authentication_path_ptr);
+        unsigned char *binding = reinterpret_cast<unsigned char *>(binding_ptr);
+
+        protoboard<FieldT> pb;
+        UnshieldingCircuit<FieldT> g(pb);
+        g.generate_r1cs_constraints();
+
+        vector<vector<bool>> auth_path;
+        for (uint i = 0; i < zsl::TREE_DEPTH; i++) {
+            auth_path.push_back(convertBytesVectorToVector(vector<unsigned char>(authentication_path + i*32, authentication_path + i*32 + 32)));
+        }
+
+        reverse(begin(auth_path), end(auth_path));
+
+        g.generate_r1cs_witness(
+            vector<unsigned char>(rho, rho + 32),
+            vector<unsigned char>(pk, pk + 32),
+            value,
+            vector<unsigned char>(asset, asset + 32),
+            tree_position,
+            auth_path,
+            vector<unsigned char>(binding, binding + 32)
+        );
+        // pb.get_constraint_system().swap_AB_if_beneficial(); //TODO check this modification.
+
+        return prove(zsl::unshielding, pb, output_proof_ptr);
+    } catch (...) {
+        return ZSL_ERR_PROVER;
     }
 }
 
-void zsl_prove_shielding(
+int zsl_prove_shielding(
     void *rho_ptr,
     void *pk_ptr,
     uint64_t value,
+    void *asset_ptr,
     void *output_proof_ptr
 )
 {
-    unsigned char *rho = reinterpret_cast<unsigned char *>(rho_ptr);
-    unsigned char *pk = reinterpret_cast<unsigned char *>(pk_ptr);
-    unsigned char *output_proof = reinterpret_cast<unsigned char *>(output_proof_ptr);
-
-
-    protoboard<FieldT> pb;
-    ShieldingCircuit<FieldT> g(pb);
-    g.generate_r1cs_constraints();
-    g.generate_r1cs_witness(
-        // rho
-        vector<unsigned char>(rho, rho + 32),
-        // pk
-        vector<unsigned char>(pk, pk + 32),
-        // value
-        value
-    );
-    // pb.get_constraint_system().swap_AB_if_beneficial(); // TODO check this modification
-    assert(pb.is_satisfied());
+    try {
+        unsigned char *rho = reinterpret_cast<unsigned char *>(rho_ptr);
+        unsigned char *pk = reinterpret_cast<unsigned char *>(pk_ptr);
+        unsigned char *asset = reinterpret_cast<unsigned char *>(asset_ptr);
+
+
+        protoboard<FieldT> pb;
+        ShieldingCircuit<FieldT> g(pb);
+        g.generate_r1cs_constraints();
+        g.generate_r1cs_witness(
+            // rho
+            vector<unsigned char>(rho, rho + 32),
+            // pk
+            vector<unsigned char>(pk, pk + 32),
+            // value
+            value,
+            // asset
+            vector<unsigned char>(asset, asset + 32)
+        );
+        // pb.get_constraint_system().swap_AB_if_beneficial(); // TODO check this modification
+
+        return prove(zsl::shielding, pb, output_proof_ptr);
+    } catch (...) {
+        return ZSL_ERR_PROVER;
+    }
+}
 
-    auto proof = r1cs_ppzksnark_prover<default_r1cs_ppzksnark_pp>(zsl::pkShielding, pb.primary_input(), pb.auxiliary_input());
-    stringstream proof_data;
-    proof_data << proof;
-    auto proof_str = proof_data.str();
-    assert(proof_str.size() == 584);
-    for (int i = 0; i < 584; i++) {
-        output_proof[i] = proof_str[i];
+// readHashes reads n hashes of 32 bytes
+vector<vector<unsigned char>> readHashes(void *ptr, size_t n) {
+    unsigned char *hashes = reinterpret_cast<unsigned char *>(ptr);
+    vector<vector<unsigned char>> toReturn;
+    for (size_t i = 0; i < n; i++) {
+        toReturn.push_back(vector<unsigned char>(hashes + i*32, hashes + i*32 + 32));
     }
+    return toReturn;
 }
 
 bool zsl_verify_transfer(
+    size_t n_inputs,
+    size_t n_outputs,
+    bool public_values,
     void *proof_ptr,
     void *anchor_ptr,
-    void *spend_nf_ptr_1,
-    void *spend_nf_ptr_2,
-    void *send_nf_ptr_1,
-    void *send_nf_ptr_2,
-    void *cm_ptr_1,
-    void *cm_ptr_2
+    void *spend_nfs_ptr,
+    void *send_nfs_ptr,
+    void *cms_ptr,
+    uint64_t vpub_in,
+    uint64_t vpub_out,
+    uint64_t fee,
+    void *asset_ptr,
+    void *binding_ptr
 )
 {
-    unsigned char *anchor = reinterpret_cast<unsigned char *>(anchor_ptr);
-    unsigned char *spend_nf_1 = reinterpret_cast<unsigned char *>(spend_nf_ptr_1);
-    unsigned char *spend_nf_2 = reinterpret_cast<unsigned char *>(spend_nf_ptr_2);
-    unsigned char *send_nf_1 = reinterpret_cast<unsigned char *>(send_nf_ptr_1);
-    unsigned char *send_nf_2 = reinterpret_cast<unsigned char *>(send_nf_ptr_2);
-    unsigned char *cm_1 = reinterpret_cast<unsigned char *>(cm_ptr_1);
-    unsigned char *cm_2 = reinterpret_cast<unsigned char *>(cm_ptr_2);
-    unsigned char *proof = reinterpret_cast<unsigned char *>(proof_ptr);
-
-    vector<unsigned char> proof_v(proof, proof+584);
-
-    stringstream proof_data;
-    for (int i = 0; i < 584; i++) {
-        proof_data << proof_v[i];
+    zsl::keys *keys = zsl::transfer_keys(n_inputs, n_outputs, public_values);
+    if (keys == nullptr) {
+        return false;
     }
-
-    assert(proof_data.str().size() == 584);
-
-    proof_data.rdbuf()->pubseekpos(0, ios_base::in);
-
-    r1cs_ppzksnark_proof<default_r1cs_ppzksnark_pp> proof_obj;
-    proof_data >> proof_obj;
+    unsigned char *anchor = reinterpret_cast<unsigned char *>(anchor_ptr);
 
     auto witness_map = TransferCircuit<FieldT>::witness_map(
         vector<unsigned char>(anchor, anchor+32),
-        vector<unsigned char>(spend_nf_1, spend_nf_1+32),
-        vector<unsigned char>(spend_nf_2, spend_nf_2+32),
-        vector<unsigned char>(send_nf_1, send_nf_1+32),
-        vector<unsigned char>(send_nf_2, send_nf_2+32),
-        vector<unsigned char>(cm_1, cm_1+32),
-        vector<unsigned char>(cm_2, cm_2+32)
+        readHashes(spend_nfs_ptr, n_inputs),
+        readHashes(send_nfs_ptr, n_outputs),
+        readHashes(cms_ptr, n_outputs),
+        public_values,
+        vpub_in,
+        vpub_out,
+        fee,
+        readHashes(asset_ptr, 1)[0],
+        readHashes(binding_ptr, 1)[0]
     );
 
-
-    if (!r1cs_ppzksnark_verifier_strong_IC<default_r1cs_ppzksnark_pp>(zsl::vkTransfer, witness_map, proof_obj)) {
-        return false;
-    } else {
-        return true;
-    }
+    return verify(*keys, proof_ptr, witness_map);
 }
 
-void zsl_prove_transfer(
-    void *input_rho_ptr_1,
-    void *input_pk_ptr_1,
-    uint64_t input_value_1,
-    uint64_t input_tree_position_1,
-    void *input_authentication_path_ptr_1,
-    void *input_rho_ptr_2,
-    void *input_pk_ptr_2,
-    uint64_t input_value_2,
-    uint64_t input_tree_position_2,
-    void *input_authentication_path_ptr_2,
-    void *output_rho_ptr_1,
-    void *output_pk_ptr_1,
-    uint64_t output_value_1,
-    void *output_rho_ptr_2,
-    void *output_pk_ptr_2,
-    uint64_t output_value_2,
+int zsl_prove_transfer(
+    size_t n_inputs,
+    size_t n_outputs,
+    bool public_values,
+    void *input_rhos_ptr,
+    void *input_sks_ptr,
+    uint64_t *input_values,
+    uint64_t *input_tree_positions,
+    void *input_authentication_paths_ptr,
+    void *output_rhos_ptr,
+    void *output_pks_ptr,
+    uint64_t *output_values,
+    uint64_t vpub_in,
+    uint64_t vpub_out,
+    uint64_t fee,
+    void *asset_ptr,
+    void *binding_ptr,
     void *output_proof_ptr
 )
 {
-    unsigned char *output_proof = reinterpret_cast<unsigned char *>(output_proof_ptr);
-
-    unsigned char *input_rho_1 = reinterpret_cast<unsigned char *>(input_rho_ptr_1);
-    unsigned char *input_pk_1 = reinterpret_cast<unsigned char *>(input_pk_ptr_1);
-    unsigned char *authentication_path_1 = reinterpret_cast<unsigned char *>(input_authentication_path_ptr_1);
-
-    unsigned char *input_rho_2 = reinterpret_cast<unsigned char *>(input_rho_ptr_2);
-    unsigned char *input_pk_2 = reinterpret_cast<unsigned char *>(input_pk_ptr_2);
-    unsigned char *authentication_path_2 = reinterpret_cast<unsigned char *>(input_authentication_path_ptr_2);
-
-    unsigned char *output_rho_1 = reinterpret_cast<unsigned char *>(output_rho_ptr_1);
-    unsigned char *output_pk_1 = reinterpret_cast<unsigned char *>(output_pk_ptr_1);
-    unsigned char *output_rho_2 = reinterpret_cast<unsigned char *>(output_rho_ptr_2);
-    unsigned char *output_pk_2 = reinterpret_cast<unsigned char *>(output_pk_ptr_2);
-
-    vector<vector<bool>> auth_path_1;
-    for (uint i = 0; i < zsl::TREE_DEPTH; i++) {
-        auth_path_1.push_back(convertBytesVectorToVector(vector<unsigned char>(authentication_path_1 + i*32, authentication_path_1 + i*32 + 32)));
+    zsl::keys *keys = zsl::transfer_keys(n_inputs, n_outputs, public_values);
+    if (keys == nullptr) {
+        return ZSL_ERR_KEYS;
     }
-
-    reverse(begin(auth_path_1), end(auth_path_1));
-
-    vector<vector<bool>> auth_path_2;
-    for (uint i = 0; i < zsl::TREE_DEPTH; i++) {
-        auth_path_2.push_back(convertBytesVectorToVector(vector<unsigned char>(authentication_path_2 + i*32, authentication_path_2 + i*32 + 32)));
+    try {
+        unsigned char *authentication_paths = reinterpret_cast<unsigned char *>(input_authentication_paths_ptr);
+
+        vector<vector<vector<bool>>> auth_paths;
+        for (size_t k = 0; k < n_inputs; k++) {
+            unsigned char *authentication_path = authentication_paths + k*zsl::TREE_DEPTH*32;
+            vector<vector<bool>> auth_path;
+            for (uint i = 0; i < zsl::TREE_DEPTH; i++) {
+                auth_path.push_back(convertBytesVectorToVector(vector<unsigned char>(authentication_path + i*32, authentication_path + i*32 + 32)));
+            }
+
+            reverse(begin(auth_path), end(auth_path));
+            auth_paths.push_back(auth_path);
+        }
+
+        protoboard<FieldT> pb;
+        TransferCircuit<FieldT> g(pb, n_inputs, n_outputs, public_values);
+        g.generate_r1cs_constraints();
+        g.generate_r1cs_witness(
+            readHashes(input_rhos_ptr, n_inputs),
+            readHashes(input_sks_ptr, n_inputs),
+            vector<uint64_t>(input_values, input_values + n_inputs),
+            vector<size_t>(input_tree_positions, input_tree_positions + n_inputs),
+            auth_paths,
+            readHashes(output_rhos_ptr, n_outputs),
+            readHashes(output_pks_ptr, n_outputs),
+            vector<uint64_t>(output_values, output_values + n_outputs),
+            vpub_in,
+            vpub_out,
+            fee,
+            readHashes(asset_ptr, 1)[0],
+            readHashes(binding_ptr, 1)[0]
+        );
+        // pb.get_constraint_system().swap_AB_if_beneficial(); // TODO check this
+
+        return prove(*keys, pb, output_proof_ptr);
+    } catch (...) {
+        return ZSL_ERR_PROVER;
     }
+}
 
-    reverse(begin(auth_path_2), end(auth_path_2));
-
+void zsl_paramgen_transfer(size_t n_inputs, size_t n_outputs, bool public_values, const char *pk_path, const char *vk_path)
+{
     protoboard<FieldT> pb;
-    TransferCircuit<FieldT> g(pb);
+    TransferCircuit<FieldT> g(pb, n_inputs, n_outputs, public_values);
     g.generate_r1cs_constraints();
-    g.generate_r1cs_witness(
-        vector<unsigned char>(input_rho_1, input_rho_1 + 32),
-        vector<unsigned char>(input_pk_1, input_pk_1 + 32),
-        input_value_1,
-        input_tree_position_1,
-        auth_path_1,
-        vector<unsigned char>(input_rho_2, input_rho_2 + 32),
-        vector<unsigned char>(input_pk_2, input_pk_2 + 32),
-        input_value_2,
-        input_tree_position_2,
-        auth_path_2,
-        vector<unsigned char>(output_rho_1, output_rho_1 + 32),
-        vector<unsigned char>(output_pk_1, output_pk_1 + 32),
-        output_value_1,
-        vector<unsigned char>(output_rho_2, output_rho_2 + 32),
-        vector<unsigned char>(output_pk_2, output_pk_2 + 32),
-        output_value_2
-    );
-    // pb.get_constraint_system().swap_AB_if_beneficial(); // TODO check this
-    assert(pb.is_satisfied());
 
+    paramgen(pb.get_constraint_system(), pk_path, vk_path);
+}
 
-    auto proof = r1cs_ppzksnark_prover<default_r1cs_ppzksnark_pp>(zsl::pkTransfer, pb.primary_input(), pb.auxiliary_input());
-
-    stringstream proof_data;
-    proof_data << proof;
-    auto proof_str = proof_data.str();
-    assert(proof_str.size() == 584);
+void zsl_paramgen_shielding(const char *pk_path, const char *vk_path)
+{
+    protoboard<FieldT> pb;
+    ShieldingCircuit<FieldT> g(pb);
+    g.generate_r1cs_constraints();
 
-    for (int i = 0; i < 584; i++) {
-        output_proof[i] = proof_str[i];
-    }
+    paramgen(pb.get_constraint_system(), pk_path, vk_path);
 }
 
-void zsl_paramgen_transfer()
+void zsl_paramgen_unshielding(const char *pk_path, const char *vk_path)
 {
     protoboard<FieldT> pb;
-    TransferCircuit<FieldT> g(pb);
+    UnshieldingCircuit<FieldT> g(pb);
     g.generate_r1cs_constraints();
 
-    const r1cs_constraint_system<FieldT> constraint_system = pb.get_constraint_system();
-    cout << "Number of R1CS constraints: " << constraint_system.num_constraints() << endl;
-    auto crs = r1cs_ppzksnark_generator<default_r1cs_ppzksnark_pp>(constraint_system);
-
-    saveToFile("/keys/transfer.pk", crs.pk);
-    saveToFile("/keys/transfer.vk", crs.vk);
+    paramgen(pb.get_constraint_system(), pk_path, vk_path);
 }
 
-void zsl_paramgen_shielding()
+uint64_t zsl_constraints_shielding()
 {
     protoboard<FieldT> pb;
     ShieldingCircuit<FieldT> g(pb);
     g.generate_r1cs_constraints();
+    return pb.get_constraint_system().num_constraints();
+}
 
-    const r1cs_constraint_system<FieldT> constraint_system = pb.get_constraint_system();
-    cout << "Number of R1CS constraints: " << constraint_system.num_constraints() << endl;
-    auto crs = r1cs_ppzksnark_generator<default_r1cs_ppzksnark_pp>(constraint_system);
+uint64_t zsl_constraints_unshielding()
+{
+    protoboard<FieldT> pb;
+    UnshieldingCircuit<FieldT> g(pb);
+    g.generate_r1cs_constraints();
+    return pb.get_constraint_system().num_constraints();
+}
 
-    saveToFile("/keys/shielding.pk", crs.pk);
-    saveToFile("/keys/shielding.vk", crs.vk);
+uint64_t zsl_constraints_transfer(size_t n_inputs, size_t n_outputs, bool public_values)
+{
+    protoboard<FieldT> pb;
+    TransferCircuit<FieldT> g(pb, n_inputs, n_outputs, public_values);
+    g.generate_r1cs_constraints();
+    return pb.get_constraint_system().num_constraints();
 }
 
-void zsl_paramgen_unshielding()
+int zsl_r1cs_shielding(const char *path)
 {
     protoboard<FieldT> pb;
-    UnshieldingCircuit<FieldT> g(pb);
+    ShieldingCircuit<FieldT> g(pb);
     g.generate_r1cs_constraints();
+    return saveToFile(path, pb.get_constraint_system()) ? ZSL_OK : ZSL_ERR_IO;
+}
 
-    const r1cs_constraint_system<FieldT> constraint_system = pb.get_constraint_system();
-    cout << "Number of R1CS constraints: " << constraint_system.num_constraints() << endl;
-    auto crs = r1cs_ppzksnark_generator<default_r1cs_ppzksnark_pp>(constraint_system);
+int zsl_r1cs_unshielding(const char *path)
+{
+    protoboard<FieldT> pb;
+    UnshieldingCircuit<FieldT> g(pb);
+    g.generate_r1cs_constraints();
+    return saveToFile(path, pb.get_constraint_system()) ? ZSL_OK : ZSL_ERR_IO;
+}
 
-    saveToFile("/keys/unshielding.pk", crs.pk);
-    saveToFile("/keys/unshielding.vk", crs.vk);
+int zsl_r1cs_transfer(size_t n_inputs, size_t n_outputs, bool public_values, const char *path)
+{
+    protoboard<FieldT> pb;
+    TransferCircuit<FieldT> g(pb, n_inputs, n_outputs, public_values);
+    g.generate_r1cs_constraints();
+    return saveToFile(path, pb.get_constraint_system()) ? ZSL_OK : ZSL_ERR_IO;
 }
@@ -24,72 +24,117 @@
 extern "C" {
 #endif
 
-    void zsl_initialize(uint tree_depth);
+    // status codes returned by zsl_prove_*, zsl_load_*_keys and zsl_r1cs_*
+    #define ZSL_OK                       0
+    #define ZSL_ERR_UNSATISFIED_WITNESS  1 // witness doesn't satisfy the circuit constraints
+    #define ZSL_ERR_PROVER               2 // libsnark raised an exception or produced a malformed proof
+    #define ZSL_ERR_KEYS                 3 // key files are missing or malformed
+    #define ZSL_ERR_IO                   4 // couldn't write the output file
+
+    // proving systems of a key set (see zsl_initialize), and the size of their proofs
+    #define ZSL_PPZKSNARK             0 // r1cs_ppzksnark (BCTV14)
+    #define ZSL_GROTH16               1 // r1cs_gg_ppzksnark (Groth16)
+    #define ZSL_PPZKSNARK_PROOF_SIZE  584
+    #define ZSL_GROTH16_PROOF_SIZE    259
+
+    // zsl_initialize sets the tree depth of the circuits and the proving system of the keys
+    // generated, loaded and used by the other functions
+    void zsl_initialize(uint tree_depth, int proving_system);
+    size_t zsl_proof_size();
+    // the asset of a note is 32 bytes
     bool zsl_verify_shielding(
         void *proof,
         void *send_nf,
         void *cm,
-        uint64_t value
+        uint64_t value,
+        void *asset
     );
-    void zsl_prove_shielding(
+    int zsl_prove_shielding(
         void *rho,
         void *pk,
         uint64_t value,
+        void *asset,
         void *output_proof
     );
-    void zsl_paramgen_shielding();
+    void zsl_paramgen_shielding(const char *pk_path, const char *vk_path);
 
-    void zsl_prove_unshielding(
+    // unshielding and shielded transfer proofs are bound to a 32 bytes binding (ex: recipient or
+    // transaction hash), a verifier input: they are only valid for it
+    int zsl_prove_unshielding(
         void *rho,
         void *sk,
         uint64_t value,
+        void *asset,
         uint64_t tree_position,
         void *authentication_path,
+        void *binding,
         void *output_proof
     );
     bool zsl_verify_unshielding(
         void *proof_ptr,
         void *spend_nf_ptr,
         void *rt_ptr,
-        uint64_t value
+        uint64_t value,
+        void *asset_ptr,
+        void *binding_ptr
     );
 
-    void zsl_load_shielding_keys();
-    void zsl_load_unshielding_keys();
-    void zsl_load_transfer_keys();
-    void zsl_paramgen_unshielding();
-
-    void zsl_paramgen_transfer();
-
-    void zsl_prove_transfer(
-        void *input_rho_ptr_1,
-        void *input_pk_ptr_1,
-        uint64_t input_value_1,
-        uint64_t input_tree_position_1,
-        void *input_authentication_path_ptr_1,
-        void *input_rho_ptr_2,
-        void *input_pk_ptr_2,
-        uint64_t input_value_2,
-        uint64_t input_tree_position_2,
-        void *input_authentication_path_ptr_2,
-        void *output_rho_ptr_1,
-        void *output_pk_ptr_1,
-        uint64_t output_value_1,
-        void *output_rho_ptr_2,
-        void *output_pk_ptr_2,
-        uint64_t output_value_2,
+    int zsl_load_shielding_keys(const char *pk_path, const char *vk_path);
+    int zsl_load_unshielding_keys(const char *pk_path, const char *vk_path);
+    void zsl_paramgen_unshielding(const char *pk_path, const char *vk_path);
+
+    // number of R1CS constraints of the circuits
+    uint64_t zsl_constraints_shielding();
+    uint64_t zsl_constraints_unshielding();
+
+    // write the R1CS of the circuits (libsnark serialization), for a multi-party setup
+    int zsl_r1cs_shielding(const char *path);
+    int zsl_r1cs_unshielding(const char *path);
+
+    // shielded transfer circuits of n_inputs inputs and n_outputs outputs, of arity 1x1, 1x2, 2x2, 4x2 or
+    // 4x4: the hashes of the inputs (outputs) are concatenated, 32 bytes each, and their authentication
+    // paths, tree_depth*32 bytes each. All the notes are of the asset asset_ptr. The circuits with
+    // public_values also balance the public values vpub_in and vpub_out, and the fee paid to the
+    // submitter of the transfer: inputs + vpub_in = outputs + vpub_out + fee. They must be 0 without.
+    int zsl_load_transfer_keys(size_t n_inputs, size_t n_outputs, bool public_values, const char *pk_path, const char *vk_path);
+    void zsl_paramgen_transfer(size_t n_inputs, size_t n_outputs, bool public_values, const char *pk_path, const char *vk_path);
+    uint64_t zsl_constraints_transfer(size_t n_inputs, size_t n_outputs, bool public_values);
+    int zsl_r1cs_transfer(size_t n_inputs, size_t n_outputs, bool public_values, const char *path);
+
+    int zsl_prove_transfer(
+        size_t n_inputs,
+        size_t n_outputs,
+        bool public_values,
+        void *input_rhos_ptr,
+        void *input_sks_ptr,
+        uint64_t *input_values,
+        uint64_t *input_tree_positions,
+        void *input_authentication_paths_ptr,
+        void *output_rhos_ptr,
+        void *output_pks_ptr,
+        uint64_t *output_values,
+        uint64_t vpub_in,
+        uint64_t vpub_out,
+        uint64_t fee,
+        void *asset_ptr,
+        void *binding_ptr,
         void *output_proof_ptr
     );
 
     bool zsl_verify_transfer(
+        size_t n_inputs,
+        size_t n_outputs,
+        bool public_values,
         void *proof_ptr,
         void *anchor_ptr,
-        void *spend_nf_ptr_1,
-        void *spend_nf_ptr_2,
-        void *send_nf_ptr_1,
-        void *send_nf_ptr_2,
-        void *cm_ptr_1,
-        void *cm_ptr_2
+        void *spend_nfs_ptr,
+        void *send_nfs_ptr,
+        void *cms_ptr,
+        uint64_t vpub_in,
+        uint64_t vpub_out,
+        uint64_t fee,
+        void *asset_ptr,
+        void *binding_ptr
     );
 
 
@@ -0,0 +1,691 @@
+// Original Copyright 2017 Zerocoin Electric Coin Company LLC
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo && !nolibzsl
+// +build cgo,!nolibzsl
+
+package snark
+
+// #cgo LDFLAGS: -L${SRCDIR} -lzsl -lm -lstdc++ -lgmp -lgomp -lff
+// #include "libsnark/libzsl/zsl.h"
+import "C"
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"unsafe"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+func init() {
+	Register("libzsl", &libzsl{})
+}
+
+// libzsl is the Backend linking with libsnark (see libsnark/libzsl)
+type libzsl struct {
+	onceInit sync.Once // Init() is only ever called once
+	initErr  error
+
+	// keysLoaded[circuit] is locked until the keys of circuit are loaded (or failed to)
+	keysLoaded [PublicTransfer4x4 + 1]sync.RWMutex
+
+	statusLock sync.RWMutex
+	status     Status
+}
+
+func (l *libzsl) Init(treeDepth uint, keyDir string, system ProvingSystem) error {
+	l.onceInit.Do(func() {
+		if system != PPZKSNARK && system != Groth16 {
+			l.initErr = fmt.Errorf("unknown proving system %v", system)
+			return
+		}
+
+		// check that key directory is mounted
+		if _, err := os.Stat(keyDir); err != nil {
+			l.initErr = fmt.Errorf("key directory %s doesn't exist or is not mounted", keyDir)
+			return
+		}
+
+		// initialize curve parameters
+		C.zsl_initialize(C.uint(treeDepth), cProvingSystem(system))
+
+		// existing keys must match their manifest, absent ones are generated
+		constraints := make(map[Circuit]uint64)
+		for _, c := range Circuits {
+			constraints[c] = cConstraints(c)
+		}
+		manifest, err := ReadManifest(keyDir)
+		switch {
+		case err == nil:
+			if err := manifest.Validate(keyDir, treeDepth, system, constraints); err != nil {
+				l.initErr = err
+				return
+			}
+		case os.IsNotExist(err):
+			for _, c := range Circuits {
+				pkPath, vkPath := KeyFiles(keyDir, c)
+				if fileExists(pkPath) || fileExists(vkPath) {
+					l.initErr = fmt.Errorf("found %s keys in %s but no %s: remove the keys to regenerate them", c, keyDir, ManifestFile)
+					return
+				}
+			}
+		default:
+			l.initErr = err
+			return
+		}
+
+		l.status = Status{
+			Backend:       "libzsl",
+			TreeDepth:     treeDepth,
+			ProvingSystem: system,
+			KeyDir:        keyDir,
+			Keys:          make(map[Circuit]KeyState),
+		}
+
+		// locking the mutexes mark the keys as "unloaded"
+		for _, c := range Circuits {
+			l.status.Keys[c] = KeysUnloaded
+			l.keysLoaded[c].Lock()
+		}
+
+		// generate the absent keys (one circuit at a time, it's memory hungry) and load them
+		go func() {
+			var generated []Circuit
+			for _, c := range Circuits {
+				c := c
+				pkPath, vkPath := KeyFiles(keyDir, c)
+				if !fileExists(vkPath) {
+					fmt.Printf("couldn't find %s, generating...\n", vkPath)
+					l.setState(c, KeysGenerating)
+					withCPaths(pkPath, vkPath, func(pk, vk *C.char) { cParamgen(c, pk, vk) })
+					generated = append(generated, c)
+				}
+				go l.loadKeys(c, func() (status C.int) {
+					withCPaths(pkPath, vkPath, func(pk, vk *C.char) { status = cLoadKeys(c, pk, vk) })
+					return
+				})
+			}
+
+			// new key set (or circuits added since it was generated), write its manifest
+			if manifest != nil && len(generated) == 0 {
+				return
+			}
+			var err error
+			if manifest == nil {
+				manifest, err = NewManifest(keyDir, treeDepth, system, constraints)
+			} else {
+				err = manifest.Add(keyDir, generated, constraints)
+			}
+			if err == nil {
+				err = manifest.Write(keyDir)
+			}
+			if err != nil {
+				fmt.Printf("couldn't write key set manifest: %v\n", err)
+			}
+		}()
+	})
+	return l.initErr
+}
+
+// loadKeys calls load and marks the keys of circuit as loaded
+func (l *libzsl) loadKeys(circuit Circuit, load func() C.int) {
+	defer l.keysLoaded[circuit].Unlock()
+	l.setState(circuit, KeysLoading)
+	if load() != C.ZSL_OK {
+		l.setState(circuit, KeysFailed)
+		fmt.Printf("couldn't load %s keys\n", circuit)
+		return
+	}
+	l.setState(circuit, KeysReady)
+	fmt.Printf("loaded %s keys\n", circuit)
+}
+
+// waitKeys blocks until the keys of circuit are loaded, and returns ErrKeysNotLoaded if they failed to
+func (l *libzsl) waitKeys(circuit Circuit) error {
+	if _, err := l.initialized(); err != nil {
+		return err
+	}
+	l.keysLoaded[circuit].RLock()
+	l.keysLoaded[circuit].RUnlock()
+
+	l.statusLock.RLock()
+	defer l.statusLock.RUnlock()
+	if l.status.Keys[circuit] != KeysReady {
+		return ErrKeysNotLoaded
+	}
+	return nil
+}
+
+// provingSystem returns the proving system of the keys (PPZKSNARK until Init is called)
+func (l *libzsl) provingSystem() ProvingSystem {
+	l.statusLock.RLock()
+	defer l.statusLock.RUnlock()
+	return l.status.ProvingSystem
+}
+
+// wellFormed returns true if proof is a well formed proof of the keys proving system (points on the curve
+// and in their subgroup), so that malformed proofs are rejected before reaching libsnark
+func (l *libzsl) wellFormed(proof []byte) bool {
+	system := zsl.ProvingSystem_PPZKSNARK
+	if l.provingSystem() == Groth16 {
+		system = zsl.ProvingSystem_GROTH16
+	}
+	return zsl.CheckProof(proof, system) == nil
+}
+
+// cConstraints returns the number of R1CS constraints of circuit
+func cConstraints(circuit Circuit) uint64 {
+	switch circuit {
+	case Shielding:
+		return uint64(C.zsl_constraints_shielding())
+	case Unshielding:
+		return uint64(C.zsl_constraints_unshielding())
+	}
+	nbInputs, nbOutputs := circuit.Arity()
+	return uint64(C.zsl_constraints_transfer(C.size_t(nbInputs), C.size_t(nbOutputs), C.bool(circuit.PublicValues())))
+}
+
+// cParamgen generates the keys of circuit
+func cParamgen(circuit Circuit, pk, vk *C.char) {
+	switch circuit {
+	case Shielding:
+		C.zsl_paramgen_shielding(pk, vk)
+	case Unshielding:
+		C.zsl_paramgen_unshielding(pk, vk)
+	default:
+		nbInputs, nbOutputs := circuit.Arity()
+		C.zsl_paramgen_transfer(C.size_t(nbInputs), C.size_t(nbOutputs), C.bool(circuit.PublicValues()), pk, vk)
+	}
+}
+
+// cLoadKeys loads the keys of circuit
+func cLoadKeys(circuit Circuit, pk, vk *C.char) C.int {
+	switch circuit {
+	case Shielding:
+		return C.zsl_load_shielding_keys(pk, vk)
+	case Unshielding:
+		return C.zsl_load_unshielding_keys(pk, vk)
+	}
+	nbInputs, nbOutputs := circuit.Arity()
+	return C.zsl_load_transfer_keys(C.size_t(nbInputs), C.size_t(nbOutputs), C.bool(circuit.PublicValues()), pk, vk)
+}
+
+// cProvingSystem returns the libzsl constant of system
+func cProvingSystem(system ProvingSystem) C.int {
+	if system == Groth16 {
+		return C.ZSL_GROTH16
+	}
+	return C.ZSL_PPZKSNARK
+}
+
+// withCPaths calls f with the key file paths copied to C strings
+func withCPaths(pkPath, vkPath string, f func(pk, vk *C.char)) {
+	ptrPk := C.CString(pkPath)
+	ptrVk := C.CString(vkPath)
+	defer func() {
+		C.free(unsafe.Pointer(ptrPk))
+		C.free(unsafe.Pointer(ptrVk))
+	}()
+	f(ptrPk, ptrVk)
+}
+
+func (l *libzsl) setState(circuit Circuit, state KeyState) {
+	l.statusLock.Lock()
+	l.status.Keys[circuit] = state
+	l.statusLock.Unlock()
+}
+
+// initialized returns the tree depth, or ErrKeysNotLoaded if Init wasn't called or failed
+func (l *libzsl) initialized() (uint, error) {
+	l.statusLock.RLock()
+	defer l.statusLock.RUnlock()
+	if l.status.Keys == nil {
+		return 0, ErrKeysNotLoaded
+	}
+	return l.status.TreeDepth, nil
+}
+
+func (l *libzsl) VerifyingKey(circuit Circuit) ([]byte, error) {
+	if _, err := l.initialized(); err != nil {
+		return nil, err
+	}
+	_, vkPath := KeyFiles(l.Status().KeyDir, circuit)
+	return ioutil.ReadFile(vkPath)
+}
+
+// ConstraintSystem exports the R1CS of circuit (see ConstraintSystemExporter)
+func (l *libzsl) ConstraintSystem(treeDepth uint, circuit Circuit) ([]byte, error) {
+	// zsl_initialize sets the tree depth of the circuits used by the loaded keys
+	if initialized, err := l.initialized(); err == nil && initialized != treeDepth {
+		return nil, fmt.Errorf("libzsl is initialized with tree depth %d", initialized)
+	}
+	C.zsl_initialize(C.uint(treeDepth), cProvingSystem(l.provingSystem()))
+
+	f, err := ioutil.TempFile("", circuit.String()+".r1cs")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	ptrPath := C.CString(path)
+	defer C.free(unsafe.Pointer(ptrPath))
+	var status C.int
+	switch circuit {
+	case Shielding:
+		status = C.zsl_r1cs_shielding(ptrPath)
+	case Unshielding:
+		status = C.zsl_r1cs_unshielding(ptrPath)
+	default:
+		nbInputs, nbOutputs := circuit.Arity()
+		if nbInputs == 0 {
+			return nil, fmt.Errorf("unknown circuit %v", circuit)
+		}
+		status = C.zsl_r1cs_transfer(C.size_t(nbInputs), C.size_t(nbOutputs), C.bool(circuit.PublicValues()), ptrPath)
+	}
+	if status != C.ZSL_OK {
+		return nil, fmt.Errorf("couldn't export the %s constraint system", circuit)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func (l *libzsl) Status() Status {
+	l.statusLock.RLock()
+	defer l.statusLock.RUnlock()
+	status := l.status
+	status.Backend = "libzsl"
+	status.Keys = make(map[Circuit]KeyState)
+	for _, c := range Circuits {
+		status.Keys[c] = l.status.Keys[c]
+	}
+	return status
+}
+
+func (l *libzsl) ProveTransfer(inputRho1 []byte,
+	inputSk1 []byte,
+	inputValue1 uint64,
+	inputTreeIndex1 uint64,
+	inputTreePath1 [][]byte,
+	inputRho2 []byte,
+	inputSk2 []byte,
+	inputValue2 uint64,
+	inputTreeIndex2 uint64,
+	inputTreePath2 [][]byte,
+	outputRho1 []byte,
+	outputPk1 []byte,
+	outputValue1 uint64,
+	outputRho2 []byte,
+	outputPk2 []byte,
+	outputValue2 uint64,
+	asset []byte,
+	binding []byte) ([]byte, error) {
+	w := transferWitness(
+		inputRho1, inputSk1, inputValue1, inputTreeIndex1, inputTreePath1,
+		inputRho2, inputSk2, inputValue2, inputTreeIndex2, inputTreePath2,
+		outputRho1, outputPk1, outputValue1,
+		outputRho2, outputPk2, outputValue2,
+		asset, binding)
+	return l.ProveTransferN(w.Inputs, w.Outputs, 0, 0, 0, w.Binding)
+}
+
+func (l *libzsl) ProveTransferN(inputs []UnshieldingWitness, outputs []ShieldingWitness, vpubIn uint64, vpubOut uint64, fee uint64, binding []byte) ([]byte, error) {
+	treeDepth, err := l.initialized()
+	if err != nil {
+		return nil, err
+	}
+	circuit, err := TransferCircuit(len(inputs), len(outputs), vpubIn != 0 || vpubOut != 0 || fee != 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// concatenate the inputs and outputs
+	var inputRhos, inputSks, inputTreePaths, outputRhos, outputPks []byte
+	inputValues := make([]C.uint64_t, len(inputs))
+	inputTreeIndexes := make([]C.uint64_t, len(inputs))
+	outputValues := make([]C.uint64_t, len(outputs))
+	for i, input := range inputs {
+		if err := checkSizes(input.Rho, input.Sk); err != nil {
+			return nil, err
+		}
+		if err := checkTreePath(input.TreePath, treeDepth); err != nil {
+			return nil, err
+		}
+		inputRhos = append(inputRhos, input.Rho...)
+		inputSks = append(inputSks, input.Sk...)
+		inputTreePaths = append(inputTreePaths, parseTreePath(input.TreePath)...)
+		inputValues[i] = C.uint64_t(input.Value)
+		inputTreeIndexes[i] = C.uint64_t(input.TreeIndex)
+	}
+	for i, output := range outputs {
+		if err := checkSizes(output.Rho, output.Pk); err != nil {
+			return nil, err
+		}
+		outputRhos = append(outputRhos, output.Rho...)
+		outputPks = append(outputPks, output.Pk...)
+		outputValues[i] = C.uint64_t(output.Value)
+	}
+	asset, err := transferAsset(inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSizes(binding); err != nil {
+		return nil, err
+	}
+	toReturn := make([]byte, l.provingSystem().ProofSize())
+
+	// copy objects (malloc)
+	ptrInputRhos := C.CBytes(inputRhos)
+	ptrInputSks := C.CBytes(inputSks)
+	ptrInputTreePaths := C.CBytes(inputTreePaths)
+	ptrOutputRhos := C.CBytes(outputRhos)
+	ptrOutputPks := C.CBytes(outputPks)
+	ptrAsset := C.CBytes(asset)
+	ptrBinding := C.CBytes(binding)
+
+	defer func() {
+		C.free(ptrInputRhos)
+		C.free(ptrInputSks)
+		C.free(ptrInputTreePaths)
+		C.free(ptrOutputRhos)
+		C.free(ptrOutputPks)
+		C.free(ptrAsset)
+		C.free(ptrBinding)
+	}()
+
+	// wait keys loaded
+	if err := l.waitKeys(circuit); err != nil {
+		return nil, err
+	}
+
+	// call C function
+	status := C.zsl_prove_transfer(C.size_t(len(inputs)),
+		C.size_t(len(outputs)),
+		C.bool(circuit.PublicValues()),
+		ptrInputRhos,
+		ptrInputSks,
+		&inputValues[0],
+		&inputTreeIndexes[0],
+		ptrInputTreePaths,
+		ptrOutputRhos,
+		ptrOutputPks,
+		&outputValues[0],
+		C.uint64_t(vpubIn),
+		C.uint64_t(vpubOut),
+		C.uint64_t(fee),
+		ptrAsset,
+		ptrBinding,
+		unsafe.Pointer(&toReturn[0]))
+
+	if err := proveError(status); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+func (l *libzsl) ProveShielding(rho []byte, pk []byte, value uint64, asset []byte) ([]byte, error) {
+	if _, err := l.initialized(); err != nil {
+		return nil, err
+	}
+	if err := checkSizes(rho, pk, asset); err != nil {
+		return nil, err
+	}
+	toReturn := make([]byte, l.provingSystem().ProofSize())
+
+	// copy objects (malloc)
+	ptrRho := C.CBytes(rho)
+	ptrPk := C.CBytes(pk)
+	ptrAsset := C.CBytes(asset)
+
+	defer func() {
+		C.free(ptrPk)
+		C.free(ptrRho)
+		C.free(ptrAsset)
+	}()
+
+	// wait keys loaded
+	if err := l.waitKeys(Shielding); err != nil {
+		return nil, err
+	}
+
+	// call C function
+	status := C.zsl_prove_shielding(ptrRho, ptrPk, C.uint64_t(value), ptrAsset, unsafe.Pointer(&toReturn[0]))
+
+	if err := proveError(status); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+func (l *libzsl) ProveUnshielding(rho []byte,
+	sk []byte,
+	value uint64,
+	asset []byte,
+	treeIndex uint64,
+	treePath [][]byte,
+	binding []byte) ([]byte, error) {
+	treeDepth, err := l.initialized()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSizes(rho, sk, asset, binding); err != nil {
+		return nil, err
+	}
+	if err := checkTreePath(treePath, treeDepth); err != nil {
+		return nil, err
+	}
+	toReturn := make([]byte, l.provingSystem().ProofSize())
+
+	// copy objects (malloc)
+	ptrRho := C.CBytes(rho)
+	ptrSk := C.CBytes(sk)
+	ptrAsset := C.CBytes(asset)
+	ptrTreePath := C.CBytes(parseTreePath(treePath))
+	ptrBinding := C.CBytes(binding)
+
+	defer func() {
+		C.free(ptrSk)
+		C.free(ptrRho)
+		C.free(ptrAsset)
+		C.free(ptrTreePath)
+		C.free(ptrBinding)
+	}()
+
+	// wait keys loaded
+	if err := l.waitKeys(Unshielding); err != nil {
+		return nil, err
+	}
+
+	// call C function
+	status := C.zsl_prove_unshielding(ptrRho,
+		ptrSk,
+		C.uint64_t(value),
+		ptrAsset,
+		C.uint64_t(treeIndex),
+		ptrTreePath,
+		ptrBinding,
+		unsafe.Pointer(&toReturn[0]))
+
+	if err := proveError(status); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+func (l *libzsl) VerifyTransfer(proof []byte,
+	treeRoot []byte,
+	spendNullifier1 []byte,
+	spendNullifier2 []byte,
+	sendNullifier1 []byte,
+	sendNullifier2 []byte,
+	commitment1 []byte,
+	commitment2 []byte,
+	binding []byte) bool {
+	return l.VerifyTransferN(proof,
+		treeRoot,
+		[][]byte{spendNullifier1, spendNullifier2},
+		[][]byte{sendNullifier1, sendNullifier2},
+		[][]byte{commitment1, commitment2},
+		0, 0, 0, nil, binding)
+}
+
+func (l *libzsl) VerifyTransferN(proof []byte,
+	treeRoot []byte,
+	spendNullifiers [][]byte,
+	sendNullifiers [][]byte,
+	commitments [][]byte,
+	vpubIn uint64,
+	vpubOut uint64,
+	fee uint64,
+	asset []byte,
+	binding []byte) bool {
+	circuit, err := TransferCircuit(len(spendNullifiers), len(commitments), vpubIn != 0 || vpubOut != 0 || fee != 0)
+	if err != nil || len(sendNullifiers) != len(commitments) {
+		return false
+	}
+	if !circuit.PublicValues() {
+		// the asset is private, the C function ignores it
+		asset = make([]byte, hashSize)
+	}
+	hashes := append(append(append([][]byte{treeRoot, asset, binding}, spendNullifiers...), sendNullifiers...), commitments...)
+	if !l.wellFormed(proof) || checkSizes(hashes...) != nil {
+		return false
+	}
+
+	// copy objects (malloc)
+	ptrProof := C.CBytes(proof)
+	ptrTreeRoot := C.CBytes(treeRoot)
+	ptrSpendNullifiers := C.CBytes(bytes.Join(spendNullifiers, nil))
+	ptrSendNullifiers := C.CBytes(bytes.Join(sendNullifiers, nil))
+	ptrCommitments := C.CBytes(bytes.Join(commitments, nil))
+	ptrAsset := C.CBytes(asset)
+	ptrBinding := C.CBytes(binding)
+
+	defer func() {
+		C.free(ptrSpendNullifiers)
+		C.free(ptrSendNullifiers)
+		C.free(ptrCommitments)
+		C.free(ptrAsset)
+		C.free(ptrBinding)
+		C.free(ptrProof)
+		C.free(ptrTreeRoot)
+	}()
+
+	// wait keys loaded
+	if l.waitKeys(circuit) != nil {
+		return false
+	}
+
+	// call C function
+	if C.zsl_verify_transfer(C.size_t(len(spendNullifiers)),
+		C.size_t(len(commitments)),
+		C.bool(circuit.PublicValues()),
+		ptrProof,
+		ptrTreeRoot,
+		ptrSpendNullifiers,
+		ptrSendNullifiers,
+		ptrCommitments,
+		C.uint64_t(vpubIn),
+		C.uint64_t(vpubOut),
+		C.uint64_t(fee),
+		ptrAsset,
+		ptrBinding) {
+		return true
+	}
+	return false
+}
+
+func (l *libzsl) VerifyShielding(proof []byte, sendNullifier []byte, commitment []byte, value uint64, asset []byte) bool {
+	if !l.wellFormed(proof) || checkSizes(sendNullifier, commitment, asset) != nil {
+		return false
+	}
+	// copy objects (malloc)
+	ptrSendNullifier := C.CBytes(sendNullifier)
+	ptrCommitment := C.CBytes(commitment)
+	ptrAsset := C.CBytes(asset)
+	ptrProof := C.CBytes(proof)
+
+	defer func() {
+		C.free(ptrSendNullifier)
+		C.free(ptrCommitment)
+		C.free(ptrAsset)
+		C.free(ptrProof)
+	}()
+
+	// wait keys loaded
+	if l.waitKeys(Shielding) != nil {
+		return false
+	}
+
+	// call C function
+	if C.zsl_verify_shielding(ptrProof, ptrSendNullifier, ptrCommitment, C.uint64_t(value), ptrAsset) {
+		return true
+	}
+	return false
+}
+
+func (l *libzsl) VerifyUnshielding(proof []byte, spendNullifier []byte, treeRoot []byte, value uint64, asset []byte, binding []byte) bool {
+	if !l.wellFormed(proof) || checkSizes(spendNullifier, treeRoot, asset, binding) != nil {
+		return false
+	}
+	// copy objects (malloc)
+	ptrSpendNullifier := C.CBytes(spendNullifier)
+	ptrTreeRoot := C.CBytes(treeRoot)
+	ptrAsset := C.CBytes(asset)
+	ptrBinding := C.CBytes(binding)
+	ptrProof := C.CBytes(proof)
+
+	defer func() {
+		C.free(ptrSpendNullifier)
+		C.free(ptrTreeRoot)
+		C.free(ptrAsset)
+		C.free(ptrBinding)
+		C.free(ptrProof)
+	}()
+
+	// wait keys loaded
+	if l.waitKeys(Unshielding) != nil {
+		return false
+	}
+
+	// call C function
+	if C.zsl_verify_unshielding(ptrProof, ptrSpendNullifier, ptrTreeRoot, C.uint64_t(value), ptrAsset, ptrBinding) {
+		return true
+	}
+	return false
+}
+
+// proveError maps a zsl_prove_* status code to an Error
+func proveError(status C.int) error {
+	switch status {
+	case C.ZSL_OK:
+		return nil
+	case C.ZSL_ERR_UNSATISFIED_WITNESS:
+		return ErrUnsatisfiedWitness
+	}
+	return ErrProver
+}
+
+func parseTreePath(treePath [][]byte) []byte {
+	concatPath := make([]byte, len(treePath)*32)
+	for k, v := range treePath {
+		i := k * 32
+		copy(concatPath[i:i+32], v)
+	}
+	return concatPath
+}
@@ -0,0 +1,147 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo && !nolibzsl
+// +build cgo,!nolibzsl
+
+package snark
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/consensys/zslbox/verifier"
+	"github.com/consensys/zslbox/zsl"
+	"github.com/jpmorganchase/zsl-q/zsl-golang/zsl/sha256"
+)
+
+// libzslTreeDepth keeps the key generation short
+const libzslTreeDepth = 4
+
+// TestLibzslPureGoVerifier cross-checks the pure Go verifier with libsnark, on libzsl proofs
+func TestLibzslPureGoVerifier(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates libzsl keys")
+	}
+	keyDir, err := ioutil.TempDir("", "zslbox-libzsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(keyDir)
+	backend, err := Get("libzsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Init(libzslTreeDepth, keyDir, PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	for !backend.Status().Ready() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	verifiers := make(map[Circuit]*verifier.Verifier)
+	for circuit, zslCircuit := range map[Circuit]zsl.Circuit{Shielding: zsl.Circuit_SHIELDING, Unshielding: zsl.Circuit_UNSHIELDING, Transfer: zsl.Circuit_TRANSFER} {
+		raw, err := backend.VerifyingKey(circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vk, err := ExportVerifyingKey(raw, PPZKSNARK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vk.Circuit = zslCircuit
+		if verifiers[circuit], err = verifier.New(vk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// shielding
+	rho, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	proof, err := backend.ProveShielding(rho, pk, 42, defaultAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendNullifier, cm := mockSendNullifier(rho), mockCommitment(rho, pk, 42, defaultAsset)
+	for _, value := range []uint64{42, 43} {
+		if cgo, goVerifier := backend.VerifyShielding(proof, sendNullifier, cm, value, defaultAsset), verifiers[Shielding].VerifyShielding(proof, sendNullifier, cm, value, defaultAsset); cgo != (value == 42) || goVerifier != cgo {
+			t.Fatalf("shielding verification mismatch (value %d): libsnark %v, pure Go %v", value, cgo, goVerifier)
+		}
+	}
+
+	// unshielding, of two notes of a tree
+	tree := zsl.NewTree(libzslTreeDepth)
+	var rhos, sks [2][]byte
+	var cms [2]zsl.Hash
+	for i := range cms {
+		rhos[i], sks[i] = zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+		pk := sha256.Sum256(sks[i])
+		cms[i] = zsl.NewHash(mockCommitment(rhos[i], pk[:], 10, defaultAsset))
+		tree.AddCommitment(cms[i])
+	}
+	treeRoot := tree.Root()
+	treeIndex, treePath, err := tree.GetWitnesses(cms[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err = backend.ProveUnshielding(rhos[0], sks[0], 10, defaultAsset, uint64(treeIndex), treePath, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendNullifier := mockSpendNullifier(rhos[0], sks[0])
+	for _, root := range [][]byte{treeRoot[:], make([]byte, zsl.HashSize)} {
+		if cgo, goVerifier := backend.VerifyUnshielding(proof, spendNullifier, root, 10, defaultAsset, defaultBinding), verifiers[Unshielding].VerifyUnshielding(proof, spendNullifier, root, 10, defaultAsset, defaultBinding); goVerifier != cgo {
+			t.Fatalf("unshielding verification mismatch: libsnark %v, pure Go %v", cgo, goVerifier)
+		}
+	}
+	if !verifiers[Unshielding].VerifyUnshielding(proof, spendNullifier, treeRoot[:], 10, defaultAsset, defaultBinding) {
+		t.Fatal("couldn't verify unshielding proof")
+	}
+
+	// transfer of both notes
+	treeIndex2, treePath2, err := tree.GetWitnesses(cms[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputRho1, outputPk1, outputRho2, outputPk2 := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	binding := zsl.RandomBytes(zsl.HashSize)
+	proof, err = backend.ProveTransfer(
+		rhos[0], sks[0], 10, uint64(treeIndex), treePath,
+		rhos[1], sks[1], 10, uint64(treeIndex2), treePath2,
+		outputRho1, outputPk1, 5,
+		outputRho2, outputPk2, 15,
+		defaultAsset, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicInputs := [][]byte{
+		treeRoot[:],
+		mockSpendNullifier(rhos[0], sks[0]), mockSpendNullifier(rhos[1], sks[1]),
+		mockSendNullifier(outputRho1), mockSendNullifier(outputRho2),
+		mockCommitment(outputRho1, outputPk1, 5, defaultAsset), mockCommitment(outputRho2, outputPk2, 15, defaultAsset),
+		binding,
+	}
+	for i := -1; i < len(publicInputs); i++ {
+		// all inputs, then each input replaced by another one
+		inputs := append([][]byte(nil), publicInputs...)
+		if i >= 0 {
+			inputs[i] = publicInputs[(i+1)%len(publicInputs)]
+		}
+		cgo := backend.VerifyTransfer(proof, inputs[0], inputs[1], inputs[2], inputs[3], inputs[4], inputs[5], inputs[6], inputs[7])
+		goVerifier := verifiers[Transfer].VerifyTransfer(proof, inputs[0], inputs[1], inputs[2], inputs[3], inputs[4], inputs[5], inputs[6], inputs[7])
+		if cgo != (i < 0) || goVerifier != cgo {
+			t.Fatalf("transfer verification mismatch (input %d replaced): libsnark %v, pure Go %v", i, cgo, goVerifier)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CircuitVersion identifies the ZSL circuits (libsnark/libzsl/gadgets.cpp).
+// It must be incremented on any change of the circuits, as it invalidates existing keys.
+const CircuitVersion = 4
+
+// ManifestFile is the name of the key set manifest, in the key directory
+const ManifestFile = "manifest.json"
+
+// Manifest describes a key set; it is written alongside the keys when they are generated, and
+// checked when they are loaded
+type Manifest struct {
+	CircuitVersion int  `json:"circuitVersion"`
+	TreeDepth      uint `json:"treeDepth"`
+	// ProvingSystem of the keys; manifests without it are for PPZKSNARK keys
+	ProvingSystem ProvingSystem           `json:"provingSystem"`
+	Created       time.Time               `json:"created"`
+	Circuits      map[string]*CircuitKeys `json:"circuits"`
+}
+
+// CircuitKeys describes the keys of a circuit
+type CircuitKeys struct {
+	// Constraints is the number of R1CS constraints of the circuit
+	Constraints uint64 `json:"constraints"`
+	// ProvingKey and VerifyingKey are the hex encoded SHA-256 of the key files
+	ProvingKey   string `json:"provingKey"`
+	VerifyingKey string `json:"verifyingKey"`
+}
+
+// NewManifest returns the manifest of the keys in keyDir
+func NewManifest(keyDir string, treeDepth uint, system ProvingSystem, constraints map[Circuit]uint64) (*Manifest, error) {
+	manifest := &Manifest{
+		CircuitVersion: CircuitVersion,
+		TreeDepth:      treeDepth,
+		ProvingSystem:  system,
+		Created:        time.Now().UTC(),
+		Circuits:       make(map[string]*CircuitKeys),
+	}
+	if err := manifest.Add(keyDir, Circuits, constraints); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Add adds the keys of circuits in keyDir to the manifest (ex: the keys of the circuits added since the key
+// set was generated)
+func (manifest *Manifest) Add(keyDir string, circuits []Circuit, constraints map[Circuit]uint64) error {
+	if manifest.Circuits == nil {
+		manifest.Circuits = make(map[string]*CircuitKeys)
+	}
+	for _, c := range circuits {
+		pkPath, vkPath := KeyFiles(keyDir, c)
+		pkHash, err := fileHash(pkPath)
+		if err != nil {
+			return err
+		}
+		vkHash, err := fileHash(vkPath)
+		if err != nil {
+			return err
+		}
+		manifest.Circuits[c.String()] = &CircuitKeys{
+			Constraints:  constraints[c],
+			ProvingKey:   pkHash,
+			VerifyingKey: vkHash,
+		}
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest of the key set in keyDir.
+// The error satisfies os.IsNotExist if there is no manifest.
+func ReadManifest(keyDir string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(keyDir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid key set manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// Write writes the manifest in keyDir
+func (manifest *Manifest) Write(keyDir string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(keyDir, ManifestFile), append(data, '\n'), 0644)
+}
+
+// Validate checks that the manifest matches the circuits (version, tree depth and number of
+// constraints), the proving system, and the keys in keyDir. Circuits absent from the manifest, added since
+// the key set was generated, must have no keys yet: they are generated and added to the manifest (see Add).
+func (manifest *Manifest) Validate(keyDir string, treeDepth uint, system ProvingSystem, constraints map[Circuit]uint64) error {
+	if manifest.CircuitVersion != CircuitVersion {
+		return fmt.Errorf("keys in %s are for circuit version %d, expected %d", keyDir, manifest.CircuitVersion, CircuitVersion)
+	}
+	if manifest.TreeDepth != treeDepth {
+		return fmt.Errorf("keys in %s are for tree depth %d, expected %d", keyDir, manifest.TreeDepth, treeDepth)
+	}
+	if manifest.ProvingSystem != system {
+		return fmt.Errorf("keys in %s are %s keys, expected %s", keyDir, manifest.ProvingSystem, system)
+	}
+	for _, c := range Circuits {
+		pkPath, vkPath := KeyFiles(keyDir, c)
+		keys, ok := manifest.Circuits[c.String()]
+		if !ok || keys == nil {
+			if fileExists(pkPath) || fileExists(vkPath) {
+				return fmt.Errorf("key set manifest in %s has no %s keys", keyDir, c)
+			}
+			continue
+		}
+		if keys.Constraints != constraints[c] {
+			return fmt.Errorf("%s keys in %s are for a circuit of %d constraints, expected %d", c, keyDir, keys.Constraints, constraints[c])
+		}
+		if err := checkFileHash(pkPath, keys.ProvingKey); err != nil {
+			return err
+		}
+		if err := checkFileHash(vkPath, keys.VerifyingKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func checkFileHash(path, expected string) error {
+	h, err := fileHash(path)
+	if err != nil {
+		return err
+	}
+	if h != expected {
+		return fmt.Errorf("%s is corrupted: SHA-256 is %s, expected %s (see %s)", path, h, expected, ManifestFile)
+	}
+	return nil
+}
+
+// fileHash returns the hex encoded SHA-256 of the file at path
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	keyDir, err := ioutil.TempDir("", "zslkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(keyDir)
+
+	// fake key files
+	for _, c := range Circuits {
+		pkPath, vkPath := KeyFiles(keyDir, c)
+		if err := ioutil.WriteFile(pkPath, []byte(c.String()+" proving key"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(vkPath, []byte(c.String()+" verifying key"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	constraints := map[Circuit]uint64{Shielding: 10, Unshielding: 20, Transfer: 30}
+
+	if _, err := ReadManifest(keyDir); !os.IsNotExist(err) {
+		t.Fatal("expected no manifest, got", err)
+	}
+	manifest, err := NewManifest(keyDir, 29, Groth16, constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Write(keyDir); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err = ReadManifest(keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Validate(keyDir, 29, Groth16, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	// mismatches
+	if err := manifest.Validate(keyDir, 30, Groth16, constraints); err == nil {
+		t.Fatal("manifest validated with wrong tree depth")
+	}
+	if err := manifest.Validate(keyDir, 29, Groth16, map[Circuit]uint64{Shielding: 10, Unshielding: 20, Transfer: 31}); err == nil {
+		t.Fatal("manifest validated with wrong number of constraints")
+	}
+	if err := manifest.Validate(keyDir, 29, PPZKSNARK, constraints); err == nil {
+		t.Fatal("manifest validated with wrong proving system")
+	}
+	manifest.CircuitVersion++
+	if err := manifest.Validate(keyDir, 29, Groth16, constraints); err == nil {
+		t.Fatal("manifest validated with wrong circuit version")
+	}
+	manifest.CircuitVersion--
+
+	// key sets of the 2x2 transfer only: the other transfer circuits are added once their keys are generated
+	delete(manifest.Circuits, Transfer4x4.String())
+	if err := manifest.Validate(keyDir, 29, Groth16, constraints); err == nil {
+		t.Fatal("manifest validated with keys missing from it")
+	}
+	pkPath, vkPath := KeyFiles(keyDir, Transfer4x4)
+	os.Remove(pkPath)
+	os.Remove(vkPath)
+	if err := manifest.Validate(keyDir, 29, Groth16, constraints); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(pkPath, []byte("transfer_4x4 proving key"), 0644)
+	ioutil.WriteFile(vkPath, []byte("transfer_4x4 verifying key"), 0644)
+	if err := manifest.Add(keyDir, []Circuit{Transfer4x4}, constraints); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Validate(keyDir, 29, Groth16, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	// manifests without proving system are for ppzksnark keys
+	if err := ioutil.WriteFile(filepath.Join(keyDir, ManifestFile), []byte(`{"circuitVersion": 1, "treeDepth": 29}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if manifest, err := ReadManifest(keyDir); err != nil || manifest.ProvingSystem != PPZKSNARK {
+		t.Fatal("expected a ppzksnark manifest", err)
+	}
+
+	// corrupted key
+	_, vkPath = KeyFiles(keyDir, Transfer)
+	if err := ioutil.WriteFile(vkPath, []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Validate(keyDir, 29, Groth16, constraints); err == nil {
+		t.Fatal("manifest validated with corrupted key")
+	}
+}
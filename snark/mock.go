@@ -0,0 +1,479 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/zslbox/zsl"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/jpmorganchase/zsl-q/zsl-golang/zsl/sha256"
+)
+
+func init() {
+	Register("mock", &mock{})
+}
+
+// mock is a pure Go Backend that doesn't link with libsnark. It computes the public inputs of
+// the circuits from the witness and returns a "proof" deterministically derived from them:
+// proofs computed by the mock verify, random ones don't. Proofs are well formed proofs of the proving
+// system (see zsl.Proof).
+// It is NOT zero-knowledge nor sound, and is meant for development and testing only.
+type mock struct {
+	lock   sync.RWMutex
+	status Status
+	// key is derived from the key directory, so that the mock key sets of different directories have
+	// different verifying keys and proofs (see NewInstance)
+	key []byte
+}
+
+// NewInstance returns a new mock, to load another key set (see Instancer)
+func (m *mock) NewInstance() Backend {
+	return &mock{}
+}
+
+func (m *mock) Init(treeDepth uint, keyDir string, system ProvingSystem) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.status.Keys != nil {
+		return nil
+	}
+	if system != PPZKSNARK && system != Groth16 {
+		return fmt.Errorf("unknown proving system %v", system)
+	}
+	m.status = Status{
+		Backend:       "mock",
+		TreeDepth:     treeDepth,
+		ProvingSystem: system,
+		KeyDir:        keyDir,
+		Keys:          make(map[Circuit]KeyState),
+	}
+	for _, c := range Circuits {
+		m.status.Keys[c] = KeysReady
+	}
+	if keyDir != "" {
+		key := sha256.Sum256([]byte(keyDir))
+		m.key = key[:]
+	}
+	return nil
+}
+
+func (m *mock) Status() Status {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	status := m.status
+	status.Backend = "mock"
+	status.Keys = make(map[Circuit]KeyState)
+	for c, state := range m.status.Keys {
+		status.Keys[c] = state
+	}
+	return status
+}
+
+// VerifyingKey returns a well-formed verifying key of the proving system, with the right number of
+// public inputs but made of generators (but ic[0], derived from the key directory): it can't verify proofs
+func (m *mock) VerifyingKey(circuit Circuit) ([]byte, error) {
+	if _, err := m.initialized(); err != nil {
+		return nil, err
+	}
+	// number of field elements the public inputs are packed in (253 bits each)
+	var nbInputs int
+	switch circuit {
+	case Shielding:
+		nbInputs = 4
+	case Unshielding:
+		nbInputs = 5
+	default:
+		in, out := circuit.Arity()
+		if in == 0 {
+			return nil, fmt.Errorf("snark: unknown circuit %s", circuit)
+		}
+		// tree root, spend nullifiers, send nullifiers and commitments, the public values, fee and asset, and
+		// the binding
+		nbBits := (2 + in + 2*out) * 256
+		if circuit.PublicValues() {
+			nbBits += 3*64 + 256
+		}
+		nbInputs = (nbBits + 252) / 253
+	}
+	g1 := G1{X: big.NewInt(1), Y: big.NewInt(2)}
+	g2 := G2{X: [2]*big.Int{mockBig(g2X0), mockBig(g2X1)}, Y: [2]*big.Int{mockBig(g2Y0), mockBig(g2Y1)}}
+	ic := make([]G1, nbInputs+1)
+	for i := range ic {
+		ic[i] = g1
+	}
+	if key := m.keyBytes(); key != nil {
+		ic[0] = toG1(new(bn256.G1).ScalarBaseMult(new(big.Int).SetBytes(key)))
+	}
+	if m.provingSystem() == Groth16 {
+		vk := &Groth16VerifyingKey{
+			AlphaBeta: toGT(bn256.Pair(groupG1.generator().(*bn256.G1), groupG2.generator().(*bn256.G2))),
+			Gamma:     g2,
+			Delta:     g2,
+			GammaABC:  ic,
+		}
+		return vk.Bytes(), nil
+	}
+	vk := &VerifyingKey{
+		AlphaA:     g2,
+		AlphaB:     g1,
+		AlphaC:     g2,
+		Gamma:      g2,
+		GammaBeta1: g1,
+		GammaBeta2: g2,
+		RCZ:        g2,
+		IC:         ic,
+	}
+	return vk.Bytes(), nil
+}
+
+// alt_bn128 G2 generator
+const (
+	g2X0 = "10857046999023057135944570762232829481370756359578518086990519993285655852781"
+	g2X1 = "11559732032986387107991004021392285783925812861821192530917403151452391805634"
+	g2Y0 = "8495653923123431417604973247489272438418190587263600148770280649306958101930"
+	g2Y1 = "4082367875863433681332203403145435568316851327593401208105741076214120093531"
+)
+
+func mockBig(s string) *big.Int {
+	b, _ := new(big.Int).SetString(s, 10)
+	return b
+}
+
+// provingSystem returns the proving system of the "keys" (PPZKSNARK until Init is called)
+func (m *mock) provingSystem() ProvingSystem {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.status.ProvingSystem
+}
+
+func (m *mock) keyBytes() []byte {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.key
+}
+
+// proof returns the mock proof of circuit for the public inputs
+func (m *mock) proof(circuit Circuit, publicInputs ...[]byte) []byte {
+	return mockProof(m.provingSystem(), m.keyBytes(), circuit, publicInputs...)
+}
+
+func (m *mock) verify(proof []byte, circuit Circuit, publicInputs ...[]byte) bool {
+	return mockVerify(proof, m.provingSystem(), m.keyBytes(), circuit, publicInputs...)
+}
+
+// initialized returns the tree depth, or ErrKeysNotLoaded if Init wasn't called
+func (m *mock) initialized() (uint, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if m.status.Keys == nil {
+		return 0, ErrKeysNotLoaded
+	}
+	return m.status.TreeDepth, nil
+}
+
+func (m *mock) ProveShielding(rho []byte, pk []byte, value uint64, asset []byte) ([]byte, error) {
+	if _, err := m.initialized(); err != nil {
+		return nil, err
+	}
+	if err := checkSizes(rho, pk, asset); err != nil {
+		return nil, err
+	}
+	return m.proof(Shielding, mockSendNullifier(rho), mockCommitment(rho, pk, value, asset), mockValue(value), asset), nil
+}
+
+func (m *mock) VerifyShielding(proof []byte, sendNullifier []byte, commitment []byte, value uint64, asset []byte) bool {
+	return m.verify(proof, Shielding, sendNullifier, commitment, mockValue(value), asset)
+}
+
+func (m *mock) ProveUnshielding(rho []byte,
+	sk []byte,
+	value uint64,
+	asset []byte,
+	treeIndex uint64,
+	treePath [][]byte,
+	binding []byte) ([]byte, error) {
+	treeDepth, err := m.initialized()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSizes(rho, sk, asset, binding); err != nil {
+		return nil, err
+	}
+	if err := checkTreePath(treePath, treeDepth); err != nil {
+		return nil, err
+	}
+	pk := sha256.Sum256(sk)
+	cm := mockCommitment(rho, pk[:], value, asset)
+	return m.proof(Unshielding,
+		mockSpendNullifier(rho, sk),
+		mockTreeRoot(cm, treeIndex, treePath),
+		mockValue(value),
+		asset,
+		binding), nil
+}
+
+func (m *mock) VerifyUnshielding(proof []byte, spendNullifier []byte, treeRoot []byte, value uint64, asset []byte, binding []byte) bool {
+	return m.verify(proof, Unshielding, spendNullifier, treeRoot, mockValue(value), asset, binding)
+}
+
+func (m *mock) ProveTransfer(inputRho1 []byte,
+	inputSk1 []byte,
+	inputValue1 uint64,
+	inputTreeIndex1 uint64,
+	inputTreePath1 [][]byte,
+	inputRho2 []byte,
+	inputSk2 []byte,
+	inputValue2 uint64,
+	inputTreeIndex2 uint64,
+	inputTreePath2 [][]byte,
+	outputRho1 []byte,
+	outputPk1 []byte,
+	outputValue1 uint64,
+	outputRho2 []byte,
+	outputPk2 []byte,
+	outputValue2 uint64,
+	asset []byte,
+	binding []byte) ([]byte, error) {
+	w := transferWitness(
+		inputRho1, inputSk1, inputValue1, inputTreeIndex1, inputTreePath1,
+		inputRho2, inputSk2, inputValue2, inputTreeIndex2, inputTreePath2,
+		outputRho1, outputPk1, outputValue1,
+		outputRho2, outputPk2, outputValue2,
+		asset, binding)
+	return m.ProveTransferN(w.Inputs, w.Outputs, 0, 0, 0, w.Binding)
+}
+
+func (m *mock) ProveTransferN(inputs []UnshieldingWitness, outputs []ShieldingWitness, vpubIn uint64, vpubOut uint64, fee uint64, binding []byte) ([]byte, error) {
+	treeDepth, err := m.initialized()
+	if err != nil {
+		return nil, err
+	}
+	circuit, err := TransferCircuit(len(inputs), len(outputs), vpubIn != 0 || vpubOut != 0 || fee != 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range inputs {
+		if err := checkSizes(input.Rho, input.Sk); err != nil {
+			return nil, err
+		}
+		if err := checkTreePath(input.TreePath, treeDepth); err != nil {
+			return nil, err
+		}
+	}
+	for _, output := range outputs {
+		if err := checkSizes(output.Rho, output.Pk); err != nil {
+			return nil, err
+		}
+	}
+	asset, err := transferAsset(inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSizes(binding); err != nil {
+		return nil, err
+	}
+
+	// sum(inputs) + vpubIn == sum(outputs) + vpubOut + fee (with the carries, as in the field)
+	in := vpubIn
+	out, outCarry := add64(vpubOut, fee)
+	var inCarry uint64
+	for _, input := range inputs {
+		var carry uint64
+		in, carry = add64(in, input.Value)
+		inCarry += carry
+	}
+	for _, output := range outputs {
+		var carry uint64
+		out, carry = add64(out, output.Value)
+		outCarry += carry
+	}
+	if in != out || inCarry != outCarry {
+		return nil, ErrUnsatisfiedWitness
+	}
+
+	// as in the circuit, the Merkle path of an input is only enforced if its value isn't zero,
+	// and all enforced inputs must be in the tree of root treeRoot (zero if none is)
+	treeRoot := make([]byte, hashSize)
+	enforced := false
+	for _, input := range inputs {
+		if input.Value == 0 {
+			continue
+		}
+		pk := sha256.Sum256(input.Sk)
+		root := mockTreeRoot(mockCommitment(input.Rho, pk[:], input.Value, asset), input.TreeIndex, input.TreePath)
+		if enforced && subtle.ConstantTimeCompare(root, treeRoot) != 1 {
+			return nil, ErrUnsatisfiedWitness
+		}
+		treeRoot, enforced = root, true
+	}
+
+	publicInputs := [][]byte{treeRoot}
+	for _, input := range inputs {
+		publicInputs = append(publicInputs, mockSpendNullifier(input.Rho, input.Sk))
+	}
+	for _, output := range outputs {
+		publicInputs = append(publicInputs, mockSendNullifier(output.Rho))
+	}
+	for _, output := range outputs {
+		publicInputs = append(publicInputs, mockCommitment(output.Rho, output.Pk, output.Value, asset))
+	}
+	if circuit.PublicValues() {
+		publicInputs = append(publicInputs, mockValue(vpubIn), mockValue(vpubOut), mockValue(fee), asset)
+	}
+	publicInputs = append(publicInputs, binding)
+	return m.proof(circuit, publicInputs...), nil
+}
+
+func (m *mock) VerifyTransfer(proof []byte,
+	treeRoot []byte,
+	spendNullifier1 []byte,
+	spendNullifier2 []byte,
+	sendNullifier1 []byte,
+	sendNullifier2 []byte,
+	commitment1 []byte,
+	commitment2 []byte,
+	binding []byte) bool {
+	return m.VerifyTransferN(proof,
+		treeRoot,
+		[][]byte{spendNullifier1, spendNullifier2},
+		[][]byte{sendNullifier1, sendNullifier2},
+		[][]byte{commitment1, commitment2},
+		0, 0, 0, nil, binding)
+}
+
+func (m *mock) VerifyTransferN(proof []byte,
+	treeRoot []byte,
+	spendNullifiers [][]byte,
+	sendNullifiers [][]byte,
+	commitments [][]byte,
+	vpubIn uint64,
+	vpubOut uint64,
+	fee uint64,
+	asset []byte,
+	binding []byte) bool {
+	circuit, err := TransferCircuit(len(spendNullifiers), len(commitments), vpubIn != 0 || vpubOut != 0 || fee != 0)
+	if err != nil || len(sendNullifiers) != len(commitments) {
+		return false
+	}
+	publicInputs := append([][]byte{treeRoot}, spendNullifiers...)
+	publicInputs = append(publicInputs, sendNullifiers...)
+	publicInputs = append(publicInputs, commitments...)
+	if circuit.PublicValues() {
+		publicInputs = append(publicInputs, mockValue(vpubIn), mockValue(vpubOut), mockValue(fee), asset)
+	}
+	publicInputs = append(publicInputs, binding)
+	return m.verify(proof, circuit, publicInputs...)
+}
+
+// add64 returns a + b and the carry
+func add64(a, b uint64) (sum, carry uint64) {
+	sum = a + b
+	if sum < a {
+		carry = 1
+	}
+	return
+}
+
+// mockProof returns a well formed proof of system whose points are multiples of the generators, by
+// scalars expanded from SHA256(key || system || circuit || publicInputs) in counter mode
+func mockProof(system ProvingSystem, key []byte, circuit Circuit, publicInputs ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("zslbox mock proof"))
+	h.Write(key)
+	h.Write([]byte{byte(system), byte(circuit)})
+	for _, input := range publicInputs {
+		var l [4]byte
+		binary.BigEndian.PutUint32(l[:], uint32(len(input)))
+		h.Write(l[:])
+		h.Write(input)
+	}
+	seed := h.Sum(nil)
+
+	scalar := func(counter uint32) *big.Int {
+		var c [4]byte
+		binary.BigEndian.PutUint32(c[:], counter)
+		h.Reset()
+		h.Write(seed)
+		h.Write(c[:])
+		return new(big.Int).SetBytes(h.Sum(nil))
+	}
+	g1 := func(counter uint32) *bn256.G1 { return new(bn256.G1).ScalarBaseMult(scalar(counter)) }
+	g2 := func(counter uint32) *bn256.G2 { return new(bn256.G2).ScalarBaseMult(scalar(counter)) }
+
+	if system == Groth16 {
+		return (&zsl.Groth16Proof{A: g1(0), B: g2(1), C: g1(2)}).Bytes()
+	}
+	return (&zsl.Proof{A: g1(0), APrime: g1(1), B: g2(2), BPrime: g1(3), C: g1(4), CPrime: g1(5), H: g1(6), K: g1(7)}).Bytes()
+}
+
+func mockVerify(proof []byte, system ProvingSystem, key []byte, circuit Circuit, publicInputs ...[]byte) bool {
+	return subtle.ConstantTimeCompare(proof, mockProof(system, key, circuit, publicInputs...)) == 1
+}
+
+func mockValue(value uint64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, value)
+	return buf
+}
+
+// commitment SHA256(rho || pk || value || asset) where value is in little endian byte order
+func mockCommitment(rho []byte, pk []byte, value uint64, asset []byte) []byte {
+	h := sha256.New()
+	h.Write(rho)
+	h.Write(pk)
+	h.Write(mockValue(value))
+	h.Write(asset)
+	return h.Sum(nil)
+}
+
+// send nullifier, SHA256(0x00 || rho)
+func mockSendNullifier(rho []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(rho)
+	return h.Sum(nil)
+}
+
+// spend nullifier SHA256(0x01 || rho || sk)
+func mockSpendNullifier(rho []byte, sk []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(rho)
+	h.Write(sk)
+	return h.Sum(nil)
+}
+
+// mockTreeRoot computes the Merkle root from a leaf, its index and authentication path,
+// where treePath[height] is the sibling of the node at that height
+func mockTreeRoot(leaf []byte, treeIndex uint64, treePath [][]byte) []byte {
+	node := leaf
+	for height, sibling := range treePath {
+		h := sha256.NewCompress()
+		if (treeIndex>>uint(height))&1 == 0 {
+			h.Write(node)
+			h.Write(sibling)
+		} else {
+			h.Write(sibling)
+			h.Write(node)
+		}
+		node = h.Compress()
+	}
+	return node
+}
@@ -0,0 +1,428 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+	"github.com/jpmorganchase/zsl-q/zsl-golang/zsl/sha256"
+)
+
+// defaultAsset is the asset of the test notes
+var defaultAsset = make([]byte, zsl.HashSize)
+
+// defaultBinding is the binding of the test proofs
+var defaultBinding = make([]byte, zsl.HashSize)
+
+func TestMockRegistered(t *testing.T) {
+	backend, err := Get("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Init(zsl.TreeDepth, "", PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	status := backend.Status()
+	if status.Backend != "mock" || status.TreeDepth != zsl.TreeDepth {
+		t.Fatal("unexpected status", status)
+	}
+	if !status.Ready() {
+		t.Fatal("mock keys should be ready", status.Keys)
+	}
+	if _, err := Get("unknown"); err == nil {
+		t.Fatal("Get should fail on unknown backend")
+	}
+}
+
+func TestMockShielding(t *testing.T) {
+	backend := newMock(t)
+	rho, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+
+	proof, err := backend.ProveShielding(rho, pk, 42, defaultAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof) != zsl.ProofSize {
+		t.Fatal("proof size should be", zsl.ProofSize)
+	}
+	if _, err := zsl.ParseProof(proof); err != nil {
+		t.Fatal("malformed shielding proof", err)
+	}
+	sendNullifier, cm := mockSendNullifier(rho), mockCommitment(rho, pk, 42, defaultAsset)
+	if !backend.VerifyShielding(proof, sendNullifier, cm, 42, defaultAsset) {
+		t.Fatal("couldn't verify shielding proof")
+	}
+	if backend.VerifyShielding(proof, sendNullifier, cm, 43, defaultAsset) {
+		t.Fatal("shielding proof verified with wrong value")
+	}
+	random := make([]byte, zsl.ProofSize)
+	rand.Read(random)
+	if backend.VerifyShielding(random, sendNullifier, cm, 42, defaultAsset) {
+		t.Fatal("random shielding proof verified")
+	}
+}
+
+func TestMockGroth16(t *testing.T) {
+	backend := &mock{}
+	if err := backend.Init(zsl.TreeDepth, "", Groth16); err != nil {
+		t.Fatal(err)
+	}
+	if backend.Status().ProvingSystem != Groth16 {
+		t.Fatal("unexpected status", backend.Status())
+	}
+	rho, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	proof, err := backend.ProveShielding(rho, pk, 42, defaultAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof) != zsl.Groth16ProofSize || Groth16.ProofSize() != zsl.Groth16ProofSize || PPZKSNARK.ProofSize() != zsl.ProofSize {
+		t.Fatal("proof size should be", zsl.Groth16ProofSize)
+	}
+	if _, err := zsl.ParseGroth16Proof(proof); err != nil {
+		t.Fatal("malformed shielding proof", err)
+	}
+	sendNullifier, cm := mockSendNullifier(rho), mockCommitment(rho, pk, 42, defaultAsset)
+	if !backend.VerifyShielding(proof, sendNullifier, cm, 42, defaultAsset) {
+		t.Fatal("couldn't verify shielding proof")
+	}
+
+	// ppzksnark proofs don't verify
+	ppzksnarkProof, err := newMock(t).ProveShielding(rho, pk, 42, defaultAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.VerifyShielding(ppzksnarkProof, sendNullifier, cm, 42, defaultAsset) || backend.VerifyShielding(ppzksnarkProof[:len(proof)], sendNullifier, cm, 42, defaultAsset) {
+		t.Fatal("ppzksnark proof verified with groth16 keys")
+	}
+}
+
+func TestMockUnshielding(t *testing.T) {
+	backend := newMock(t)
+	rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	pk := sha256.Sum256(sk)
+
+	tree := zsl.NewTree(zsl.TreeDepth)
+	tree.AddCommitment(zsl.NewHash(zsl.RandomBytes(zsl.HashSize)))
+	cm := zsl.NewHash(mockCommitment(rho, pk[:], 10, defaultAsset))
+	tree.AddCommitment(cm)
+	treeIndex, treePath, err := tree.GetWitnesses(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+
+	proof, err := backend.ProveUnshielding(rho, sk, 10, defaultAsset, uint64(treeIndex), treePath, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.VerifyUnshielding(proof, mockSpendNullifier(rho, sk), treeRoot[:], 10, defaultAsset, defaultBinding) {
+		t.Fatal("couldn't verify unshielding proof")
+	}
+	if backend.VerifyUnshielding(proof, mockSpendNullifier(rho, sk), make([]byte, zsl.HashSize), 10, defaultAsset, defaultBinding) {
+		t.Fatal("unshielding proof verified with wrong tree root")
+	}
+}
+
+func TestMockTransferN(t *testing.T) {
+	backend := newMock(t)
+	tree := zsl.NewTree(zsl.TreeDepth)
+	for _, arity := range [][2]int{{1, 1}, {1, 2}, {4, 2}, {4, 4}} {
+		inputs := make([]UnshieldingWitness, arity[0])
+		spendNullifiers := make([][]byte, arity[0])
+		for i := range inputs {
+			rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+			pk := sha256.Sum256(sk)
+			cm := zsl.NewHash(mockCommitment(rho, pk[:], 3, defaultAsset))
+			tree.AddCommitment(cm)
+			inputs[i] = UnshieldingWitness{Rho: rho, Sk: sk, Value: 3, Asset: defaultAsset}
+			spendNullifiers[i] = mockSpendNullifier(rho, sk)
+		}
+		for i := range inputs {
+			pk := sha256.Sum256(inputs[i].Sk)
+			treeIndex, treePath, err := tree.GetWitnesses(zsl.NewHash(mockCommitment(inputs[i].Rho, pk[:], 3, defaultAsset)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			inputs[i].TreeIndex, inputs[i].TreePath = uint64(treeIndex), treePath
+		}
+		treeRoot := tree.Root()
+
+		// the inputs value goes to the first output
+		outputs := make([]ShieldingWitness, arity[1])
+		sendNullifiers := make([][]byte, arity[1])
+		commitments := make([][]byte, arity[1])
+		for i := range outputs {
+			outputs[i] = ShieldingWitness{Rho: zsl.RandomBytes(zsl.HashSize), Pk: zsl.RandomBytes(zsl.HashSize), Asset: defaultAsset}
+		}
+		outputs[0].Value = 3 * uint64(arity[0])
+		for i, output := range outputs {
+			sendNullifiers[i] = mockSendNullifier(output.Rho)
+			commitments[i] = mockCommitment(output.Rho, output.Pk, output.Value, defaultAsset)
+		}
+
+		proof, err := backend.ProveTransferN(inputs, outputs, 0, 0, 0, defaultBinding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 0, 0, nil, defaultBinding) {
+			t.Fatalf("couldn't verify %dx%d transfer proof", arity[0], arity[1])
+		}
+		if arity[1] > 1 && backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, append(commitments[1:], commitments[0]), 0, 0, 0, nil, defaultBinding) {
+			t.Fatalf("%dx%d transfer proof verified with wrong commitments", arity[0], arity[1])
+		}
+		if len(inputs) == 1 {
+			continue
+		}
+		// a proof of another arity doesn't verify
+		if backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers[1:], sendNullifiers, commitments, 0, 0, 0, nil, defaultBinding) {
+			t.Fatal("transfer proof verified with missing spend nullifier")
+		}
+	}
+
+	if _, err := backend.ProveTransferN(make([]UnshieldingWitness, 3), make([]ShieldingWitness, 3), 0, 0, 0, defaultBinding); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+}
+
+func TestMockPublicTransfer(t *testing.T) {
+	backend := newMock(t)
+	rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	pk := sha256.Sum256(sk)
+	tree := zsl.NewTree(zsl.TreeDepth)
+	cm := zsl.NewHash(mockCommitment(rho, pk[:], 10, defaultAsset))
+	tree.AddCommitment(cm)
+	treeIndex, treePath, err := tree.GetWitnesses(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+	inputs := []UnshieldingWitness{{Rho: rho, Sk: sk, Value: 10, Asset: defaultAsset, TreeIndex: uint64(treeIndex), TreePath: treePath}}
+	spendNullifiers := [][]byte{mockSpendNullifier(rho, sk)}
+
+	// 10 + 5 in = 12 + 3 out
+	output := ShieldingWitness{Rho: zsl.RandomBytes(zsl.HashSize), Pk: zsl.RandomBytes(zsl.HashSize), Value: 12, Asset: defaultAsset}
+	sendNullifiers := [][]byte{mockSendNullifier(output.Rho)}
+	commitments := [][]byte{mockCommitment(output.Rho, output.Pk, output.Value, defaultAsset)}
+	proof, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 5, 3, 0, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 5, 3, 0, defaultAsset, defaultBinding) {
+		t.Fatal("couldn't verify transfer proof with public values")
+	}
+	if backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 5, 4, 0, defaultAsset, defaultBinding) ||
+		backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 0, 0, nil, defaultBinding) {
+		t.Fatal("transfer proof verified with wrong public values")
+	}
+
+	// the public values are in the balance
+	if _, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 3, 0, defaultBinding); err != ErrUnsatisfiedWitness {
+		t.Fatal("expected ErrUnsatisfiedWitness, got", err)
+	}
+	if _, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 0, 0, defaultBinding); err != ErrUnsatisfiedWitness {
+		t.Fatal("expected ErrUnsatisfiedWitness, got", err)
+	}
+}
+
+func TestMockFee(t *testing.T) {
+	backend := newMock(t)
+	rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	pk := sha256.Sum256(sk)
+	tree := zsl.NewTree(zsl.TreeDepth)
+	cm := zsl.NewHash(mockCommitment(rho, pk[:], 10, defaultAsset))
+	tree.AddCommitment(cm)
+	treeIndex, treePath, err := tree.GetWitnesses(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+	inputs := []UnshieldingWitness{{Rho: rho, Sk: sk, Value: 10, Asset: defaultAsset, TreeIndex: uint64(treeIndex), TreePath: treePath}}
+	spendNullifiers := [][]byte{mockSpendNullifier(rho, sk)}
+
+	// 10 in = 9 out + a fee of 1, with the circuit with public values
+	output := ShieldingWitness{Rho: zsl.RandomBytes(zsl.HashSize), Pk: zsl.RandomBytes(zsl.HashSize), Value: 9, Asset: defaultAsset}
+	sendNullifiers := [][]byte{mockSendNullifier(output.Rho)}
+	commitments := [][]byte{mockCommitment(output.Rho, output.Pk, output.Value, defaultAsset)}
+	proof, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 0, 1, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 0, 1, defaultAsset, defaultBinding) {
+		t.Fatal("couldn't verify transfer proof with fee")
+	}
+	if backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 0, 2, defaultAsset, defaultBinding) ||
+		backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 1, 0, defaultAsset, defaultBinding) {
+		t.Fatal("transfer proof verified with wrong fee")
+	}
+
+	// the fee is in the balance
+	if _, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 0, 2, defaultBinding); err != ErrUnsatisfiedWitness {
+		t.Fatal("expected ErrUnsatisfiedWitness, got", err)
+	}
+}
+
+func TestMockAssets(t *testing.T) {
+	backend := newMock(t)
+	asset, otherAsset := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	pk := sha256.Sum256(sk)
+
+	// the asset is in the commitment
+	cm := mockCommitment(rho, pk[:], 10, asset)
+	if string(cm) == string(mockCommitment(rho, pk[:], 10, otherAsset)) {
+		t.Fatal("notes of different assets have the same commitment")
+	}
+	proof, err := backend.ProveShielding(rho, pk[:], 10, asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.VerifyShielding(proof, mockSendNullifier(rho), cm, 10, asset) {
+		t.Fatal("couldn't verify shielding proof")
+	}
+	if backend.VerifyShielding(proof, mockSendNullifier(rho), cm, 10, otherAsset) {
+		t.Fatal("shielding proof verified with wrong asset")
+	}
+
+	// notes of different assets share the tree, but not a transfer
+	tree := zsl.NewTree(zsl.TreeDepth)
+	tree.AddCommitment(zsl.NewHash(mockCommitment(zsl.RandomBytes(zsl.HashSize), pk[:], 10, otherAsset)))
+	tree.AddCommitment(zsl.NewHash(cm))
+	treeIndex, treePath, err := tree.GetWitnesses(zsl.NewHash(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+	inputs := []UnshieldingWitness{{Rho: rho, Sk: sk, Value: 10, Asset: asset, TreeIndex: uint64(treeIndex), TreePath: treePath}}
+	output := ShieldingWitness{Rho: zsl.RandomBytes(zsl.HashSize), Pk: zsl.RandomBytes(zsl.HashSize), Value: 8, Asset: asset}
+	proof, err = backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 2, 0, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendNullifiers := [][]byte{mockSpendNullifier(rho, sk)}
+	sendNullifiers := [][]byte{mockSendNullifier(output.Rho)}
+	commitments := [][]byte{mockCommitment(output.Rho, output.Pk, output.Value, asset)}
+	if !backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 2, 0, asset, defaultBinding) {
+		t.Fatal("couldn't verify transfer proof")
+	}
+	if backend.VerifyTransferN(proof, treeRoot[:], spendNullifiers, sendNullifiers, commitments, 0, 2, 0, otherAsset, defaultBinding) {
+		t.Fatal("transfer proof verified with wrong asset")
+	}
+	output.Asset = otherAsset
+	if _, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 2, 0, defaultBinding); err != ErrUnsatisfiedWitness {
+		t.Fatal("expected ErrUnsatisfiedWitness, got", err)
+	}
+	output.Asset = otherAsset[1:]
+	if _, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 2, 0, defaultBinding); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+}
+
+func TestMockBinding(t *testing.T) {
+	backend := newMock(t)
+	binding, otherBinding := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	rho, sk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	pk := sha256.Sum256(sk)
+	tree := zsl.NewTree(zsl.TreeDepth)
+	cm := zsl.NewHash(mockCommitment(rho, pk[:], 10, defaultAsset))
+	tree.AddCommitment(cm)
+	treeIndex, treePath, err := tree.GetWitnesses(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+	spendNullifier := mockSpendNullifier(rho, sk)
+
+	// a proof is only valid for its binding
+	proof, err := backend.ProveUnshielding(rho, sk, 10, defaultAsset, uint64(treeIndex), treePath, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.VerifyUnshielding(proof, spendNullifier, treeRoot[:], 10, defaultAsset, binding) {
+		t.Fatal("couldn't verify unshielding proof")
+	}
+	if backend.VerifyUnshielding(proof, spendNullifier, treeRoot[:], 10, defaultAsset, otherBinding) {
+		t.Fatal("unshielding proof verified with wrong binding")
+	}
+
+	inputs := []UnshieldingWitness{{Rho: rho, Sk: sk, Value: 10, Asset: defaultAsset, TreeIndex: uint64(treeIndex), TreePath: treePath}}
+	output := ShieldingWitness{Rho: zsl.RandomBytes(zsl.HashSize), Pk: zsl.RandomBytes(zsl.HashSize), Value: 10, Asset: defaultAsset}
+	sendNullifiers := [][]byte{mockSendNullifier(output.Rho)}
+	commitments := [][]byte{mockCommitment(output.Rho, output.Pk, output.Value, defaultAsset)}
+	proof, err = backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 0, 0, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.VerifyTransferN(proof, treeRoot[:], [][]byte{spendNullifier}, sendNullifiers, commitments, 0, 0, 0, nil, binding) {
+		t.Fatal("couldn't verify transfer proof")
+	}
+	if backend.VerifyTransferN(proof, treeRoot[:], [][]byte{spendNullifier}, sendNullifiers, commitments, 0, 0, 0, nil, otherBinding) {
+		t.Fatal("transfer proof verified with wrong binding")
+	}
+
+	if _, err := backend.ProveUnshielding(rho, sk, 10, defaultAsset, uint64(treeIndex), treePath, binding[1:]); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+	if _, err := backend.ProveTransferN(inputs, []ShieldingWitness{output}, 0, 0, 0, nil); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+}
+
+func TestMockErrors(t *testing.T) {
+	if _, err := (&mock{}).ProveShielding(zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize), 1, defaultAsset); err != ErrKeysNotLoaded {
+		t.Fatal("expected ErrKeysNotLoaded, got", err)
+	}
+
+	backend := newMock(t)
+	if _, err := backend.ProveShielding(zsl.RandomBytes(31), zsl.RandomBytes(zsl.HashSize), 1, defaultAsset); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+
+	rho, sk, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+	treePath := make([][]byte, zsl.TreeDepth)
+	for i := range treePath {
+		treePath[i] = make([]byte, zsl.HashSize)
+	}
+	if _, err := backend.ProveUnshielding(rho, sk, 1, defaultAsset, 0, treePath[1:], defaultBinding); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+
+	// unbalanced transfer
+	_, err := backend.ProveTransfer(rho, sk, 0, 0, treePath, rho, sk, 0, 0, treePath, rho, pk, 1, rho, pk, 0, defaultAsset, defaultBinding)
+	if err != ErrUnsatisfiedWitness {
+		t.Fatal("expected ErrUnsatisfiedWitness, got", err)
+	}
+
+	// inputs in different trees
+	_, err = backend.ProveTransfer(rho, sk, 1, 0, treePath, rho, sk, 1, 1, treePath, rho, pk, 1, rho, pk, 1, defaultAsset, defaultBinding)
+	if err != ErrUnsatisfiedWitness {
+		t.Fatal("expected ErrUnsatisfiedWitness, got", err)
+	}
+
+	// zero value inputs aren't checked against the tree
+	_, err = backend.ProveTransfer(rho, sk, 0, 0, treePath, rho, sk, 0, 1, treePath, rho, pk, 0, rho, pk, 0, defaultAsset, defaultBinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newMock(t *testing.T) *mock {
+	backend := &mock{}
+	if err := backend.Init(zsl.TreeDepth, "", PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	return backend
+}
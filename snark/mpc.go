@@ -0,0 +1,620 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CeremonyFile is the name of the ceremony transcript, in the ceremony directory
+const CeremonyFile = "ceremony.json"
+
+// CeremonyPhase is the phase of a setup Ceremony
+type CeremonyPhase int
+
+const (
+	// CeremonyPowersOfTau is the first phase: the participants contribute to tau, where the circuit
+	// polynomials are evaluated
+	CeremonyPowersOfTau CeremonyPhase = iota + 1
+	// CeremonyCircuitSecrets is the second phase: the participants contribute to the other secrets of the keys
+	CeremonyCircuitSecrets
+	// CeremonyDone is reached once the keys are computed
+	CeremonyDone
+)
+
+func (p CeremonyPhase) String() string {
+	switch p {
+	case CeremonyPowersOfTau:
+		return "powers of tau"
+	case CeremonyCircuitSecrets:
+		return "circuit secrets"
+	case CeremonyDone:
+		return "done"
+	}
+	return fmt.Sprintf("ceremonyPhase(%d)", int(p))
+}
+
+// Ceremony is a multi-party computation of the proving and verifying keys of the ZSL circuits, replacing the
+// r1cs_ppzksnark_generator call of the libzsl backend: the keys are sound as long as one of the participants
+// destroyed their secrets. It computes PPZKSNARK keys only: Groth16 would need another circuit secrets phase.
+//
+// The coordinator creates the ceremony from the circuits constraint systems (NewCeremony), the participants
+// Contribute in turn to the powers of tau, the coordinator moves to the next phase (NextPhase), the
+// participants Contribute again, and the coordinator computes the keys (Finalize). Anyone can Verify the
+// ceremony at any point.
+//
+// A ceremony is a directory with the transcript (CeremonyFile), the constraint systems (circuit.r1cs), the
+// accumulators of each phase and contribution (circuit.phaseP.N) and the Lagrange basis (circuit.lagrange).
+// The directory is passed from a participant to the next: only one of them contributes at a time.
+type Ceremony struct {
+	dir string
+
+	CircuitVersion int                         `json:"circuitVersion"`
+	TreeDepth      uint                        `json:"treeDepth"`
+	Phase          CeremonyPhase               `json:"phase"`
+	Circuits       map[string]*CeremonyCircuit `json:"circuits"`
+	Contributions  []*Contribution             `json:"contributions"`
+}
+
+// CeremonyCircuit describes the setup of a circuit. Hashes are hex encoded SHA-256.
+type CeremonyCircuit struct {
+	// Constraints is the number of R1CS constraints of the circuit
+	Constraints uint64 `json:"constraints"`
+	// DomainSize is the size of the QAP evaluation domain, the number of powers of tau
+	DomainSize int `json:"domainSize"`
+	// ConstraintSystem is the hash of the constraint system
+	ConstraintSystem string `json:"constraintSystem"`
+	// Phase1 and Phase2 are the hashes of the initial accumulators of the phases, Lagrange is the hash of the
+	// Lagrange basis computed from the last powers of tau
+	Phase1   string `json:"phase1"`
+	Lagrange string `json:"lagrange,omitempty"`
+	Phase2   string `json:"phase2,omitempty"`
+	// ProvingKey and VerifyingKey are the hashes of the keys (see Manifest)
+	ProvingKey   string `json:"provingKey,omitempty"`
+	VerifyingKey string `json:"verifyingKey,omitempty"`
+}
+
+// Contribution is the record of a participant contribution to a phase
+type Contribution struct {
+	Phase       CeremonyPhase `json:"phase"`
+	Participant string        `json:"participant"`
+	Time        time.Time     `json:"time"`
+	// Circuits are the contributions to the accumulator of each circuit
+	Circuits map[string]*CircuitContribution `json:"circuits"`
+}
+
+// CircuitContribution is the contribution to the accumulator of a circuit
+type CircuitContribution struct {
+	// PublicKey is the hex encoded public key of the participant secrets, which proves the accumulator
+	// was updated with them
+	PublicKey string `json:"publicKey"`
+	// Accumulator is the hash of the accumulator after the contribution
+	Accumulator string `json:"accumulator"`
+}
+
+// NewCeremony starts a ceremony in dir, for the libsnark serialized constraint systems of the circuits
+// (see ConstraintSystemExporter)
+func NewCeremony(dir string, treeDepth uint, constraintSystems map[Circuit][]byte) (*Ceremony, error) {
+	if _, err := os.Stat(filepath.Join(dir, CeremonyFile)); err == nil {
+		return nil, fmt.Errorf("found a ceremony in %s", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &Ceremony{
+		dir:            dir,
+		CircuitVersion: CircuitVersion,
+		TreeDepth:      treeDepth,
+		Phase:          CeremonyPowersOfTau,
+		Circuits:       make(map[string]*CeremonyCircuit),
+	}
+	for _, circuit := range Circuits {
+		raw, ok := constraintSystems[circuit]
+		if !ok {
+			return nil, fmt.Errorf("no %s constraint system", circuit)
+		}
+		cs, err := parseConstraintSystem(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", circuit, err)
+		}
+		domain, err := newEvaluationDomain(len(cs.constraints) + cs.primaryInputSize + 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", circuit, err)
+		}
+		if err := ioutil.WriteFile(c.file(circuit, "r1cs"), raw, 0644); err != nil {
+			return nil, err
+		}
+		m := domain.m
+		phase1, err := writeAccumulator(c.accumulatorFile(circuit, CeremonyPowersOfTau, 0), CeremonyPowersOfTau, m, func(a *accumulatorWriter) error {
+			return writePhase1(a, m)
+		})
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.Sum256(raw)
+		c.Circuits[circuit.String()] = &CeremonyCircuit{
+			Constraints:      uint64(len(cs.constraints)),
+			DomainSize:       m,
+			ConstraintSystem: hex.EncodeToString(h[:]),
+			Phase1:           phase1,
+		}
+	}
+	return c, c.save()
+}
+
+// OpenCeremony reads the ceremony in dir
+func OpenCeremony(dir string) (*Ceremony, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, CeremonyFile))
+	if err != nil {
+		return nil, err
+	}
+	c := &Ceremony{dir: dir}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid ceremony transcript: %v", err)
+	}
+	for _, circuit := range Circuits {
+		if info := c.Circuits[circuit.String()]; info == nil || radix2Domain(info.DomainSize) == nil {
+			return nil, fmt.Errorf("invalid ceremony transcript: invalid %s circuit", circuit)
+		}
+	}
+	return c, nil
+}
+
+// save writes the transcript, replacing the previous one at once
+func (c *Ceremony) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, CeremonyFile)
+	if err := ioutil.WriteFile(path+".new", append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".new", path)
+}
+
+func (c *Ceremony) file(circuit Circuit, ext string) string {
+	return filepath.Join(c.dir, circuit.String()+"."+ext)
+}
+
+// accumulatorFile returns the path of the accumulator after n contributions to phase
+func (c *Ceremony) accumulatorFile(circuit Circuit, phase CeremonyPhase, n int) string {
+	return c.file(circuit, fmt.Sprintf("phase%d.%d", phase, n))
+}
+
+// contributions returns the contributions to phase
+func (c *Ceremony) contributions(phase CeremonyPhase) []*Contribution {
+	var contributions []*Contribution
+	for _, contribution := range c.Contributions {
+		if contribution.Phase == phase {
+			contributions = append(contributions, contribution)
+		}
+	}
+	return contributions
+}
+
+// accumulatorHash returns the hash of the accumulator after n contributions to phase
+func (c *Ceremony) accumulatorHash(circuit Circuit, phase CeremonyPhase, n int) (string, error) {
+	if n == 0 {
+		if phase == CeremonyPowersOfTau {
+			return c.Circuits[circuit.String()].Phase1, nil
+		}
+		return c.Circuits[circuit.String()].Phase2, nil
+	}
+	contribution := c.contributions(phase)[n-1].Circuits[circuit.String()]
+	if contribution == nil {
+		return "", fmt.Errorf("no %s contribution in contribution %d of phase %d", circuit, n, phase)
+	}
+	return contribution.Accumulator, nil
+}
+
+// Contribute adds a contribution of participant to the current phase, with secrets read from random (ex:
+// crypto/rand.Reader). The secrets are only kept in memory until Contribute returns.
+func (c *Ceremony) Contribute(participant string, random io.Reader) (*Contribution, error) {
+	phase := c.Phase
+	if phase != CeremonyPowersOfTau && phase != CeremonyCircuitSecrets {
+		return nil, errors.New("the ceremony is over")
+	}
+	n := len(c.contributions(phase))
+	contribution := &Contribution{
+		Phase:       phase,
+		Participant: participant,
+		Time:        time.Now().UTC(),
+		Circuits:    make(map[string]*CircuitContribution),
+	}
+	var written []string
+	fail := func(err error) (*Contribution, error) {
+		for _, path := range written {
+			os.Remove(path)
+		}
+		return nil, err
+	}
+	for _, circuit := range Circuits {
+		m := c.Circuits[circuit.String()].DomainSize
+		prevHash, err := c.accumulatorHash(circuit, phase, n)
+		if err != nil {
+			return fail(err)
+		}
+		nbSecrets := 1
+		if phase == CeremonyCircuitSecrets {
+			nbSecrets = numSecrets
+		}
+		secrets := make([]*big.Int, nbSecrets)
+		keys := make([]*publicKey, nbSecrets)
+		for i := range secrets {
+			if secrets[i], err = randomFr(random); err != nil {
+				return fail(err)
+			}
+			if keys[i], err = newPublicKey(secrets[i], prevHash, i, random); err != nil {
+				return fail(err)
+			}
+		}
+
+		old, err := openAccumulator(c.accumulatorFile(circuit, phase, n), phase, m)
+		if err != nil {
+			return fail(err)
+		}
+		path := c.accumulatorFile(circuit, phase, n+1)
+		h, err := writeAccumulator(path, phase, m, func(a *accumulatorWriter) error {
+			var err error
+			if phase == CeremonyPowersOfTau {
+				err = contributePhase1(a, old, m, secrets[0])
+			} else {
+				err = contributePhase2(a, old, m, secrets)
+			}
+			if err != nil {
+				return err
+			}
+			oldHash, err := old.close()
+			if err == nil && oldHash != prevHash {
+				err = fmt.Errorf("%s is corrupted: SHA-256 is %s, expected %s (see %s)", old.f.Name(), oldHash, prevHash, CeremonyFile)
+			}
+			return err
+		})
+		old.f.Close()
+		if err != nil {
+			return fail(err)
+		}
+		written = append(written, path)
+		contribution.Circuits[circuit.String()] = &CircuitContribution{
+			PublicKey:   encodePublicKeys(keys),
+			Accumulator: h,
+		}
+	}
+
+	c.Contributions = append(c.Contributions, contribution)
+	if err := c.save(); err != nil {
+		c.Contributions = c.Contributions[:len(c.Contributions)-1]
+		return fail(err)
+	}
+	return contribution, nil
+}
+
+// NextPhase ends the powers of tau phase: it computes the Lagrange basis of each circuit from the last
+// accumulator, and the initial accumulators of the circuit secrets phase
+func (c *Ceremony) NextPhase() error {
+	if c.Phase != CeremonyPowersOfTau {
+		return fmt.Errorf("the ceremony is in the %s phase", c.Phase)
+	}
+	if len(c.contributions(CeremonyPowersOfTau)) == 0 {
+		return errors.New("no contribution to the powers of tau")
+	}
+	for _, circuit := range Circuits {
+		info := c.Circuits[circuit.String()]
+		basis, err := c.lagrangeBasis(circuit)
+		if err != nil {
+			return err
+		}
+		if info.Lagrange, err = writeAccumulator(c.file(circuit, "lagrange"), CeremonyCircuitSecrets, info.DomainSize, func(a *accumulatorWriter) error {
+			return writeLagrange(a, basis)
+		}); err != nil {
+			return err
+		}
+		if info.Phase2, err = writeAccumulator(c.accumulatorFile(circuit, CeremonyCircuitSecrets, 0), CeremonyCircuitSecrets, info.DomainSize, func(a *accumulatorWriter) error {
+			return writePhase2(a, basis)
+		}); err != nil {
+			return err
+		}
+	}
+	c.Phase = CeremonyCircuitSecrets
+	return c.save()
+}
+
+// lagrangeBasis computes the Lagrange basis of the circuit domain from the last powers of tau
+func (c *Ceremony) lagrangeBasis(circuit Circuit) (*lagrange, error) {
+	m := c.Circuits[circuit.String()].DomainSize
+	n := len(c.contributions(CeremonyPowersOfTau))
+	expected, err := c.accumulatorHash(circuit, CeremonyPowersOfTau, n)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := openAccumulator(c.accumulatorFile(circuit, CeremonyPowersOfTau, n), CeremonyPowersOfTau, m)
+	if err != nil {
+		return nil, err
+	}
+	defer acc.f.Close()
+	tauG1, err := acc.readN(groupG1, m+1)
+	if err != nil {
+		return nil, err
+	}
+	tauG2, err := acc.readN(groupG2, m+1)
+	if err != nil {
+		return nil, err
+	}
+	h, err := acc.close()
+	if err != nil {
+		return nil, err
+	}
+	if h != expected {
+		return nil, fmt.Errorf("%s is corrupted: SHA-256 is %s, expected %s (see %s)", acc.f.Name(), h, expected, CeremonyFile)
+	}
+	domain := radix2Domain(m)
+	return &lagrange{g1: domain.lagrangeBasis(groupG1, tauG1), g2: domain.lagrangeBasis(groupG2, tauG2)}, nil
+}
+
+// Finalize ends the circuit secrets phase: it writes the proving and verifying keys of the circuits computed
+// from the last accumulators in keyDir, with their manifest
+func (c *Ceremony) Finalize(keyDir string) error {
+	if c.Phase != CeremonyCircuitSecrets {
+		return fmt.Errorf("the ceremony is in the %s phase", c.Phase)
+	}
+	if c.CircuitVersion != CircuitVersion {
+		return fmt.Errorf("the ceremony is for circuit version %d, expected %d", c.CircuitVersion, CircuitVersion)
+	}
+	if len(c.contributions(CeremonyCircuitSecrets)) == 0 {
+		return errors.New("no contribution to the circuit secrets")
+	}
+	constraints := make(map[Circuit]uint64)
+	for _, circuit := range Circuits {
+		info := c.Circuits[circuit.String()]
+		pkPath, vkPath := KeyFiles(keyDir, circuit)
+		pk, err := os.Create(pkPath)
+		if err != nil {
+			return err
+		}
+		vk, err := os.Create(vkPath)
+		if err != nil {
+			pk.Close()
+			return err
+		}
+		info.ProvingKey, info.VerifyingKey, err = c.computeKeys(circuit, pk, vk)
+		if closeErr := pk.Close(); err == nil {
+			err = closeErr
+		}
+		if closeErr := vk.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		constraints[circuit] = info.Constraints
+	}
+	manifest, err := NewManifest(keyDir, c.TreeDepth, PPZKSNARK, constraints)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Write(keyDir); err != nil {
+		return err
+	}
+	c.Phase = CeremonyDone
+	return c.save()
+}
+
+// computeKeys writes the keys of circuit computed from the last accumulators to pk and vk, and returns
+// their hashes
+func (c *Ceremony) computeKeys(circuit Circuit, pk, vk io.Writer) (string, string, error) {
+	cs, err := c.constraintSystem(circuit)
+	if err != nil {
+		return "", "", err
+	}
+	cs.swapABIfBeneficial()
+	var paths [2]string
+	for i, phase := range []CeremonyPhase{CeremonyPowersOfTau, CeremonyCircuitSecrets} {
+		n := len(c.contributions(phase))
+		expected, err := c.accumulatorHash(circuit, phase, n)
+		if err != nil {
+			return "", "", err
+		}
+		paths[i] = c.accumulatorFile(circuit, phase, n)
+		if err := checkFileHash(paths[i], expected); err != nil {
+			return "", "", err
+		}
+	}
+	pkHash, vkHash := sha256.New(), sha256.New()
+	if err := writeKeys(io.MultiWriter(pk, pkHash), io.MultiWriter(vk, vkHash), cs, paths[0], paths[1]); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(pkHash.Sum(nil)), hex.EncodeToString(vkHash.Sum(nil)), nil
+}
+
+// constraintSystem reads the constraint system of circuit
+func (c *Ceremony) constraintSystem(circuit Circuit) (*constraintSystem, error) {
+	info := c.Circuits[circuit.String()]
+	path := c.file(circuit, "r1cs")
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if h := sha256.Sum256(raw); hex.EncodeToString(h[:]) != info.ConstraintSystem {
+		return nil, fmt.Errorf("%s is corrupted: SHA-256 is %x, expected %s (see %s)", path, h, info.ConstraintSystem, CeremonyFile)
+	}
+	cs, err := parseConstraintSystem(raw)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(cs.constraints)) != info.Constraints {
+		return nil, fmt.Errorf("%s has %d constraints, expected %d", path, len(cs.constraints), info.Constraints)
+	}
+	domain, err := newEvaluationDomain(len(cs.constraints) + cs.primaryInputSize + 1)
+	if err != nil {
+		return nil, err
+	}
+	if domain.m != info.DomainSize {
+		return nil, fmt.Errorf("%s domain size is %d, expected %d", path, domain.m, info.DomainSize)
+	}
+	return cs, nil
+}
+
+// Verify checks the ceremony files against the transcript: the constraint systems, the initial accumulators
+// of the phases, the Lagrange basis and the keys are recomputed, and each contribution must update the
+// previous accumulator with the secrets of its public keys.
+func (c *Ceremony) Verify() error {
+	seed, err := newRandomCoefficients(rand.Reader)
+	if err != nil {
+		return err
+	}
+	for _, circuit := range Circuits {
+		info := c.Circuits[circuit.String()]
+		m := info.DomainSize
+		if _, err := c.constraintSystem(circuit); err != nil {
+			return err
+		}
+
+		// powers of tau
+		initial := newAccumulatorWriter(ioutil.Discard, CeremonyPowersOfTau, m)
+		writePhase1(initial, m)
+		if err := c.checkHash(initial, info.Phase1, c.accumulatorFile(circuit, CeremonyPowersOfTau, 0)); err != nil {
+			return err
+		}
+		for i, contribution := range c.contributions(CeremonyPowersOfTau) {
+			if err := c.verifyContribution(circuit, i+1, contribution, nil, nil, seed); err != nil {
+				return err
+			}
+		}
+		if c.Phase == CeremonyPowersOfTau {
+			continue
+		}
+
+		// circuit secrets
+		basis, err := c.lagrangeBasis(circuit)
+		if err != nil {
+			return err
+		}
+		lagrangeFile := newAccumulatorWriter(ioutil.Discard, CeremonyCircuitSecrets, m)
+		writeLagrange(lagrangeFile, basis)
+		if err := c.checkHash(lagrangeFile, info.Lagrange, c.file(circuit, "lagrange")); err != nil {
+			return err
+		}
+		initial = newAccumulatorWriter(ioutil.Discard, CeremonyCircuitSecrets, m)
+		writePhase2(initial, basis)
+		if err := c.checkHash(initial, info.Phase2, c.accumulatorFile(circuit, CeremonyCircuitSecrets, 0)); err != nil {
+			return err
+		}
+		sumL := lagrangeSums(basis, seed)
+		for i, contribution := range c.contributions(CeremonyCircuitSecrets) {
+			if err := c.verifyContribution(circuit, i+1, contribution, basis, sumL, seed); err != nil {
+				return err
+			}
+		}
+		if c.Phase == CeremonyCircuitSecrets {
+			continue
+		}
+
+		// keys
+		pkHash, vkHash, err := c.computeKeys(circuit, ioutil.Discard, ioutil.Discard)
+		if err != nil {
+			return err
+		}
+		if pkHash != info.ProvingKey || vkHash != info.VerifyingKey {
+			return fmt.Errorf("%s keys don't match the last accumulators", circuit)
+		}
+	}
+	return nil
+}
+
+// checkHash checks that a recomputed accumulator matches the transcript and its file
+func (c *Ceremony) checkHash(recomputed *accumulatorWriter, expected, path string) error {
+	h, err := recomputed.flush()
+	if err != nil {
+		return err
+	}
+	if h != expected {
+		return fmt.Errorf("%s doesn't match its recomputed SHA-256 %s", filepath.Base(path), h)
+	}
+	return checkFileHash(path, expected)
+}
+
+// verifyContribution verifies the contribution n to the accumulator of circuit (see verifyPhase1 and
+// verifyPhase2)
+func (c *Ceremony) verifyContribution(circuit Circuit, n int, contribution *Contribution, basis *lagrange, sumL []point, seed *randomCoefficients) error {
+	m := c.Circuits[circuit.String()].DomainSize
+	phase := contribution.Phase
+	fail := func(err error) error {
+		return fmt.Errorf("%s: contribution %d of %q to the %s: %v", circuit, n, contribution.Participant, phase, err)
+	}
+	cc := contribution.Circuits[circuit.String()]
+	if cc == nil {
+		return fail(errors.New("no contribution"))
+	}
+	prevHash, err := c.accumulatorHash(circuit, phase, n-1)
+	if err != nil {
+		return fail(err)
+	}
+	nbSecrets := 1
+	if phase == CeremonyCircuitSecrets {
+		nbSecrets = numSecrets
+	}
+	keys, err := decodePublicKeys(cc.PublicKey, nbSecrets)
+	if err != nil {
+		return fail(err)
+	}
+
+	// previous accumulator, already checked
+	oldPath := c.accumulatorFile(circuit, phase, n-1)
+	var oldTau point
+	var oldSingles []point
+	if phase == CeremonyPowersOfTau {
+		oldTau, err = readTau(oldPath, m)
+	} else {
+		var old *accumulatorReader
+		if old, err = openAccumulator(oldPath, phase, m); err == nil {
+			oldSingles, err = readSingles(old)
+			old.f.Close()
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	acc, err := openAccumulator(c.accumulatorFile(circuit, phase, n), phase, m)
+	if err != nil {
+		return fail(err)
+	}
+	defer acc.f.Close()
+	var h string
+	if phase == CeremonyPowersOfTau {
+		h, err = verifyPhase1(acc, m, oldTau, prevHash, keys[0], seed)
+	} else {
+		h, err = verifyPhase2(acc, m, oldSingles, prevHash, keys, basis, sumL, seed)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if h != cc.Accumulator {
+		return fail(fmt.Errorf("SHA-256 of the accumulator is %s, expected %s", h, cc.Accumulator))
+	}
+	return nil
+}
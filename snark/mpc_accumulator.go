@@ -0,0 +1,340 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// The accumulators of the ceremony are stored in files starting with accumulatorMagic, the phase (1 byte)
+// and the domain size (8 bytes, big endian), followed by marshaled bn256 points.
+const (
+	accumulatorMagic      = "zslmpc"
+	accumulatorHeaderSize = len(accumulatorMagic) + 1 + 8
+)
+
+// chunkSize is the number of points read, processed in parallel and written at once
+const chunkSize = 1 << 12
+
+func accumulatorHeader(phase CeremonyPhase, m int) []byte {
+	header := make([]byte, accumulatorHeaderSize)
+	copy(header, accumulatorMagic)
+	header[len(accumulatorMagic)] = byte(phase)
+	binary.BigEndian.PutUint64(header[len(accumulatorMagic)+1:], uint64(m))
+	return header
+}
+
+// accumulatorReader reads the points of an accumulator file in order, and hashes it
+type accumulatorReader struct {
+	f    *os.File
+	r    *bufio.Reader
+	hash hash.Hash
+	buf  []byte
+}
+
+func openAccumulator(path string, phase CeremonyPhase, m int) (*accumulatorReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &accumulatorReader{f: f, hash: sha256.New(), buf: make([]byte, groupG2.pointSize())}
+	a.r = bufio.NewReaderSize(io.TeeReader(f, a.hash), 1<<20)
+	header := make([]byte, accumulatorHeaderSize)
+	if _, err := io.ReadFull(a.r, header); err != nil || !bytes.Equal(header, accumulatorHeader(phase, m)) {
+		f.Close()
+		return nil, fmt.Errorf("%s isn't a phase %d accumulator of domain size %d", path, phase, m)
+	}
+	return a, nil
+}
+
+func (a *accumulatorReader) read(g group) (point, error) {
+	buf := a.buf[:g.pointSize()]
+	if _, err := io.ReadFull(a.r, buf); err != nil {
+		return nil, fmt.Errorf("%s: %v", a.f.Name(), err)
+	}
+	p, err := g.unmarshal(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", a.f.Name(), err)
+	}
+	return p, nil
+}
+
+func (a *accumulatorReader) readN(g group, n int) ([]point, error) {
+	points := make([]point, n)
+	for i := range points {
+		var err error
+		if points[i], err = a.read(g); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+// close checks that the whole file was read and returns its hex encoded SHA-256
+func (a *accumulatorReader) close() (string, error) {
+	defer a.f.Close()
+	if _, err := a.r.ReadByte(); err != io.EOF {
+		return "", fmt.Errorf("%s: trailing data", a.f.Name())
+	}
+	return hex.EncodeToString(a.hash.Sum(nil)), nil
+}
+
+// loadPoints reads the n points of g at offset (after the header) of an accumulator file
+func loadPoints(path string, phase CeremonyPhase, m int, offset int64, g group, n int) ([]point, error) {
+	a, err := openAccumulator(path, phase, m)
+	if err != nil {
+		return nil, err
+	}
+	defer a.f.Close()
+	if _, err := a.f.Seek(int64(accumulatorHeaderSize)+offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	a.r.Reset(a.f)
+	return a.readN(g, n)
+}
+
+// accumulatorWriter writes and hashes an accumulator
+type accumulatorWriter struct {
+	w    *bufio.Writer
+	hash hash.Hash
+}
+
+func newAccumulatorWriter(w io.Writer, phase CeremonyPhase, m int) *accumulatorWriter {
+	a := &accumulatorWriter{hash: sha256.New()}
+	a.w = bufio.NewWriterSize(io.MultiWriter(w, a.hash), 1<<20)
+	a.w.Write(accumulatorHeader(phase, m))
+	return a
+}
+
+func (a *accumulatorWriter) write(points ...point) {
+	for _, p := range points {
+		a.w.Write(p.Marshal())
+	}
+}
+
+// flush returns the hex encoded SHA-256 of the accumulator
+func (a *accumulatorWriter) flush() (string, error) {
+	if err := a.w.Flush(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(a.hash.Sum(nil)), nil
+}
+
+// writeAccumulator creates the accumulator file at path with write, and returns its hex encoded SHA-256
+func writeAccumulator(path string, phase CeremonyPhase, m int, write func(a *accumulatorWriter) error) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	a := newAccumulatorWriter(f, phase, m)
+	err = write(a)
+	var h string
+	if err == nil {
+		h, err = a.flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return h, nil
+}
+
+// mapPoints reads n points of g from r and writes f(i, p_i) to w
+func mapPoints(g group, r *accumulatorReader, w *accumulatorWriter, n int, f func(i int, p point) point) error {
+	for start := 0; start < n; start += chunkSize {
+		end := start + chunkSize
+		if end > n {
+			end = n
+		}
+		points, err := r.readN(g, end-start)
+		if err != nil {
+			return err
+		}
+		parallelize(len(points), func(s, e int) {
+			for i := s; i < e; i++ {
+				points[i] = f(start+i, points[i])
+			}
+		})
+		w.write(points...)
+	}
+	return nil
+}
+
+// weightedSums reads the points p_start..p_end-1 of g from r and returns sum_i coeffs[j](i) p_i for each j.
+// A coefficient function returns nil for the points it skips.
+func weightedSums(g group, r *accumulatorReader, start, end int, coeffs ...func(i int) *big.Int) ([]point, error) {
+	sums := make([]point, len(coeffs))
+	for j := range sums {
+		sums[j] = g.infinity()
+	}
+	var lock sync.Mutex
+	for chunkStart := start; chunkStart < end; chunkStart += chunkSize {
+		chunkEnd := chunkStart + chunkSize
+		if chunkEnd > end {
+			chunkEnd = end
+		}
+		points, err := r.readN(g, chunkEnd-chunkStart)
+		if err != nil {
+			return nil, err
+		}
+		parallelize(len(points), func(s, e int) {
+			partial := make([]point, len(coeffs))
+			for j := range partial {
+				partial[j] = g.infinity()
+			}
+			for i := s; i < e; i++ {
+				for j, coeff := range coeffs {
+					if k := coeff(chunkStart + i); k != nil {
+						partial[j] = g.add(partial[j], g.scalarMult(points[i], k))
+					}
+				}
+			}
+			lock.Lock()
+			for j := range sums {
+				sums[j] = g.add(sums[j], partial[j])
+			}
+			lock.Unlock()
+		})
+	}
+	return sums, nil
+}
+
+// randomCoefficients are the 128 bits coefficients of the random linear combinations checking an accumulator
+// with a few pairings. They're derived from a secret random seed, so that they can be recomputed for each
+// family of points.
+type randomCoefficients [32]byte
+
+func newRandomCoefficients(random io.Reader) (*randomCoefficients, error) {
+	seed := &randomCoefficients{}
+	if _, err := io.ReadFull(random, seed[:]); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+func (seed *randomCoefficients) at(i int) *big.Int {
+	var buf [len(seed) + 8]byte
+	copy(buf[:], seed[:])
+	binary.BigEndian.PutUint64(buf[len(seed):], uint64(i))
+	h := sha256.Sum256(buf[:])
+	return new(big.Int).SetBytes(h[:16])
+}
+
+// -------------------------------------------------------------------------------------------------
+// Public keys
+
+// publicKey proves the knowledge of the secret x of a contribution, and binds it to the previous accumulator:
+// s is a random G1 point, sx = x s, and rx = x r where the G2 point r is derived from the hash of the
+// previous accumulator, s and sx. The accumulator updates are checked against (s, sx) and (r, rx).
+type publicKey struct {
+	s, sx, rx point
+}
+
+// publicKeySize is the size of the marshaled s, sx and rx
+const publicKeySize = 2*2*fpSize + 4*fpSize
+
+func newPublicKey(x *big.Int, prevHash string, index int, random io.Reader) (*publicKey, error) {
+	k, err := randomFr(random)
+	if err != nil {
+		return nil, err
+	}
+	pk := &publicKey{s: groupG1.scalarMult(groupG1.generator(), k)}
+	pk.sx = groupG1.scalarMult(pk.s, x)
+	pk.rx = groupG2.scalarMult(pk.r(prevHash, index), x)
+	return pk, nil
+}
+
+func (pk *publicKey) r(prevHash string, index int) point {
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte{byte(index)})
+	h.Write(pk.s.Marshal())
+	h.Write(pk.sx.Marshal())
+	k := new(big.Int).SetBytes(h.Sum(nil))
+	return groupG2.scalarMult(groupG2.generator(), k.Mod(k, frModulus))
+}
+
+// verify checks the proof of knowledge of x
+func (pk *publicKey) verify(prevHash string, index int) error {
+	if isInfinity(pk.s) || isInfinity(pk.sx) || isInfinity(pk.rx) {
+		return errInfinity
+	}
+	if !isInfinity(groupG2.scalarMult(pk.rx, frModulus)) {
+		return errors.New("public key isn't in G2")
+	}
+	if !pairingEqual(pk.sx, pk.r(prevHash, index), pk.s, pk.rx) {
+		return errors.New("invalid proof of knowledge")
+	}
+	return nil
+}
+
+// checkUpdate checks that new = x old for the secret x of the public key
+func (pk *publicKey) checkUpdate(g group, old, new point, prevHash string, index int) bool {
+	if g == groupG1 {
+		return pairingEqual(new, pk.r(prevHash, index), old, pk.rx)
+	}
+	return pairingEqual(pk.s, new, pk.sx, old)
+}
+
+func encodePublicKeys(keys []*publicKey) string {
+	var buf bytes.Buffer
+	for _, pk := range keys {
+		buf.Write(pk.s.Marshal())
+		buf.Write(pk.sx.Marshal())
+		buf.Write(pk.rx.Marshal())
+	}
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func decodePublicKeys(encoded string, n int) ([]*publicKey, error) {
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != n*publicKeySize {
+		return nil, errors.New("invalid public key size")
+	}
+	keys := make([]*publicKey, n)
+	for i := range keys {
+		buf := raw[i*publicKeySize:]
+		keys[i] = &publicKey{s: new(bn256.G1), sx: new(bn256.G1), rx: new(bn256.G2)}
+		if _, err := keys[i].s.(*bn256.G1).Unmarshal(buf[:2*fpSize]); err != nil {
+			return nil, err
+		}
+		if _, err := keys[i].sx.(*bn256.G1).Unmarshal(buf[2*fpSize : 4*fpSize]); err != nil {
+			return nil, err
+		}
+		if _, err := keys[i].rx.(*bn256.G2).Unmarshal(buf[4*fpSize : publicKeySize]); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,419 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"sync"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+var (
+	// frModulus is the order of the alt_bn128 groups, the modulus of the scalar field Fr
+	frModulus = bn256.Order
+
+	// frMontgomeryR = 2^256 mod r and its inverse, to convert from / to Montgomery form
+	frMontgomeryR    = new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 256), frModulus)
+	frMontgomeryRInv = new(big.Int).ModInverse(frMontgomeryR, frModulus)
+
+	// frRootOfUnity generates the subgroup of order 2^frTwoAdicity of Fr* (libff alt_bn128_Fr::root_of_unity)
+	frRootOfUnity, _ = new(big.Int).SetString("19103219067921713944291392827692070036145651957329286315305642004821462161904", 10)
+)
+
+const frTwoAdicity = 28
+
+func frMul(a, b *big.Int) *big.Int {
+	e := new(big.Int).Mul(a, b)
+	return e.Mod(e, frModulus)
+}
+
+func frSub(a, b *big.Int) *big.Int {
+	e := new(big.Int).Sub(a, b)
+	return e.Mod(e, frModulus)
+}
+
+func frExp(a *big.Int, e int) *big.Int {
+	return new(big.Int).Exp(a, big.NewInt(int64(e)), frModulus)
+}
+
+func frInverse(a *big.Int) *big.Int {
+	return new(big.Int).ModInverse(a, frModulus)
+}
+
+// randomFr returns a random non-zero element of Fr
+func randomFr(random io.Reader) (*big.Int, error) {
+	for {
+		e, err := rand.Int(random, frModulus)
+		if err != nil {
+			return nil, err
+		}
+		if e.Sign() != 0 {
+			return e, nil
+		}
+	}
+}
+
+// rootOfUnity returns the generator of the subgroup of order n (a power of 2) of Fr* that libff uses
+// (get_root_of_unity)
+func rootOfUnity(n int) *big.Int {
+	e := new(big.Int).Set(frRootOfUnity)
+	for i := log2(n); i < frTwoAdicity; i++ {
+		e.Mul(e, e).Mod(e, frModulus)
+	}
+	return e
+}
+
+// log2 returns ceil(log2(n)), as libff::log2
+func log2(n int) int {
+	r := 0
+	for 1<<uint(r) < n {
+		r++
+	}
+	return r
+}
+
+// -------------------------------------------------------------------------------------------------
+// G1 and G2
+
+// point is a *bn256.G1 or a *bn256.G2
+type point interface {
+	Marshal() []byte
+}
+
+// group implements the operations of the ceremony on the points of G1 or G2
+type group interface {
+	generator() point
+	infinity() point
+	add(a, b point) point
+	sub(a, b point) point
+	scalarMult(a point, k *big.Int) point
+	// pointSize is the size of a marshaled point
+	pointSize() int
+	unmarshal(buf []byte) (point, error)
+	// writeLibsnark writes a point as libsnark serializes it
+	writeLibsnark(w io.Writer, p point)
+}
+
+var (
+	groupG1 group = g1Group{}
+	groupG2 group = g2Group{}
+)
+
+type g1Group struct{}
+
+func (g1Group) generator() point {
+	return new(bn256.G1).ScalarBaseMult(big.NewInt(1))
+}
+
+func (g1Group) infinity() point {
+	return new(bn256.G1).ScalarBaseMult(new(big.Int))
+}
+
+func (g1Group) add(a, b point) point {
+	return new(bn256.G1).Add(a.(*bn256.G1), b.(*bn256.G1))
+}
+
+func (g1Group) sub(a, b point) point {
+	neg := new(bn256.G1).Neg(b.(*bn256.G1))
+	return neg.Add(a.(*bn256.G1), neg)
+}
+
+func (g1Group) scalarMult(a point, k *big.Int) point {
+	return new(bn256.G1).ScalarMult(a.(*bn256.G1), k)
+}
+
+func (g1Group) pointSize() int {
+	return 2 * fpSize
+}
+
+func (g1Group) unmarshal(buf []byte) (point, error) {
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (g1Group) writeLibsnark(w io.Writer, p point) {
+	writeG1(w, toG1(p.(*bn256.G1)))
+}
+
+type g2Group struct{}
+
+func (g2Group) generator() point {
+	return new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+}
+
+func (g2Group) infinity() point {
+	return new(bn256.G2).ScalarBaseMult(new(big.Int))
+}
+
+func (g2Group) add(a, b point) point {
+	return new(bn256.G2).Add(a.(*bn256.G2), b.(*bn256.G2))
+}
+
+func (g2Group) sub(a, b point) point {
+	neg := new(bn256.G2).Neg(b.(*bn256.G2))
+	return neg.Add(a.(*bn256.G2), neg)
+}
+
+func (g2Group) scalarMult(a point, k *big.Int) point {
+	return new(bn256.G2).ScalarMult(a.(*bn256.G2), k)
+}
+
+func (g2Group) pointSize() int {
+	return 4 * fpSize
+}
+
+func (g2Group) unmarshal(buf []byte) (point, error) {
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (g2Group) writeLibsnark(w io.Writer, p point) {
+	writeG2(w, toG2(p.(*bn256.G2)))
+}
+
+// toG1 and toG2 convert bn256 points to affine coordinates. bn256 marshals the point at infinity
+// as (0, 0), and G2 coordinates imaginary part first.
+func toG1(p *bn256.G1) G1 {
+	m := p.Marshal()
+	return G1{
+		X: new(big.Int).SetBytes(m[:fpSize]),
+		Y: new(big.Int).SetBytes(m[fpSize:]),
+	}
+}
+
+func toG2(p *bn256.G2) G2 {
+	m := p.Marshal()
+	return G2{
+		X: [2]*big.Int{new(big.Int).SetBytes(m[fpSize : 2*fpSize]), new(big.Int).SetBytes(m[:fpSize])},
+		Y: [2]*big.Int{new(big.Int).SetBytes(m[3*fpSize:]), new(big.Int).SetBytes(m[2*fpSize : 3*fpSize])},
+	}
+}
+
+// toGT converts a bn256 GT element, marshaled with the coefficients of each extension in reverse order
+// (x·w + y, x·v² + y·v + z, x·i + y), to libff order
+func toGT(e *bn256.GT) GT {
+	m := e.Marshal()
+	var gt GT
+	for i := range gt {
+		gt[i] = new(big.Int).SetBytes(m[(len(gt)-1-i)*fpSize : (len(gt)-i)*fpSize])
+	}
+	return gt
+}
+
+func isInfinity(p point) bool {
+	for _, b := range p.Marshal() {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func equal(a, b point) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
+
+// pairingEqual returns true if e(a1, a2) == e(b1, b2)
+func pairingEqual(a1 point, a2 point, b1 point, b2 point) bool {
+	negB1 := new(bn256.G1).Neg(b1.(*bn256.G1))
+	return bn256.PairingCheck([]*bn256.G1{a1.(*bn256.G1), negB1}, []*bn256.G2{a2.(*bn256.G2), b2.(*bn256.G2)})
+}
+
+// parallelize calls f on ranges of [0, n), from runtime.NumCPU() goroutines
+func parallelize(n int, f func(start, end int)) {
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		f(0, n)
+		return
+	}
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			f(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// scalarMultAll returns k[i] points[i]
+func scalarMultAll(g group, points []point, k func(i int) *big.Int) []point {
+	result := make([]point, len(points))
+	parallelize(len(points), func(start, end int) {
+		for i := start; i < end; i++ {
+			result[i] = g.scalarMult(points[i], k(i))
+		}
+	})
+	return result
+}
+
+// -------------------------------------------------------------------------------------------------
+// QAP evaluation domain
+
+// evaluationDomain is the libfqfft evaluation domain that libsnark picks for a QAP of minSize
+// (get_evaluation_domain): a basic radix-2 domain, the subgroup of order m of Fr*, or a step radix-2
+// domain, the subgroup of order bigM and a coset of the subgroup of order smallM (m = bigM + smallM).
+type evaluationDomain struct {
+	m, bigM, smallM int
+}
+
+func newEvaluationDomain(minSize int) (*evaluationDomain, error) {
+	if d := radix2Domain(minSize); d != nil {
+		return d, nil
+	}
+	bigM := 1 << uint(log2(minSize)-1)
+	roundedSmall := 1 << uint(log2(minSize-bigM))
+	if d := radix2Domain(bigM + roundedSmall); d != nil {
+		return d, nil
+	}
+	return nil, fmt.Errorf("no evaluation domain of size %d", minSize)
+}
+
+// radix2Domain returns the basic or step radix-2 domain of size m, or nil if there is none. libfqfft
+// tries an extended radix-2 domain in between, which only exists for log2(m) = 29.
+func radix2Domain(m int) *evaluationDomain {
+	if m <= 1 || log2(m) > frTwoAdicity {
+		return nil
+	}
+	if m&(m-1) == 0 {
+		return &evaluationDomain{m: m}
+	}
+	bigM := 1 << uint(log2(m)-1)
+	smallM := m - bigM
+	if smallM&(smallM-1) != 0 {
+		return nil
+	}
+	return &evaluationDomain{m: m, bigM: bigM, smallM: smallM}
+}
+
+// lagrangeBasis returns the evaluations at tau of the Lagrange polynomials of the domain followed by
+// its vanishing polynomial ([L_0(tau)], ..., [L_m-1(tau)], [Z(tau)]), from powers[j] = [tau^j], j = 0..m.
+// It follows libfqfft (evaluate_all_lagrange_polynomials, compute_vanishing_polynomial).
+func (d *evaluationDomain) lagrangeBasis(g group, powers []point) []point {
+	m := d.m
+	basis := make([]point, m+1)
+	if d.smallM == 0 {
+		// L_k(tau) = 1/m sum_j omega^-jk tau^j, Z(tau) = tau^m - 1
+		copy(basis, powers[:m])
+		fft(g, basis[:m], frInverse(rootOfUnity(m)))
+		mInv := frInverse(big.NewInt(int64(m)))
+		copy(basis, scalarMultAll(g, basis[:m], func(int) *big.Int { return mInv }))
+		basis[m] = g.sub(powers[m], powers[0])
+		return basis
+	}
+
+	bigM, smallM := d.bigM, d.smallM
+	omega := rootOfUnity(2 * bigM)
+	omegaSmall := frExp(omega, smallM)
+
+	// i < bigM: L_i(tau) = (tau^smallM - omega^smallM) / (omega_big^(i smallM) - omega^smallM) inner_i(tau),
+	// with inner_i(tau) = 1/bigM sum_j omega_big^-ij tau^j the Lagrange polynomials of the subgroup of order bigM
+	x := append([]point(nil), powers[smallM:smallM+bigM]...)
+	y := append([]point(nil), powers[:bigM]...)
+	omegaBigInv := frInverse(frExp(omega, 2))
+	fft(g, x, omegaBigInv)
+	fft(g, y, omegaBigInv)
+	bigOmegaSmall := frExp(omega, 2*smallM)
+	c := make([]*big.Int, bigM)
+	e := big.NewInt(1)
+	bigInv := frInverse(big.NewInt(int64(bigM)))
+	for i := range c {
+		c[i] = frMul(frInverse(frSub(e, omegaSmall)), bigInv)
+		e = frMul(e, bigOmegaSmall)
+	}
+	parallelize(bigM, func(start, end int) {
+		for i := start; i < end; i++ {
+			basis[i] = g.sub(g.scalarMult(x[i], c[i]), g.scalarMult(y[i], frMul(c[i], omegaSmall)))
+		}
+	})
+
+	// i < smallM: L_bigM+i(tau) = (tau^bigM - 1) / (omega^bigM - 1) inner_i(tau / omega), with
+	// inner_i(x) = 1/smallM sum_j omega_small^-ij x^j the Lagrange polynomials of the subgroup of order smallM
+	q := make([]point, smallM)
+	omegaInv := frInverse(omega)
+	parallelize(smallM, func(start, end int) {
+		for j := start; j < end; j++ {
+			q[j] = g.scalarMult(g.sub(powers[j+bigM], powers[j]), frExp(omegaInv, j))
+		}
+	})
+	fft(g, q, frInverse(rootOfUnity(smallM)))
+	k := frInverse(frMul(frSub(frExp(omega, bigM), big.NewInt(1)), big.NewInt(int64(smallM))))
+	copy(basis[bigM:], scalarMultAll(g, q, func(int) *big.Int { return k }))
+
+	// Z(tau) = (tau^bigM - 1) (tau^smallM - omega^smallM)
+	basis[m] = g.add(g.sub(powers[m], g.scalarMult(powers[bigM], omegaSmall)), g.sub(g.scalarMult(powers[0], omegaSmall), powers[smallM]))
+	return basis
+}
+
+// fft replaces a by its discrete Fourier transform a[k] = sum_j omega^jk a[j], for omega of order len(a),
+// a power of 2
+func fft(g group, a []point, omega *big.Int) {
+	n := len(a)
+	logn := uint(log2(n))
+	for i := 0; i < n; i++ {
+		j := 0
+		for b := uint(0); b < logn; b++ {
+			j |= (i >> b & 1) << (logn - 1 - b)
+		}
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for half := 1; half < n; half *= 2 {
+		// twiddles of the butterflies of size 2 half
+		w := frExp(omega, n/(2*half))
+		twiddles := make([]*big.Int, half)
+		twiddles[0] = big.NewInt(1)
+		for k := 1; k < half; k++ {
+			twiddles[k] = frMul(twiddles[k-1], w)
+		}
+		parallelize(n/2, func(start, end int) {
+			for b := start; b < end; b++ {
+				k := b % half
+				i := (b/half)*2*half + k
+				t := a[i+half]
+				if k != 0 {
+					t = g.scalarMult(t, twiddles[k])
+				}
+				a[i], a[i+half] = g.add(a[i], t), g.sub(a[i], t)
+			}
+		})
+	}
+}
+
+// errInfinity is returned when a point of a public key or an accumulator is unexpectedly 0
+var errInfinity = errors.New("unexpected point at infinity")
@@ -0,0 +1,167 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// writeKeys writes the libsnark serialized proving and verifying keys computed from the final accumulators,
+// as r1cs_ppzksnark_generator would for cs (swapped, see swapABIfBeneficial) with the secrets of the
+// ceremony
+func writeKeys(pkWriter, vkWriter io.Writer, cs *constraintSystem, phase1Path, phase2Path string) error {
+	q, err := newQAP(cs)
+	if err != nil {
+		return err
+	}
+	m := q.domain.m
+	w := bufio.NewWriterSize(pkWriter, 1<<20)
+	loadFamily := func(family int) ([]point, error) {
+		return loadPoints(phase2Path, CeremonyCircuitSecrets, m, familyOffset(family, m), phase2Families[family].g, m+1)
+	}
+
+	// A, B and C queries: sparse vectors of knowledge commitments (libsnark At, Bt, Ct, with Z appended)
+	// of the non zero polynomials. The inputs A polynomials are in the verifying key IC query instead.
+	var ic []point
+	queries := []struct {
+		polynomials [][]qapTerm
+		g, h        int
+	}{
+		{q.a, familyRhoA, familyAlphaRhoA},
+		{q.b, familyRhoB, familyAlphaRhoB},
+		{q.c, familyRhoAB, familyAlphaRhoAB},
+	}
+	for i, query := range queries {
+		var indices []int
+		for v, terms := range query.polynomials {
+			if len(terms) > 0 && (i > 0 || v > q.numInputs) {
+				indices = append(indices, v)
+			}
+		}
+		indices = append(indices, q.numVariables+1)
+
+		var values [2][]point
+		for j, family := range []int{query.g, query.h} {
+			basis, err := loadFamily(family)
+			if err != nil {
+				return err
+			}
+			values[j] = evaluateAll(phase2Families[family].g, basis, query.polynomials, indices)
+			if family == familyRhoA {
+				inputs := make([]int, q.numInputs+1)
+				for v := range inputs {
+					inputs[v] = v
+				}
+				ic = evaluateAll(groupG1, basis, query.polynomials, inputs)
+			}
+		}
+
+		fmt.Fprintf(w, "%d\n%d\n", q.numVariables+2, len(indices))
+		for _, v := range indices {
+			fmt.Fprintf(w, "%d\n", v)
+		}
+		fmt.Fprintf(w, "%d\n", len(indices))
+		for k := range indices {
+			phase2Families[query.g].g.writeLibsnark(w, values[0][k])
+			phase2Families[query.h].g.writeLibsnark(w, values[1][k])
+		}
+	}
+
+	// H query: powers of tau
+	powers, err := loadPoints(phase1Path, CeremonyPowersOfTau, m, 0, groupG1, m+1)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d\n", len(powers))
+	for _, p := range powers {
+		groupG1.writeLibsnark(w, p)
+	}
+
+	// K query: beta (rA At + rB Bt + rC Ct), then beta rA Z, beta rB Z and beta rC Z
+	k := make([]point, q.numVariables+4)
+	all := make([]int, q.numVariables+1)
+	for v := range all {
+		all[v] = v
+	}
+	for i, family := range []int{familyBetaRhoA, familyBetaRhoB, familyBetaRhoAB} {
+		basis, err := loadFamily(family)
+		if err != nil {
+			return err
+		}
+		values := evaluateAll(groupG1, basis, queries[i].polynomials, all)
+		for v, p := range values {
+			if i == 0 {
+				k[v] = p
+			} else {
+				k[v] = groupG1.add(k[v], p)
+			}
+		}
+		k[q.numVariables+1+i] = basis[m]
+	}
+	fmt.Fprintf(w, "%d\n", len(k))
+	for _, p := range k {
+		groupG1.writeLibsnark(w, p)
+	}
+
+	cs.write(w)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// verifying key
+	acc, err := openAccumulator(phase2Path, CeremonyCircuitSecrets, m)
+	if err != nil {
+		return err
+	}
+	singles, err := readSingles(acc)
+	acc.f.Close()
+	if err != nil {
+		return err
+	}
+	vk := &VerifyingKey{
+		AlphaA:     toG2(singles[singleAlphaA2].(*bn256.G2)),
+		AlphaB:     toG1(singles[singleAlphaB1].(*bn256.G1)),
+		AlphaC:     toG2(singles[singleAlphaC2].(*bn256.G2)),
+		Gamma:      toG2(singles[singleGamma2].(*bn256.G2)),
+		GammaBeta1: toG1(singles[singleGammaBeta1].(*bn256.G1)),
+		GammaBeta2: toG2(singles[singleGammaBeta2].(*bn256.G2)),
+		RCZ:        toG2(singles[singleRhoABZ2].(*bn256.G2)),
+	}
+	for _, p := range ic {
+		vk.IC = append(vk.IC, toG1(p.(*bn256.G1)))
+	}
+	_, err = vkWriter.Write(vk.Bytes())
+	return err
+}
+
+// evaluateAll returns the evaluations of the polynomials at indices in basis (the last index is Z)
+func evaluateAll(g group, basis []point, polynomials [][]qapTerm, indices []int) []point {
+	values := make([]point, len(indices))
+	m := len(basis) - 1
+	parallelize(len(indices), func(start, end int) {
+		for i := start; i < end; i++ {
+			if v := indices[i]; v < len(polynomials) {
+				values[i] = evaluate(g, basis, polynomials[v])
+			} else {
+				values[i] = basis[m]
+			}
+		}
+	})
+	return values
+}
@@ -0,0 +1,391 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// -------------------------------------------------------------------------------------------------
+// Phase 1: powers of tau
+//
+// The accumulator is [tau^j]G1 then [tau^j]G2, for j = 0..m. A contribution multiplies tau by a secret.
+
+func writePhase1(a *accumulatorWriter, m int) error {
+	for _, g := range []group{groupG1, groupG2} {
+		for j := 0; j <= m; j++ {
+			a.write(g.generator())
+		}
+	}
+	return nil
+}
+
+func contributePhase1(a *accumulatorWriter, old *accumulatorReader, m int, tau *big.Int) error {
+	for _, g := range []group{groupG1, groupG2} {
+		err := mapPoints(g, old, a, m+1, func(j int, p point) point {
+			return g.scalarMult(p, frExp(tau, j))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTau reads [tau]G1 from a phase 1 accumulator
+func readTau(path string, m int) (point, error) {
+	points, err := loadPoints(path, CeremonyPowersOfTau, m, int64(groupG1.pointSize()), groupG1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return points[0], nil
+}
+
+// verifyPhase1 checks that the accumulator updates the previous one, of hash prevHash and [tau]G1 oldTau,
+// with the secret of pk. It returns the accumulator hash.
+func verifyPhase1(acc *accumulatorReader, m int, oldTau point, prevHash string, pk *publicKey, seed *randomCoefficients) (string, error) {
+	if err := pk.verify(prevHash, 0); err != nil {
+		return "", err
+	}
+
+	// powers: with r random, sum_j r_j [tau^j+1] = tau sum_j r_j [tau^j]
+	current := func(j int) *big.Int {
+		if j < m {
+			return seed.at(j)
+		}
+		return nil
+	}
+	next := func(j int) *big.Int {
+		if j > 0 {
+			return seed.at(j - 1)
+		}
+		return nil
+	}
+	var first [2][2]point
+	var sums [2][]point
+	for i, g := range []group{groupG1, groupG2} {
+		for j := range first[i] {
+			var err error
+			if first[i][j], err = acc.read(g); err != nil {
+				return "", err
+			}
+		}
+		if !equal(first[i][0], g.generator()) {
+			return "", errors.New("invalid accumulator: tau^0 isn't 1")
+		}
+		if isInfinity(first[i][1]) {
+			return "", errInfinity
+		}
+		rest, err := weightedSums(g, acc, 2, m+1, current, next)
+		if err != nil {
+			return "", err
+		}
+		sums[i] = []point{
+			g.add(rest[0], g.add(g.scalarMult(first[i][0], seed.at(0)), g.scalarMult(first[i][1], seed.at(1)))),
+			g.add(rest[1], g.scalarMult(first[i][1], seed.at(0))),
+		}
+	}
+	hash, err := acc.close()
+	if err != nil {
+		return "", err
+	}
+
+	g1, g2 := groupG1.generator(), groupG2.generator()
+	tau1, tau2 := first[0][1], first[1][1]
+	switch {
+	case !pk.checkUpdate(groupG1, oldTau, tau1, prevHash, 0):
+		return "", errors.New("the contribution doesn't update the previous accumulator")
+	case !pairingEqual(tau1, g2, g1, tau2):
+		return "", errors.New("invalid accumulator: tau differs in G1 and G2")
+	case !pairingEqual(sums[0][1], g2, sums[0][0], tau2):
+		return "", errors.New("invalid accumulator: G1 elements aren't powers of tau")
+	case !pairingEqual(g1, sums[1][1], tau1, sums[1][0]):
+		return "", errors.New("invalid accumulator: G2 elements aren't powers of tau")
+	}
+	return hash, nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// Phase 2: circuit secrets
+//
+// The accumulator is a list of single elements followed by families of m+1 elements, multiples of the
+// Lagrange basis ([L_0(tau)], ..., [L_m-1(tau)], [Z(tau)]). A contribution multiplies each element by a
+// product of secrets (phase2Element). The keys are linear combinations of the final accumulator.
+
+// phase 2 secrets (r1cs_ppzksnark_generator: rA, rB, alphaA, alphaB, alphaC, beta, gamma)
+const (
+	secretRhoA = iota
+	secretRhoB
+	secretAlphaA
+	secretAlphaB
+	secretAlphaC
+	secretBeta
+	secretGamma
+	numSecrets
+)
+
+// phase2Element is an element (or family of elements) of the phase 2 accumulator, and the secrets it's
+// multiplied by
+type phase2Element struct {
+	g       group
+	secrets []int
+}
+
+func (e phase2Element) scalar(secrets []*big.Int) *big.Int {
+	k := big.NewInt(1)
+	for _, s := range e.secrets {
+		k = frMul(k, secrets[s])
+	}
+	return k
+}
+
+// single elements, the initial accumulator has generators, and Z(tau) for singleRhoABZ2
+const (
+	singleRhoA2 = iota
+	singleRhoB1
+	singleRhoB2
+	singleAlphaA2
+	singleAlphaB1
+	singleAlphaC2
+	singleBeta1
+	singleBeta2
+	singleGamma2
+	singleRhoAB2
+	singleRhoABZ2
+	singleGammaBeta1
+	singleGammaBeta2
+	numSingles
+)
+
+var phase2Singles = [numSingles]phase2Element{
+	singleRhoA2:      {groupG2, []int{secretRhoA}},
+	singleRhoB1:      {groupG1, []int{secretRhoB}},
+	singleRhoB2:      {groupG2, []int{secretRhoB}},
+	singleAlphaA2:    {groupG2, []int{secretAlphaA}},
+	singleAlphaB1:    {groupG1, []int{secretAlphaB}},
+	singleAlphaC2:    {groupG2, []int{secretAlphaC}},
+	singleBeta1:      {groupG1, []int{secretBeta}},
+	singleBeta2:      {groupG2, []int{secretBeta}},
+	singleGamma2:     {groupG2, []int{secretGamma}},
+	singleRhoAB2:     {groupG2, []int{secretRhoA, secretRhoB}},
+	singleRhoABZ2:    {groupG2, []int{secretRhoA, secretRhoB}},
+	singleGammaBeta1: {groupG1, []int{secretGamma, secretBeta}},
+	singleGammaBeta2: {groupG2, []int{secretGamma, secretBeta}},
+}
+
+// families of elements, and the proving key queries they're for
+const (
+	familyRhoA       = iota // A
+	familyAlphaRhoA         // A
+	familyRhoB              // B
+	familyAlphaRhoB         // B
+	familyRhoAB             // C
+	familyAlphaRhoAB        // C
+	familyBetaRhoA          // K
+	familyBetaRhoB          // K
+	familyBetaRhoAB         // K
+	numFamilies
+)
+
+var phase2Families = [numFamilies]phase2Element{
+	familyRhoA:       {groupG1, []int{secretRhoA}},
+	familyAlphaRhoA:  {groupG1, []int{secretAlphaA, secretRhoA}},
+	familyRhoB:       {groupG2, []int{secretRhoB}},
+	familyAlphaRhoB:  {groupG1, []int{secretAlphaB, secretRhoB}},
+	familyRhoAB:      {groupG1, []int{secretRhoA, secretRhoB}},
+	familyAlphaRhoAB: {groupG1, []int{secretAlphaC, secretRhoA, secretRhoB}},
+	familyBetaRhoA:   {groupG1, []int{secretBeta, secretRhoA}},
+	familyBetaRhoB:   {groupG1, []int{secretBeta, secretRhoB}},
+	familyBetaRhoAB:  {groupG1, []int{secretBeta, secretRhoA, secretRhoB}},
+}
+
+// familyOffset returns the offset of a family in a phase 2 accumulator
+func familyOffset(family, m int) int64 {
+	var offset int64
+	for _, e := range phase2Singles {
+		offset += int64(e.g.pointSize())
+	}
+	for _, e := range phase2Families[:family] {
+		offset += int64(e.g.pointSize()) * int64(m+1)
+	}
+	return offset
+}
+
+// lagrange is the Lagrange basis in G1 and G2, in the lagrange file then the initial phase 2 accumulator
+type lagrange struct {
+	g1, g2 []point
+}
+
+func writeLagrange(a *accumulatorWriter, basis *lagrange) error {
+	a.write(basis.g1...)
+	a.write(basis.g2...)
+	return nil
+}
+
+func readLagrange(path string, m int) (*lagrange, error) {
+	acc, err := openAccumulator(path, CeremonyCircuitSecrets, m)
+	if err != nil {
+		return nil, err
+	}
+	basis := &lagrange{}
+	if basis.g1, err = acc.readN(groupG1, m+1); err != nil {
+		return nil, err
+	}
+	if basis.g2, err = acc.readN(groupG2, m+1); err != nil {
+		return nil, err
+	}
+	if _, err := acc.close(); err != nil {
+		return nil, err
+	}
+	return basis, nil
+}
+
+func (basis *lagrange) of(g group) []point {
+	if g == groupG1 {
+		return basis.g1
+	}
+	return basis.g2
+}
+
+func writePhase2(a *accumulatorWriter, basis *lagrange) error {
+	for i, e := range phase2Singles {
+		if i == singleRhoABZ2 {
+			a.write(basis.g2[len(basis.g2)-1])
+		} else {
+			a.write(e.g.generator())
+		}
+	}
+	for _, e := range phase2Families {
+		a.write(basis.of(e.g)...)
+	}
+	return nil
+}
+
+func contributePhase2(a *accumulatorWriter, old *accumulatorReader, m int, secrets []*big.Int) error {
+	for _, e := range phase2Singles {
+		p, err := old.read(e.g)
+		if err != nil {
+			return err
+		}
+		a.write(e.g.scalarMult(p, e.scalar(secrets)))
+	}
+	for _, e := range phase2Families {
+		k := e.scalar(secrets)
+		err := mapPoints(e.g, old, a, m+1, func(_ int, p point) point {
+			return e.g.scalarMult(p, k)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSingles reads the single elements of a phase 2 accumulator
+func readSingles(acc *accumulatorReader) ([]point, error) {
+	singles := make([]point, numSingles)
+	for i, e := range phase2Singles {
+		var err error
+		if singles[i], err = acc.read(e.g); err != nil {
+			return nil, err
+		}
+		if isInfinity(singles[i]) {
+			return nil, errInfinity
+		}
+	}
+	return singles, nil
+}
+
+// lagrangeSums returns the random linear combinations of the Lagrange basis (in G1 and G2)
+func lagrangeSums(basis *lagrange, seed *randomCoefficients) []point {
+	sums := make([]point, 2)
+	for i, g := range []group{groupG1, groupG2} {
+		points := basis.of(g)
+		sums[i] = g.infinity()
+		for _, p := range scalarMultAll(g, points, seed.at) {
+			sums[i] = g.add(sums[i], p)
+		}
+	}
+	return sums
+}
+
+// verifyPhase2 checks that the accumulator updates the previous one, of hash prevHash and single elements
+// oldSingles, with the secrets of keys. sumL are the lagrangeSums of basis with seed. It returns the
+// accumulator hash.
+func verifyPhase2(acc *accumulatorReader, m int, oldSingles []point, prevHash string, keys []*publicKey, basis *lagrange, sumL []point, seed *randomCoefficients) (string, error) {
+	for i, pk := range keys {
+		if err := pk.verify(prevHash, i); err != nil {
+			return "", err
+		}
+	}
+
+	singles, err := readSingles(acc)
+	if err != nil {
+		return "", err
+	}
+	var sums [numFamilies]point
+	for f, e := range phase2Families {
+		s, err := weightedSums(e.g, acc, 0, m+1, seed.at)
+		if err != nil {
+			return "", err
+		}
+		sums[f] = s[0]
+	}
+	hash, err := acc.close()
+	if err != nil {
+		return "", err
+	}
+
+	// single elements, multiplied by one secret: updates of the previous ones
+	for i, e := range phase2Singles {
+		if len(e.secrets) == 1 && !keys[e.secrets[0]].checkUpdate(e.g, oldSingles[i], singles[i], prevHash, e.secrets[0]) {
+			return "", fmt.Errorf("the contribution doesn't update the previous accumulator (single element %d)", i)
+		}
+	}
+
+	g1, g2 := groupG1.generator(), groupG2.generator()
+	z1 := basis.g1[m]
+	checks := []struct {
+		a1, a2, b1, b2 point
+		what           string
+	}{
+		// single elements
+		{singles[singleRhoB1], g2, g1, singles[singleRhoB2], "rhoB"},
+		{singles[singleBeta1], g2, g1, singles[singleBeta2], "beta"},
+		{singles[singleRhoB1], singles[singleRhoA2], g1, singles[singleRhoAB2], "rhoA rhoB"},
+		{z1, singles[singleRhoAB2], g1, singles[singleRhoABZ2], "rhoA rhoB Z"},
+		{singles[singleGammaBeta1], g2, singles[singleBeta1], singles[singleGamma2], "gamma beta"},
+		{g1, singles[singleGammaBeta2], singles[singleGammaBeta1], g2, "gamma beta"},
+
+		// families, from their random linear combinations
+		{sums[familyRhoA], g2, sumL[0], singles[singleRhoA2], "rhoA"},
+		{sums[familyAlphaRhoA], g2, sums[familyRhoA], singles[singleAlphaA2], "alphaA rhoA"},
+		{g1, sums[familyRhoB], singles[singleRhoB1], sumL[1], "rhoB"},
+		{sums[familyAlphaRhoB], g2, singles[singleAlphaB1], sums[familyRhoB], "alphaB rhoB"},
+		{sums[familyRhoAB], g2, sums[familyRhoA], singles[singleRhoB2], "rhoA rhoB"},
+		{sums[familyAlphaRhoAB], g2, sums[familyRhoAB], singles[singleAlphaC2], "alphaC rhoA rhoB"},
+		{sums[familyBetaRhoA], g2, sums[familyRhoA], singles[singleBeta2], "beta rhoA"},
+		{sums[familyBetaRhoB], g2, singles[singleBeta1], sums[familyRhoB], "beta rhoB"},
+		{sums[familyBetaRhoAB], g2, sums[familyRhoAB], singles[singleBeta2], "beta rhoA rhoB"},
+	}
+	for _, c := range checks {
+		if !pairingEqual(c.a1, c.a2, c.b1, c.b2) {
+			return "", fmt.Errorf("invalid accumulator: inconsistent %s elements", c.what)
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// ConstraintSystemExporter is implemented by the backends that can export the R1CS of the circuits, to
+// run a setup Ceremony
+type ConstraintSystemExporter interface {
+	// ConstraintSystem returns the libsnark serialized r1cs_constraint_system of circuit
+	ConstraintSystem(treeDepth uint, circuit Circuit) ([]byte, error)
+}
+
+// constraintSystem is a libsnark r1cs_constraint_system: constraints <a, x> * <b, x> = <c, x> where x is
+// (1, primary inputs, auxiliary inputs)
+type constraintSystem struct {
+	primaryInputSize   int
+	auxiliaryInputSize int
+	constraints        []constraint
+}
+
+type constraint struct {
+	a, b, c []linearTerm
+}
+
+type linearTerm struct {
+	index int
+	coeff *big.Int
+}
+
+func (cs *constraintSystem) numVariables() int {
+	return cs.primaryInputSize + cs.auxiliaryInputSize
+}
+
+// parseConstraintSystem parses a libsnark serialized r1cs_constraint_system
+func parseConstraintSystem(raw []byte) (*constraintSystem, error) {
+	r := bufio.NewReader(bytes.NewReader(raw))
+	cs := &constraintSystem{}
+	var nbConstraints int
+	for _, n := range []*int{&cs.primaryInputSize, &cs.auxiliaryInputSize, &nbConstraints} {
+		var err error
+		if *n, err = readSize(r, len(raw)); err != nil {
+			return nil, fmt.Errorf("invalid constraint system: %v", err)
+		}
+	}
+
+	// most coefficients are the same few values (1, -1, powers of 2): share them
+	coeffs := make(map[string]*big.Int)
+	readLinearCombination := func() ([]linearTerm, error) {
+		nbTerms, err := readSize(r, len(raw))
+		if err != nil {
+			return nil, err
+		}
+		terms := make([]linearTerm, nbTerms)
+		var buf [fpSize]byte
+		for i := range terms {
+			if terms[i].index, err = readSize(r, cs.numVariables()+1); err != nil {
+				return nil, err
+			}
+			if _, err := io.ReadFull(r, buf[:]); err != nil {
+				return nil, err
+			}
+			coeff, ok := coeffs[string(buf[:])]
+			if !ok {
+				if coeff, err = readMontgomery(bytes.NewReader(buf[:]), frModulus, frMontgomeryRInv); err != nil {
+					return nil, err
+				}
+				coeffs[string(buf[:])] = coeff
+			}
+			terms[i].coeff = coeff
+		}
+		return terms, nil
+	}
+	if nbConstraints > len(raw) {
+		return nil, errors.New("invalid constraint system size")
+	}
+	cs.constraints = make([]constraint, nbConstraints)
+	for i := range cs.constraints {
+		for _, lc := range []*[]linearTerm{&cs.constraints[i].a, &cs.constraints[i].b, &cs.constraints[i].c} {
+			var err error
+			if *lc, err = readLinearCombination(); err != nil {
+				return nil, fmt.Errorf("invalid constraint system: constraint %d: %v", i, err)
+			}
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return nil, errors.New("invalid constraint system: trailing data")
+	}
+	return cs, nil
+}
+
+// readSize reads a libsnark serialized size or index ("%d\n"), lower than max
+func readSize(r *bufio.Reader, max int) (int, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(string(line[:len(line)-1]))
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n >= max {
+		return 0, fmt.Errorf("%d out of range", n)
+	}
+	return n, nil
+}
+
+// write writes the libsnark serialization of the constraint system
+func (cs *constraintSystem) write(w io.Writer) {
+	fmt.Fprintf(w, "%d\n%d\n%d\n", cs.primaryInputSize, cs.auxiliaryInputSize, len(cs.constraints))
+	for _, c := range cs.constraints {
+		for _, lc := range [][]linearTerm{c.a, c.b, c.c} {
+			fmt.Fprintf(w, "%d\n", len(lc))
+			for _, term := range lc {
+				fmt.Fprintf(w, "%d\n", term.index)
+				writeMontgomery(w, term.coeff, frModulus, frMontgomeryR)
+			}
+		}
+	}
+}
+
+// swapABIfBeneficial swaps the A and B linear combinations if B touches more variables, as
+// r1cs_ppzksnark_generator does (r1cs_constraint_system::swap_AB_if_beneficial)
+func (cs *constraintSystem) swapABIfBeneficial() {
+	touchedA := make([]bool, cs.numVariables()+1)
+	touchedB := make([]bool, cs.numVariables()+1)
+	for _, c := range cs.constraints {
+		for _, term := range c.a {
+			touchedA[term.index] = true
+		}
+		for _, term := range c.b {
+			touchedB[term.index] = true
+		}
+	}
+	var countA, countB int
+	for i := range touchedA {
+		if touchedA[i] {
+			countA++
+		}
+		if touchedB[i] {
+			countB++
+		}
+	}
+	if countB > countA {
+		for i := range cs.constraints {
+			cs.constraints[i].a, cs.constraints[i].b = cs.constraints[i].b, cs.constraints[i].a
+		}
+	}
+}
+
+// qapTerm is the coefficient of the Lagrange polynomial L_k in a QAP polynomial
+type qapTerm struct {
+	k     int
+	coeff *big.Int
+}
+
+// qap is the quadratic arithmetic program of a constraint system (r1cs_to_qap_instance_map_with_evaluation)
+// in the Lagrange basis of its domain: the A polynomial of variable i is sum_j a[i][j].coeff L_a[i][j].k,
+// and likewise for B and C.
+type qap struct {
+	domain                  *evaluationDomain
+	numInputs, numVariables int
+	a, b, c                 [][]qapTerm
+}
+
+func newQAP(cs *constraintSystem) (*qap, error) {
+	domain, err := newEvaluationDomain(len(cs.constraints) + cs.primaryInputSize + 1)
+	if err != nil {
+		return nil, err
+	}
+	q := &qap{
+		domain:       domain,
+		numInputs:    cs.primaryInputSize,
+		numVariables: cs.numVariables(),
+		a:            make([][]qapTerm, cs.numVariables()+1),
+		b:            make([][]qapTerm, cs.numVariables()+1),
+		c:            make([][]qapTerm, cs.numVariables()+1),
+	}
+	for k, c := range cs.constraints {
+		q.add(q.a, k, c.a)
+		q.add(q.b, k, c.b)
+		q.add(q.c, k, c.c)
+	}
+	// the constraints input_i * 0 = 0, for the input consistency of the proofs
+	for i := 0; i <= cs.primaryInputSize; i++ {
+		q.a[i] = append(q.a[i], qapTerm{k: len(cs.constraints) + i, coeff: big.NewInt(1)})
+	}
+	return q, nil
+}
+
+// add adds the linear combination of constraint k to the polynomials
+func (q *qap) add(polynomials [][]qapTerm, k int, lc []linearTerm) {
+	for _, term := range lc {
+		terms := polynomials[term.index]
+		if n := len(terms); n > 0 && terms[n-1].k == k {
+			// variable repeated in the linear combination
+			coeff := new(big.Int).Add(terms[n-1].coeff, term.coeff)
+			coeff.Mod(coeff, frModulus)
+			if coeff.Sign() == 0 {
+				polynomials[term.index] = terms[:n-1]
+			} else {
+				terms[n-1].coeff = coeff
+			}
+			continue
+		}
+		if term.coeff.Sign() != 0 {
+			polynomials[term.index] = append(terms, qapTerm{k: k, coeff: term.coeff})
+		}
+	}
+}
+
+// evaluate returns sum_j terms[j].coeff basis[terms[j].k]
+func evaluate(g group, basis []point, terms []qapTerm) point {
+	sum := g.infinity()
+	for _, term := range terms {
+		if term.coeff.IsInt64() && term.coeff.Int64() == 1 {
+			sum = g.add(sum, basis[term.k])
+		} else {
+			sum = g.add(sum, g.scalarMult(basis[term.k], term.coeff))
+		}
+	}
+	return sum
+}
@@ -0,0 +1,507 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// testConstraintSystem returns a small constraint system, with 2 inputs and nbConstraints (up to 4)
+// constraints. If swapped, B touches more variables than A.
+func testConstraintSystem(nbConstraints int, swapped bool) *constraintSystem {
+	one, two, minusOne := big.NewInt(1), big.NewInt(2), new(big.Int).Sub(frModulus, big.NewInt(1))
+	// variables: 1, x1, x2 (inputs), w1, w2
+	constraints := []constraint{
+		// x1 * x1 = w1
+		{a: []linearTerm{{1, one}}, b: []linearTerm{{1, one}}, c: []linearTerm{{3, one}}},
+		// (w1 + 2) * x2 = w2
+		{a: []linearTerm{{3, one}, {0, two}}, b: []linearTerm{{2, one}}, c: []linearTerm{{4, one}}},
+		// w2 * 1 = x1 - x2
+		{a: []linearTerm{{4, one}}, b: []linearTerm{{0, one}}, c: []linearTerm{{1, one}, {2, minusOne}}},
+		// (w1 + w1) * 1 = 2 w1
+		{a: []linearTerm{{3, one}, {3, one}}, b: []linearTerm{{0, one}}, c: []linearTerm{{3, two}}},
+	}[:nbConstraints]
+	if swapped {
+		for i := range constraints {
+			constraints[i].a, constraints[i].b = constraints[i].b, constraints[i].a
+		}
+	}
+	return &constraintSystem{primaryInputSize: 2, auxiliaryInputSize: 2, constraints: constraints}
+}
+
+func TestConstraintSystemEncoding(t *testing.T) {
+	cs := testConstraintSystem(4, false)
+	var buf bytes.Buffer
+	cs.write(&buf)
+	parsed, err := parseConstraintSystem(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf2 bytes.Buffer
+	parsed.write(&buf2)
+	if !bytes.Equal(buf.Bytes(), buf2.Bytes()) {
+		t.Fatal("parseConstraintSystem(raw).write() != raw")
+	}
+	if _, err := parseConstraintSystem(buf.Bytes()[:buf.Len()-1]); err == nil {
+		t.Fatal("parsed truncated constraint system")
+	}
+
+	swapped := testConstraintSystem(4, true)
+	swapped.swapABIfBeneficial()
+	buf2.Reset()
+	swapped.write(&buf2)
+	if !bytes.Equal(buf.Bytes(), buf2.Bytes()) {
+		t.Fatal("A and B should be swapped back")
+	}
+}
+
+func TestEvaluationDomain(t *testing.T) {
+	for minSize, expected := range map[int]evaluationDomain{
+		5:  {m: 5, bigM: 4, smallM: 1},
+		6:  {m: 6, bigM: 4, smallM: 2},
+		7:  {m: 8},
+		8:  {m: 8},
+		13: {m: 16},
+		40: {m: 40, bigM: 32, smallM: 8},
+	} {
+		d, err := newEvaluationDomain(minSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *d != expected {
+			t.Fatalf("domain of size %d: expected %+v, got %+v", minSize, expected, *d)
+		}
+	}
+	if rootOfUnity(1<<frTwoAdicity).Cmp(frRootOfUnity) != 0 || frExp(rootOfUnity(4), 4).Cmp(big.NewInt(1)) != 0 {
+		t.Fatal("invalid root of unity")
+	}
+}
+
+// lagrangeScalars evaluates the Lagrange polynomials and the vanishing polynomial of the domain at t,
+// as libfqfft does
+func lagrangeScalars(d *evaluationDomain, t *big.Int) ([]*big.Int, *big.Int) {
+	basic := func(m int, t *big.Int) []*big.Int {
+		u := make([]*big.Int, m)
+		if m == 1 {
+			u[0] = big.NewInt(1)
+			return u
+		}
+		omega := rootOfUnity(m)
+		l := frMul(frSub(frExp(t, m), big.NewInt(1)), frInverse(big.NewInt(int64(m))))
+		r := big.NewInt(1)
+		for i := range u {
+			u[i] = frMul(l, frInverse(frSub(t, r)))
+			l = frMul(l, omega)
+			r = frMul(r, omega)
+		}
+		return u
+	}
+	if d.smallM == 0 {
+		return basic(d.m, t), frSub(frExp(t, d.m), big.NewInt(1))
+	}
+	omega := rootOfUnity(2 * d.bigM)
+	innerBig := basic(d.bigM, t)
+	innerSmall := basic(d.smallM, frMul(t, frInverse(omega)))
+	u := make([]*big.Int, d.m)
+	l0 := frSub(frExp(t, d.smallM), frExp(omega, d.smallM))
+	elt := big.NewInt(1)
+	for i := 0; i < d.bigM; i++ {
+		u[i] = frMul(frMul(innerBig[i], l0), frInverse(frSub(elt, frExp(omega, d.smallM))))
+		elt = frMul(elt, frExp(omega, 2*d.smallM))
+	}
+	l1 := frMul(frSub(frExp(t, d.bigM), big.NewInt(1)), frInverse(frSub(frExp(omega, d.bigM), big.NewInt(1))))
+	for i := 0; i < d.smallM; i++ {
+		u[d.bigM+i] = frMul(l1, innerSmall[i])
+	}
+	return u, frMul(frSub(frExp(t, d.bigM), big.NewInt(1)), l0)
+}
+
+func TestLagrangeBasis(t *testing.T) {
+	tau, _ := randomFr(rand.Reader)
+	for _, m := range []int{5, 6, 8} {
+		d := radix2Domain(m)
+		for _, g := range []group{groupG1, groupG2} {
+			powers := make([]point, m+1)
+			for j := range powers {
+				powers[j] = g.scalarMult(g.generator(), frExp(tau, j))
+			}
+			basis := d.lagrangeBasis(g, powers)
+			u, z := lagrangeScalars(d, tau)
+			for k, p := range basis {
+				expected := z
+				if k < m {
+					expected = u[k]
+				}
+				if !equal(p, g.scalarMult(g.generator(), expected)) {
+					t.Fatalf("domain of size %d: invalid Lagrange basis element %d", m, k)
+				}
+			}
+			if m != 6 {
+				break // G2 is slow, one domain is enough
+			}
+		}
+	}
+}
+
+func TestPointEncoding(t *testing.T) {
+	expected := G2{X: [2]*big.Int{mockBig(g2X0), mockBig(g2X1)}, Y: [2]*big.Int{mockBig(g2Y0), mockBig(g2Y1)}}
+	g2 := toG2(groupG2.generator().(*bn256.G2))
+	for i := range g2.X {
+		if g2.X[i].Cmp(expected.X[i]) != 0 || g2.Y[i].Cmp(expected.Y[i]) != 0 {
+			t.Fatal("unexpected G2 generator", g2)
+		}
+	}
+
+	one := toGT(bn256.Pair(groupG1.infinity().(*bn256.G1), groupG2.generator().(*bn256.G2)))
+	for i, e := range one {
+		if (i == 0 && e.Cmp(big.NewInt(1)) != 0) || (i > 0 && e.Sign() != 0) {
+			t.Fatal("unexpected GT one", one)
+		}
+	}
+}
+
+// referenceKeys computes the keys of cs (swapped) with the secrets, as r1cs_ppzksnark_generator does
+func referenceKeys(cs *constraintSystem, tau, rhoA, rhoB, alphaA, alphaB, alphaC, beta, gamma *big.Int) ([]byte, []byte) {
+	d, _ := newEvaluationDomain(len(cs.constraints) + cs.primaryInputSize + 1)
+	u, zt := lagrangeScalars(d, tau)
+	n := cs.numVariables() + 1
+	var at, bt, ct []*big.Int
+	for i := 0; i < n; i++ {
+		at, bt, ct = append(at, new(big.Int)), append(bt, new(big.Int)), append(ct, new(big.Int))
+	}
+	for i := 0; i <= cs.primaryInputSize; i++ {
+		at[i] = u[len(cs.constraints)+i]
+	}
+	for k, c := range cs.constraints {
+		for _, lc := range []struct {
+			terms []linearTerm
+			t     []*big.Int
+		}{{c.a, at}, {c.b, bt}, {c.c, ct}} {
+			for _, term := range lc.terms {
+				lc.t[term.index] = new(big.Int).Mod(new(big.Int).Add(lc.t[term.index], frMul(u[k], term.coeff)), frModulus)
+			}
+		}
+	}
+	at, bt, ct = append(at, zt), append(bt, zt), append(ct, zt)
+	rhoC := frMul(rhoA, rhoB)
+	var kt []*big.Int
+	for i := 0; i < n; i++ {
+		s := new(big.Int).Add(frMul(rhoA, at[i]), frMul(rhoB, bt[i]))
+		kt = append(kt, frMul(beta, s.Add(s, frMul(rhoC, ct[i]))))
+	}
+	kt = append(kt, frMul(frMul(beta, rhoA), zt), frMul(frMul(beta, rhoB), zt), frMul(frMul(beta, rhoC), zt))
+	var ic []*big.Int
+	for i := 0; i <= cs.primaryInputSize; i++ {
+		ic = append(ic, frMul(rhoA, at[i]))
+		at[i] = new(big.Int)
+	}
+
+	g1 := func(k *big.Int) point { return groupG1.scalarMult(groupG1.generator(), k) }
+	g2 := func(k *big.Int) point { return groupG2.scalarMult(groupG2.generator(), k) }
+	var pk bytes.Buffer
+	for _, query := range []struct {
+		t          []*big.Int
+		g          func(*big.Int) point
+		gGroup     group
+		rho, alpha *big.Int
+	}{
+		{at, g1, groupG1, rhoA, alphaA},
+		{bt, g2, groupG2, rhoB, alphaB},
+		{ct, g1, groupG1, rhoC, alphaC},
+	} {
+		var indices []int
+		for i, e := range query.t {
+			if e.Sign() != 0 {
+				indices = append(indices, i)
+			}
+		}
+		fmt.Fprintf(&pk, "%d\n%d\n", len(query.t), len(indices))
+		for _, i := range indices {
+			fmt.Fprintf(&pk, "%d\n", i)
+		}
+		fmt.Fprintf(&pk, "%d\n", len(indices))
+		for _, i := range indices {
+			query.gGroup.writeLibsnark(&pk, query.g(frMul(query.rho, query.t[i])))
+			groupG1.writeLibsnark(&pk, g1(frMul(frMul(query.alpha, query.rho), query.t[i])))
+		}
+	}
+	fmt.Fprintf(&pk, "%d\n", d.m+1)
+	for j := 0; j <= d.m; j++ {
+		groupG1.writeLibsnark(&pk, g1(frExp(tau, j)))
+	}
+	fmt.Fprintf(&pk, "%d\n", len(kt))
+	for _, k := range kt {
+		groupG1.writeLibsnark(&pk, g1(k))
+	}
+	cs.write(&pk)
+
+	toG1p := func(p point) G1 { return toG1(p.(*bn256.G1)) }
+	toG2p := func(p point) G2 { return toG2(p.(*bn256.G2)) }
+	vk := &VerifyingKey{
+		AlphaA:     toG2p(g2(alphaA)),
+		AlphaB:     toG1p(g1(alphaB)),
+		AlphaC:     toG2p(g2(alphaC)),
+		Gamma:      toG2p(g2(gamma)),
+		GammaBeta1: toG1p(g1(frMul(gamma, beta))),
+		GammaBeta2: toG2p(g2(frMul(gamma, beta))),
+		RCZ:        toG2p(g2(frMul(rhoC, zt))),
+	}
+	for _, e := range ic {
+		vk.IC = append(vk.IC, toG1p(g1(e)))
+	}
+	return pk.Bytes(), vk.Bytes()
+}
+
+// TestCeremonyKeys runs the phases with known secrets and compares the keys with referenceKeys
+func TestCeremonyKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zslceremony")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, cs := range []*constraintSystem{testConstraintSystem(4, false), testConstraintSystem(3, true)} {
+		cs.swapABIfBeneficial()
+		q, err := newQAP(cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := q.domain.m
+		seed, _ := newRandomCoefficients(rand.Reader)
+		path := func(phase CeremonyPhase, n int) string {
+			return filepath.Join(dir, fmt.Sprintf("phase%d.%d", phase, n))
+		}
+		prevHash := func(phase CeremonyPhase, n int) string {
+			h, err := fileHash(path(phase, n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return h
+		}
+		contribute := func(phase CeremonyPhase, n int, secrets []*big.Int) []*publicKey {
+			old, err := openAccumulator(path(phase, n-1), phase, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer old.f.Close()
+			keys := make([]*publicKey, len(secrets))
+			for i, x := range secrets {
+				keys[i], _ = newPublicKey(x, prevHash(phase, n-1), i, rand.Reader)
+			}
+			_, err = writeAccumulator(path(phase, n), phase, m, func(a *accumulatorWriter) error {
+				if phase == CeremonyPowersOfTau {
+					return contributePhase1(a, old, m, secrets[0])
+				}
+				return contributePhase2(a, old, m, secrets)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return keys
+		}
+
+		// powers of tau
+		if _, err := writeAccumulator(path(CeremonyPowersOfTau, 0), CeremonyPowersOfTau, m, func(a *accumulatorWriter) error {
+			return writePhase1(a, m)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		tau := big.NewInt(1)
+		for n := 1; n <= 2; n++ {
+			x, _ := randomFr(rand.Reader)
+			tau = frMul(tau, x)
+			keys := contribute(CeremonyPowersOfTau, n, []*big.Int{x})
+			oldTau, err := readTau(path(CeremonyPowersOfTau, n-1), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acc, _ := openAccumulator(path(CeremonyPowersOfTau, n), CeremonyPowersOfTau, m)
+			if _, err := verifyPhase1(acc, m, oldTau, prevHash(CeremonyPowersOfTau, n-1), keys[0], seed); err != nil {
+				t.Fatal(err)
+			}
+			// the public key of another secret doesn't verify
+			acc, _ = openAccumulator(path(CeremonyPowersOfTau, n), CeremonyPowersOfTau, m)
+			otherKey, _ := newPublicKey(big.NewInt(42), prevHash(CeremonyPowersOfTau, n-1), 0, rand.Reader)
+			if _, err := verifyPhase1(acc, m, oldTau, prevHash(CeremonyPowersOfTau, n-1), otherKey, seed); err == nil {
+				t.Fatal("contribution verified with another public key")
+			}
+		}
+
+		// circuit secrets
+		tauG1, _ := loadPoints(path(CeremonyPowersOfTau, 2), CeremonyPowersOfTau, m, 0, groupG1, m+1)
+		tauG2, _ := loadPoints(path(CeremonyPowersOfTau, 2), CeremonyPowersOfTau, m, int64(groupG1.pointSize()*(m+1)), groupG2, m+1)
+		basis := &lagrange{g1: q.domain.lagrangeBasis(groupG1, tauG1), g2: q.domain.lagrangeBasis(groupG2, tauG2)}
+		if _, err := writeAccumulator(path(CeremonyCircuitSecrets, 0), CeremonyCircuitSecrets, m, func(a *accumulatorWriter) error {
+			return writePhase2(a, basis)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sumL := lagrangeSums(basis, seed)
+		secrets := make([]*big.Int, numSecrets)
+		for i := range secrets {
+			secrets[i] = big.NewInt(1)
+		}
+		for n := 1; n <= 2; n++ {
+			x := make([]*big.Int, numSecrets)
+			for i := range x {
+				x[i], _ = randomFr(rand.Reader)
+				secrets[i] = frMul(secrets[i], x[i])
+			}
+			keys := contribute(CeremonyCircuitSecrets, n, x)
+			old, _ := openAccumulator(path(CeremonyCircuitSecrets, n-1), CeremonyCircuitSecrets, m)
+			oldSingles, err := readSingles(old)
+			old.f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			acc, _ := openAccumulator(path(CeremonyCircuitSecrets, n), CeremonyCircuitSecrets, m)
+			if _, err := verifyPhase2(acc, m, oldSingles, prevHash(CeremonyCircuitSecrets, n-1), keys, basis, sumL, seed); err != nil {
+				t.Fatal(err)
+			}
+			acc, _ = openAccumulator(path(CeremonyCircuitSecrets, n), CeremonyCircuitSecrets, m)
+			keys[secretBeta], keys[secretGamma] = keys[secretGamma], keys[secretBeta]
+			if _, err := verifyPhase2(acc, m, oldSingles, prevHash(CeremonyCircuitSecrets, n-1), keys, basis, sumL, seed); err == nil {
+				t.Fatal("contribution verified with swapped public keys")
+			}
+		}
+
+		var pk, vk bytes.Buffer
+		if err := writeKeys(&pk, &vk, cs, path(CeremonyPowersOfTau, 2), path(CeremonyCircuitSecrets, 2)); err != nil {
+			t.Fatal(err)
+		}
+		expectedPk, expectedVk := referenceKeys(cs, tau, secrets[secretRhoA], secrets[secretRhoB], secrets[secretAlphaA],
+			secrets[secretAlphaB], secrets[secretAlphaC], secrets[secretBeta], secrets[secretGamma])
+		if !bytes.Equal(pk.Bytes(), expectedPk) {
+			t.Fatalf("domain of size %d: unexpected proving key", m)
+		}
+		if !bytes.Equal(vk.Bytes(), expectedVk) {
+			t.Fatalf("domain of size %d: unexpected verifying key", m)
+		}
+	}
+}
+
+func TestCeremony(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zslceremony")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyDir := filepath.Join(dir, "keys")
+	if err := os.Mkdir(keyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	ceremonyDir := filepath.Join(dir, "ceremony")
+
+	constraintSystems := make(map[Circuit][]byte)
+	constraints := make(map[Circuit]uint64)
+	for _, c := range Circuits {
+		var buf bytes.Buffer
+		constraints[c] = uint64(2 + int(c)%3)
+		testConstraintSystem(2+int(c)%3, c == Unshielding).write(&buf)
+		constraintSystems[c] = buf.Bytes()
+	}
+	ceremony, err := NewCeremony(ceremonyDir, 4, constraintSystems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCeremony(ceremonyDir, 4, constraintSystems); err == nil {
+		t.Fatal("started a second ceremony in the same directory")
+	}
+	if err := ceremony.NextPhase(); err == nil {
+		t.Fatal("moved to the next phase without contributions")
+	}
+	for _, participant := range []string{"alice", "bob"} {
+		if _, err := ceremony.Contribute(participant, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ceremony.NextPhase(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ceremony.Finalize(keyDir); err == nil {
+		t.Fatal("finalized without contributions")
+	}
+	for _, participant := range []string{"carol", "dave"} {
+		if _, err := ceremony.Contribute(participant, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ceremony.Finalize(keyDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ceremony.Contribute("eve", rand.Reader); err == nil {
+		t.Fatal("contributed after the ceremony")
+	}
+
+	// keys
+	manifest, err := ReadManifest(keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Validate(keyDir, 4, PPZKSNARK, constraints); err != nil {
+		t.Fatal(err)
+	}
+	_, vkPath := KeyFiles(keyDir, Transfer)
+	raw, err := ioutil.ReadFile(vkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseVerifyingKey(raw); err != nil {
+		t.Fatal(err)
+	}
+
+	// transcript
+	ceremony, err = OpenCeremony(ceremonyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ceremony.Phase != CeremonyDone || len(ceremony.Contributions) != 4 {
+		t.Fatal("unexpected transcript", ceremony.Phase, len(ceremony.Contributions))
+	}
+	if err := ceremony.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a contribution must use the secrets of its public keys
+	c1, c2 := ceremony.Contributions[2].Circuits["shielding"], ceremony.Contributions[3].Circuits["shielding"]
+	c1.PublicKey, c2.PublicKey = c2.PublicKey, c1.PublicKey
+	if err := ceremony.Verify(); err == nil {
+		t.Fatal("verified contributions with swapped public keys")
+	}
+	c1.PublicKey, c2.PublicKey = c2.PublicKey, c1.PublicKey
+
+	// corrupted accumulator
+	path := ceremony.accumulatorFile(Transfer, CeremonyPowersOfTau, 1)
+	acc, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc[len(acc)-1] ^= 1
+	if err := ioutil.WriteFile(path, acc, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ceremony.Verify(); err == nil {
+		t.Fatal("verified a corrupted accumulator")
+	}
+}
@@ -0,0 +1,616 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// poolPollInterval is the interval between status requests to a worker loading its keys
+	poolPollInterval = time.Second
+	// minRestartDelay and maxRestartDelay bound the delay before restarting a crashing worker
+	minRestartDelay = 100 * time.Millisecond
+	maxRestartDelay = 30 * time.Second
+)
+
+// PoolConfig configures a Pool
+type PoolConfig struct {
+	// Command and Args start a worker process, which calls ServeWorker (ex: zslbox -snark_worker)
+	Command string
+	Args    []string
+	// Workers is the number of worker processes. Each one loads the keys, and runs one proof or
+	// verification at a time.
+	Workers int
+}
+
+// Pool is a Backend running proofs and verifications in worker processes: a libsnark crash only kills
+// its worker, and the job fails with ErrWorkerFailed (see ErrorVerifier for verifications). Pool is a
+// ContextProver: when the context of a proof is done, its worker is killed. Workers are restarted when
+// they exit, and take jobs once they have loaded their keys. Only the first worker generates absent keys; the others start once it's done.
+type Pool struct {
+	config PoolConfig
+
+	initLock sync.Mutex // held during Init
+
+	lock     sync.Mutex
+	init     *workerRequest // opInit request sent to new workers, nil until Init succeeds
+	workers  []*worker      // current worker of each slot, nil while (re)starting
+	statuses []Status       // last status of each slot's worker
+	idle     []*worker      // workers waiting for a job
+	wake     chan struct{}  // closed (and replaced) when a worker becomes idle
+	vks      map[Circuit][]byte
+	closed   bool
+}
+
+// NewPool returns a Pool; worker processes are started by Init
+func NewPool(config PoolConfig) *Pool {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	return &Pool{
+		config:   config,
+		workers:  make([]*worker, config.Workers),
+		statuses: make([]Status, config.Workers),
+		wake:     make(chan struct{}),
+		vks:      make(map[Circuit][]byte),
+	}
+}
+
+// Init starts the first worker, and returns the error of its backend Init if any. Only the first
+// successful call has an effect.
+func (p *Pool) Init(treeDepth uint, keyDir string, system ProvingSystem) error {
+	p.initLock.Lock()
+	defer p.initLock.Unlock()
+	if p.initRequest() != nil {
+		return nil
+	}
+	init := &workerRequest{Op: opInit, TreeDepth: treeDepth, KeyDir: keyDir, ProvingSystem: system}
+	w, err := p.start(init)
+	if err != nil {
+		return err
+	}
+	status, err := w.call(context.Background(), &workerRequest{Op: opStatus})
+	p.lock.Lock()
+	p.init = init
+	p.workers[0] = w
+	if err == nil {
+		p.statuses[0] = status.Status
+	}
+	p.lock.Unlock()
+
+	settled := make(chan struct{})
+	go p.run(0, w, settled)
+	go func() {
+		<-settled
+		for slot := 1; slot < p.config.Workers; slot++ {
+			go p.run(slot, nil, nil)
+		}
+	}()
+	return nil
+}
+
+// Status reports the most advanced key state of the workers, for each circuit
+func (p *Pool) Status() Status {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	status := Status{Keys: make(map[Circuit]KeyState)}
+	if p.init != nil {
+		status.TreeDepth, status.ProvingSystem, status.KeyDir = p.init.TreeDepth, p.init.ProvingSystem, p.init.KeyDir
+	}
+	for _, s := range p.statuses {
+		if status.Backend == "" {
+			status.Backend = s.Backend
+		}
+		for _, c := range Circuits {
+			if state, ok := s.Keys[c]; ok && keyStateProgress[state] > keyStateProgress[status.Keys[c]] {
+				status.Keys[c] = state
+			}
+		}
+	}
+	for _, c := range Circuits {
+		if _, ok := status.Keys[c]; !ok {
+			status.Keys[c] = KeysUnloaded
+		}
+	}
+	return status
+}
+
+// keyStateProgress orders the key states, to report the most advanced one of the workers
+var keyStateProgress = map[KeyState]int{
+	KeysUnloaded:   0,
+	KeysFailed:     1,
+	KeysGenerating: 2,
+	KeysLoading:    3,
+	KeysReady:      4,
+}
+
+// VerifyingKey returns the verifying key of circuit, from any running worker
+func (p *Pool) VerifyingKey(circuit Circuit) ([]byte, error) {
+	p.lock.Lock()
+	vk, ok := p.vks[circuit]
+	workers := append([]*worker(nil), p.workers...)
+	p.lock.Unlock()
+	if ok {
+		return vk, nil
+	}
+	err := error(ErrKeysNotLoaded)
+	for _, w := range workers {
+		if w == nil {
+			continue
+		}
+		var response *workerResponse
+		if response, err = w.call(context.Background(), &workerRequest{Op: opVerifyingKey, Circuit: circuit}); err != nil {
+			continue
+		}
+		if err = response.err(); err != nil {
+			return nil, err
+		}
+		p.lock.Lock()
+		p.vks[circuit] = response.Data
+		p.lock.Unlock()
+		return response.Data, nil
+	}
+	return nil, err
+}
+
+// ProveContext proves witness on a worker, which is killed (and restarted) if ctx is done first
+func (p *Pool) ProveContext(ctx context.Context, witness Witness) ([]byte, error) {
+	response, err := p.do(ctx, &workerRequest{Op: opProve, Witness: witness})
+	if err != nil {
+		return nil, err
+	}
+	return response.Data, response.err()
+}
+
+func (p *Pool) ProveShielding(rho []byte, pk []byte, value uint64, asset []byte) ([]byte, error) {
+	return p.ProveContext(context.Background(), &ShieldingWitness{Rho: rho, Pk: pk, Value: value, Asset: asset})
+}
+
+func (p *Pool) ProveUnshielding(rho []byte,
+	sk []byte,
+	value uint64,
+	asset []byte,
+	treeIndex uint64,
+	treePath [][]byte,
+	binding []byte) ([]byte, error) {
+	return p.ProveContext(context.Background(), &UnshieldingWitness{Rho: rho, Sk: sk, Value: value, Asset: asset, TreeIndex: treeIndex, TreePath: treePath, Binding: binding})
+}
+
+func (p *Pool) ProveTransfer(inputRho1 []byte,
+	inputSk1 []byte,
+	inputValue1 uint64,
+	inputTreeIndex1 uint64,
+	inputTreePath1 [][]byte,
+	inputRho2 []byte,
+	inputSk2 []byte,
+	inputValue2 uint64,
+	inputTreeIndex2 uint64,
+	inputTreePath2 [][]byte,
+	outputRho1 []byte,
+	outputPk1 []byte,
+	outputValue1 uint64,
+	outputRho2 []byte,
+	outputPk2 []byte,
+	outputValue2 uint64,
+	asset []byte,
+	binding []byte) ([]byte, error) {
+	return p.ProveContext(context.Background(), transferWitness(
+		inputRho1, inputSk1, inputValue1, inputTreeIndex1, inputTreePath1,
+		inputRho2, inputSk2, inputValue2, inputTreeIndex2, inputTreePath2,
+		outputRho1, outputPk1, outputValue1,
+		outputRho2, outputPk2, outputValue2,
+		asset, binding))
+}
+
+func (p *Pool) ProveTransferN(inputs []UnshieldingWitness, outputs []ShieldingWitness, vpubIn uint64, vpubOut uint64, fee uint64, binding []byte) ([]byte, error) {
+	return p.ProveContext(context.Background(), &TransferWitness{Inputs: inputs, Outputs: outputs, VpubIn: vpubIn, VpubOut: vpubOut, Fee: fee, Binding: binding})
+}
+
+func (p *Pool) VerifyShielding(proof []byte, sendNullifier []byte, commitment []byte, value uint64, asset []byte) bool {
+	return p.verify(&ShieldingVerification{Proof: proof, SendNullifier: sendNullifier, Commitment: commitment, Value: value, Asset: asset})
+}
+
+func (p *Pool) VerifyUnshielding(proof []byte, spendNullifier []byte, treeRoot []byte, value uint64, asset []byte, binding []byte) bool {
+	return p.verify(&UnshieldingVerification{Proof: proof, SpendNullifier: spendNullifier, TreeRoot: treeRoot, Value: value, Asset: asset, Binding: binding})
+}
+
+func (p *Pool) VerifyTransfer(proof []byte,
+	treeRoot []byte,
+	spendNullifier1 []byte,
+	spendNullifier2 []byte,
+	sendNullifier1 []byte,
+	sendNullifier2 []byte,
+	commitment1 []byte,
+	commitment2 []byte,
+	binding []byte) bool {
+	return p.VerifyTransferN(proof,
+		treeRoot,
+		[][]byte{spendNullifier1, spendNullifier2},
+		[][]byte{sendNullifier1, sendNullifier2},
+		[][]byte{commitment1, commitment2},
+		0, 0, 0, nil, binding)
+}
+
+func (p *Pool) VerifyTransferN(proof []byte,
+	treeRoot []byte,
+	spendNullifiers [][]byte,
+	sendNullifiers [][]byte,
+	commitments [][]byte,
+	vpubIn uint64,
+	vpubOut uint64,
+	fee uint64,
+	asset []byte,
+	binding []byte) bool {
+	return p.verify(&TransferVerification{
+		Proof:           proof,
+		TreeRoot:        treeRoot,
+		SpendNullifiers: spendNullifiers,
+		SendNullifiers:  sendNullifiers,
+		Commitments:     commitments,
+		VpubIn:          vpubIn,
+		VpubOut:         vpubOut,
+		Fee:             fee,
+		Asset:           asset,
+		Binding:         binding,
+	})
+}
+
+// verify returns false if the verification is invalid, or failed (see VerifyWithError)
+func (p *Pool) verify(v Verification) bool {
+	valid, err := p.VerifyWithError(context.Background(), v)
+	return err == nil && valid
+}
+
+// VerifyWithError implements ErrorVerifier: it returns ErrWorkerFailed if the worker verifying v exited,
+// ErrUnknownKeySet if the pool is closed (its key set was retired). If ctx is done first, the worker is
+// killed and it returns ctx.Err().
+func (p *Pool) VerifyWithError(ctx context.Context, v Verification) (bool, error) {
+	response, err := p.do(ctx, &workerRequest{Op: opVerify, Verification: v})
+	if err != nil {
+		return false, err
+	}
+	return response.Valid, nil
+}
+
+// Close kills the workers. Jobs waiting for a worker fail with ErrUnknownKeySet, as the jobs started after.
+func (p *Pool) Close() error {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.wake)
+	}
+	workers := append([]*worker(nil), p.workers...)
+	p.lock.Unlock()
+	for _, w := range workers {
+		if w != nil {
+			w.kill(true)
+			<-w.done
+		}
+	}
+	return nil
+}
+
+func (p *Pool) initRequest() *workerRequest {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.init
+}
+
+// do runs request on an idle worker. If ctx is done first, the worker is killed and do returns ctx.Err().
+func (p *Pool) do(ctx context.Context, request *workerRequest) (*workerResponse, error) {
+	w, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response, err := w.call(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	p.setIdle(w)
+	return response, nil
+}
+
+// acquire waits for an idle worker, or returns ctx.Err() when ctx is done, ErrUnknownKeySet when the pool
+// is closed
+func (p *Pool) acquire(ctx context.Context) (*worker, error) {
+	if p.initRequest() == nil {
+		return nil, ErrKeysNotLoaded
+	}
+	for {
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return nil, ErrUnknownKeySet
+		}
+		for len(p.idle) > 0 {
+			w := p.idle[0]
+			p.idle = p.idle[1:]
+			if !w.exited() {
+				p.lock.Unlock()
+				return w, nil
+			}
+		}
+		wake := p.wake
+		p.lock.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// setIdle makes w available for jobs, unless the pool is closed
+func (p *Pool) setIdle(w *worker) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return
+	}
+	p.idle = append(p.idle, w)
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+// run keeps a worker running in slot (w, or a new one if nil), restarting it when it exits.
+// settled, if not nil, is closed once the first worker loaded its keys (or exited).
+func (p *Pool) run(slot int, w *worker, settled chan struct{}) {
+	delay := minRestartDelay
+	for {
+		if w == nil {
+			var err error
+			if w, err = p.start(p.initRequest()); err != nil {
+				if p.isClosed() {
+					return
+				}
+				fmt.Printf("couldn't start snark worker %d: %v\n", slot, err)
+				time.Sleep(delay)
+				delay = nextRestartDelay(delay)
+				continue
+			}
+		}
+		if !p.setWorker(slot, w) {
+			w.kill(true)
+			<-w.done
+			return
+		}
+		started := time.Now()
+		p.poll(slot, w)
+		if settled != nil {
+			close(settled)
+			settled = nil
+		}
+		<-w.done
+		p.setWorker(slot, nil)
+		if p.isClosed() {
+			return
+		}
+
+		// restart right away a worker killed to stop a job, or one that ran for a while
+		if w.wasKilled() || time.Since(started) > maxRestartDelay {
+			delay = minRestartDelay
+		} else {
+			fmt.Printf("snark worker %d exited (%v), restarting in %s\n", slot, w.err, delay)
+			time.Sleep(delay)
+			delay = nextRestartDelay(delay)
+		}
+		w = nil
+	}
+}
+
+func nextRestartDelay(delay time.Duration) time.Duration {
+	if delay *= 2; delay > maxRestartDelay {
+		delay = maxRestartDelay
+	}
+	return delay
+}
+
+// poll updates the status of the worker in slot until its keys are loaded (or failed to), then makes it
+// available for jobs. It returns early if the worker exits.
+func (p *Pool) poll(slot int, w *worker) {
+	for {
+		response, err := w.call(context.Background(), &workerRequest{Op: opStatus})
+		if err != nil {
+			return
+		}
+		p.lock.Lock()
+		p.statuses[slot] = response.Status
+		p.lock.Unlock()
+		if keysSettled(response.Status) {
+			p.setIdle(w)
+			return
+		}
+		select {
+		case <-w.done:
+			return
+		case <-time.After(poolPollInterval):
+		}
+	}
+}
+
+// keysSettled returns true if the keys of all circuits are loaded or failed to
+func keysSettled(status Status) bool {
+	for _, c := range Circuits {
+		if state := status.Keys[c]; state != KeysReady && state != KeysFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// setWorker sets the worker of slot, and returns false if the pool is closed
+func (p *Pool) setWorker(slot int, w *worker) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.workers[slot] = w
+	if w == nil {
+		p.statuses[slot] = Status{}
+	}
+	return !p.closed
+}
+
+func (p *Pool) isClosed() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.closed
+}
+
+// start starts a worker process and initializes its backend
+func (p *Pool) start(init *workerRequest) (*worker, error) {
+	requestsR, requestsW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	responsesR, responsesW, err := os.Pipe()
+	if err != nil {
+		requestsR.Close()
+		requestsW.Close()
+		return nil, err
+	}
+	cmd := exec.Command(p.config.Command, p.config.Args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{requestsR, responsesW} // fds 3 and 4
+	err = cmd.Start()
+	requestsR.Close()
+	responsesW.Close()
+	if err != nil {
+		requestsW.Close()
+		responsesR.Close()
+		return nil, err
+	}
+
+	w := &worker{
+		cmd:      cmd,
+		requests: requestsW,
+		encoder:  gob.NewEncoder(requestsW),
+		pending:  make(map[uint64]chan *workerResponse),
+		done:     make(chan struct{}),
+	}
+	go w.read(responsesR)
+	request := *init
+	response, err := w.call(context.Background(), &request)
+	if err == nil {
+		err = response.err()
+	}
+	if err != nil {
+		w.kill(true)
+		<-w.done
+		return nil, err
+	}
+	return w, nil
+}
+
+// worker is a worker process of a Pool
+type worker struct {
+	cmd      *exec.Cmd
+	requests io.Closer
+	encoder  *gob.Encoder
+
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *workerResponse
+	killed  bool // by the pool
+
+	done chan struct{} // closed once the process exited
+	err  error         // exit status, set before done is closed
+}
+
+// call sends request to the worker and waits for its response. If ctx is done first, the worker is
+// killed and call returns ctx.Err(); if the worker exits, call returns ErrWorkerFailed.
+func (w *worker) call(ctx context.Context, request *workerRequest) (*workerResponse, error) {
+	responses := make(chan *workerResponse, 1)
+	w.lock.Lock()
+	w.nextID++
+	request.ID = w.nextID
+	w.pending[request.ID] = responses
+	err := w.encoder.Encode(request)
+	w.lock.Unlock()
+	if err != nil {
+		w.kill(false)
+		return nil, ErrWorkerFailed
+	}
+
+	select {
+	case response := <-responses:
+		return response, nil
+	case <-w.done:
+		select {
+		case response := <-responses:
+			return response, nil
+		default:
+			return nil, ErrWorkerFailed
+		}
+	case <-ctx.Done():
+		w.kill(true)
+		return nil, ctx.Err()
+	}
+}
+
+// read dispatches the responses of the worker, until it exits
+func (w *worker) read(responses io.ReadCloser) {
+	decoder := gob.NewDecoder(responses)
+	for {
+		response := &workerResponse{}
+		if err := decoder.Decode(response); err != nil {
+			break
+		}
+		w.lock.Lock()
+		ch, ok := w.pending[response.ID]
+		delete(w.pending, response.ID)
+		w.lock.Unlock()
+		if ok {
+			ch <- response
+		}
+	}
+	w.cmd.Process.Kill()
+	w.err = w.cmd.Wait()
+	w.requests.Close()
+	responses.Close()
+	close(w.done)
+}
+
+// kill kills the worker process; byPool is false if the worker is already failing
+func (w *worker) kill(byPool bool) {
+	w.lock.Lock()
+	if byPool {
+		w.killed = true
+	}
+	w.lock.Unlock()
+	w.cmd.Process.Kill()
+}
+
+func (w *worker) wasKilled() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.killed
+}
+
+func (w *worker) exited() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+// testWorkerEnv makes the test binary a pool worker serving a testWorker
+const testWorkerEnv = "ZSLBOX_TEST_SNARK_WORKER"
+
+// shielding values making a testWorker hang or crash
+const (
+	hangValue  = 1<<64 - 1
+	crashValue = 1<<64 - 2
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testWorkerEnv) != "" {
+		if err := ServeWorker(testWorker{&mock{}}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testWorker is a mock which hangs or crashes on some shielding values, and hangs verifying shieldings
+// of hangValue
+type testWorker struct {
+	*mock
+}
+
+func (w testWorker) ProveShielding(rho []byte, pk []byte, value uint64, asset []byte) ([]byte, error) {
+	switch value {
+	case hangValue:
+		time.Sleep(time.Hour)
+	case crashValue:
+		os.Exit(2)
+	}
+	return w.mock.ProveShielding(rho, pk, value, asset)
+}
+
+func (w testWorker) VerifyShielding(proof []byte, sendNullifier []byte, commitment []byte, value uint64, asset []byte) bool {
+	if value == hangValue {
+		time.Sleep(time.Hour)
+	}
+	return w.mock.VerifyShielding(proof, sendNullifier, commitment, value, asset)
+}
+
+func TestPool(t *testing.T) {
+	pool := newTestPool(t, 2)
+	defer pool.Close()
+	backend := newMock(t)
+
+	var wg sync.WaitGroup
+	for i := uint64(0); i < 4; i++ {
+		wg.Add(1)
+		go func(value uint64) {
+			defer wg.Done()
+			rho, pk := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+			proof, err := Prove(context.Background(), pool, &ShieldingWitness{Rho: rho, Pk: pk, Value: value, Asset: defaultAsset})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expected, _ := backend.ProveShielding(rho, pk, value, defaultAsset)
+			if !bytes.Equal(proof, expected) {
+				t.Error("pool and in process proofs differ")
+			}
+			if !pool.VerifyShielding(proof, mockSendNullifier(rho), mockCommitment(rho, pk, value, defaultAsset), value, defaultAsset) {
+				t.Error("couldn't verify shielding proof")
+			}
+			if pool.VerifyShielding(proof, mockSendNullifier(rho), mockCommitment(rho, pk, value, defaultAsset), value+1, defaultAsset) {
+				t.Error("shielding proof verified with wrong value")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	vk, err := pool.VerifyingKey(Transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := backend.VerifyingKey(Transfer); !bytes.Equal(vk, expected) {
+		t.Fatal("unexpected verifying key")
+	}
+	status := pool.Status()
+	if !status.Ready() || status.Backend != "mock" || status.TreeDepth != zsl.TreeDepth {
+		t.Fatal("unexpected status", status)
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	pool := newTestPool(t, 1)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Prove(ctx, pool, testWitness(hangValue)); err != context.DeadlineExceeded {
+		t.Fatal("expected context.DeadlineExceeded, got", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("proof wasn't stopped with its context")
+	}
+
+	// the worker is restarted
+	if _, err := Prove(context.Background(), pool, testWitness(1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolCrash(t *testing.T) {
+	pool := newTestPool(t, 1)
+	defer pool.Close()
+
+	if _, err := Prove(context.Background(), pool, testWitness(crashValue)); err != ErrWorkerFailed {
+		t.Fatal("expected ErrWorkerFailed, got", err)
+	}
+
+	// the worker is restarted
+	if _, err := Prove(context.Background(), pool, testWitness(1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolVerifyCrash(t *testing.T) {
+	pool := newTestPool(t, 1)
+	defer pool.Close()
+
+	// the worker is killed mid-verification: the verification fails, the proof isn't invalid
+	hanging := &ShieldingVerification{Proof: make([]byte, zsl.ProofSize), Value: hangValue}
+	verifications := []func() error{
+		func() error { _, err := Verify(context.Background(), pool, hanging); return err },
+		func() error {
+			_, err := VerifyBatch(context.Background(), pool, []Verification{hanging}, 1)
+			return err
+		},
+	}
+	for _, verify := range verifications {
+		waitIdle(t, pool, 1)
+		errs := make(chan error)
+		go func() { errs <- verify() }()
+		waitIdle(t, pool, 0)
+		pool.lock.Lock()
+		pool.workers[0].cmd.Process.Kill()
+		pool.lock.Unlock()
+		if err := <-errs; err != ErrWorkerFailed {
+			t.Fatal("expected ErrWorkerFailed, got", err)
+		}
+	}
+}
+
+func TestPoolVerifyContext(t *testing.T) {
+	pool := newTestPool(t, 1)
+	defer pool.Close()
+	waitIdle(t, pool, 1)
+
+	// a verification stops with its context
+	hanging := &ShieldingVerification{Proof: make([]byte, zsl.ProofSize), Value: hangValue}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Verify(ctx, pool, hanging); err != context.DeadlineExceeded {
+		t.Fatal("expected context.DeadlineExceeded, got", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("verification wasn't stopped with its context")
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	pool := newTestPool(t, 1)
+	waitIdle(t, pool, 1)
+
+	// a verification waiting for the busy worker fails when the pool is closed, as the ones after
+	hanging := &ShieldingVerification{Proof: make([]byte, zsl.ProofSize), Value: hangValue}
+	errs := make(chan error, 2)
+	go func() {
+		_, err := Verify(context.Background(), pool, hanging)
+		errs <- err
+	}()
+	waitIdle(t, pool, 0)
+	go func() {
+		_, err := Verify(context.Background(), pool, &ShieldingVerification{Proof: make([]byte, zsl.ProofSize), Value: 1})
+		errs <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	pool.Close()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err != ErrUnknownKeySet && err != ErrWorkerFailed {
+				t.Fatal("expected ErrUnknownKeySet or ErrWorkerFailed, got", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("verification blocked on a closed pool")
+		}
+	}
+	if _, err := Verify(context.Background(), pool, hanging); err != ErrUnknownKeySet {
+		t.Fatal("expected ErrUnknownKeySet, got", err)
+	}
+}
+
+// waitIdle waits until n workers of pool are idle
+func waitIdle(t *testing.T, pool *Pool, n int) {
+	for i := 0; i < 5000; i++ {
+		pool.lock.Lock()
+		idle := len(pool.idle)
+		pool.lock.Unlock()
+		if idle == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expected", n, "idle workers")
+}
+
+func TestPoolInitError(t *testing.T) {
+	os.Setenv(testWorkerEnv, "1")
+	pool := NewPool(PoolConfig{Command: os.Args[0], Workers: 1})
+	defer pool.Close()
+
+	if err := pool.Init(zsl.TreeDepth, "", ProvingSystem(42)); err == nil {
+		t.Fatal("Init should fail on unknown proving system")
+	}
+	if _, err := pool.ProveShielding(nil, nil, 1, nil); err != ErrKeysNotLoaded {
+		t.Fatal("expected ErrKeysNotLoaded, got", err)
+	}
+	if pool.VerifyShielding(nil, nil, nil, 1, nil) {
+		t.Fatal("verification should fail before Init")
+	}
+}
+
+func newTestPool(t *testing.T, workers int) *Pool {
+	os.Setenv(testWorkerEnv, "1")
+	pool := NewPool(PoolConfig{Command: os.Args[0], Workers: workers})
+	if err := pool.Init(zsl.TreeDepth, "", PPZKSNARK); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+func testWitness(value uint64) *ShieldingWitness {
+	return &ShieldingWitness{Rho: zsl.RandomBytes(zsl.HashSize), Pk: zsl.RandomBytes(zsl.HashSize), Value: value, Asset: defaultAsset}
+}
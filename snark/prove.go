@@ -0,0 +1,156 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bytes"
+	"context"
+)
+
+// Witness is the private inputs of a proof, to prove with Prove:
+// a ShieldingWitness, UnshieldingWitness or TransferWitness
+type Witness interface {
+	// Circuit returns the circuit of the proof
+	Circuit() Circuit
+	prove(backend Backend) ([]byte, error)
+}
+
+// ShieldingWitness holds the inputs of Backend.ProveShielding
+type ShieldingWitness struct {
+	Rho   []byte
+	Pk    []byte
+	Value uint64
+	Asset []byte
+}
+
+// UnshieldingWitness holds the inputs of Backend.ProveUnshielding
+type UnshieldingWitness struct {
+	Rho       []byte
+	Sk        []byte
+	Value     uint64
+	Asset     []byte
+	TreeIndex uint64
+	TreePath  [][]byte
+	Binding   []byte
+}
+
+// TransferWitness holds the inputs of Backend.ProveTransferN: the spent notes, the output notes, the
+// public values, the fee and the binding of the proof. All the notes must be of the same asset.
+type TransferWitness struct {
+	Inputs  []UnshieldingWitness
+	Outputs []ShieldingWitness
+	VpubIn  uint64
+	VpubOut uint64
+	Fee     uint64
+	Binding []byte
+}
+
+func (w *ShieldingWitness) Circuit() Circuit   { return Shielding }
+func (w *UnshieldingWitness) Circuit() Circuit { return Unshielding }
+
+// Circuit returns the transfer circuit of the numbers of inputs and outputs and of the public values,
+// Transfer if there is none (proving fails with ErrInvalidInputSize)
+func (w *TransferWitness) Circuit() Circuit {
+	if c, err := TransferCircuit(len(w.Inputs), len(w.Outputs), w.VpubIn != 0 || w.VpubOut != 0 || w.Fee != 0); err == nil {
+		return c
+	}
+	return Transfer
+}
+
+func (w *ShieldingWitness) prove(backend Backend) ([]byte, error) {
+	return backend.ProveShielding(w.Rho, w.Pk, w.Value, w.Asset)
+}
+
+func (w *UnshieldingWitness) prove(backend Backend) ([]byte, error) {
+	return backend.ProveUnshielding(w.Rho, w.Sk, w.Value, w.Asset, w.TreeIndex, w.TreePath, w.Binding)
+}
+
+func (w *TransferWitness) prove(backend Backend) ([]byte, error) {
+	return backend.ProveTransferN(w.Inputs, w.Outputs, w.VpubIn, w.VpubOut, w.Fee, w.Binding)
+}
+
+// transferWitness returns the witness of the 2 inputs, 2 outputs ProveTransfer
+func transferWitness(inputRho1 []byte,
+	inputSk1 []byte,
+	inputValue1 uint64,
+	inputTreeIndex1 uint64,
+	inputTreePath1 [][]byte,
+	inputRho2 []byte,
+	inputSk2 []byte,
+	inputValue2 uint64,
+	inputTreeIndex2 uint64,
+	inputTreePath2 [][]byte,
+	outputRho1 []byte,
+	outputPk1 []byte,
+	outputValue1 uint64,
+	outputRho2 []byte,
+	outputPk2 []byte,
+	outputValue2 uint64,
+	asset []byte,
+	binding []byte) *TransferWitness {
+	return &TransferWitness{
+		Inputs: []UnshieldingWitness{
+			{Rho: inputRho1, Sk: inputSk1, Value: inputValue1, Asset: asset, TreeIndex: inputTreeIndex1, TreePath: inputTreePath1},
+			{Rho: inputRho2, Sk: inputSk2, Value: inputValue2, Asset: asset, TreeIndex: inputTreeIndex2, TreePath: inputTreePath2},
+		},
+		Outputs: []ShieldingWitness{
+			{Rho: outputRho1, Pk: outputPk1, Value: outputValue1, Asset: asset},
+			{Rho: outputRho2, Pk: outputPk2, Value: outputValue2, Asset: asset},
+		},
+		Binding: binding,
+	}
+}
+
+// transferAsset returns the asset of the notes of a shielded transfer: ErrInvalidInputSize if there are none or
+// an asset isn't hashSize bytes, ErrUnsatisfiedWitness if they aren't all of the same asset
+func transferAsset(inputs []UnshieldingWitness, outputs []ShieldingWitness) ([]byte, error) {
+	var assets [][]byte
+	for _, input := range inputs {
+		assets = append(assets, input.Asset)
+	}
+	for _, output := range outputs {
+		assets = append(assets, output.Asset)
+	}
+	if len(assets) == 0 {
+		return nil, ErrInvalidInputSize
+	}
+	if err := checkSizes(assets...); err != nil {
+		return nil, err
+	}
+	for _, asset := range assets[1:] {
+		if !bytes.Equal(asset, assets[0]) {
+			return nil, ErrUnsatisfiedWitness
+		}
+	}
+	return assets[0], nil
+}
+
+// ContextProver is implemented by backends that can stop a running proof (see Pool)
+type ContextProver interface {
+	// ProveContext proves witness, or stops and returns ctx.Err() when ctx is done
+	ProveContext(ctx context.Context, witness Witness) ([]byte, error)
+}
+
+// Prove proves witness on backend. If backend is a ContextProver, the proof stops when ctx is done;
+// otherwise ctx is only checked before proving: a proof running in process (cgo) can't be interrupted.
+func Prove(ctx context.Context, backend Backend, witness Witness) ([]byte, error) {
+	if prover, ok := backend.(ContextProver); ok {
+		return prover.ProveContext(ctx, witness)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return witness.prove(backend)
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"sync"
+)
+
+// SchedulerConfig sets the limits of a Scheduler
+type SchedulerConfig struct {
+	// MaxJobs is the maximum number of concurrent jobs (proofs and verifications)
+	MaxJobs int
+	// MaxProofs is the maximum number of concurrent proofs of each circuit; proving is memory
+	// hungry (especially shielded transfers). A circuit absent from the map is limited by MaxJobs only.
+	MaxProofs map[Circuit]int
+	// QueueSize is the maximum number of jobs waiting for a slot; beyond, jobs fail with ErrQueueFull
+	QueueSize int
+}
+
+// Scheduler runs proving and verifying jobs within concurrency limits. Jobs wait in a bounded
+// queue for a slot (a batch of verifications for as many slots as it runs in parallel); verifications
+// are scheduled before proofs, then jobs are first in first out.
+type Scheduler struct {
+	config SchedulerConfig
+
+	lock    sync.Mutex
+	running int
+	proving map[Circuit]int
+	queue   []*job // verifications first, then proofs, in arrival order
+}
+
+type job struct {
+	verify  bool
+	circuit Circuit
+	slots   int           // number of slots the job runs on
+	ready   chan struct{} // closed when the job gets its slots
+}
+
+// NewScheduler returns a Scheduler with the provided limits
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	if config.MaxJobs < 1 {
+		config.MaxJobs = 1
+	}
+	return &Scheduler{
+		config:  config,
+		proving: make(map[Circuit]int),
+	}
+}
+
+// Prove runs prove once a slot is available for circuit. It returns ErrQueueFull if too many jobs
+// are waiting, or ctx.Err() if ctx is done before prove starts.
+func (s *Scheduler) Prove(ctx context.Context, circuit Circuit, prove func() ([]byte, error)) ([]byte, error) {
+	j := &job{circuit: circuit, slots: 1, ready: make(chan struct{})}
+	if err := s.acquire(ctx, j); err != nil {
+		return nil, err
+	}
+	defer s.release(j)
+	return prove()
+}
+
+// Verify runs verify once a slot is available, before any waiting proof. It returns ErrQueueFull if
+// too many jobs are waiting, ctx.Err() if ctx is done before verify starts, or the error of verify.
+func (s *Scheduler) Verify(ctx context.Context, verify func() (bool, error)) (bool, error) {
+	return s.VerifyBatch(ctx, 1, func(int) (bool, error) { return verify() })
+}
+
+// VerifyBatch runs verify, a batch of size verifications, on up to MaxJobs slots: once they are available,
+// verify is called with their number, the parallelism it must not exceed. It returns like Verify.
+func (s *Scheduler) VerifyBatch(ctx context.Context, size int, verify func(parallelism int) (bool, error)) (bool, error) {
+	slots := size
+	if slots > s.config.MaxJobs {
+		slots = s.config.MaxJobs
+	}
+	if slots < 1 {
+		slots = 1
+	}
+	j := &job{verify: true, slots: slots, ready: make(chan struct{})}
+	if err := s.acquire(ctx, j); err != nil {
+		return false, err
+	}
+	defer s.release(j)
+	return verify(slots)
+}
+
+// Queued returns the number of jobs waiting for a slot
+func (s *Scheduler) Queued() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.queue)
+}
+
+// acquire blocks until j gets a slot, or ctx is done
+func (s *Scheduler) acquire(ctx context.Context, j *job) error {
+	s.lock.Lock()
+	if len(s.queue) >= s.config.QueueSize && !s.available(j) {
+		s.lock.Unlock()
+		return ErrQueueFull
+	}
+	s.enqueue(j)
+	s.dispatch()
+	s.lock.Unlock()
+
+	select {
+	case <-j.ready:
+		return nil
+	case <-ctx.Done():
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		select {
+		case <-j.ready:
+			// got a slot in the meantime, give it back
+			s.releaseLocked(j)
+		default:
+			s.remove(j)
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) release(j *job) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.releaseLocked(j)
+}
+
+func (s *Scheduler) releaseLocked(j *job) {
+	s.running -= j.slots
+	if !j.verify {
+		s.proving[j.circuit]--
+	}
+	s.dispatch()
+}
+
+// available returns true if j can run now
+func (s *Scheduler) available(j *job) bool {
+	if s.running+j.slots > s.config.MaxJobs {
+		return false
+	}
+	if j.verify {
+		return true
+	}
+	max, limited := s.config.MaxProofs[j.circuit]
+	return !limited || s.proving[j.circuit] < max
+}
+
+// dispatch starts the queued jobs that can run, by priority. A verification waiting for slots holds back
+// the jobs after it, so that a batch isn't starved by single verifications.
+func (s *Scheduler) dispatch() {
+	for i := 0; i < len(s.queue) && s.running < s.config.MaxJobs; {
+		j := s.queue[i]
+		if !s.available(j) {
+			if j.verify {
+				return
+			}
+			i++
+			continue
+		}
+		s.running += j.slots
+		if !j.verify {
+			s.proving[j.circuit]++
+		}
+		s.queue = append(s.queue[:i], s.queue[i+1:]...)
+		close(j.ready)
+	}
+}
+
+// enqueue inserts j after the queued jobs of the same or higher priority
+func (s *Scheduler) enqueue(j *job) {
+	i := len(s.queue)
+	if j.verify {
+		for i = 0; i < len(s.queue) && s.queue[i].verify; i++ {
+		}
+	}
+	s.queue = append(s.queue, nil)
+	copy(s.queue[i+1:], s.queue[i:])
+	s.queue[i] = j
+}
+
+func (s *Scheduler) remove(j *job) {
+	for i := range s.queue {
+		if s.queue[i] == j {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedulerLimits(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxJobs: 2, MaxProofs: map[Circuit]int{Transfer: 1}, QueueSize: 2})
+
+	// a transfer proof is running, a second one has to wait
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go s.Prove(context.Background(), Transfer, func() ([]byte, error) {
+		close(running)
+		<-release
+		return nil, nil
+	})
+	<-running
+
+	done := make(chan []byte)
+	go func() {
+		proof, err := s.Prove(context.Background(), Transfer, func() ([]byte, error) { return []byte{2}, nil })
+		if err != nil {
+			t.Error(err)
+		}
+		done <- proof
+	}()
+	waitQueued(t, s, 1)
+
+	// other circuits and verifications still run
+	if _, err := s.Prove(context.Background(), Shielding, func() ([]byte, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Verify(context.Background(), func() (bool, error) { return true, nil }); err != nil || !ok {
+		t.Fatal("verification should run", err)
+	}
+
+	// queue full
+	go s.Prove(context.Background(), Transfer, func() ([]byte, error) { return nil, nil })
+	waitQueued(t, s, 2)
+	if _, err := s.Prove(context.Background(), Transfer, func() ([]byte, error) { return nil, nil }); err != ErrQueueFull {
+		t.Fatal("expected ErrQueueFull, got", err)
+	}
+
+	close(release)
+	if proof := <-done; len(proof) != 1 || proof[0] != 2 {
+		t.Fatal("unexpected proof", proof)
+	}
+	waitQueued(t, s, 0)
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxJobs: 1, QueueSize: 10})
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go s.Prove(context.Background(), Shielding, func() ([]byte, error) {
+		close(running)
+		<-release
+		return nil, nil
+	})
+	<-running
+
+	// a proof queued before a verification runs after it
+	order := make(chan string, 2)
+	go s.Prove(context.Background(), Shielding, func() ([]byte, error) {
+		order <- "prove"
+		return nil, nil
+	})
+	waitQueued(t, s, 1)
+	go s.Verify(context.Background(), func() (bool, error) {
+		order <- "verify"
+		return true, nil
+	})
+	waitQueued(t, s, 2)
+
+	close(release)
+	if first, second := <-order, <-order; first != "verify" || second != "prove" {
+		t.Fatal("verification should run first, got", first, second)
+	}
+}
+
+func TestSchedulerDeadline(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxJobs: 1, QueueSize: 10})
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go s.Verify(context.Background(), func() (bool, error) {
+		close(running)
+		<-release
+		return true, nil
+	})
+	<-running
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.Prove(ctx, Transfer, func() ([]byte, error) {
+		t.Fatal("shouldn't run after the deadline")
+		return nil, nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatal("expected context.DeadlineExceeded, got", err)
+	}
+	if s.Queued() != 0 {
+		t.Fatal("expired job should leave the queue")
+	}
+	close(release)
+}
+
+func TestSchedulerBatch(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxJobs: 2, QueueSize: 10})
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go s.Prove(context.Background(), Shielding, func() ([]byte, error) {
+		close(running)
+		<-release
+		return nil, nil
+	})
+	<-running
+
+	// a batch takes up to MaxJobs slots: it waits for the running proof, and the verifications queued
+	// after it wait for the batch
+	order := make(chan string, 2)
+	go s.VerifyBatch(context.Background(), 5, func(parallelism int) (bool, error) {
+		if parallelism != 2 {
+			t.Error("expected a parallelism of 2, got", parallelism)
+		}
+		order <- "batch"
+		return true, nil
+	})
+	waitQueued(t, s, 1)
+	go s.Verify(context.Background(), func() (bool, error) {
+		order <- "verify"
+		return true, nil
+	})
+	waitQueued(t, s, 2)
+
+	close(release)
+	if first, second := <-order, <-order; first != "batch" || second != "verify" {
+		t.Fatal("batch should run first, got", first, second)
+	}
+	waitQueued(t, s, 0)
+}
+
+// waitQueued waits until n jobs are queued in s
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	for i := 0; i < 1000; i++ {
+		if s.Queued() == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expected", n, "queued jobs, got", s.Queued())
+}
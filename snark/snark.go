@@ -0,0 +1,356 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snark computes and verifies the zkSNARKs of the ZSL circuits (shielding, unshielding
+// and shielded transfer) through a pluggable proving Backend.
+//
+// Two backends are provided: "libzsl" links with libsnark through cgo (Linux only, excluded when
+// building with the nolibzsl tag), and "mock", a pure Go implementation for development and testing.
+package snark
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Circuit identifies one of the ZSL circuits
+type Circuit int
+
+const (
+	Shielding Circuit = iota
+	Unshielding
+	// Transfer is the shielded transfer of 2 inputs and 2 outputs, Transfer<N>x<M> of N inputs and M outputs
+	Transfer
+	Transfer1x1
+	Transfer1x2
+	Transfer4x2
+	Transfer4x4
+	// PublicTransfer* are the shielded transfers with public values in and out, and a public fee paid to
+	// the submitter of the transfer: inputs + vpubIn = outputs + vpubOut + fee
+	PublicTransfer
+	PublicTransfer1x1
+	PublicTransfer1x2
+	PublicTransfer4x2
+	PublicTransfer4x4
+)
+
+// Circuits lists all the ZSL circuits
+var Circuits = []Circuit{
+	Shielding, Unshielding,
+	Transfer, Transfer1x1, Transfer1x2, Transfer4x2, Transfer4x4,
+	PublicTransfer, PublicTransfer1x1, PublicTransfer1x2, PublicTransfer4x2, PublicTransfer4x4,
+}
+
+// transferArities are the numbers of inputs and outputs of the shielded transfer circuits
+var transferArities = map[Circuit][2]int{
+	Transfer1x1:       {1, 1},
+	Transfer1x2:       {1, 2},
+	Transfer:          {2, 2},
+	Transfer4x2:       {4, 2},
+	Transfer4x4:       {4, 4},
+	PublicTransfer1x1: {1, 1},
+	PublicTransfer1x2: {1, 2},
+	PublicTransfer:    {2, 2},
+	PublicTransfer4x2: {4, 2},
+	PublicTransfer4x4: {4, 4},
+}
+
+// TransferCircuit returns the shielded transfer circuit of nbInputs inputs and nbOutputs outputs, with
+// public values or not, or ErrInvalidInputSize if there is none
+func TransferCircuit(nbInputs, nbOutputs int, publicValues bool) (Circuit, error) {
+	for c, arity := range transferArities {
+		if arity == [2]int{nbInputs, nbOutputs} && c.PublicValues() == publicValues {
+			return c, nil
+		}
+	}
+	return 0, ErrInvalidInputSize
+}
+
+// Arity returns the numbers of inputs and outputs of a shielded transfer circuit (0, 0 for the others)
+func (c Circuit) Arity() (nbInputs, nbOutputs int) {
+	arity := transferArities[c]
+	return arity[0], arity[1]
+}
+
+// PublicValues returns true for the shielded transfer circuits with public values in and out, and fee
+func (c Circuit) PublicValues() bool {
+	return c >= PublicTransfer && c <= PublicTransfer4x4
+}
+
+// String returns the circuit name, which is also the base name of its key files
+// (ex: transfer_4x2 for Transfer4x2, public_transfer_1x2 for PublicTransfer1x2)
+func (c Circuit) String() string {
+	switch c {
+	case Shielding:
+		return "shielding"
+	case Unshielding:
+		return "unshielding"
+	case Transfer:
+		return "transfer"
+	case PublicTransfer:
+		return "public_transfer"
+	}
+	arity, ok := transferArities[c]
+	if !ok {
+		return fmt.Sprintf("circuit(%d)", int(c))
+	}
+	if c.PublicValues() {
+		return fmt.Sprintf("public_transfer_%dx%d", arity[0], arity[1])
+	}
+	return fmt.Sprintf("transfer_%dx%d", arity[0], arity[1])
+}
+
+// KeyFiles returns the paths of the proving and verifying keys of circuit in keyDir
+// (ex: keyDir/shielding.pk, keyDir/shielding.vk)
+func KeyFiles(keyDir string, circuit Circuit) (pkPath, vkPath string) {
+	pkPath = filepath.Join(keyDir, circuit.String()+".pk")
+	vkPath = filepath.Join(keyDir, circuit.String()+".vk")
+	return
+}
+
+// ProvingSystem is the libsnark proving system of a key set
+type ProvingSystem int
+
+const (
+	// PPZKSNARK is r1cs_ppzksnark (BCTV14)
+	PPZKSNARK ProvingSystem = iota
+	// Groth16 is r1cs_gg_ppzksnark: smaller proofs, verified with 3 pairings instead of 12
+	Groth16
+)
+
+// ProvingSystems lists the supported proving systems
+var ProvingSystems = []ProvingSystem{PPZKSNARK, Groth16}
+
+// String returns the proving system name, as in the key set Manifest
+func (s ProvingSystem) String() string {
+	switch s {
+	case PPZKSNARK:
+		return "ppzksnark"
+	case Groth16:
+		return "groth16"
+	}
+	return fmt.Sprintf("provingSystem(%d)", int(s))
+}
+
+// ParseProvingSystem returns the proving system of the given name (see String)
+func ParseProvingSystem(name string) (ProvingSystem, error) {
+	for _, s := range ProvingSystems {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("snark: unknown proving system %q (supported: %v)", name, ProvingSystems)
+}
+
+// ProofSize returns the size of a libsnark serialized proof on alt_bn128 (without point compression)
+func (s ProvingSystem) ProofSize() int {
+	if s == Groth16 {
+		return 2*g1Size + g2Size
+	}
+	return 7*g1Size + g2Size
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s ProvingSystem) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *ProvingSystem) UnmarshalText(text []byte) error {
+	var err error
+	*s, err = ParseProvingSystem(string(text))
+	return err
+}
+
+// KeyState is the state of the proving and verifying keys of a circuit
+type KeyState int
+
+const (
+	KeysUnloaded KeyState = iota
+	KeysGenerating
+	KeysLoading
+	KeysReady
+	KeysFailed
+)
+
+func (s KeyState) String() string {
+	switch s {
+	case KeysUnloaded:
+		return "unloaded"
+	case KeysGenerating:
+		return "generating"
+	case KeysLoading:
+		return "loading"
+	case KeysReady:
+		return "ready"
+	case KeysFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("keyState(%d)", int(s))
+}
+
+// Status describes the state of a Backend
+type Status struct {
+	Backend       string
+	TreeDepth     uint
+	ProvingSystem ProvingSystem
+	KeyDir        string
+	// Keys is the state of the keys of each circuit
+	Keys map[Circuit]KeyState
+}
+
+// Ready returns true if the keys of all circuits are loaded
+func (s Status) Ready() bool {
+	for _, c := range Circuits {
+		if s.Keys[c] != KeysReady {
+			return false
+		}
+	}
+	return true
+}
+
+// Backend computes and verifies the zkSNARKs of the ZSL circuits
+type Backend interface {
+	// Init loads (or generates if absent) the keys from keyDir, for the proving system. Only the first
+	// call has an effect.
+	Init(treeDepth uint, keyDir string, system ProvingSystem) error
+
+	// Status reports the state of the backend. It doesn't block while keys are loading.
+	Status() Status
+
+	// VerifyingKey returns the libsnark serialized verifying key of circuit (see ParseVerifyingKey and
+	// ParseGroth16VerifyingKey)
+	VerifyingKey(circuit Circuit) ([]byte, error)
+
+	// Prove* functions return a proof of Status().ProvingSystem, or an Error if the inputs are
+	// malformed, the witness doesn't satisfy the circuit or the keys aren't loaded. Verify* functions
+	// return false if the proof or the public inputs are invalid.
+	// Assets are hashSize bytes: the commitment of a note is SHA256(rho || pk || value || asset).
+	// Unshielding and shielded transfer proofs are bound to a hashSize bytes binding (ex: recipient or
+	// transaction hash), a public input: they are only valid for it, so they can't be replayed in another
+	// context.
+	ProveShielding(rho []byte, pk []byte, value uint64, asset []byte) ([]byte, error)
+	VerifyShielding(proof []byte, sendNullifier []byte, commitment []byte, value uint64, asset []byte) bool
+
+	ProveUnshielding(rho []byte,
+		sk []byte,
+		value uint64,
+		asset []byte,
+		treeIndex uint64,
+		treePath [][]byte,
+		binding []byte) ([]byte, error)
+	VerifyUnshielding(proof []byte, spendNullifier []byte, treeRoot []byte, value uint64, asset []byte, binding []byte) bool
+
+	ProveTransfer(inputRho1 []byte,
+		inputSk1 []byte,
+		inputValue1 uint64,
+		inputTreeIndex1 uint64,
+		inputTreePath1 [][]byte,
+		inputRho2 []byte,
+		inputSk2 []byte,
+		inputValue2 uint64,
+		inputTreeIndex2 uint64,
+		inputTreePath2 [][]byte,
+		outputRho1 []byte,
+		outputPk1 []byte,
+		outputValue1 uint64,
+		outputRho2 []byte,
+		outputPk2 []byte,
+		outputValue2 uint64,
+		asset []byte,
+		binding []byte) ([]byte, error)
+	// the asset of a shielded transfer is private (see VerifyTransferN)
+	VerifyTransfer(proof []byte,
+		treeRoot []byte,
+		spendNullifier1 []byte,
+		spendNullifier2 []byte,
+		sendNullifier1 []byte,
+		sendNullifier2 []byte,
+		commitment1 []byte,
+		commitment2 []byte,
+		binding []byte) bool
+
+	// ProveTransferN and VerifyTransferN are ProveTransfer and VerifyTransfer for the shielded transfer
+	// circuit of len(inputs) inputs and len(outputs) outputs, and the public values vpubIn, vpubOut and fee:
+	// the circuit with public values unless all are 0 (see TransferCircuit). The notes must all be of
+	// the same asset, a public input with the public values only (asset is ignored without). The bindings
+	// of the inputs are ignored: the proof is bound to binding.
+	ProveTransferN(inputs []UnshieldingWitness, outputs []ShieldingWitness, vpubIn uint64, vpubOut uint64, fee uint64, binding []byte) ([]byte, error)
+	VerifyTransferN(proof []byte,
+		treeRoot []byte,
+		spendNullifiers [][]byte,
+		sendNullifiers [][]byte,
+		commitments [][]byte,
+		vpubIn uint64,
+		vpubOut uint64,
+		fee uint64,
+		asset []byte,
+		binding []byte) bool
+}
+
+// -------------------------------------------------------------------------------------------------
+// Backend registry
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// Register makes a Backend available under the provided name.
+// It panics if Register is called twice with the same name or if backend is nil.
+func Register(name string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if backend == nil {
+		panic("snark: Register backend is nil")
+	}
+	if _, dup := backends[name]; dup {
+		panic("snark: Register called twice for backend " + name)
+	}
+	backends[name] = backend
+}
+
+// Backends returns a sorted list of the names of the registered backends
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	var list []string
+	for name := range backends {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Get returns the Backend registered under name
+func Get(name string) (Backend, error) {
+	backendsMu.RLock()
+	backend, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		// Backends read locks backendsMu again
+		return nil, fmt.Errorf("snark: unknown backend %q (registered: %v)", name, Backends())
+	}
+	return backend, nil
+}
+
+// DefaultBackend returns the name of the backend to use when none is specified:
+// libzsl if it was compiled in, mock otherwise
+func DefaultBackend() string {
+	if _, err := Get("libzsl"); err == nil {
+		return "libzsl"
+	}
+	return "mock"
+}
@@ -0,0 +1,421 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+// libsnark (libff) serializes alt_bn128 elements as built in libzsl: BINARY_OUTPUT, MONTGOMERY_OUTPUT
+// and NO_PT_COMPRESSION. A field element is 32 bytes, little endian 64-bit limbs, in Montgomery form.
+// A point is a '0' or '1' (point at infinity) ascii flag followed by its affine coordinates.
+const (
+	fpSize = 32
+	g1Size = 1 + 2*fpSize
+	g2Size = 1 + 4*fpSize
+)
+
+var (
+	// FieldModulus is the modulus of the alt_bn128 base field
+	FieldModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+
+	// montgomeryR = 2^256 mod p and its inverse, to convert from / to Montgomery form
+	montgomeryR    = new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 256), FieldModulus)
+	montgomeryRInv = new(big.Int).ModInverse(montgomeryR, FieldModulus)
+)
+
+// G1 is an alt_bn128 G1 point in affine coordinates. The point at infinity is (0, 0).
+type G1 struct {
+	X, Y *big.Int
+}
+
+// G2 is an alt_bn128 G2 point in affine coordinates, over Fp2 = Fp[i]/(i^2 + 1):
+// X[0] + X[1] * i, Y[0] + Y[1] * i. The point at infinity is (0, 0).
+type G2 struct {
+	X, Y [2]*big.Int
+}
+
+// GT is an alt_bn128 GT element, in Fp12 = Fp6[w]/(w^2 - v) over Fp6 = Fp2[v]/(v^3 - (9 + i)), as its 12
+// Fp coefficients in libff order: c0.c0.c0, c0.c0.c1, c0.c1.c0, ..., c1.c2.c1
+type GT [12]*big.Int
+
+// VerifyingKey is a libsnark ppzksnark (BCTV14) verifying key, see r1cs_ppzksnark_verification_key
+type VerifyingKey struct {
+	AlphaA     G2
+	AlphaB     G1
+	AlphaC     G2
+	Gamma      G2
+	GammaBeta1 G1
+	GammaBeta2 G2
+	RCZ        G2
+	// IC is the input consistency query: IC[0] is the constant term, IC[i] is for the i-th public input
+	IC []G1
+}
+
+// Fingerprint returns the hex encoded SHA-256 of a raw (libsnark serialized) verifying key
+func Fingerprint(raw []byte) string {
+	h := sha256.Sum256(raw)
+	return hex.EncodeToString(h[:])
+}
+
+// ParseVerifyingKey parses a libsnark serialized verifying key (.vk file)
+func ParseVerifyingKey(raw []byte) (*VerifyingKey, error) {
+	r := bufio.NewReader(bytes.NewReader(raw))
+	vk := &VerifyingKey{}
+	var err error
+	readG1 := func(p *G1) {
+		if err == nil {
+			*p, err = readG1(r)
+		}
+	}
+	readG2 := func(p *G2) {
+		if err == nil {
+			*p, err = readG2(r)
+		}
+	}
+	readG2(&vk.AlphaA)
+	readG1(&vk.AlphaB)
+	readG2(&vk.AlphaC)
+	readG2(&vk.Gamma)
+	readG1(&vk.GammaBeta1)
+	readG2(&vk.GammaBeta2)
+	readG2(&vk.RCZ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verifying key: %v", err)
+	}
+	if vk.IC, err = readAccumulationVector(r, len(raw)); err != nil {
+		return nil, fmt.Errorf("invalid verifying key IC query: %v", err)
+	}
+	return vk, nil
+}
+
+// readAccumulationVector reads a libsnark accumulation_vector of G1 points (first, then the sparse vector
+// of the rest) as a dense vector. maxSize bounds the size of the vector.
+func readAccumulationVector(r *bufio.Reader, maxSize int) ([]G1, error) {
+	first, err := readG1(r)
+	if err != nil {
+		return nil, err
+	}
+	var domainSize, nbIndices, nbValues int
+	if _, err := fmt.Fscanf(r, "%d\n%d\n", &domainSize, &nbIndices); err != nil {
+		return nil, err
+	}
+	if domainSize < 0 || nbIndices < 0 || nbIndices > domainSize || domainSize > maxSize {
+		return nil, errors.New("invalid size")
+	}
+	indices := make([]int, nbIndices)
+	for i := range indices {
+		if _, err := fmt.Fscanf(r, "%d\n", &indices[i]); err != nil {
+			return nil, err
+		}
+		if indices[i] < 0 || indices[i] >= domainSize {
+			return nil, errors.New("invalid index")
+		}
+	}
+	if _, err := fmt.Fscanf(r, "%d\n", &nbValues); err != nil {
+		return nil, err
+	}
+	if nbValues != nbIndices {
+		return nil, errors.New("invalid size")
+	}
+	v := make([]G1, domainSize+1)
+	v[0] = first
+	for i := 1; i < len(v); i++ {
+		v[i] = G1{X: new(big.Int), Y: new(big.Int)}
+	}
+	for _, index := range indices {
+		if v[index+1], err = readG1(r); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// writeAccumulationVector writes v as a dense libsnark accumulation_vector
+func writeAccumulationVector(w io.Writer, v []G1) {
+	writeG1(w, v[0])
+	rest := len(v) - 1
+	fmt.Fprintf(w, "%d\n%d\n", rest, rest)
+	for i := 0; i < rest; i++ {
+		fmt.Fprintf(w, "%d\n", i)
+	}
+	fmt.Fprintf(w, "%d\n", rest)
+	for _, p := range v[1:] {
+		writeG1(w, p)
+	}
+}
+
+// Bytes returns the libsnark serialization of the verifying key
+func (vk *VerifyingKey) Bytes() []byte {
+	var buf bytes.Buffer
+	writeG2(&buf, vk.AlphaA)
+	writeG1(&buf, vk.AlphaB)
+	writeG2(&buf, vk.AlphaC)
+	writeG2(&buf, vk.Gamma)
+	writeG1(&buf, vk.GammaBeta1)
+	writeG2(&buf, vk.GammaBeta2)
+	writeG2(&buf, vk.RCZ)
+	writeAccumulationVector(&buf, vk.IC)
+	return buf.Bytes()
+}
+
+// Groth16VerifyingKey is a libsnark Groth16 verifying key, see r1cs_gg_ppzksnark_verification_key
+type Groth16VerifyingKey struct {
+	// AlphaBeta is e(alpha G1, beta G2)
+	AlphaBeta GT
+	Gamma     G2
+	Delta     G2
+	// GammaABC is the input consistency query: GammaABC[0] is the constant term, GammaABC[i] is for the
+	// i-th public input
+	GammaABC []G1
+}
+
+// ParseGroth16VerifyingKey parses a libsnark serialized Groth16 verifying key (.vk file of a Groth16
+// key set)
+func ParseGroth16VerifyingKey(raw []byte) (*Groth16VerifyingKey, error) {
+	r := bufio.NewReader(bytes.NewReader(raw))
+	vk := &Groth16VerifyingKey{}
+	var err error
+	for i := range vk.AlphaBeta {
+		if vk.AlphaBeta[i], err = readFp(r); err != nil {
+			return nil, fmt.Errorf("invalid verifying key: %v", err)
+		}
+	}
+	if vk.Gamma, err = readG2(r); err != nil {
+		return nil, fmt.Errorf("invalid verifying key: %v", err)
+	}
+	if vk.Delta, err = readG2(r); err != nil {
+		return nil, fmt.Errorf("invalid verifying key: %v", err)
+	}
+	if vk.GammaABC, err = readAccumulationVector(r, len(raw)); err != nil {
+		return nil, fmt.Errorf("invalid verifying key gamma ABC query: %v", err)
+	}
+	return vk, nil
+}
+
+// Bytes returns the libsnark serialization of the verifying key
+func (vk *Groth16VerifyingKey) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, e := range vk.AlphaBeta {
+		writeFp(&buf, e)
+	}
+	writeG2(&buf, vk.Gamma)
+	writeG2(&buf, vk.Delta)
+	writeAccumulationVector(&buf, vk.GammaABC)
+	return buf.Bytes()
+}
+
+// ExportVerifyingKey returns a libsnark serialized verifying key of the proving system with its points,
+// as GetVerifyingKey returns it (without circuit)
+func ExportVerifyingKey(raw []byte, system ProvingSystem) (*zsl.VerifyingKey, error) {
+	toReturn := &zsl.VerifyingKey{Raw: raw, Fingerprint: Fingerprint(raw)}
+	if system == Groth16 {
+		vk, err := ParseGroth16VerifyingKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		toReturn.ProvingSystem = zsl.ProvingSystem_GROTH16
+		for _, e := range vk.AlphaBeta {
+			toReturn.AlphaBeta = append(toReturn.AlphaBeta, FpHex(e))
+		}
+		toReturn.Gamma = g2Point(vk.Gamma)
+		toReturn.Delta = g2Point(vk.Delta)
+		for _, p := range vk.GammaABC {
+			toReturn.Ic = append(toReturn.Ic, g1Point(p))
+		}
+		return toReturn, nil
+	}
+
+	vk, err := ParseVerifyingKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	toReturn.ProvingSystem = zsl.ProvingSystem_PPZKSNARK
+	toReturn.AlphaA = g2Point(vk.AlphaA)
+	toReturn.AlphaB = g1Point(vk.AlphaB)
+	toReturn.AlphaC = g2Point(vk.AlphaC)
+	toReturn.Gamma = g2Point(vk.Gamma)
+	toReturn.GammaBeta1 = g1Point(vk.GammaBeta1)
+	toReturn.GammaBeta2 = g2Point(vk.GammaBeta2)
+	toReturn.RCZ = g2Point(vk.RCZ)
+	for _, p := range vk.IC {
+		toReturn.Ic = append(toReturn.Ic, g1Point(p))
+	}
+	return toReturn, nil
+}
+
+// ExportGroth16AlphaBeta sets the Alpha and Beta points of vk from a libsnark serialized Groth16 proving key
+// (.pk file), which starts with alpha_g1, beta_g1 and beta_g2: only the first points are read from pk
+func ExportGroth16AlphaBeta(pk io.Reader, vk *zsl.VerifyingKey) error {
+	alpha, err := readG1(pk)
+	if err != nil {
+		return fmt.Errorf("invalid proving key: %v", err)
+	}
+	if _, err := readG1(pk); err != nil {
+		return fmt.Errorf("invalid proving key: %v", err)
+	}
+	beta, err := readG2(pk)
+	if err != nil {
+		return fmt.Errorf("invalid proving key: %v", err)
+	}
+	vk.Alpha = g1Point(alpha)
+	vk.Beta = g2Point(beta)
+	return nil
+}
+
+func g1Point(p G1) *zsl.G1Point {
+	return &zsl.G1Point{X: FpHex(p.X), Y: FpHex(p.Y)}
+}
+
+func g2Point(p G2) *zsl.G2Point {
+	return &zsl.G2Point{
+		X: []string{FpHex(p.X[0]), FpHex(p.X[1])},
+		Y: []string{FpHex(p.Y[0]), FpHex(p.Y[1])},
+	}
+}
+
+// FpHex returns the 0x prefixed, 32 bytes big endian hex encoding of a field element
+func FpHex(e *big.Int) string {
+	b := make([]byte, fpSize)
+	eb := e.Bytes()
+	copy(b[fpSize-len(eb):], eb)
+	return "0x" + hex.EncodeToString(b)
+}
+
+func readFp(r io.Reader) (*big.Int, error) {
+	return readMontgomery(r, FieldModulus, montgomeryRInv)
+}
+
+func writeFp(w io.Writer, e *big.Int) {
+	writeMontgomery(w, e, FieldModulus, montgomeryR)
+}
+
+// readMontgomery reads a field element of the given modulus in Montgomery form (rInv is R^-1 mod modulus)
+func readMontgomery(r io.Reader, modulus, rInv *big.Int) (*big.Int, error) {
+	var buf [fpSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	// little endian to big endian
+	for i, j := 0, fpSize-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	e := new(big.Int).SetBytes(buf[:])
+	if e.Cmp(modulus) >= 0 {
+		return nil, errors.New("field element out of range")
+	}
+	return e.Mul(e, rInv).Mod(e, modulus), nil
+}
+
+// writeMontgomery writes a field element of the given modulus in Montgomery form (r is R mod modulus)
+func writeMontgomery(w io.Writer, e, modulus, r *big.Int) {
+	mont := new(big.Int).Mul(e, r)
+	mont.Mod(mont, modulus)
+	var buf [fpSize]byte
+	b := mont.Bytes()
+	// big endian to little endian
+	for i := range b {
+		buf[i] = b[len(b)-1-i]
+	}
+	w.Write(buf[:])
+}
+
+func readInfinity(r io.Reader) (bool, error) {
+	var flag [1]byte
+	if _, err := io.ReadFull(r, flag[:]); err != nil {
+		return false, err
+	}
+	switch flag[0] {
+	case '0':
+		return false, nil
+	case '1':
+		return true, nil
+	}
+	return false, errors.New("invalid point flag")
+}
+
+func readG1(r io.Reader) (G1, error) {
+	infinity, err := readInfinity(r)
+	if err != nil {
+		return G1{}, err
+	}
+	var p G1
+	if p.X, err = readFp(r); err != nil {
+		return G1{}, err
+	}
+	if p.Y, err = readFp(r); err != nil {
+		return G1{}, err
+	}
+	if infinity {
+		p.X.SetInt64(0)
+		p.Y.SetInt64(0)
+	}
+	return p, nil
+}
+
+func readG2(r io.Reader) (G2, error) {
+	infinity, err := readInfinity(r)
+	if err != nil {
+		return G2{}, err
+	}
+	var p G2
+	for _, e := range []**big.Int{&p.X[0], &p.X[1], &p.Y[0], &p.Y[1]} {
+		if *e, err = readFp(r); err != nil {
+			return G2{}, err
+		}
+		if infinity {
+			(*e).SetInt64(0)
+		}
+	}
+	return p, nil
+}
+
+// writeG1 and writeG2 write the point at infinity as libff does: (0, 1) with the infinity flag
+func writeG1(w io.Writer, p G1) {
+	if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+		w.Write([]byte{'1'})
+		writeFp(w, p.X)
+		writeFp(w, big.NewInt(1))
+		return
+	}
+	w.Write([]byte{'0'})
+	writeFp(w, p.X)
+	writeFp(w, p.Y)
+}
+
+func writeG2(w io.Writer, p G2) {
+	if p.X[0].Sign() == 0 && p.X[1].Sign() == 0 && p.Y[0].Sign() == 0 && p.Y[1].Sign() == 0 {
+		w.Write([]byte{'1'})
+		writeFp(w, p.X[0])
+		writeFp(w, p.X[1])
+		writeFp(w, big.NewInt(1))
+		writeFp(w, p.Y[1])
+		return
+	}
+	w.Write([]byte{'0'})
+	writeFp(w, p.X[0])
+	writeFp(w, p.X[1])
+	writeFp(w, p.Y[0])
+	writeFp(w, p.Y[1])
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+func TestReadFpMontgomery(t *testing.T) {
+	// 1 in Montgomery form is R mod p = 0x0e0a77c19a07df2f666ea36f7879462c0a78eb28f5c70b3dd35d438dc58f0d9d,
+	// serialized as little endian limbs
+	mont, _ := hex.DecodeString("9d0d8fc58d435dd33d0bc7f528eb780a2c4679786fa36e662fdf079ac1770a0e")
+	e, err := readFp(bytes.NewReader(mont))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Cmp(big.NewInt(1)) != 0 {
+		t.Fatal("expected 1, got", e)
+	}
+
+	var buf bytes.Buffer
+	writeFp(&buf, big.NewInt(1))
+	if !bytes.Equal(buf.Bytes(), mont) {
+		t.Fatal("writeFp(1) should be R mod p")
+	}
+}
+
+func TestVerifyingKeyEncoding(t *testing.T) {
+	backend := newMock(t)
+	raw, err := backend.VerifyingKey(Transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk, err := ParseVerifyingKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vk.IC) != 10 {
+		t.Fatal("transfer verifying key should have 10 IC elements, got", len(vk.IC))
+	}
+	if FpHex(vk.AlphaB.X) != "0x0000000000000000000000000000000000000000000000000000000000000001" {
+		t.Fatal("unexpected G1 encoding", FpHex(vk.AlphaB.X))
+	}
+	if !bytes.Equal(vk.Bytes(), raw) {
+		t.Fatal("ParseVerifyingKey(raw).Bytes() != raw")
+	}
+
+	// point at infinity
+	vk.IC[3] = G1{X: new(big.Int), Y: new(big.Int)}
+	vk2, err := ParseVerifyingKey(vk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vk2.IC[3].X.Sign() != 0 || vk2.IC[3].Y.Sign() != 0 {
+		t.Fatal("expected point at infinity")
+	}
+
+	// truncated
+	if _, err := ParseVerifyingKey(raw[:len(raw)-1]); err == nil {
+		t.Fatal("parsed truncated verifying key")
+	}
+	if _, err := ParseVerifyingKey(zsl.RandomBytes(uint(len(raw)))); err == nil {
+		t.Fatal("parsed random verifying key")
+	}
+}
+
+func TestGroth16VerifyingKeyEncoding(t *testing.T) {
+	backend := &mock{}
+	if err := backend.Init(zsl.TreeDepth, "", Groth16); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := backend.VerifyingKey(Shielding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk, err := ParseGroth16VerifyingKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vk.GammaABC) != 5 {
+		t.Fatal("shielding verifying key should have 5 gamma ABC elements, got", len(vk.GammaABC))
+	}
+	if !bytes.Equal(vk.Bytes(), raw) {
+		t.Fatal("ParseGroth16VerifyingKey(raw).Bytes() != raw")
+	}
+	if _, err := ParseGroth16VerifyingKey(raw[:len(raw)-1]); err == nil {
+		t.Fatal("parsed truncated verifying key")
+	}
+	if _, err := ParseVerifyingKey(raw); err == nil {
+		t.Fatal("parsed groth16 verifying key as a ppzksnark one")
+	}
+}
+
+func TestExportGroth16AlphaBeta(t *testing.T) {
+	one, two := big.NewInt(1), big.NewInt(2)
+	alpha, beta1 := G1{X: one, Y: two}, G1{X: two, Y: one}
+	beta2 := G2{X: [2]*big.Int{one, two}, Y: [2]*big.Int{two, one}}
+	var pk bytes.Buffer
+	writeG1(&pk, alpha)
+	writeG1(&pk, beta1)
+	writeG2(&pk, beta2)
+	pk.WriteString("rest of the proving key")
+
+	vk := &zsl.VerifyingKey{}
+	if err := ExportGroth16AlphaBeta(bytes.NewReader(pk.Bytes()), vk); err != nil {
+		t.Fatal(err)
+	}
+	if vk.Alpha.X != FpHex(one) || vk.Alpha.Y != FpHex(two) {
+		t.Fatal("unexpected alpha", vk.Alpha)
+	}
+	if vk.Beta.X[0] != FpHex(one) || vk.Beta.X[1] != FpHex(two) || vk.Beta.Y[0] != FpHex(two) || vk.Beta.Y[1] != FpHex(one) {
+		t.Fatal("unexpected beta", vk.Beta)
+	}
+	if err := ExportGroth16AlphaBeta(bytes.NewReader(pk.Bytes()[:g1Size]), vk); err == nil {
+		t.Fatal("expected error on truncated proving key")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snark
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// A Pool talks to its workers over two pipes, passed as extra files (stdout is left to libsnark's logs):
+// gob encoded workerRequests on fd 3, workerResponses on fd 4. A worker serves requests concurrently.
+const (
+	workerRequestsFd  = 3
+	workerResponsesFd = 4
+)
+
+func init() {
+	gob.Register(&ShieldingWitness{})
+	gob.Register(&UnshieldingWitness{})
+	gob.Register(&TransferWitness{})
+	gob.Register(&ShieldingVerification{})
+	gob.Register(&UnshieldingVerification{})
+	gob.Register(&TransferVerification{})
+}
+
+type workerOp int
+
+const (
+	opInit workerOp = iota
+	opStatus
+	opVerifyingKey
+	opProve
+	opVerify
+)
+
+type workerRequest struct {
+	ID uint64
+	Op workerOp
+
+	// opInit
+	TreeDepth     uint
+	KeyDir        string
+	ProvingSystem ProvingSystem
+
+	Circuit      Circuit // opVerifyingKey
+	Witness      Witness // opProve
+	Verification Verification
+}
+
+type workerResponse struct {
+	ID     uint64
+	Data   []byte // proof or verifying key
+	Valid  bool
+	Status Status
+
+	// Code is set for Error errors, Err for the others
+	Code Error
+	Err  string
+}
+
+// err returns the error of the response, if any
+func (r *workerResponse) err() error {
+	if r.Code != 0 {
+		return r.Code
+	}
+	if r.Err != "" {
+		return errors.New(r.Err)
+	}
+	return nil
+}
+
+// ServeWorker serves the requests of the Pool which started this process on backend, until the pool
+// closes the requests pipe
+func ServeWorker(backend Backend) error {
+	return serveWorker(backend, os.NewFile(workerRequestsFd, "requests"), os.NewFile(workerResponsesFd, "responses"))
+}
+
+func serveWorker(backend Backend, requests io.Reader, responses io.Writer) error {
+	decoder, encoder := gob.NewDecoder(requests), gob.NewEncoder(responses)
+	var lock sync.Mutex
+	for {
+		request := &workerRequest{}
+		if err := decoder.Decode(request); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			response := request.serve(backend)
+			lock.Lock()
+			defer lock.Unlock()
+			encoder.Encode(response)
+		}()
+	}
+}
+
+func (request *workerRequest) serve(backend Backend) *workerResponse {
+	response := &workerResponse{ID: request.ID}
+	var err error
+	switch request.Op {
+	case opInit:
+		err = backend.Init(request.TreeDepth, request.KeyDir, request.ProvingSystem)
+	case opStatus:
+		response.Status = backend.Status()
+	case opVerifyingKey:
+		response.Data, err = backend.VerifyingKey(request.Circuit)
+	case opProve:
+		if request.Witness == nil {
+			err = errors.New("snark: missing witness")
+			break
+		}
+		response.Data, err = request.Witness.prove(backend)
+	case opVerify:
+		response.Valid = request.Verification != nil && request.Verification.verify(backend)
+	default:
+		err = errors.New("snark: unknown worker request")
+	}
+	if e, ok := err.(Error); ok {
+		response.Code = e
+	} else if err != nil {
+		response.Err = err.Error()
+	}
+	return response
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/zslbox/zsl"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"golang.org/x/crypto/sha3"
+)
+
+// proofWords returns the number of 32 bytes words of a proof in calldata: A, A', B (G2), B', C, C', H, K for
+// PPZKSNARK, A, B (G2), C for GROTH16
+func proofWords(system zsl.ProvingSystem) int {
+	if system == zsl.ProvingSystem_GROTH16 {
+		return 8
+	}
+	return 18
+}
+
+// signature returns the ABI signature of the verifyProof function of the Solidity verifier of circuit
+func signature(system zsl.ProvingSystem, circuit zsl.Circuit) string {
+	return fmt.Sprintf("verifyProof(uint256[%d],uint256[%d])", proofWords(system), NbPublicInputs(circuit))
+}
+
+// Selector returns the ABI function selector of the verifyProof function of the Solidity verifier of circuit
+// (see Solidity)
+func Selector(system zsl.ProvingSystem, circuit zsl.Circuit) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(signature(system, circuit)))
+	return h.Sum(nil)[:4]
+}
+
+// Calldata returns the ABI encoded call of verifyProof(proof, inputs) of the Solidity verifier of circuit:
+// its selector, the proof points (G2: x.c1, x.c0, y.c1, y.c0, as EIP-197) and the packed public inputs, as
+// 32 bytes big endian words.
+func Calldata(proof []byte, system zsl.ProvingSystem, circuit zsl.Circuit, inputs []*big.Int) ([]byte, error) {
+	if len(inputs) != NbPublicInputs(circuit) {
+		return nil, fmt.Errorf("%s circuit expects %d public inputs, got %d", circuit, NbPublicInputs(circuit), len(inputs))
+	}
+	calldata := Selector(system, circuit)
+	if system == zsl.ProvingSystem_GROTH16 {
+		p, err := zsl.ParseGroth16Proof(proof)
+		if err != nil {
+			return nil, err
+		}
+		calldata = append(calldata, p.A.Marshal()...)
+		calldata = append(calldata, p.B.Marshal()...)
+		calldata = append(calldata, p.C.Marshal()...)
+	} else {
+		p, err := zsl.ParseProof(proof)
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range []interface {
+			Marshal() []byte
+		}{p.A, p.APrime, p.B, p.BPrime, p.C, p.CPrime, p.H, p.K} {
+			calldata = append(calldata, point.Marshal()...)
+		}
+	}
+	for _, input := range inputs {
+		if input.Sign() < 0 || input.Cmp(bn256.Order) >= 0 {
+			return nil, fmt.Errorf("public input out of range: %v", input)
+		}
+		calldata = append(calldata, word(input)...)
+	}
+	return calldata, nil
+}
+
+// ShieldingCalldata returns the calldata verifying a shielding of value of asset with the Solidity verifier
+func ShieldingCalldata(shielding *zsl.Shielding, value uint64, asset []byte) ([]byte, error) {
+	inputs, err := ShieldingInputs(shielding.SendNullifier, shielding.Commitment, value, asset)
+	if err != nil {
+		return nil, err
+	}
+	return Calldata(shielding.Snark, shielding.ProvingSystem, zsl.Circuit_SHIELDING, inputs)
+}
+
+// UnshieldingCalldata returns the calldata verifying an unshielding of value of asset, of a note of the tree of
+// root treeRoot, bound to binding, with the Solidity verifier
+func UnshieldingCalldata(unshielding *zsl.Unshielding, treeRoot []byte, value uint64, asset []byte, binding []byte) ([]byte, error) {
+	inputs, err := UnshieldingInputs(unshielding.SpendNullifier, treeRoot, value, asset, binding)
+	if err != nil {
+		return nil, err
+	}
+	return Calldata(unshielding.Snark, unshielding.ProvingSystem, zsl.Circuit_UNSHIELDING, inputs)
+}
+
+// TransferCalldata returns the calldata verifying a shielded transfer, of notes of the tree of root treeRoot,
+// bound to binding, with the Solidity verifier of the transfer circuit of its arity
+func TransferCalldata(transfer *zsl.ShieldedTransfer, treeRoot []byte, binding []byte) ([]byte, error) {
+	return TransferCalldataN(transfer, treeRoot, 0, 0, 0, nil, binding)
+}
+
+// TransferCalldataN is TransferCalldata for a shielded transfer of public values vpubIn and vpubOut, and fee, of
+// asset, verified with the Solidity verifier of the transfer circuit with public values unless all are 0
+func TransferCalldataN(transfer *zsl.ShieldedTransfer, treeRoot []byte, vpubIn uint64, vpubOut uint64, fee uint64, asset []byte, binding []byte) ([]byte, error) {
+	circuit, ok := transferCircuit(transfer.SpendNullifiers, transfer.SendNullifiers, transfer.Commitments, vpubIn, vpubOut, fee)
+	if !ok {
+		return nil, ErrInvalidInputSize
+	}
+	inputs, err := TransferNInputs(treeRoot, transfer.SpendNullifiers, transfer.SendNullifiers, transfer.Commitments, vpubIn, vpubOut, fee, asset, binding)
+	if err != nil {
+		return nil, err
+	}
+	return Calldata(transfer.Snark, transfer.ProvingSystem, circuit, inputs)
+}
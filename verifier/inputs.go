@@ -0,0 +1,222 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/consensys/zslbox/zsl"
+)
+
+// fieldCapacity is the number of bits packed in a public input, the capacity of the alt_bn128 scalar field
+// (libff Fr::capacity())
+const fieldCapacity = 253
+
+// ErrInvalidInputSize is returned when a nullifier, commitment, tree root, asset or binding isn't 32 bytes, or a shielded
+// transfer doesn't have the nullifiers and commitments of a transfer circuit (see zsl.TransferCircuit)
+var ErrInvalidInputSize = errors.New("invalid input size")
+
+// NbPublicInputs returns the number of public inputs (field elements) of circuit, or 0 for an unknown
+// circuit
+func NbPublicInputs(circuit zsl.Circuit) int {
+	switch circuit {
+	case zsl.Circuit_SHIELDING:
+		// nullifier, commitment, value and asset
+		return (3*zsl.HashSize*8 + 64 + fieldCapacity - 1) / fieldCapacity
+	case zsl.Circuit_UNSHIELDING:
+		// nullifier, tree root, value, asset and binding
+		return (4*zsl.HashSize*8 + 64 + fieldCapacity - 1) / fieldCapacity
+	}
+	if nbInputs, nbOutputs := circuit.Arity(); nbInputs > 0 {
+		// tree root, nbInputs spend nullifiers, nbOutputs send nullifiers and commitments, the public values,
+		// fee and asset, and the binding
+		nbBits := (2 + nbInputs + 2*nbOutputs) * zsl.HashSize * 8
+		if circuit.PublicValues() {
+			nbBits += 3*64 + zsl.HashSize*8
+		}
+		return (nbBits + fieldCapacity - 1) / fieldCapacity
+	}
+	return 0
+}
+
+// ShieldingInputs returns the public inputs of the shielding circuit, as ShieldingCircuit::witness_map
+// computes them: the bits of sendNullifier || commitment || value (8 bytes, little endian) || asset, each
+// byte most significant bit first, packed in field elements of 253 bits, least significant bit first.
+func ShieldingInputs(sendNullifier []byte, commitment []byte, value uint64, asset []byte) ([]*big.Int, error) {
+	if err := checkHashes(sendNullifier, commitment, asset); err != nil {
+		return nil, err
+	}
+	return packInputs(sendNullifier, commitment, valueBytes(value), asset), nil
+}
+
+// UnshieldingInputs returns the public inputs of the unshielding circuit: spendNullifier || treeRoot ||
+// value || asset || binding, packed as ShieldingInputs
+func UnshieldingInputs(spendNullifier []byte, treeRoot []byte, value uint64, asset []byte, binding []byte) ([]*big.Int, error) {
+	if err := checkHashes(spendNullifier, treeRoot, asset, binding); err != nil {
+		return nil, err
+	}
+	return packInputs(spendNullifier, treeRoot, valueBytes(value), asset, binding), nil
+}
+
+// TransferInputs returns the public inputs of the transfer circuit: treeRoot || spendNullifier1 ||
+// spendNullifier2 || sendNullifier1 || sendNullifier2 || commitment1 || commitment2 || binding, packed as
+// ShieldingInputs
+func TransferInputs(treeRoot []byte,
+	spendNullifier1 []byte,
+	spendNullifier2 []byte,
+	sendNullifier1 []byte,
+	sendNullifier2 []byte,
+	commitment1 []byte,
+	commitment2 []byte,
+	binding []byte) ([]*big.Int, error) {
+	hashes := [][]byte{treeRoot, spendNullifier1, spendNullifier2, sendNullifier1, sendNullifier2, commitment1, commitment2, binding}
+	if err := checkHashes(hashes...); err != nil {
+		return nil, err
+	}
+	return packInputs(hashes...), nil
+}
+
+// TransferNInputs returns the public inputs of the shielded transfer circuit of len(spendNullifiers) inputs
+// and len(commitments) outputs: treeRoot || spendNullifiers || sendNullifiers || commitments || binding, packed
+// as ShieldingInputs. Unless vpubIn, vpubOut and fee are 0, it's the circuit with public values, whose inputs
+// have vpubIn || vpubOut || fee (8 bytes each, little endian) || asset before the binding; the asset is private (and
+// ignored) otherwise.
+func TransferNInputs(treeRoot []byte,
+	spendNullifiers [][]byte,
+	sendNullifiers [][]byte,
+	commitments [][]byte,
+	vpubIn uint64,
+	vpubOut uint64,
+	fee uint64,
+	asset []byte,
+	binding []byte) ([]*big.Int, error) {
+	circuit, ok := transferCircuit(spendNullifiers, sendNullifiers, commitments, vpubIn, vpubOut, fee)
+	if !ok {
+		return nil, ErrInvalidInputSize
+	}
+	hashes := append([][]byte{treeRoot}, spendNullifiers...)
+	hashes = append(hashes, sendNullifiers...)
+	hashes = append(hashes, commitments...)
+	if err := checkHashes(hashes...); err != nil {
+		return nil, err
+	}
+	if circuit.PublicValues() {
+		if err := checkHashes(asset); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, valueBytes(vpubIn), valueBytes(vpubOut), valueBytes(fee), asset)
+	}
+	if err := checkHashes(binding); err != nil {
+		return nil, err
+	}
+	return packInputs(append(hashes, binding)...), nil
+}
+
+// transferCircuit returns the shielded transfer circuit of the nullifiers, commitments, public values and
+// fee, false if there is none
+func transferCircuit(spendNullifiers [][]byte, sendNullifiers [][]byte, commitments [][]byte, vpubIn uint64, vpubOut uint64, fee uint64) (zsl.Circuit, bool) {
+	if len(sendNullifiers) != len(commitments) {
+		return 0, false
+	}
+	return zsl.TransferCircuit(len(spendNullifiers), len(commitments), vpubIn != 0 || vpubOut != 0 || fee != 0)
+}
+
+// PublicInputs returns the packed public inputs of the operation of request, hex encoded (see GetPublicInputs)
+func PublicInputs(request *zsl.PublicInputsRequest) (*zsl.PublicInputs, error) {
+	var inputs []*big.Int
+	var err error
+	toReturn := &zsl.PublicInputs{}
+	switch {
+	case request.Shielding != nil && request.Unshielding == nil && request.ShieldedTransfer == nil:
+		if request.Shielding.Shielding == nil {
+			return nil, errors.New("missing shielding")
+		}
+		toReturn.Circuit = zsl.Circuit_SHIELDING
+		inputs, err = ShieldingInputs(request.Shielding.Shielding.SendNullifier, request.Shielding.Shielding.Commitment, request.Shielding.Value, zsl.NoteAsset(request.Shielding.Asset))
+	case request.Shielding == nil && request.Unshielding != nil && request.ShieldedTransfer == nil:
+		toReturn.Circuit = zsl.Circuit_UNSHIELDING
+		inputs, err = UnshieldingInputs(request.Unshielding.SpendNullifier, request.Unshielding.TreeRoot, request.Unshielding.Value, zsl.NoteAsset(request.Unshielding.Asset), zsl.ProofBinding(request.Unshielding.Binding))
+	case request.Shielding == nil && request.Unshielding == nil && request.ShieldedTransfer != nil:
+		transfer := request.ShieldedTransfer.ShieldedTransfer
+		if transfer == nil {
+			return nil, errors.New("missing shielded transfer")
+		}
+		vpubIn, vpubOut, fee := request.ShieldedTransfer.VpubIn, request.ShieldedTransfer.VpubOut, request.ShieldedTransfer.Fee
+		circuit, ok := transferCircuit(transfer.SpendNullifiers, transfer.SendNullifiers, transfer.Commitments, vpubIn, vpubOut, fee)
+		if !ok {
+			return nil, ErrInvalidInputSize
+		}
+		toReturn.Circuit = circuit
+		inputs, err = TransferNInputs(request.ShieldedTransfer.TreeRoot, transfer.SpendNullifiers, transfer.SendNullifiers, transfer.Commitments, vpubIn, vpubOut, fee, zsl.NoteAsset(request.ShieldedTransfer.Asset), zsl.ProofBinding(request.ShieldedTransfer.Binding))
+	default:
+		return nil, errors.New("exactly one of shielding, unshielding and shieldedTransfer must be set")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range inputs {
+		toReturn.Inputs = append(toReturn.Inputs, "0x"+hex.EncodeToString(word(input)))
+	}
+	return toReturn, nil
+}
+
+// word returns the 32 bytes big endian encoding of a field element
+func word(e *big.Int) []byte {
+	w := make([]byte, fpSize)
+	b := e.Bytes()
+	copy(w[fpSize-len(b):], b)
+	return w
+}
+
+// checkHashes returns ErrInvalidInputSize if one of the hashes isn't zsl.HashSize bytes
+func checkHashes(hashes ...[]byte) error {
+	for _, h := range hashes {
+		if len(h) != zsl.HashSize {
+			return ErrInvalidInputSize
+		}
+	}
+	return nil
+}
+
+// valueBytes returns the 8 bytes little endian encoding of a value, as in the public inputs
+func valueBytes(value uint64) []byte {
+	buf := make([]byte, 8)
+	for i := uint(0); i < 8; i++ {
+		buf[i] = byte(value >> (8 * i))
+	}
+	return buf
+}
+
+// packInputs packs the bits of the concatenated fields as libff pack_bit_vector_into_field_element_vector does
+func packInputs(fields ...[]byte) []*big.Int {
+	var buf []byte
+	for _, field := range fields {
+		buf = append(buf, field...)
+	}
+
+	nbBits := len(buf) * 8
+	inputs := make([]*big.Int, 0, (nbBits+fieldCapacity-1)/fieldCapacity)
+	for start := 0; start < nbBits; start += fieldCapacity {
+		e := new(big.Int)
+		for j := 0; j < fieldCapacity && start+j < nbBits; j++ {
+			pos := start + j
+			e.SetBit(e, j, uint(buf[pos/8]>>uint(7-pos%8)&1))
+		}
+		inputs = append(inputs, e)
+	}
+	return inputs
+}
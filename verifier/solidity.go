@@ -0,0 +1,304 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+
+	"github.com/consensys/zslbox/zsl"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// ContractName returns the name of the Solidity verifier contract of circuit (ex: ShieldingVerifier,
+// Transfer4x2Verifier)
+func ContractName(circuit zsl.Circuit) string {
+	name := strings.Replace(strings.ToLower(circuit.String()), "_", "", -1)
+	return strings.ToUpper(name[:1]) + name[1:] + "Verifier"
+}
+
+// Solidity returns the source of a Solidity contract verifying the proofs of vk.Circuit with the alt_bn128
+// precompiles (EIP-196, EIP-197), on chains without the ZSL precompiles. Its verifyProof function checks the
+// same equations as Verify, from the calldata returned by Calldata.
+//
+// Groth16 keys must have their Alpha and Beta points (see snark.ExportGroth16AlphaBeta): the precompiles
+// can't compare e(alpha, beta) with a GT element.
+func Solidity(vk *zsl.VerifyingKey) ([]byte, error) {
+	v, err := New(vk)
+	if err != nil {
+		return nil, err
+	}
+	g2 := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	data := &solidityData{
+		Contract:    ContractName(v.circuit),
+		Circuit:     strings.ToLower(v.circuit.String()),
+		System:      strings.ToLower(v.system.String()),
+		Fingerprint: vk.Fingerprint,
+		Signature:   signature(v.system, v.circuit),
+		ProofSize:   proofWords(v.system),
+		NbInputs:    len(v.ic) - 1,
+		G2:          words(g2.Marshal()),
+		Gamma:       words(v.gamma.Marshal()),
+	}
+	for _, p := range v.ic {
+		data.IC = append(data.IC, words(p.Marshal()))
+	}
+	if v.system == zsl.ProvingSystem_GROTH16 {
+		p := &parser{}
+		alpha, beta := p.g1(vk.Alpha), p.g2(vk.Beta)
+		if p.err != nil {
+			return nil, fmt.Errorf("invalid verifying key alpha and beta: %v", p.err)
+		}
+		if !bytes.Equal(bn256.Pair(alpha, beta).Marshal(), v.alphaBeta.Marshal()) {
+			return nil, errors.New("invalid verifying key: e(alpha, beta) doesn't match alphaBeta")
+		}
+		data.Groth16 = true
+		data.Alpha = words(alpha.Marshal())
+		data.Beta = words(beta.Marshal())
+		data.Delta = words(v.delta.Marshal())
+	} else {
+		data.AlphaA = words(v.alphaA.Marshal())
+		data.AlphaB = words(v.alphaB.Marshal())
+		data.AlphaC = words(v.alphaC.Marshal())
+		data.GammaBeta1 = words(v.gammaBeta1.Marshal())
+		data.GammaBeta2 = words(v.gammaBeta2.Marshal())
+		data.RCZ = words(v.rCZ.Marshal())
+	}
+
+	var buf bytes.Buffer
+	if err := solidityTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// solidityData fills solidityTemplate; points are 0x prefixed hex words, in EIP-197 order
+// (G2: x.c1, x.c0, y.c1, y.c0), which is also the bn256 marshaling order
+type solidityData struct {
+	Contract, Circuit, System, Fingerprint, Signature string
+	ProofSize, NbInputs                               int
+	Groth16                                           bool
+
+	G2, Gamma []string
+	IC        [][]string
+
+	// PPZKSNARK
+	AlphaA, AlphaB, AlphaC, GammaBeta1, GammaBeta2, RCZ []string
+
+	// GROTH16
+	Alpha, Beta, Delta []string
+}
+
+// words splits a bn256 marshaled point in 32 bytes words
+func words(m []byte) []string {
+	var w []string
+	for i := 0; i < len(m); i += fpSize {
+		w = append(w, "0x"+hex.EncodeToString(m[i:i+fpSize]))
+	}
+	return w
+}
+
+var solidityTemplate = template.Must(template.New("verifier").Funcs(template.FuncMap{
+	"join": func(w []string) string { return "uint256(" + strings.Join(w, "), uint256(") + ")" },
+	"last": func(i int, a [][]string) bool { return i == len(a)-1 },
+}).Parse(`// Code generated by zslsolidity. DO NOT EDIT.
+//
+// Verifier of the ZSL {{.Circuit}} circuit proofs ({{.System}}){{if .Fingerprint}}, verifying key {{.Fingerprint}}{{end}}.
+// Proofs and public inputs are encoded by the ZSLBox verifier package (see verifier.Calldata).
+
+pragma solidity ^0.4.24;
+
+contract {{.Contract}} {
+    // alt_bn128 base field and scalar field moduli
+    uint256 constant FIELD_MODULUS = 0x30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47;
+    uint256 constant SCALAR_MODULUS = 0x30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000001;
+
+    // {{.Signature}}
+    // Returns true if proof is valid for the packed public inputs. Reverts if a point isn't on the curve.
+    function verifyProof(uint256[{{.ProofSize}}] proof, uint256[{{.NbInputs}}] input) public view returns (bool) {
+        uint256 i;
+        for (i = 0; i < proof.length; i++) {
+            if (proof[i] >= FIELD_MODULUS) {
+                return false;
+            }
+        }
+        // acc = ic(0) + sum input[i] ic(i + 1)
+        uint256[2] memory acc = ic(0);
+        for (i = 0; i < input.length; i++) {
+            if (input[i] >= SCALAR_MODULUS) {
+                return false;
+            }
+            acc = ecAdd(acc, ecMul(ic(i + 1), input[i]));
+        }
+{{- if .Groth16}}
+
+        uint256[] memory p = new uint256[](24);
+        // e(A, B) = e(alpha, beta) e(acc, gamma) e(C, delta)
+        setPair(p, 0, [proof[0], proof[1]], [proof[2], proof[3], proof[4], proof[5]]);
+        setPair(p, 1, negate(acc), gamma());
+        setPair(p, 2, negate([proof[6], proof[7]]), delta());
+        setPair(p, 3, negate(alpha()), beta());
+        return pairing(p);
+    }
+{{- else}}
+
+        uint256[2] memory a = [proof[0], proof[1]];
+        uint256[4] memory b = [proof[4], proof[5], proof[6], proof[7]];
+        uint256[2] memory c = [proof[10], proof[11]];
+        uint256[2] memory accA = ecAdd(a, acc);
+
+        // knowledge commitments: e(A, alphaA) = e(A', g2), e(alphaB, B) = e(B', g2), e(C, alphaC) = e(C', g2)
+        uint256[] memory p = new uint256[](12);
+        setPair(p, 0, a, alphaA());
+        setPair(p, 1, negate([proof[2], proof[3]]), g2());
+        if (!pairing(p)) {
+            return false;
+        }
+        setPair(p, 0, alphaB(), b);
+        setPair(p, 1, negate([proof[8], proof[9]]), g2());
+        if (!pairing(p)) {
+            return false;
+        }
+        setPair(p, 0, c, alphaC());
+        setPair(p, 1, negate([proof[12], proof[13]]), g2());
+        if (!pairing(p)) {
+            return false;
+        }
+
+        // QAP divisibility: e(A + acc, B) = e(H, rC Z) e(C, g2)
+        p = new uint256[](18);
+        setPair(p, 0, accA, b);
+        setPair(p, 1, negate([proof[14], proof[15]]), rCZ());
+        setPair(p, 2, negate(c), g2());
+        if (!pairing(p)) {
+            return false;
+        }
+
+        // same coefficients: e(K, gamma) = e(A + acc + C, gamma beta) e(gamma beta, B)
+        setPair(p, 0, [proof[16], proof[17]], gamma());
+        setPair(p, 1, negate(ecAdd(accA, c)), gammaBeta2());
+        setPair(p, 2, negate(gammaBeta1()), b);
+        return pairing(p);
+    }
+{{- end}}
+
+    // verifying key
+
+    function ic(uint256 i) internal pure returns (uint256[2]) {
+{{- range $i, $p := .IC}}
+        {{if not (last $i $.IC)}}if (i == {{$i}}) {{end}}return [{{join $p}}];
+{{- end}}
+    }
+
+    function g2() internal pure returns (uint256[4]) {
+        return [{{join .G2}}];
+    }
+
+    function gamma() internal pure returns (uint256[4]) {
+        return [{{join .Gamma}}];
+    }
+{{- if .Groth16}}
+
+    function alpha() internal pure returns (uint256[2]) {
+        return [{{join .Alpha}}];
+    }
+
+    function beta() internal pure returns (uint256[4]) {
+        return [{{join .Beta}}];
+    }
+
+    function delta() internal pure returns (uint256[4]) {
+        return [{{join .Delta}}];
+    }
+{{- else}}
+
+    function alphaA() internal pure returns (uint256[4]) {
+        return [{{join .AlphaA}}];
+    }
+
+    function alphaB() internal pure returns (uint256[2]) {
+        return [{{join .AlphaB}}];
+    }
+
+    function alphaC() internal pure returns (uint256[4]) {
+        return [{{join .AlphaC}}];
+    }
+
+    function gammaBeta1() internal pure returns (uint256[2]) {
+        return [{{join .GammaBeta1}}];
+    }
+
+    function gammaBeta2() internal pure returns (uint256[4]) {
+        return [{{join .GammaBeta2}}];
+    }
+
+    function rCZ() internal pure returns (uint256[4]) {
+        return [{{join .RCZ}}];
+    }
+{{- end}}
+
+    // alt_bn128 precompiles
+
+    function negate(uint256[2] p) internal pure returns (uint256[2]) {
+        if (p[0] == 0 && p[1] == 0) {
+            return p;
+        }
+        return [p[0], FIELD_MODULUS - p[1]];
+    }
+
+    function ecAdd(uint256[2] p, uint256[2] q) internal view returns (uint256[2] r) {
+        uint256[4] memory input = [p[0], p[1], q[0], q[1]];
+        bool success;
+        assembly {
+            success := staticcall(gas, 0x06, input, 0x80, r, 0x40)
+        }
+        require(success, "ecAdd failed");
+    }
+
+    function ecMul(uint256[2] p, uint256 s) internal view returns (uint256[2] r) {
+        uint256[3] memory input = [p[0], p[1], s];
+        bool success;
+        assembly {
+            success := staticcall(gas, 0x07, input, 0x60, r, 0x40)
+        }
+        require(success, "ecMul failed");
+    }
+
+    // setPair sets the k-th (G1, G2) pair of a pairing check input
+    function setPair(uint256[] p, uint256 k, uint256[2] a, uint256[4] b) internal pure {
+        p[6 * k] = a[0];
+        p[6 * k + 1] = a[1];
+        for (uint256 i = 0; i < 4; i++) {
+            p[6 * k + 2 + i] = b[i];
+        }
+    }
+
+    // pairing returns true if the product of the pairings of the pairs of p is 1
+    function pairing(uint256[] p) internal view returns (bool) {
+        uint256[1] memory out;
+        bool success;
+        assembly {
+            success := staticcall(gas, 0x08, add(p, 0x20), mul(mload(p), 0x20), out, 0x20)
+        }
+        require(success, "pairing failed");
+        return out[0] == 1;
+    }
+}
+`))
@@ -0,0 +1,179 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// No Solidity compiler or EVM is vendored: the tests check the generated source and the calldata layout,
+// and the calldata is decoded back as the contract reads it, then verified with the Go verifier.
+
+func TestSolidity(t *testing.T) {
+	for _, system := range []zsl.ProvingSystem{zsl.ProvingSystem_PPZKSNARK, zsl.ProvingSystem_GROTH16} {
+		td := newTrapdoor(t, zsl.Circuit_TRANSFER, system)
+		td.vk.Fingerprint = "f1f2"
+		source, err := Solidity(td.vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(source)
+		for _, expected := range []string{
+			"contract TransferVerifier {",
+			"function verifyProof(uint256[" + map[zsl.ProvingSystem]string{zsl.ProvingSystem_PPZKSNARK: "18", zsl.ProvingSystem_GROTH16: "8"}[system] + "] proof, uint256[9] input)",
+			"verifying key f1f2",
+			"if (i == 8) return [uint256(" + td.vk.Ic[8].X + "), uint256(" + td.vk.Ic[8].Y + ")];",
+			"        return [uint256(" + td.vk.Ic[9].X + "), uint256(" + td.vk.Ic[9].Y + ")];",
+			// G2 points are x.c1, x.c0, y.c1, y.c0
+			"return [uint256(" + td.vk.Gamma.X[1] + "), uint256(" + td.vk.Gamma.X[0] + "), uint256(" + td.vk.Gamma.Y[1] + "), uint256(" + td.vk.Gamma.Y[0] + ")];",
+		} {
+			if !strings.Contains(s, expected) {
+				t.Fatalf("%s verifier doesn't contain %q:\n%s", system, expected, s)
+			}
+		}
+		if strings.Contains(s, "<no value>") || strings.Contains(s, "if (i == 9)") {
+			t.Fatalf("%s verifier is malformed:\n%s", system, s)
+		}
+	}
+
+	// groth16 keys need alpha and beta, matching alphaBeta
+	td := newTrapdoor(t, zsl.Circuit_SHIELDING, zsl.ProvingSystem_GROTH16)
+	td.vk.Alpha = g1Point(g1(randomScalar(t)))
+	if _, err := Solidity(td.vk); err == nil {
+		t.Fatal("expected error on alpha not matching alphaBeta")
+	}
+	td.vk.Alpha = nil
+	if _, err := Solidity(td.vk); err == nil {
+		t.Fatal("expected error on missing alpha")
+	}
+	if ContractName(zsl.Circuit_UNSHIELDING) != "UnshieldingVerifier" {
+		t.Fatal("unexpected contract name", ContractName(zsl.Circuit_UNSHIELDING))
+	}
+}
+
+// decodeCalldata splits calldata in its selector, G1 and G2 points and inputs, as the Solidity verifier does
+func decodeCalldata(t *testing.T, calldata []byte, system zsl.ProvingSystem, circuit zsl.Circuit) ([]byte, []*big.Int) {
+	nbWords := proofWords(system) + NbPublicInputs(circuit)
+	if len(calldata) != 4+nbWords*fpSize {
+		t.Fatalf("calldata is %d bytes, expected %d", len(calldata), 4+nbWords*fpSize)
+	}
+	if !bytes.Equal(calldata[:4], Selector(system, circuit)) {
+		t.Fatal("unexpected selector")
+	}
+	var inputs []*big.Int
+	for i := 4 + proofWords(system)*fpSize; i < len(calldata); i += fpSize {
+		inputs = append(inputs, new(big.Int).SetBytes(calldata[i:i+fpSize]))
+	}
+	return calldata[4 : 4+proofWords(system)*fpSize], inputs
+}
+
+func TestCalldata(t *testing.T) {
+	// selector of verifyProof(uint256[18],uint256[4])
+	if hex.EncodeToString(Selector(zsl.ProvingSystem_PPZKSNARK, zsl.Circuit_SHIELDING)) != "1be561f8" {
+		t.Fatal("unexpected selector")
+	}
+
+	h := zsl.RandomBytes(zsl.HashSize)
+	for _, system := range []zsl.ProvingSystem{zsl.ProvingSystem_PPZKSNARK, zsl.ProvingSystem_GROTH16} {
+		// shielding
+		td := newTrapdoor(t, zsl.Circuit_SHIELDING, system)
+		v, _ := New(td.vk)
+		inputs, _ := ShieldingInputs(h, h, 42, h)
+		shielding := &zsl.Shielding{Snark: td.prove(t, inputs), SendNullifier: h, Commitment: h, ProvingSystem: system}
+		calldata, err := ShieldingCalldata(shielding, 42, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		words, decoded := decodeCalldata(t, calldata, system, zsl.Circuit_SHIELDING)
+		for i := range inputs {
+			if decoded[i].Cmp(inputs[i]) != 0 {
+				t.Fatal("unexpected input", i)
+			}
+		}
+		// A is the first G1 point, B the first G2 point
+		var a bn256.G1
+		var b bn256.G2
+		if _, err := a.Unmarshal(words[:2*fpSize]); err != nil {
+			t.Fatal(err)
+		}
+		bOffset := 4 * fpSize
+		if system == zsl.ProvingSystem_GROTH16 {
+			bOffset = 2 * fpSize
+		}
+		if _, err := b.Unmarshal(words[bOffset : bOffset+4*fpSize]); err != nil {
+			t.Fatal(err)
+		}
+		var proof []byte
+		if system == zsl.ProvingSystem_GROTH16 {
+			var c bn256.G1
+			if _, err := c.Unmarshal(words[6*fpSize:]); err != nil {
+				t.Fatal(err)
+			}
+			proof = (&zsl.Groth16Proof{A: &a, B: &b, C: &c}).Bytes()
+		} else {
+			p, _ := zsl.ParseProof(shielding.Snark)
+			if !bytes.Equal(a.Marshal(), p.A.Marshal()) || !bytes.Equal(b.Marshal(), p.B.Marshal()) || !bytes.Equal(words[16*fpSize:], p.K.Marshal()) {
+				t.Fatal("unexpected proof points")
+			}
+			proof = shielding.Snark
+		}
+		if !v.Verify(proof, decoded) {
+			t.Fatal("couldn't verify decoded calldata", system)
+		}
+
+		// transfer
+		td = newTrapdoor(t, zsl.Circuit_TRANSFER, system)
+		inputs, _ = TransferInputs(h, h, h, h, h, h, h, h)
+		transfer := &zsl.ShieldedTransfer{
+			Snark:           td.prove(t, inputs),
+			SpendNullifiers: [][]byte{h, h},
+			SendNullifiers:  [][]byte{h, h},
+			Commitments:     [][]byte{h, h},
+			ProvingSystem:   system,
+		}
+		calldata, err = TransferCalldata(transfer, h, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodeCalldata(t, calldata, system, zsl.Circuit_TRANSFER)
+		transfer.Commitments = transfer.Commitments[1:]
+		if _, err := TransferCalldata(transfer, h, h); err != ErrInvalidInputSize {
+			t.Fatal("expected ErrInvalidInputSize, got", err)
+		}
+	}
+
+	// unshielding: malformed proof and wrong proving system
+	unshielding := &zsl.Unshielding{Snark: zsl.RandomBytes(zsl.ProofSize), SpendNullifier: h}
+	if _, err := UnshieldingCalldata(unshielding, h, 1, h, h); err == nil {
+		t.Fatal("expected error on malformed proof")
+	}
+	td := newTrapdoor(t, zsl.Circuit_UNSHIELDING, zsl.ProvingSystem_GROTH16)
+	inputs, _ := UnshieldingInputs(h, h, 1, h, h)
+	unshielding.Snark = td.prove(t, inputs)
+	if _, err := UnshieldingCalldata(unshielding, h, 1, h, h); err == nil {
+		t.Fatal("expected error on groth16 proof with ppzksnark proving system")
+	}
+	unshielding.ProvingSystem = zsl.ProvingSystem_GROTH16
+	if _, err := UnshieldingCalldata(unshielding, h, 1, h, h); err != nil {
+		t.Fatal(err)
+	}
+}
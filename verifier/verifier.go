@@ -0,0 +1,266 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verifier verifies the proofs of the ZSL circuits in pure Go, without libsnark, from the
+// verifying keys exported by ZSLBox (see GetVerifyingKey). It checks the same equations as the libsnark
+// verifiers of ZSLBox (r1cs_ppzksnark_verifier_strong_IC, or r1cs_gg_ppzksnark_verifier_strong_IC for
+// Groth16 keys), on the public inputs packed as the circuits do.
+package verifier
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/zslbox/zsl"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+const fpSize = 32
+
+// fieldModulus is the modulus of the alt_bn128 base field
+var fieldModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+
+// Verifier verifies the proofs of a circuit with its verifying key
+type Verifier struct {
+	circuit zsl.Circuit
+	system  zsl.ProvingSystem
+
+	// ic is the input consistency query (gamma_ABC_g1 for Groth16)
+	ic    []*bn256.G1
+	gamma *bn256.G2
+
+	// PPZKSNARK
+	alphaA     *bn256.G2
+	alphaB     *bn256.G1
+	alphaC     *bn256.G2
+	gammaBeta1 *bn256.G1
+	gammaBeta2 *bn256.G2
+	rCZ        *bn256.G2
+
+	// GROTH16
+	alphaBeta *bn256.GT
+	delta     *bn256.G2
+}
+
+// New returns a Verifier of the proofs of vk.Circuit, from its points (vk.Raw is ignored)
+func New(vk *zsl.VerifyingKey) (*Verifier, error) {
+	if expected := NbPublicInputs(vk.Circuit) + 1; expected == 1 || len(vk.Ic) != expected {
+		return nil, fmt.Errorf("invalid verifying key: %s circuit expects %d ic points, got %d", vk.Circuit, expected, len(vk.Ic))
+	}
+	v := &Verifier{circuit: vk.Circuit, system: vk.ProvingSystem}
+	p := &parser{}
+	for _, point := range vk.Ic {
+		v.ic = append(v.ic, p.g1(point))
+	}
+	v.gamma = p.g2(vk.Gamma)
+	switch vk.ProvingSystem {
+	case zsl.ProvingSystem_PPZKSNARK:
+		v.alphaA = p.g2(vk.AlphaA)
+		v.alphaB = p.g1(vk.AlphaB)
+		v.alphaC = p.g2(vk.AlphaC)
+		v.gammaBeta1 = p.g1(vk.GammaBeta1)
+		v.gammaBeta2 = p.g2(vk.GammaBeta2)
+		v.rCZ = p.g2(vk.RCZ)
+	case zsl.ProvingSystem_GROTH16:
+		v.alphaBeta = p.gt(vk.AlphaBeta)
+		v.delta = p.g2(vk.Delta)
+	default:
+		return nil, fmt.Errorf("unknown proving system %s", vk.ProvingSystem)
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("invalid verifying key: %v", p.err)
+	}
+	return v, nil
+}
+
+// Circuit returns the circuit of the verifying key
+func (v *Verifier) Circuit() zsl.Circuit {
+	return v.circuit
+}
+
+// ProvingSystem returns the proving system of the verifying key
+func (v *Verifier) ProvingSystem() zsl.ProvingSystem {
+	return v.system
+}
+
+// VerifyShielding returns true if proof is a valid shielding proof for the public inputs
+func (v *Verifier) VerifyShielding(proof []byte, sendNullifier []byte, commitment []byte, value uint64, asset []byte) bool {
+	inputs, err := ShieldingInputs(sendNullifier, commitment, value, asset)
+	return err == nil && v.circuit == zsl.Circuit_SHIELDING && v.Verify(proof, inputs)
+}
+
+// VerifyUnshielding returns true if proof is a valid unshielding proof for the public inputs
+func (v *Verifier) VerifyUnshielding(proof []byte, spendNullifier []byte, treeRoot []byte, value uint64, asset []byte, binding []byte) bool {
+	inputs, err := UnshieldingInputs(spendNullifier, treeRoot, value, asset, binding)
+	return err == nil && v.circuit == zsl.Circuit_UNSHIELDING && v.Verify(proof, inputs)
+}
+
+// VerifyTransfer returns true if proof is a valid transfer proof for the public inputs
+func (v *Verifier) VerifyTransfer(proof []byte,
+	treeRoot []byte,
+	spendNullifier1 []byte,
+	spendNullifier2 []byte,
+	sendNullifier1 []byte,
+	sendNullifier2 []byte,
+	commitment1 []byte,
+	commitment2 []byte,
+	binding []byte) bool {
+	inputs, err := TransferInputs(treeRoot, spendNullifier1, spendNullifier2, sendNullifier1, sendNullifier2, commitment1, commitment2, binding)
+	return err == nil && v.circuit == zsl.Circuit_TRANSFER && v.Verify(proof, inputs)
+}
+
+// VerifyTransferN returns true if proof is a valid proof of the shielded transfer circuit of the verifying key
+// for the public inputs, of len(spendNullifiers) inputs and len(commitments) outputs, with public values (and
+// asset) unless vpubIn, vpubOut and fee are 0
+func (v *Verifier) VerifyTransferN(proof []byte,
+	treeRoot []byte,
+	spendNullifiers [][]byte,
+	sendNullifiers [][]byte,
+	commitments [][]byte,
+	vpubIn uint64,
+	vpubOut uint64,
+	fee uint64,
+	asset []byte,
+	binding []byte) bool {
+	circuit, ok := transferCircuit(spendNullifiers, sendNullifiers, commitments, vpubIn, vpubOut, fee)
+	if !ok || v.circuit != circuit {
+		return false
+	}
+	inputs, err := TransferNInputs(treeRoot, spendNullifiers, sendNullifiers, commitments, vpubIn, vpubOut, fee, asset, binding)
+	return err == nil && v.Verify(proof, inputs)
+}
+
+// Verify returns true if proof (libsnark serialized) is a valid proof for the packed public inputs (see
+// ShieldingInputs, UnshieldingInputs, TransferInputs and TransferNInputs)
+func (v *Verifier) Verify(proof []byte, inputs []*big.Int) bool {
+	if len(inputs)+1 != len(v.ic) {
+		return false
+	}
+	// acc = ic[0] + sum inputs[i] ic[i+1]
+	acc := new(bn256.G1).Set(v.ic[0])
+	for i, input := range inputs {
+		if input.Sign() < 0 || input.Cmp(bn256.Order) >= 0 {
+			return false
+		}
+		acc.Add(acc, new(bn256.G1).ScalarMult(v.ic[i+1], input))
+	}
+
+	if v.system == zsl.ProvingSystem_GROTH16 {
+		p, err := zsl.ParseGroth16Proof(proof)
+		if err != nil {
+			return false
+		}
+		// e(A, B) = e(alpha, beta) e(acc, gamma) e(C, delta)
+		e := bn256.Miller(p.A, p.B)
+		e.Add(e, bn256.Miller(new(bn256.G1).Neg(acc), v.gamma))
+		e.Add(e, bn256.Miller(new(bn256.G1).Neg(p.C), v.delta))
+		return bytes.Equal(e.Finalize().Marshal(), v.alphaBeta.Marshal())
+	}
+
+	p, err := zsl.ParseProof(proof)
+	if err != nil {
+		return false
+	}
+	g2 := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	neg := func(p *bn256.G1) *bn256.G1 { return new(bn256.G1).Neg(p) }
+	accA := new(bn256.G1).Add(p.A, acc)
+
+	// knowledge commitments: e(A, alphaA) = e(A', g2), e(alphaB, B) = e(B', g2), e(C, alphaC) = e(C', g2)
+	if !bn256.PairingCheck([]*bn256.G1{p.A, neg(p.APrime)}, []*bn256.G2{v.alphaA, g2}) ||
+		!bn256.PairingCheck([]*bn256.G1{v.alphaB, neg(p.BPrime)}, []*bn256.G2{p.B, g2}) ||
+		!bn256.PairingCheck([]*bn256.G1{p.C, neg(p.CPrime)}, []*bn256.G2{v.alphaC, g2}) {
+		return false
+	}
+	// QAP divisibility: e(A + acc, B) = e(H, rC Z) e(C, g2)
+	if !bn256.PairingCheck([]*bn256.G1{accA, neg(p.H), neg(p.C)}, []*bn256.G2{p.B, v.rCZ, g2}) {
+		return false
+	}
+	// same coefficients: e(K, gamma) = e(A + acc + C, gamma beta) e(gamma beta, B)
+	return bn256.PairingCheck([]*bn256.G1{p.K, neg(new(bn256.G1).Add(accA, p.C)), neg(v.gammaBeta1)}, []*bn256.G2{v.gamma, v.gammaBeta2, p.B})
+}
+
+// parser parses the hex encoded points of a verifying key, and keeps the first error
+type parser struct {
+	err error
+}
+
+func (p *parser) fp(s string) []byte {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err == nil && (len(b) > fpSize || new(big.Int).SetBytes(b).Cmp(fieldModulus) >= 0) {
+		err = errors.New("field element out of range")
+	}
+	if err != nil {
+		p.fail(err)
+		return make([]byte, fpSize)
+	}
+	buf := make([]byte, fpSize)
+	copy(buf[fpSize-len(b):], b)
+	return buf
+}
+
+func (p *parser) g1(point *zsl.G1Point) *bn256.G1 {
+	g1 := new(bn256.G1)
+	if point == nil {
+		p.fail(errors.New("missing point"))
+		return g1
+	}
+	if _, err := g1.Unmarshal(append(p.fp(point.X), p.fp(point.Y)...)); err != nil {
+		p.fail(err)
+	}
+	return g1
+}
+
+// g2 converts the coordinates ([c0, c1]) to bn256 marshaling order (c1, c0)
+func (p *parser) g2(point *zsl.G2Point) *bn256.G2 {
+	g2 := new(bn256.G2)
+	if point == nil || len(point.X) != 2 || len(point.Y) != 2 {
+		p.fail(errors.New("missing point"))
+		return g2
+	}
+	var m []byte
+	for _, s := range []string{point.X[1], point.X[0], point.Y[1], point.Y[0]} {
+		m = append(m, p.fp(s)...)
+	}
+	if _, err := g2.Unmarshal(m); err != nil {
+		p.fail(err)
+	}
+	return g2
+}
+
+// gt converts the coefficients, in libff order, to bn256 marshaling order (reversed)
+func (p *parser) gt(coefficients []string) *bn256.GT {
+	gt := new(bn256.GT)
+	if len(coefficients) != 12 {
+		p.fail(errors.New("missing GT element"))
+		return gt
+	}
+	var m []byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		m = append(m, p.fp(coefficients[i])...)
+	}
+	if _, err := gt.Unmarshal(m); err != nil {
+		p.fail(err)
+	}
+	return gt
+}
+
+func (p *parser) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
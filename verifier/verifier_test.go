@@ -0,0 +1,412 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/zslbox/zsl"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// trapdoor is a verifying key generated from known secrets, which can forge proofs for any public inputs.
+// Pure Go tests can't create proofs otherwise; libzsl_test.go in the snark package verifies libsnark proofs.
+type trapdoor struct {
+	vk *zsl.VerifyingKey
+	ic []*big.Int
+
+	// PPZKSNARK
+	alphaA, alphaB, alphaC, beta, gamma, rCZ *big.Int
+
+	// GROTH16
+	alpha, delta *big.Int
+}
+
+func randomScalar(t *testing.T) *big.Int {
+	k, err := rand.Int(rand.Reader, bn256.Order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func g1(k *big.Int) *bn256.G1 {
+	return new(bn256.G1).ScalarBaseMult(k)
+}
+
+func g2(k *big.Int) *bn256.G2 {
+	return new(bn256.G2).ScalarBaseMult(k)
+}
+
+func mul(a ...*big.Int) *big.Int {
+	r := big.NewInt(1)
+	for _, e := range a {
+		r.Mul(r, e).Mod(r, bn256.Order)
+	}
+	return r
+}
+
+func add(a ...*big.Int) *big.Int {
+	r := new(big.Int)
+	for _, e := range a {
+		r.Add(r, e).Mod(r, bn256.Order)
+	}
+	return r
+}
+
+func sub(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	return r.Mod(r, bn256.Order)
+}
+
+func div(a, b *big.Int) *big.Int {
+	return mul(a, new(big.Int).ModInverse(b, bn256.Order))
+}
+
+func hexFp(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+func g1Point(p *bn256.G1) *zsl.G1Point {
+	m := p.Marshal()
+	return &zsl.G1Point{X: hexFp(m[:fpSize]), Y: hexFp(m[fpSize:])}
+}
+
+func g2Point(p *bn256.G2) *zsl.G2Point {
+	m := p.Marshal()
+	return &zsl.G2Point{
+		X: []string{hexFp(m[fpSize : 2*fpSize]), hexFp(m[:fpSize])},
+		Y: []string{hexFp(m[3*fpSize:]), hexFp(m[2*fpSize : 3*fpSize])},
+	}
+}
+
+func newTrapdoor(t *testing.T, circuit zsl.Circuit, system zsl.ProvingSystem) *trapdoor {
+	td := &trapdoor{vk: &zsl.VerifyingKey{Circuit: circuit, ProvingSystem: system}}
+	for i := 0; i <= NbPublicInputs(circuit); i++ {
+		td.ic = append(td.ic, randomScalar(t))
+		td.vk.Ic = append(td.vk.Ic, g1Point(g1(td.ic[i])))
+	}
+	td.gamma = randomScalar(t)
+	td.vk.Gamma = g2Point(g2(td.gamma))
+	if system == zsl.ProvingSystem_GROTH16 {
+		td.alpha, td.beta, td.delta = randomScalar(t), randomScalar(t), randomScalar(t)
+		m := bn256.Pair(g1(td.alpha), g2(td.beta)).Marshal()
+		// libff order is the reverse of bn256 marshaling
+		for i := 11; i >= 0; i-- {
+			td.vk.AlphaBeta = append(td.vk.AlphaBeta, hexFp(m[i*fpSize:(i+1)*fpSize]))
+		}
+		td.vk.Delta = g2Point(g2(td.delta))
+		td.vk.Alpha, td.vk.Beta = g1Point(g1(td.alpha)), g2Point(g2(td.beta))
+		return td
+	}
+	td.alphaA, td.alphaB, td.alphaC, td.beta, td.rCZ = randomScalar(t), randomScalar(t), randomScalar(t), randomScalar(t), randomScalar(t)
+	td.vk.AlphaA = g2Point(g2(td.alphaA))
+	td.vk.AlphaB = g1Point(g1(td.alphaB))
+	td.vk.AlphaC = g2Point(g2(td.alphaC))
+	td.vk.GammaBeta1 = g1Point(g1(mul(td.gamma, td.beta)))
+	td.vk.GammaBeta2 = g2Point(g2(mul(td.gamma, td.beta)))
+	td.vk.RCZ = g2Point(g2(td.rCZ))
+	return td
+}
+
+// prove returns a proof that satisfies the verification equations for inputs
+func (td *trapdoor) prove(t *testing.T, inputs []*big.Int) []byte {
+	// s is the discrete logarithm of acc
+	s := td.ic[0]
+	for i, input := range inputs {
+		s = add(s, mul(input, td.ic[i+1]))
+	}
+	a, b := randomScalar(t), randomScalar(t)
+	if td.vk.ProvingSystem == zsl.ProvingSystem_GROTH16 {
+		// a b = alpha beta + s gamma + c delta
+		c := div(sub(sub(mul(a, b), mul(td.alpha, td.beta)), mul(s, td.gamma)), td.delta)
+		return (&zsl.Groth16Proof{A: g1(a), B: g2(b), C: g1(c)}).Bytes()
+	}
+	c := randomScalar(t)
+	return (&zsl.Proof{
+		A:      g1(a),
+		APrime: g1(mul(td.alphaA, a)),
+		B:      g2(b),
+		BPrime: g1(mul(td.alphaB, b)),
+		C:      g1(c),
+		CPrime: g1(mul(td.alphaC, c)),
+		// (a + s) b = h rCZ + c
+		H: g1(div(sub(mul(add(a, s), b), c), td.rCZ)),
+		// k gamma = (a + s + c) gamma beta + gamma beta b
+		K: g1(mul(td.beta, add(a, s, c, b))),
+	}).Bytes()
+}
+
+func TestPublicInputs(t *testing.T) {
+	if NbPublicInputs(zsl.Circuit_SHIELDING) != 4 || NbPublicInputs(zsl.Circuit_UNSHIELDING) != 5 || NbPublicInputs(zsl.Circuit_TRANSFER) != 9 {
+		t.Fatal("unexpected number of public inputs")
+	}
+
+	// 256 bits set, 256 bits unset, then value 1 (little endian, most significant bit first: bit 7) and the
+	// default asset
+	inputs, err := ShieldingInputs(bytes.Repeat([]byte{0xff}, zsl.HashSize), make([]byte, zsl.HashSize), 1, make([]byte, zsl.HashSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*big.Int{
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), fieldCapacity), big.NewInt(1)),
+		big.NewInt(7),
+		new(big.Int).Lsh(big.NewInt(1), 512+7-2*fieldCapacity),
+		new(big.Int),
+	}
+	if len(inputs) != len(expected) {
+		t.Fatal("unexpected number of inputs", len(inputs))
+	}
+	for i := range inputs {
+		if inputs[i].Cmp(expected[i]) != 0 {
+			t.Fatalf("input %d is %v, expected %v", i, inputs[i], expected[i])
+		}
+	}
+
+	// first bit of each byte
+	h := bytes.Repeat([]byte{0x80}, zsl.HashSize)
+	inputs, err = TransferInputs(h, h, h, h, h, h, h, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != NbPublicInputs(zsl.Circuit_TRANSFER) || inputs[0].Bit(0) != 1 || inputs[0].Bit(1) != 0 || inputs[0].Bit(248) != 1 || inputs[1].Bit(3) != 1 {
+		t.Fatal("unexpected transfer inputs")
+	}
+
+	if _, err := UnshieldingInputs(h[1:], h, 1, h, h); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+	if _, err := ShieldingInputs(h, h, 1, h[1:]); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+	if _, err := UnshieldingInputs(h, h, 1, h, nil); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+
+	// the 2x2 circuit of TransferNInputs is the transfer circuit
+	hashes := func(n int) [][]byte {
+		toReturn := make([][]byte, n)
+		for i := range toReturn {
+			toReturn[i] = h
+		}
+		return toReturn
+	}
+	inputsN, err := TransferNInputs(h, hashes(2), hashes(2), hashes(2), 0, 0, 0, nil, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range inputs {
+		if inputs[i].Cmp(inputsN[i]) != 0 {
+			t.Fatalf("input %d is %v, expected %v", i, inputsN[i], inputs[i])
+		}
+	}
+	for circuit, nbInputs := range map[zsl.Circuit]int{
+		zsl.Circuit_TRANSFER_1X1: 6,
+		zsl.Circuit_TRANSFER_1X2: 8,
+		zsl.Circuit_TRANSFER_4X2: 11,
+		zsl.Circuit_TRANSFER_4X4: 15,
+	} {
+		n, m := circuit.Arity()
+		inputs, err := TransferNInputs(h, hashes(n), hashes(m), hashes(m), 0, 0, 0, nil, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if NbPublicInputs(circuit) != nbInputs || len(inputs) != nbInputs {
+			t.Fatal("unexpected number of public inputs for", circuit)
+		}
+	}
+	for circuit, nbInputs := range map[zsl.Circuit]int{
+		zsl.Circuit_PUBLIC_TRANSFER:     10,
+		zsl.Circuit_PUBLIC_TRANSFER_1X1: 7,
+		zsl.Circuit_PUBLIC_TRANSFER_1X2: 9,
+		zsl.Circuit_PUBLIC_TRANSFER_4X2: 12,
+		zsl.Circuit_PUBLIC_TRANSFER_4X4: 16,
+	} {
+		n, m := circuit.Arity()
+		inputs, err := TransferNInputs(h, hashes(n), hashes(m), hashes(m), 0, 1, 0, h, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if NbPublicInputs(circuit) != nbInputs || len(inputs) != nbInputs {
+			t.Fatal("unexpected number of public inputs for", circuit)
+		}
+	}
+
+	// vpubIn 1 is bit 7*256 + 7 of the 2x2 inputs, vpubOut 1 64 bits further and fee 1 128 bits further, the
+	// first bit of the asset bit 7*256 + 192 and the first bit of the binding bit 7*256 + 448
+	zero := make([]byte, zsl.HashSize)
+	asset := append([]byte{0x80}, zero[1:]...)
+	inputs, err = TransferNInputs(zero, [][]byte{zero, zero}, [][]byte{zero, zero}, [][]byte{zero, zero}, 1, 1, 1, asset, asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected7 := new(big.Int)
+	for _, bit := range []int{7*256 + 7, 7*256 + 64 + 7, 7*256 + 128 + 7, 7*256 + 192} {
+		expected7.SetBit(expected7, bit-7*fieldCapacity, 1)
+	}
+	expected8 := new(big.Int).Lsh(big.NewInt(1), 7*256+448-8*fieldCapacity)
+	if len(inputs) != 10 || inputs[7].Cmp(expected7) != 0 || inputs[8].Cmp(expected8) != 0 || inputs[9].Sign() != 0 {
+		t.Fatal("unexpected public values inputs", inputs)
+	}
+	if _, err := TransferNInputs(zero, [][]byte{zero, zero}, [][]byte{zero, zero}, [][]byte{zero, zero}, 1, 1, 0, nil, zero); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+	if _, err := TransferNInputs(zero, [][]byte{zero, zero}, [][]byte{zero, zero}, [][]byte{zero, zero}, 0, 0, 0, nil, nil); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+
+	if _, err := TransferNInputs(h, hashes(3), hashes(3), hashes(3), 0, 0, 0, nil, h); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+	if _, err := TransferNInputs(h, hashes(1), hashes(1), hashes(2), 0, 0, 0, nil, h); err != ErrInvalidInputSize {
+		t.Fatal("expected ErrInvalidInputSize, got", err)
+	}
+}
+
+func TestPublicInputsRequest(t *testing.T) {
+	h := zsl.RandomBytes(zsl.HashSize)
+	expected, _ := TransferInputs(h, h, h, h, h, h, h, make([]byte, zsl.HashSize))
+	transfer := &zsl.ShieldedTransfer{SpendNullifiers: [][]byte{h, h}, SendNullifiers: [][]byte{h, h}, Commitments: [][]byte{h, h}}
+	inputs, err := PublicInputs(&zsl.PublicInputsRequest{ShieldedTransfer: &zsl.VerifyShieldedTransferRequest{ShieldedTransfer: transfer, TreeRoot: h}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs.Circuit != zsl.Circuit_TRANSFER || len(inputs.Inputs) != len(expected) {
+		t.Fatal("unexpected transfer inputs", inputs)
+	}
+	for i, input := range inputs.Inputs {
+		if input != hexFp(word(expected[i])) {
+			t.Fatalf("input %d is %s, expected %v", i, input, expected[i])
+		}
+	}
+
+	// value 1 is bit 512 + 7 of the shielding inputs
+	inputs, err = PublicInputs(&zsl.PublicInputsRequest{Shielding: &zsl.VerifyShieldingRequest{Shielding: &zsl.Shielding{SendNullifier: make([]byte, zsl.HashSize), Commitment: make([]byte, zsl.HashSize)}, Value: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs.Circuit != zsl.Circuit_SHIELDING || len(inputs.Inputs) != 4 || inputs.Inputs[2] != "0x0000000000000000000000000000000000000000000000000000000000002000" {
+		t.Fatal("unexpected shielding inputs", inputs)
+	}
+
+	// the first bit of the asset is bit 512 + 64 of the unshielding inputs, the first bit of the binding bit
+	// 512 + 64 + 256
+	asset := append([]byte{0x80}, make([]byte, zsl.HashSize-1)...)
+	inputs, err = PublicInputs(&zsl.PublicInputsRequest{Unshielding: &zsl.VerifyUnshieldingRequest{SpendNullifier: make([]byte, zsl.HashSize), TreeRoot: make([]byte, zsl.HashSize), Asset: asset, Binding: asset}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs.Circuit != zsl.Circuit_UNSHIELDING || len(inputs.Inputs) != 5 || inputs.Inputs[2] != "0x0000000000000000000000000000000000000000000000400000000000000000" ||
+		inputs.Inputs[3] != "0x0000000000000000000000000000000000000000000002000000000000000000" {
+		t.Fatal("unexpected unshielding inputs", inputs)
+	}
+
+	for _, request := range []*zsl.PublicInputsRequest{
+		{},
+		{Shielding: &zsl.VerifyShieldingRequest{}},
+		{Shielding: &zsl.VerifyShieldingRequest{Shielding: &zsl.Shielding{}}, Unshielding: &zsl.VerifyUnshieldingRequest{}},
+		{Unshielding: &zsl.VerifyUnshieldingRequest{SpendNullifier: h, TreeRoot: h[1:]}},
+		{Unshielding: &zsl.VerifyUnshieldingRequest{SpendNullifier: h, TreeRoot: h, Asset: h[1:]}},
+		{Unshielding: &zsl.VerifyUnshieldingRequest{SpendNullifier: h, TreeRoot: h, Binding: h[1:]}},
+		{ShieldedTransfer: &zsl.VerifyShieldedTransferRequest{ShieldedTransfer: &zsl.ShieldedTransfer{}, TreeRoot: h}},
+	} {
+		if _, err := PublicInputs(request); err == nil {
+			t.Fatal("expected error on invalid request", request)
+		}
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	for _, system := range []zsl.ProvingSystem{zsl.ProvingSystem_PPZKSNARK, zsl.ProvingSystem_GROTH16} {
+		sendNullifier, commitment, asset := zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize), zsl.RandomBytes(zsl.HashSize)
+		inputs, _ := ShieldingInputs(sendNullifier, commitment, 42, asset)
+
+		td := newTrapdoor(t, zsl.Circuit_SHIELDING, system)
+		v, err := New(td.vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof := td.prove(t, inputs)
+		if !v.VerifyShielding(proof, sendNullifier, commitment, 42, asset) {
+			t.Fatal("couldn't verify shielding proof", system)
+		}
+		if v.VerifyShielding(proof, sendNullifier, commitment, 43, asset) || v.VerifyShielding(proof, sendNullifier, commitment, 42, commitment) || v.VerifyShielding(proof, commitment, sendNullifier, 42, asset) {
+			t.Fatal("shielding proof verified with wrong inputs", system)
+		}
+		if v.VerifyUnshielding(proof, sendNullifier, commitment, 42, asset, asset) {
+			t.Fatal("shielding proof verified as unshielding proof", system)
+		}
+		if v.VerifyShielding(td.prove(t, inputs)[1:], sendNullifier, commitment, 42, asset) || v.VerifyShielding(zsl.RandomBytes(uint(len(proof))), sendNullifier, commitment, 42, asset) {
+			t.Fatal("malformed proof verified", system)
+		}
+
+		// proof of another key
+		other, err := New(newTrapdoor(t, zsl.Circuit_SHIELDING, system).vk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.VerifyShielding(proof, sendNullifier, commitment, 42, asset) {
+			t.Fatal("proof verified with another key", system)
+		}
+	}
+
+	// each ppzksnark equation is checked
+	td := newTrapdoor(t, zsl.Circuit_TRANSFER, zsl.ProvingSystem_PPZKSNARK)
+	v, err := New(td.vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := zsl.RandomBytes(zsl.HashSize)
+	inputs, _ := TransferInputs(h, h, h, h, h, h, h, h)
+	proof, _ := zsl.ParseProof(td.prove(t, inputs))
+	if !v.VerifyTransfer(proof.Bytes(), h, h, h, h, h, h, h, h) {
+		t.Fatal("couldn't verify transfer proof")
+	}
+	for i, p := range []**bn256.G1{&proof.APrime, &proof.BPrime, &proof.CPrime, &proof.H, &proof.K} {
+		original := *p
+		*p = new(bn256.G1).Add(original, g1(big.NewInt(1)))
+		if v.Verify(proof.Bytes(), inputs) {
+			t.Fatal("proof verified with tampered point", i)
+		}
+		*p = original
+	}
+}
+
+func TestInvalidVerifyingKey(t *testing.T) {
+	td := newTrapdoor(t, zsl.Circuit_UNSHIELDING, zsl.ProvingSystem_PPZKSNARK)
+	td.vk.Circuit = zsl.Circuit_TRANSFER
+	if _, err := New(td.vk); err == nil {
+		t.Fatal("expected error on wrong number of ic points")
+	}
+	td.vk.Circuit = zsl.Circuit_UNSHIELDING
+	td.vk.AlphaB = &zsl.G1Point{X: td.vk.AlphaB.X, Y: td.vk.AlphaB.X}
+	if _, err := New(td.vk); err == nil {
+		t.Fatal("expected error on point not on curve")
+	}
+	td.vk.AlphaB = nil
+	if _, err := New(td.vk); err == nil {
+		t.Fatal("expected error on missing point")
+	}
+
+	td = newTrapdoor(t, zsl.Circuit_UNSHIELDING, zsl.ProvingSystem_GROTH16)
+	td.vk.AlphaBeta = td.vk.AlphaBeta[1:]
+	if _, err := New(td.vk); err == nil {
+		t.Fatal("expected error on missing alphaBeta")
+	}
+}
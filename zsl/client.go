@@ -26,6 +26,12 @@ import (
 type Client struct {
 	conn   *grpc.ClientConn
 	ZSLBox ZSLBoxClient
+	// KeySetAdmin is only served on the admin address of servers started with -admin (-admin_addr)
+	KeySetAdmin KeySetAdminClient
+	// NullifierRegistry is only served by servers started with -nullifier_store
+	NullifierRegistry NullifierRegistryClient
+	// CommitmentTree is only served by servers started with -commitment_tree
+	CommitmentTree CommitmentTreeClient
 }
 
 // NewClient connects to a gRPC endpoint (ZSLBox) and return the gRPC connection and ZSLBox service
@@ -44,6 +50,9 @@ func NewClient(serverAddr string) (*Client, error) {
 		return nil, err
 	}
 	toReturn.ZSLBox = NewZSLBoxClient(toReturn.conn)
+	toReturn.KeySetAdmin = NewKeySetAdminClient(toReturn.conn)
+	toReturn.NullifierRegistry = NewNullifierRegistryClient(toReturn.conn)
+	toReturn.CommitmentTree = NewCommitmentTreeClient(toReturn.conn)
 
 	return toReturn, nil
 }
@@ -15,19 +15,28 @@
 package zsl
 
 import (
+	"bytes"
 	"math/rand"
 	"os"
 	"testing"
 
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-var zslboxURL = "localhost:9000"
+var (
+	zslboxURL      = "localhost:9000"
+	zslboxAdminURL = "localhost:9002"
+)
 
 func init() {
 	if userURL := os.Getenv("ZSLBOX_URL"); userURL != "" {
 		zslboxURL = userURL
 	}
+	if adminURL := os.Getenv("ZSLBOX_ADMIN_URL"); adminURL != "" {
+		zslboxAdminURL = adminURL
+	}
 }
 
 func TestShieldedTransfer(t *testing.T) {
@@ -120,6 +129,9 @@ func TestShieldedTransfer(t *testing.T) {
 	if len(shielded.Snark) != ProofSize {
 		t.Fatalf("proof should be %dbytes", ProofSize)
 	}
+	if _, err := ParseProof(shielded.Snark); err != nil {
+		t.Fatal("malformed proof", err)
+	}
 
 	// Now let's verify our proof.
 	treeRoot := tree.Root()
@@ -134,6 +146,534 @@ func TestShieldedTransfer(t *testing.T) {
 	}
 }
 
+func TestShieldedTransferArities(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one input note of value 42, in the tree
+	address, err := client.ZSLBox.GetNewAddress(context.Background(), &Void{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 42}
+	cmBytes, err := client.ZSLBox.GetCommitment(context.Background(), note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := NewTree(TreeDepth)
+	cm := NewHash(cmBytes.Bytes)
+	if _, err = tree.AddCommitment(cm); err != nil {
+		t.Fatal(err)
+	}
+	treeIndex, treePath, err := tree.GetWitnesses(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := &ShieldedInput{Sk: address.Sk, Rho: note.Rho, Value: note.Value, TreeIndex: uint64(treeIndex), TreePath: treePath}
+	treeRoot := tree.Root()
+
+	// 1x1: no empty note needed, 1x2: change
+	for _, outputs := range [][]*Note{
+		{{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 42}},
+		{{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 40}, {Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 2}},
+	} {
+		shielded, err := client.ZSLBox.CreateShieldedTransfer(context.Background(),
+			&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: outputs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shielded.SpendNullifiers) != 1 || len(shielded.SendNullifiers) != len(outputs) || len(shielded.Commitments) != len(outputs) {
+			t.Fatal("unexpected number of nullifiers and commitments", shielded)
+		}
+		verifyResult, err := client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifyResult.Result {
+			t.Fatalf("1x%d shielded transfer proof should verify", len(outputs))
+		}
+
+		// the public inputs are those of the 1xM circuit
+		publicInputs, err := client.ZSLBox.GetPublicInputs(context.Background(),
+			&PublicInputsRequest{ShieldedTransfer: &VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:]}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if circuit, _ := TransferCircuit(1, len(outputs), false); publicInputs.Circuit != circuit {
+			t.Fatalf("expected circuit %s, got %s", circuit, publicInputs.Circuit)
+		}
+
+		// a proof doesn't verify with the nullifiers of another arity
+		shielded.SpendNullifiers = append(shielded.SpendNullifiers, shielded.SpendNullifiers[0])
+		verifyResult, err = client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:]})
+		if err == nil && verifyResult.Result {
+			t.Fatal("shielded transfer proof verified with an extra spend nullifier")
+		}
+	}
+
+	// unshield 2 of the 42, keep the change shielded
+	change := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 40}
+	shielded, err := client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{change}, VpubOut: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyResult, err := client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+		&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], VpubOut: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyResult.Result {
+		t.Fatal("shielded transfer proof with public values should verify")
+	}
+	for _, vpub := range [][2]uint64{{0, 0}, {0, 3}, {2, 0}} {
+		verifyResult, err = client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], VpubIn: vpub[0], VpubOut: vpub[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyResult.Result {
+			t.Fatal("shielded transfer proof verified with wrong public values", vpub)
+		}
+	}
+	_, err = client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{change}, VpubOut: 3})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument for an unbalanced transfer, got", err)
+	}
+
+	// pay a fee of 1 to the relayer of the transfer, in the same proof
+	change = &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 41}
+	shielded, err = client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{change}, Fee: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyResult, err = client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+		&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], Fee: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyResult.Result {
+		t.Fatal("shielded transfer proof with fee should verify")
+	}
+	for _, request := range []*VerifyShieldedTransferRequest{
+		{ShieldedTransfer: shielded, TreeRoot: treeRoot[:]},
+		{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], Fee: 2},
+		{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], VpubOut: 1},
+	} {
+		verifyResult, err = client.ZSLBox.VerifyShieldedTransfer(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyResult.Result {
+			t.Fatal("shielded transfer proof verified with wrong fee", request.Fee)
+		}
+	}
+	_, err = client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{change}, Fee: 2})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument for an unbalanced transfer, got", err)
+	}
+
+	// there is no 3x3 circuit
+	_, err = client.ZSLBox.CreateShieldedTransfer(context.Background(), &ShieldedTransferRequest{
+		Inputs:  []*ShieldedInput{input, input, input},
+		Outputs: []*Note{note, note, note},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument, got", err)
+	}
+}
+
+func TestMultiAsset(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := client.ZSLBox.GetNewAddress(context.Background(), &Void{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the asset is in the commitment, empty is the default asset
+	asset := RandomBytes(HashSize)
+	note := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 42, Asset: asset}
+	cmBytes, err := client.ZSLBox.GetCommitment(context.Background(), note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, other := range [][]byte{nil, make([]byte, HashSize), RandomBytes(HashSize)} {
+		otherCm, err := client.ZSLBox.GetCommitment(context.Background(), &Note{Pk: note.Pk, Rho: note.Rho, Value: note.Value, Asset: other})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(otherCm.Bytes, cmBytes.Bytes) {
+			t.Fatal("notes of different assets have the same commitment")
+		}
+	}
+
+	// shield the note
+	shielding, err := client.ZSLBox.CreateShielding(context.Background(), note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(shielding.Commitment, cmBytes.Bytes) {
+		t.Fatal("unexpected shielding commitment")
+	}
+	for _, a := range [][]byte{asset, nil} {
+		verifyResult, err := client.ZSLBox.VerifyShielding(context.Background(), &VerifyShieldingRequest{Shielding: shielding, Value: note.Value, Asset: a})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyResult.Result != (a != nil) {
+			t.Fatal("unexpected shielding verification with asset", a)
+		}
+	}
+
+	// the tree holds notes of both assets
+	defaultNote := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 42}
+	defaultCm, err := client.ZSLBox.GetCommitment(context.Background(), defaultNote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := NewTree(TreeDepth)
+	for _, cm := range [][]byte{defaultCm.Bytes, cmBytes.Bytes} {
+		if _, err := tree.AddCommitment(NewHash(cm)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	treeIndex, treePath, err := tree.GetWitnesses(NewHash(cmBytes.Bytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+	input := &ShieldedInput{Sk: address.Sk, Rho: note.Rho, Value: note.Value, Asset: asset, TreeIndex: uint64(treeIndex), TreePath: treePath}
+
+	// transfer 40 of the asset, unshield 2
+	output := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 40, Asset: asset}
+	shielded, err := client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{output}, VpubOut: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range [][]byte{asset, nil} {
+		verifyResult, err := client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], VpubOut: 2, Asset: a})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyResult.Result != (a != nil) {
+			t.Fatal("unexpected shielded transfer verification with asset", a)
+		}
+	}
+
+	// the notes of a transfer are of the same asset
+	output.Asset = nil
+	_, err = client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{output}, VpubOut: 2})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument for a transfer of mixed assets, got", err)
+	}
+
+	// assets are empty or 32 bytes: a malformed asset would give a commitment no circuit can prove
+	for _, malformed := range [][]byte{asset[1:], append(asset, 0)} {
+		malformedNote := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 1, Asset: malformed}
+		_, err = client.ZSLBox.GetCommitment(context.Background(), malformedNote)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for the commitment of a malformed asset, got", err)
+		}
+		_, err = client.ZSLBox.CreateShielding(context.Background(), malformedNote)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for a malformed asset, got", err)
+		}
+		_, err = client.ZSLBox.VerifyShielding(context.Background(), &VerifyShieldingRequest{Shielding: shielding, Value: note.Value, Asset: malformed})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for the verification of a malformed asset, got", err)
+		}
+		_, err = client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], VpubOut: 2, Asset: malformed})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for the verification of a malformed asset, got", err)
+		}
+		batch, err := client.ZSLBox.VerifyBatch(context.Background(), &VerifyBatchRequest{
+			Shieldings: []*VerifyShieldingRequest{{Shielding: shielding, Value: note.Value, Asset: malformed}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batch.Shieldings[0] {
+			t.Fatal("shielding of a malformed asset is valid in a batch")
+		}
+	}
+}
+
+func TestProofBinding(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := client.ZSLBox.GetNewAddress(context.Background(), &Void{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 42}
+	cmBytes, err := client.ZSLBox.GetCommitment(context.Background(), note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := NewTree(TreeDepth)
+	if _, err := tree.AddCommitment(NewHash(cmBytes.Bytes)); err != nil {
+		t.Fatal(err)
+	}
+	treeIndex, treePath, err := tree.GetWitnesses(NewHash(cmBytes.Bytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeRoot := tree.Root()
+
+	// an unshielding proof only verifies with its binding (ex: the recipient), empty is 32 zero bytes
+	binding := RandomBytes(HashSize)
+	input := &ShieldedInput{Sk: address.Sk, Rho: note.Rho, Value: note.Value, TreeIndex: uint64(treeIndex), TreePath: treePath, Binding: binding}
+	unshielding, err := client.ZSLBox.CreateUnshielding(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range [][]byte{binding, nil, RandomBytes(HashSize)} {
+		verifyResult, err := client.ZSLBox.VerifyUnshielding(context.Background(), &VerifyUnshieldingRequest{
+			Snark:          unshielding.Snark,
+			SpendNullifier: unshielding.SpendNullifier,
+			TreeRoot:       treeRoot[:],
+			Value:          note.Value,
+			ProvingSystem:  unshielding.ProvingSystem,
+			KeyId:          unshielding.KeyId,
+			Binding:        b,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyResult.Result != bytes.Equal(b, binding) {
+			t.Fatal("unexpected unshielding verification with binding", b)
+		}
+	}
+
+	// so does a shielded transfer proof; the bindings of its inputs are ignored
+	output := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: 42}
+	shielded, err := client.ZSLBox.CreateShieldedTransfer(context.Background(),
+		&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{output}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range [][]byte{nil, binding} {
+		verifyResult, err := client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], Binding: b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verifyResult.Result != (b == nil) {
+			t.Fatal("unexpected shielded transfer verification with binding", b)
+		}
+	}
+
+	// bindings are empty or 32 bytes, rejected before reaching the backend
+	for _, malformed := range [][]byte{binding[1:], append(binding, 0)} {
+		_, err = client.ZSLBox.CreateShieldedTransfer(context.Background(),
+			&ShieldedTransferRequest{Inputs: []*ShieldedInput{input}, Outputs: []*Note{output}, Binding: malformed})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for a malformed binding, got", err)
+		}
+		_, err = client.ZSLBox.CreateUnshielding(context.Background(),
+			&ShieldedInput{Sk: address.Sk, Rho: note.Rho, Value: note.Value, TreeIndex: uint64(treeIndex), TreePath: treePath, Binding: malformed})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for a malformed binding, got", err)
+		}
+		_, err = client.ZSLBox.VerifyUnshielding(context.Background(), &VerifyUnshieldingRequest{
+			Snark:          unshielding.Snark,
+			SpendNullifier: unshielding.SpendNullifier,
+			TreeRoot:       treeRoot[:],
+			Value:          note.Value,
+			ProvingSystem:  unshielding.ProvingSystem,
+			KeyId:          unshielding.KeyId,
+			Binding:        malformed,
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for the verification of a malformed binding, got", err)
+		}
+		_, err = client.ZSLBox.VerifyShieldedTransfer(context.Background(),
+			&VerifyShieldedTransferRequest{ShieldedTransfer: shielded, TreeRoot: treeRoot[:], Binding: malformed})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatal("expected InvalidArgument for the verification of a malformed binding, got", err)
+		}
+	}
+}
+
+func TestNullifierRegistry(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spendNullifier, sendNullifier := RandomBytes(HashSize), RandomBytes(HashSize)
+	nullifiers := &Nullifiers{SpendNullifiers: [][]byte{spendNullifier}, SendNullifiers: [][]byte{sendNullifier}}
+	result, err := client.NullifierRegistry.CheckNullifiers(context.Background(), nullifiers)
+	if status.Code(err) == codes.Unimplemented {
+		t.Skip("the server has no nullifier registry (-nullifier_store)")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Spent) != 1 || len(result.Used) != 1 || result.Spent[0] || result.Used[0] {
+		t.Fatal("unexpected seen nullifiers", result)
+	}
+	result, err = client.NullifierRegistry.CommitNullifiers(context.Background(), nullifiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Committed {
+		t.Fatal("nullifiers should be committed")
+	}
+
+	// a commit is all or none: the send nullifier was seen, the new spend nullifier isn't recorded
+	other := &Nullifiers{SpendNullifiers: [][]byte{RandomBytes(HashSize)}, SendNullifiers: [][]byte{sendNullifier}}
+	result, err = client.NullifierRegistry.CommitNullifiers(context.Background(), other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Committed || result.Spent[0] || !result.Used[0] {
+		t.Fatal("unexpected commit result", result)
+	}
+	result, err = client.NullifierRegistry.CheckNullifiers(context.Background(), other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Spent[0] {
+		t.Fatal("nullifier of a failed commit was recorded")
+	}
+
+	// the kinds of nullifiers are distinct
+	result, err = client.NullifierRegistry.CheckNullifiers(context.Background(), &Nullifiers{SendNullifiers: [][]byte{spendNullifier}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Used[0] {
+		t.Fatal("spend nullifier seen as a send nullifier")
+	}
+
+	_, err = client.NullifierRegistry.CheckNullifiers(context.Background(), &Nullifiers{SpendNullifiers: [][]byte{spendNullifier[1:]}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument, got", err)
+	}
+}
+
+func TestCommitmentTree(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := client.CommitmentTree.GetRoot(context.Background(), &Void{})
+	if status.Code(err) == codes.Unimplemented {
+		t.Skip("the server has no commitment tree (-commitment_tree)")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// AppendCommitments is only served on the admin address
+	commitments := [][]byte{RandomBytes(HashSize), RandomBytes(HashSize)}
+	_, err = client.CommitmentTree.AppendCommitments(context.Background(), &Commitments{Commitments: commitments})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatal("expected PermissionDenied, got", err)
+	}
+	t.Log("connecting to ", zslboxAdminURL)
+	admin, err := NewClient(zslboxAdminURL)
+	defer admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := admin.CommitmentTree.AppendCommitments(context.Background(), &Commitments{Commitments: commitments})
+	if status.Code(err) == codes.Unavailable {
+		t.Skip("the server has no admin address (-admin)")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Size != before.Size+2 || state.Depth != before.Depth {
+		t.Fatal("unexpected tree state", state)
+	}
+	rootAt, err := client.CommitmentTree.GetRootAt(context.Background(), &RootAtRequest{Size: before.Size})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rootAt.Root, before.Root) {
+		t.Fatal("root at the size before the append doesn't match the root before the append")
+	}
+
+	// the witnesses by commitment and by index lead to the root
+	for _, request := range []*WitnessRequest{{Commitment: commitments[1]}, {TreeIndex: before.Size + 1}} {
+		witness, err := client.CommitmentTree.GetWitness(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if witness.TreeIndex != before.Size+1 || !bytes.Equal(witness.Commitment, commitments[1]) || uint64(len(witness.TreePath)) != state.Depth {
+			t.Fatal("unexpected witness", witness)
+		}
+		node, index := NewHash(witness.Commitment), witness.TreeIndex
+		for _, sibling := range witness.TreePath {
+			if index&1 == 0 {
+				node = shaCompress(node, NewHash(sibling))
+			} else {
+				node = shaCompress(NewHash(sibling), node)
+			}
+			index >>= 1
+		}
+		if !bytes.Equal(node[:], witness.Root) {
+			t.Fatal("tree path doesn't lead to the root")
+		}
+	}
+
+	// an append is all or none
+	_, err = admin.CommitmentTree.AppendCommitments(context.Background(), &Commitments{Commitments: [][]byte{RandomBytes(HashSize), commitments[0]}})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatal("expected AlreadyExists, got", err)
+	}
+	after, err := client.CommitmentTree.GetRoot(context.Background(), &Void{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size != state.Size {
+		t.Fatal("commitments of a failed append were appended")
+	}
+
+	_, err = client.CommitmentTree.GetWitness(context.Background(), &WitnessRequest{Commitment: RandomBytes(HashSize)})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("expected NotFound, got", err)
+	}
+	_, err = client.CommitmentTree.GetRootAt(context.Background(), &RootAtRequest{Size: after.Size + 1})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("expected NotFound, got", err)
+	}
+}
+
 func TestShielding(t *testing.T) {
 	// connect to zsl box
 	t.Log("connecting to ", zslboxURL)
@@ -167,6 +707,9 @@ func TestShielding(t *testing.T) {
 	if len(shielding.Snark) != ProofSize {
 		t.Fatalf("proof should be %dbytes", ProofSize)
 	}
+	if _, err := ParseProof(shielding.Snark); err != nil {
+		t.Fatal("malformed proof", err)
+	}
 
 	// now let's verify our proof.
 	t.Log("verifying shielding")
@@ -282,3 +825,211 @@ func TestRandomVerifyShielding(t *testing.T) {
 	}
 
 }
+
+func TestUnbalancedShieldedTransfer(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty input notes (not checked against the tree), paths of the right size
+	emptyPath := make([][]byte, TreeDepth)
+	for i := 0; i < TreeDepth; i++ {
+		emptyPath[i] = make([]byte, HashSize)
+	}
+	inputs := make([]*ShieldedInput, 2)
+	for i := range inputs {
+		inputs[i] = &ShieldedInput{Sk: RandomBytes(HashSize), Rho: RandomBytes(HashSize), TreePath: emptyPath}
+	}
+
+	// outputs worth more than the inputs
+	outputs := []*Note{
+		{Pk: RandomBytes(HashSize), Rho: RandomBytes(HashSize), Value: 1},
+		{Pk: RandomBytes(HashSize), Rho: RandomBytes(HashSize), Value: 0},
+	}
+
+	_, err = client.ZSLBox.CreateShieldedTransfer(context.Background(), &ShieldedTransferRequest{Inputs: inputs, Outputs: outputs})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument error, got", err)
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shield a few notes; every other verify request has a wrong value
+	batch := &VerifyBatchRequest{}
+	for i := 0; i < 4; i++ {
+		address, err := client.ZSLBox.GetNewAddress(context.Background(), &Void{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		note := &Note{Pk: address.Pk, Rho: RandomBytes(HashSize), Value: uint64(i)}
+		shielding, err := client.ZSLBox.CreateShielding(context.Background(), note)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch.Shieldings = append(batch.Shieldings, &VerifyShieldingRequest{Shielding: shielding, Value: note.Value + uint64(i%2)})
+	}
+
+	// malformed requests are invalid
+	batch.Unshieldings = append(batch.Unshieldings, &VerifyUnshieldingRequest{Snark: RandomBytes(ProofSize - 1)})
+	batch.ShieldedTransfers = append(batch.ShieldedTransfers, &VerifyShieldedTransferRequest{})
+
+	t.Log("verifying batch")
+	result, err := client.ZSLBox.VerifyBatch(context.Background(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Shieldings) != 4 || len(result.Unshieldings) != 1 || len(result.ShieldedTransfers) != 1 {
+		t.Fatal("expected one result per request")
+	}
+	for i, valid := range result.Shieldings {
+		if valid != (i%2 == 0) {
+			t.Fatal("shielding", i, "should be", i%2 == 0)
+		}
+	}
+	if result.Unshieldings[0] || result.ShieldedTransfers[0] {
+		t.Fatal("malformed requests should be invalid")
+	}
+}
+
+func TestGetVerifyingKey(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// public inputs are packed in 4 field elements for shielding and unshielding, 8 for transfer, and
+	// ceil((1 + N + 2M) * 256 / 253) for the other NxM transfers
+	for circuit, nbInputs := range map[Circuit]int{
+		Circuit_SHIELDING:    4,
+		Circuit_UNSHIELDING:  5,
+		Circuit_TRANSFER:     9,
+		Circuit_TRANSFER_1X1: 6,
+		Circuit_TRANSFER_1X2: 8,
+		Circuit_TRANSFER_4X2: 11,
+		Circuit_TRANSFER_4X4: 15,
+
+		// and ceil(((1 + N + 2M) * 256 + 128 + 256) / 253) with public values and asset
+		Circuit_PUBLIC_TRANSFER:     10,
+		Circuit_PUBLIC_TRANSFER_1X1: 7,
+		Circuit_PUBLIC_TRANSFER_1X2: 9,
+		Circuit_PUBLIC_TRANSFER_4X2: 12,
+		Circuit_PUBLIC_TRANSFER_4X4: 16,
+	} {
+		vk, err := client.ZSLBox.GetVerifyingKey(context.Background(), &VerifyingKeyRequest{Circuit: circuit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vk.Raw) == 0 || len(vk.Fingerprint) != 2*HashSize {
+			t.Fatal("expected raw verifying key and its fingerprint")
+		}
+		if vk.TreeDepth != TreeDepth {
+			t.Fatalf("tree depth should be %d, got %d", TreeDepth, vk.TreeDepth)
+		}
+		if len(vk.Ic) != nbInputs+1 {
+			t.Fatalf("%s verifying key should have %d IC elements, got %d", circuit, nbInputs+1, len(vk.Ic))
+		}
+		if len(vk.AlphaA.X) != 2 || len(vk.AlphaB.X) != 66 {
+			t.Fatal("unexpected point encoding")
+		}
+	}
+}
+
+func TestGetPublicInputs(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shielding of value 1: bit 512 + 7 is the 14th bit of the third field element
+	zero := make([]byte, HashSize)
+	inputs, err := client.ZSLBox.GetPublicInputs(context.Background(), &PublicInputsRequest{
+		Shielding: &VerifyShieldingRequest{Shielding: &Shielding{SendNullifier: zero, Commitment: zero}, Value: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs.Circuit != Circuit_SHIELDING || len(inputs.Inputs) != 4 ||
+		inputs.Inputs[0] != "0x0000000000000000000000000000000000000000000000000000000000000000" ||
+		inputs.Inputs[2] != "0x0000000000000000000000000000000000000000000000000000000000002000" {
+		t.Fatal("unexpected shielding public inputs", inputs)
+	}
+
+	h := RandomBytes(HashSize)
+	inputs, err = client.ZSLBox.GetPublicInputs(context.Background(), &PublicInputsRequest{
+		ShieldedTransfer: &VerifyShieldedTransferRequest{
+			ShieldedTransfer: &ShieldedTransfer{SpendNullifiers: [][]byte{h, h}, SendNullifiers: [][]byte{h, h}, Commitments: [][]byte{h, h}},
+			TreeRoot:         h,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs.Circuit != Circuit_TRANSFER || len(inputs.Inputs) != 9 {
+		t.Fatal("unexpected transfer public inputs", inputs)
+	}
+
+	// no operation
+	_, err = client.ZSLBox.GetPublicInputs(context.Background(), &PublicInputsRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected InvalidArgument, got", err)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	// connect to zsl box
+	t.Log("connecting to ", zslboxURL)
+	client, err := NewClient(zslboxURL)
+	defer client.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note := &Note{Pk: RandomBytes(HashSize), Rho: RandomBytes(HashSize), Value: 1}
+	shielding, err := client.ZSLBox.CreateShielding(context.Background(), note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk, err := client.ZSLBox.GetVerifyingKey(context.Background(), &VerifyingKeyRequest{Circuit: Circuit_SHIELDING})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shielding.KeyId) != 2*HashSize || shielding.KeyId != vk.KeyId {
+		t.Fatal("proofs should carry the key ID of the active key set")
+	}
+
+	// the proof is verified with the key set of its key ID
+	verifyResult, err := client.ZSLBox.VerifyShielding(context.Background(), &VerifyShieldingRequest{Shielding: shielding, Value: note.Value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyResult.Result {
+		t.Fatal("couldn't verify proof with its key set")
+	}
+	shielding.KeyId = "unknown"
+	_, err = client.ZSLBox.VerifyShielding(context.Background(), &VerifyShieldingRequest{Shielding: shielding, Value: note.Value})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("expected NotFound, got", err)
+	}
+	_, err = client.ZSLBox.GetVerifyingKey(context.Background(), &VerifyingKeyRequest{Circuit: Circuit_SHIELDING, KeyId: "unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("expected NotFound, got", err)
+	}
+}
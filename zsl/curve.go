@@ -0,0 +1,379 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zsl
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// alt_bn128 points, as libsnark (libff) serializes them in libzsl: an ascii '0' or '1' (point at infinity)
+// flag followed by the affine coordinates, each field element 32 bytes, little endian, in Montgomery form.
+//
+// The compressed encoding is the big endian x coordinate (G2: x.c1 || x.c0) whose two most significant
+// bits, unused as the modulus is 254 bits, are flags: compressedInfinity for the point at infinity (all
+// other bits zero), compressedOdd if y is odd (G2: y.c0 is odd, or y.c0 is zero and y.c1 is odd).
+const (
+	fpSize           = 32
+	g1Size           = 1 + 2*fpSize
+	g2Size           = 1 + 4*fpSize
+	g1CompressedSize = fpSize
+	g2CompressedSize = 2 * fpSize
+
+	compressedInfinity = 0x80
+	compressedOdd      = 0x40
+	compressedFlags    = compressedInfinity | compressedOdd
+)
+
+var (
+	// fieldModulus is the modulus of the alt_bn128 base field
+	fieldModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+
+	// montgomeryR = 2^256 mod p and its inverse, to convert from / to Montgomery form
+	montgomeryR    = new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 256), fieldModulus)
+	montgomeryRInv = new(big.Int).ModInverse(montgomeryR, fieldModulus)
+
+	// curveB and twistB are the constants of the curve equations y^2 = x^3 + b of G1 and G2:
+	// b = 3, b' = 3 / (9 + i) = 3 (9 - i) / 82
+	curveB = big.NewInt(3)
+	twistB = func() fp2 {
+		inv82 := new(big.Int).ModInverse(big.NewInt(82), fieldModulus)
+		c0 := new(big.Int).Mul(big.NewInt(27), inv82)
+		c1 := new(big.Int).Mul(big.NewInt(-3), inv82)
+		return fp2{c0.Mod(c0, fieldModulus), c1.Mod(c1, fieldModulus)}
+	}()
+)
+
+var (
+	errInvalidPointFlag = errors.New("invalid point flag")
+	errFieldElement     = errors.New("field element out of range")
+	errNotInGroup       = errors.New("point not on curve or not in subgroup")
+)
+
+// -------------------------------------------------------------------------------------------------
+// libsnark serialization
+
+func readFp(r io.Reader) (*big.Int, error) {
+	var buf [fpSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	reverse(buf[:])
+	e := new(big.Int).SetBytes(buf[:])
+	if e.Cmp(fieldModulus) >= 0 {
+		return nil, errFieldElement
+	}
+	return e.Mul(e, montgomeryRInv).Mod(e, fieldModulus), nil
+}
+
+func writeFp(w io.Writer, e *big.Int) {
+	mont := new(big.Int).Mul(e, montgomeryR)
+	mont.Mod(mont, fieldModulus)
+	buf := fpBytes(mont)
+	reverse(buf)
+	w.Write(buf)
+}
+
+func readInfinity(r io.Reader) (bool, error) {
+	var flag [1]byte
+	if _, err := io.ReadFull(r, flag[:]); err != nil {
+		return false, err
+	}
+	switch flag[0] {
+	case '0':
+		return false, nil
+	case '1':
+		return true, nil
+	}
+	return false, errInvalidPointFlag
+}
+
+// readCoordinates reads a libsnark serialized point of n coordinates, and returns them (zero for the
+// point at infinity) in bn256 marshaling order
+func readCoordinates(r io.Reader, n int) ([]byte, error) {
+	infinity, err := readInfinity(r)
+	if err != nil {
+		return nil, err
+	}
+	m := make([]byte, n*fpSize)
+	for i := 0; i < n; i++ {
+		e, err := readFp(r)
+		if err != nil {
+			return nil, err
+		}
+		if !infinity {
+			copy(m[marshalIndex(n, i)*fpSize:], fpBytes(e))
+		}
+	}
+	return m, nil
+}
+
+// writeCoordinates writes a point of n coordinates marshaled by bn256, the point at infinity as libff
+// does: (0, 1) with the infinity flag
+func writeCoordinates(w io.Writer, m []byte) {
+	n := len(m) / fpSize
+	if isZero(m) {
+		w.Write([]byte{'1'})
+		for i := 0; i < n; i++ {
+			if i == n/2 {
+				writeFp(w, big.NewInt(1))
+			} else {
+				writeFp(w, new(big.Int))
+			}
+		}
+		return
+	}
+	w.Write([]byte{'0'})
+	for i := 0; i < n; i++ {
+		j := marshalIndex(n, i)
+		writeFp(w, new(big.Int).SetBytes(m[j*fpSize:(j+1)*fpSize]))
+	}
+}
+
+// marshalIndex returns the index in bn256 marshaling of the i-th of n coordinates in libff order: bn256
+// marshals G2 coordinates imaginary part first
+func marshalIndex(n, i int) int {
+	if n == 4 {
+		return i ^ 1
+	}
+	return i
+}
+
+func readG1(r io.Reader) (*bn256.G1, error) {
+	m, err := readCoordinates(r, 2)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalG1(m)
+}
+
+func readG2(r io.Reader) (*bn256.G2, error) {
+	m, err := readCoordinates(r, 4)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalG2(m)
+}
+
+func writeG1(w io.Writer, p *bn256.G1) {
+	writeCoordinates(w, p.Marshal())
+}
+
+func writeG2(w io.Writer, p *bn256.G2) {
+	writeCoordinates(w, p.Marshal())
+}
+
+// unmarshalG1 and unmarshalG2 return the point of bn256 marshaled coordinates, if it is in G1 / G2.
+// G1 is the whole curve; bn256 checks that G2 points are in the subgroup of order r of the twist.
+func unmarshalG1(m []byte) (*bn256.G1, error) {
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(m); err != nil {
+		return nil, errNotInGroup
+	}
+	return p, nil
+}
+
+func unmarshalG2(m []byte) (*bn256.G2, error) {
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(m); err != nil {
+		return nil, errNotInGroup
+	}
+	return p, nil
+}
+
+// -------------------------------------------------------------------------------------------------
+// compressed encoding
+
+func compressG1(w io.Writer, p *bn256.G1) {
+	m := p.Marshal()
+	odd := new(big.Int).SetBytes(m[fpSize:]).Bit(0) == 1
+	writeCompressed(w, m[:fpSize], isZero(m), odd)
+}
+
+func compressG2(w io.Writer, p *bn256.G2) {
+	m := p.Marshal()
+	y := fp2{new(big.Int).SetBytes(m[3*fpSize:]), new(big.Int).SetBytes(m[2*fpSize : 3*fpSize])}
+	writeCompressed(w, m[:2*fpSize], isZero(m), y.isOdd())
+}
+
+func writeCompressed(w io.Writer, x []byte, infinity, odd bool) {
+	c := make([]byte, len(x))
+	switch {
+	case infinity:
+		c[0] = compressedInfinity
+	case odd:
+		copy(c, x)
+		c[0] |= compressedOdd
+	default:
+		copy(c, x)
+	}
+	w.Write(c)
+}
+
+// readCompressed reads a compressed point of size bytes, and returns its x coordinate (bn256 marshaling
+// order) and flags
+func readCompressed(r io.Reader, size int) (x []byte, infinity, odd bool, err error) {
+	x = make([]byte, size)
+	if _, err := io.ReadFull(r, x); err != nil {
+		return nil, false, false, err
+	}
+	flags := x[0] & compressedFlags
+	x[0] &^= compressedFlags
+	if flags&compressedInfinity != 0 {
+		if flags != compressedInfinity || !isZero(x) {
+			return nil, false, false, errInvalidPointFlag
+		}
+		return x, true, false, nil
+	}
+	for i := 0; i < size; i += fpSize {
+		if new(big.Int).SetBytes(x[i:i+fpSize]).Cmp(fieldModulus) >= 0 {
+			return nil, false, false, errFieldElement
+		}
+	}
+	return x, false, flags&compressedOdd != 0, nil
+}
+
+func decompressG1(r io.Reader) (*bn256.G1, error) {
+	x, infinity, odd, err := readCompressed(r, g1CompressedSize)
+	if err != nil {
+		return nil, err
+	}
+	if infinity {
+		return unmarshalG1(make([]byte, 2*fpSize))
+	}
+	// y^2 = x^3 + b
+	y2 := new(big.Int).Exp(new(big.Int).SetBytes(x), big.NewInt(3), fieldModulus)
+	y2.Add(y2, curveB).Mod(y2, fieldModulus)
+	y := new(big.Int).ModSqrt(y2, fieldModulus)
+	if y == nil {
+		return nil, errNotInGroup
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(fieldModulus, y)
+	}
+	return unmarshalG1(append(x, fpBytes(y)...))
+}
+
+func decompressG2(r io.Reader) (*bn256.G2, error) {
+	x, infinity, odd, err := readCompressed(r, g2CompressedSize)
+	if err != nil {
+		return nil, err
+	}
+	if infinity {
+		return unmarshalG2(make([]byte, 4*fpSize))
+	}
+	// y^2 = x^3 + b'
+	xe := fp2{new(big.Int).SetBytes(x[fpSize:]), new(big.Int).SetBytes(x[:fpSize])}
+	y, ok := xe.mul(xe).mul(xe).add(twistB).sqrt()
+	if !ok {
+		return nil, errNotInGroup
+	}
+	if y.isOdd() != odd {
+		y = y.neg()
+	}
+	return unmarshalG2(append(append(x, fpBytes(y[1])...), fpBytes(y[0])...))
+}
+
+// fpBytes returns the 32 bytes big endian encoding of a field element
+func fpBytes(e *big.Int) []byte {
+	b := make([]byte, fpSize)
+	eb := e.Bytes()
+	copy(b[fpSize-len(eb):], eb)
+	return b
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Fp2 = Fp[i]/(i^2 + 1)
+
+// fp2 is c0 + c1 * i
+type fp2 [2]*big.Int
+
+func (a fp2) add(b fp2) fp2 {
+	c0 := new(big.Int).Add(a[0], b[0])
+	c1 := new(big.Int).Add(a[1], b[1])
+	return fp2{c0.Mod(c0, fieldModulus), c1.Mod(c1, fieldModulus)}
+}
+
+func (a fp2) neg() fp2 {
+	c0 := new(big.Int).Neg(a[0])
+	c1 := new(big.Int).Neg(a[1])
+	return fp2{c0.Mod(c0, fieldModulus), c1.Mod(c1, fieldModulus)}
+}
+
+func (a fp2) mul(b fp2) fp2 {
+	c0 := new(big.Int).Mul(a[0], b[0])
+	c0.Sub(c0, new(big.Int).Mul(a[1], b[1]))
+	c1 := new(big.Int).Mul(a[0], b[1])
+	c1.Add(c1, new(big.Int).Mul(a[1], b[0]))
+	return fp2{c0.Mod(c0, fieldModulus), c1.Mod(c1, fieldModulus)}
+}
+
+// isOdd returns the sign used by the compressed encoding: the parity of c0, or of c1 if c0 is zero
+func (a fp2) isOdd() bool {
+	if a[0].Sign() != 0 {
+		return a[0].Bit(0) == 1
+	}
+	return a[1].Bit(0) == 1
+}
+
+// sqrt returns a square root of a, and false if a isn't a square. With n = sqrt(c0^2 + c1^2), the root is
+// x0 + x1 * i with x0 = sqrt((c0 ± n) / 2) and x1 = c1 / (2 x0).
+func (a fp2) sqrt() (fp2, bool) {
+	if a[1].Sign() == 0 {
+		if x0 := new(big.Int).ModSqrt(a[0], fieldModulus); x0 != nil {
+			return fp2{x0, new(big.Int)}, true
+		}
+		x1 := new(big.Int).ModSqrt(new(big.Int).Sub(fieldModulus, a[0]), fieldModulus)
+		if x1 == nil {
+			return fp2{}, false
+		}
+		return fp2{new(big.Int), x1}, true
+	}
+	norm := new(big.Int).Mul(a[0], a[0])
+	norm.Add(norm, new(big.Int).Mul(a[1], a[1])).Mod(norm, fieldModulus)
+	n := new(big.Int).ModSqrt(norm, fieldModulus)
+	if n == nil {
+		return fp2{}, false
+	}
+	half := new(big.Int).ModInverse(big.NewInt(2), fieldModulus)
+	for _, t := range []*big.Int{new(big.Int).Add(a[0], n), new(big.Int).Sub(a[0], n)} {
+		t.Mul(t, half).Mod(t, fieldModulus)
+		if x0 := new(big.Int).ModSqrt(t, fieldModulus); x0 != nil && x0.Sign() != 0 {
+			x1 := new(big.Int).Lsh(x0, 1)
+			x1.ModInverse(x1, fieldModulus)
+			x1.Mul(x1, a[1]).Mod(x1, fieldModulus)
+			return fp2{x0, x1}, true
+		}
+	}
+	return fp2{}, false
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zsl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// Proof is a ppzksnark (BCTV14) proof, see libsnark r1cs_ppzksnark_proof. Its points are checked to be on
+// the curve and in G1 / G2 when parsed, so a parsed proof is well formed (but not necessarily valid).
+type Proof struct {
+	A      *bn256.G1
+	APrime *bn256.G1
+	B      *bn256.G2
+	BPrime *bn256.G1
+	C      *bn256.G1
+	CPrime *bn256.G1
+	H      *bn256.G1
+	K      *bn256.G1
+}
+
+// Groth16Proof is a Groth16 proof, see libsnark r1cs_gg_ppzksnark_proof
+type Groth16Proof struct {
+	A *bn256.G1
+	B *bn256.G2
+	C *bn256.G1
+}
+
+// ParseProof parses a libsnark serialized ppzksnark proof (ProofSize bytes)
+func ParseProof(raw []byte) (*Proof, error) {
+	p := &Proof{}
+	if err := readProof(raw, ProofSize, p.points(), readG1, readG2); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DecompressProof parses a compressed ppzksnark proof (CompressedProofSize bytes), see Proof.Compress
+func DecompressProof(compressed []byte) (*Proof, error) {
+	p := &Proof{}
+	if err := readProof(compressed, CompressedProofSize, p.points(), decompressG1, decompressG2); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Bytes returns the libsnark serialization of the proof
+func (p *Proof) Bytes() []byte {
+	return writeProof(p.points(), writeG1, writeG2)
+}
+
+// Compress returns the compressed encoding of the proof: the points, in the libsnark serialization order,
+// as their x coordinate and the sign of their y coordinate
+func (p *Proof) Compress() []byte {
+	return writeProof(p.points(), compressG1, compressG2)
+}
+
+// points returns pointers to the points of the proof, in libsnark serialization order (knowledge
+// commitments g_A, g_B and g_C, then g_H and g_K)
+func (p *Proof) points() []interface{} {
+	return []interface{}{&p.A, &p.APrime, &p.B, &p.BPrime, &p.C, &p.CPrime, &p.H, &p.K}
+}
+
+// ParseGroth16Proof parses a libsnark serialized Groth16 proof (Groth16ProofSize bytes)
+func ParseGroth16Proof(raw []byte) (*Groth16Proof, error) {
+	p := &Groth16Proof{}
+	if err := readProof(raw, Groth16ProofSize, p.points(), readG1, readG2); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DecompressGroth16Proof parses a compressed Groth16 proof (CompressedGroth16ProofSize bytes), see
+// Groth16Proof.Compress
+func DecompressGroth16Proof(compressed []byte) (*Groth16Proof, error) {
+	p := &Groth16Proof{}
+	if err := readProof(compressed, CompressedGroth16ProofSize, p.points(), decompressG1, decompressG2); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Bytes returns the libsnark serialization of the proof
+func (p *Groth16Proof) Bytes() []byte {
+	return writeProof(p.points(), writeG1, writeG2)
+}
+
+// Compress returns the compressed encoding of the proof, see Proof.Compress
+func (p *Groth16Proof) Compress() []byte {
+	return writeProof(p.points(), compressG1, compressG2)
+}
+
+func (p *Groth16Proof) points() []interface{} {
+	return []interface{}{&p.A, &p.B, &p.C}
+}
+
+// CheckProof returns an error if raw isn't a well formed proof of the proving system
+func CheckProof(raw []byte, system ProvingSystem) error {
+	var err error
+	switch system {
+	case ProvingSystem_PPZKSNARK:
+		_, err = ParseProof(raw)
+	case ProvingSystem_GROTH16:
+		_, err = ParseGroth16Proof(raw)
+	default:
+		err = fmt.Errorf("unknown proving system %v", system)
+	}
+	return err
+}
+
+// readProof reads the points (**bn256.G1 or **bn256.G2) of a proof of the given size
+func readProof(buf []byte, size int, points []interface{}, readG1 func(io.Reader) (*bn256.G1, error), readG2 func(io.Reader) (*bn256.G2, error)) error {
+	if len(buf) != size {
+		return fmt.Errorf("invalid proof: size must be %d", size)
+	}
+	r := bytes.NewReader(buf)
+	var err error
+	for i, p := range points {
+		switch p := p.(type) {
+		case **bn256.G1:
+			*p, err = readG1(r)
+		case **bn256.G2:
+			*p, err = readG2(r)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid proof: point %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func writeProof(points []interface{}, writeG1 func(io.Writer, *bn256.G1), writeG2 func(io.Writer, *bn256.G2)) []byte {
+	var buf bytes.Buffer
+	for _, p := range points {
+		switch p := p.(type) {
+		case **bn256.G1:
+			writeG1(&buf, *p)
+		case **bn256.G2:
+			writeG2(&buf, *p)
+		}
+	}
+	return buf.Bytes()
+}
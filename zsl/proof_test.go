@@ -0,0 +1,191 @@
+// Copyright 2018 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zsl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+func randomG1(t *testing.T) *bn256.G1 {
+	_, p, err := bn256.RandomG1(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func randomG2(t *testing.T) *bn256.G2 {
+	_, p, err := bn256.RandomG2(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func randomProof(t *testing.T) *Proof {
+	return &Proof{
+		A:      randomG1(t),
+		APrime: randomG1(t),
+		B:      randomG2(t),
+		BPrime: randomG1(t),
+		C:      randomG1(t),
+		CPrime: randomG1(t),
+		H:      randomG1(t),
+		// point at infinity
+		K: new(bn256.G1).ScalarBaseMult(new(big.Int)),
+	}
+}
+
+func TestPointEncoding(t *testing.T) {
+	// libff serialization of the G1 generator (1, 2): Montgomery form of 1 and 2, little endian
+	var buf bytes.Buffer
+	writeG1(&buf, new(bn256.G1).ScalarBaseMult(big.NewInt(1)))
+	one, _ := hex.DecodeString("0e0a77c19a07df2f666ea36f7879462c0a78eb28f5c70b3dd35d438dc58f0d9d")
+	two, _ := hex.DecodeString("1c14ef83340fbe5eccdd46def0f28c5814f1d651eb8e167ba6ba871b8b1e1b3a")
+	reverse(one)
+	reverse(two)
+	if expected := append(append([]byte{'0'}, one...), two...); !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatal("unexpected G1 generator encoding", hex.EncodeToString(buf.Bytes()))
+	}
+
+	// compression of points with both signs of y, and of the point at infinity
+	for i := 0; i < 16; i++ {
+		g1, g2 := randomG1(t), randomG2(t)
+		if i == 0 {
+			g1, g2 = new(bn256.G1).ScalarBaseMult(new(big.Int)), new(bn256.G2).ScalarBaseMult(new(big.Int))
+		}
+		buf.Reset()
+		compressG1(&buf, g1)
+		compressG2(&buf, new(bn256.G2).Neg(g2))
+		compressG2(&buf, g2)
+		if buf.Len() != g1CompressedSize+2*g2CompressedSize {
+			t.Fatal("unexpected compressed size", buf.Len())
+		}
+		d1, err := decompressG1(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		negD2, err := decompressG2(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d2, err := decompressG2(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(d1.Marshal(), g1.Marshal()) || !bytes.Equal(d2.Marshal(), g2.Marshal()) || !bytes.Equal(new(bn256.G2).Neg(negD2).Marshal(), g2.Marshal()) {
+			t.Fatal("decompressed points don't match")
+		}
+	}
+}
+
+func TestProofEncoding(t *testing.T) {
+	proof := randomProof(t)
+	raw := proof.Bytes()
+	if len(raw) != ProofSize {
+		t.Fatal("proof size should be", ProofSize)
+	}
+	parsed, err := ParseProof(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Bytes(), raw) || !bytes.Equal(parsed.H.Marshal(), proof.H.Marshal()) || !bytes.Equal(parsed.B.Marshal(), proof.B.Marshal()) {
+		t.Fatal("parsed proof doesn't match")
+	}
+	compressed := proof.Compress()
+	if len(compressed) != CompressedProofSize {
+		t.Fatal("compressed proof size should be", CompressedProofSize)
+	}
+	decompressed, err := DecompressProof(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed.Bytes(), raw) {
+		t.Fatal("decompressed proof doesn't match")
+	}
+	if CheckProof(raw, ProvingSystem_PPZKSNARK) != nil || CheckProof(raw, ProvingSystem_GROTH16) == nil {
+		t.Fatal("unexpected CheckProof result")
+	}
+
+	groth16 := &Groth16Proof{A: randomG1(t), B: randomG2(t), C: randomG1(t)}
+	raw = groth16.Bytes()
+	if len(raw) != Groth16ProofSize || len(groth16.Compress()) != CompressedGroth16ProofSize {
+		t.Fatal("unexpected groth16 proof sizes")
+	}
+	if parsed, err := ParseGroth16Proof(raw); err != nil || !bytes.Equal(parsed.Bytes(), raw) {
+		t.Fatal("parsed groth16 proof doesn't match", err)
+	}
+	if decompressed, err := DecompressGroth16Proof(groth16.Compress()); err != nil || !bytes.Equal(decompressed.Bytes(), raw) {
+		t.Fatal("decompressed groth16 proof doesn't match", err)
+	}
+	if CheckProof(raw, ProvingSystem_GROTH16) != nil || CheckProof(raw, ProvingSystem_PPZKSNARK) == nil {
+		t.Fatal("unexpected CheckProof result")
+	}
+}
+
+func TestMalformedProof(t *testing.T) {
+	raw := randomProof(t).Bytes()
+	corrupt := func(f func(p []byte)) []byte {
+		p := append([]byte(nil), raw...)
+		f(p)
+		return p
+	}
+
+	// a twist point outside of G2, (x, y) with x = 1
+	x := fp2{big.NewInt(1), new(big.Int)}
+	y, ok := x.mul(x).mul(x).add(twistB).sqrt()
+	if !ok {
+		t.Fatal("x^3 + b' should be a square")
+	}
+	var outside bytes.Buffer
+	outside.WriteByte('0')
+	for _, e := range []*big.Int{x[0], x[1], y[0], y[1]} {
+		writeFp(&outside, e)
+	}
+
+	for name, p := range map[string][]byte{
+		"short":               raw[:ProofSize-1],
+		"random":              RandomBytes(ProofSize),
+		"invalid flag":        corrupt(func(p []byte) { p[0] = 2 }),
+		"out of range":        corrupt(func(p []byte) { copy(p[1:1+fpSize], bytes.Repeat([]byte{0xff}, fpSize)) }),
+		"not on curve":        corrupt(func(p []byte) { p[1] ^= 1 }),
+		"G2 not on curve":     corrupt(func(p []byte) { p[2*g1Size+1] ^= 1 }),
+		"G2 not in subgroup":  corrupt(func(p []byte) { copy(p[2*g1Size:], outside.Bytes()) }),
+		"groth16 proof bytes": (&Groth16Proof{A: randomG1(t), B: randomG2(t), C: randomG1(t)}).Bytes(),
+	} {
+		if _, err := ParseProof(p); err == nil {
+			t.Fatal("parsed malformed proof:", name)
+		}
+	}
+
+	compressed := randomProof(t).Compress()
+	outsideCompressed := append(make([]byte, fpSize), fpBytes(x[0])...)
+	for name, c := range map[string][]byte{
+		"short":              compressed[1:],
+		"invalid flags":      append([]byte{compressedFlags}, compressed[1:]...),
+		"out of range":       append(bytes.Repeat([]byte{0x3f}, fpSize), compressed[fpSize:]...),
+		"G2 not in subgroup": append(append(append([]byte(nil), compressed[:2*fpSize]...), outsideCompressed...), compressed[2*fpSize+g2CompressedSize:]...),
+	} {
+		if _, err := DecompressProof(c); err == nil {
+			t.Fatal("decompressed malformed proof:", name)
+		}
+	}
+}
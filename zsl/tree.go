@@ -20,26 +20,39 @@ import (
 	"github.com/jpmorganchase/zsl-q/zsl-golang/zsl/sha256"
 )
 
+var (
+	// ErrCommitmentNotFound is returned for a commitment (or index) that isn't in the tree
+	ErrCommitmentNotFound = errors.New("commitment not found")
+	// ErrCommitmentExists is returned when adding a commitment already in the tree
+	ErrCommitmentExists = errors.New("commitment already exists")
+	// ErrTreeFull is returned when adding a commitment to a tree of 2^depth commitments
+	ErrTreeFull = errors.New("tree is full")
+)
+
 // Tree is an incremental Merkle Tree of fixed depth
 // as described in ZCash protocol
-// It is rudimentary and for testing purposes only (needs optimization)
+// It caches the nodes of its complete subtrees: nodes[height][index] is the root of the subtree of the
+// commitments [index << height, (index+1) << height), so that adding a commitment, computing a root
+// and an authentication path are O(depth). The left siblings of the path of the next commitment (its
+// frontier) are complete subtrees, the right ones are empty.
 type Tree struct {
 	depth              uint
 	maxElements        uint
 	nbCommitments      uint
 	commitmentsIndices map[Hash]uint
-	commitments        map[uint]Hash
+	nodes              [][]Hash // nodes[0] are the commitments
 	EmptyRootsByHeight []Hash
 }
 
-// NewTree returns a new Merkle Tree of fixed depth depth
+// NewTree returns a new Merkle Tree of fixed depth depth, the tree depth of the key set of the proofs
+// (see VerifyingKey.TreeDepth)
 func NewTree(depth uint) *Tree {
 	// tree has max elements 2^depth
 	toReturn := &Tree{depth: depth, maxElements: pow(2, int(depth))}
 
 	// initialize data structs
 	toReturn.commitmentsIndices = make(map[Hash]uint)
-	toReturn.commitments = make(map[uint]Hash)
+	toReturn.nodes = make([][]Hash, depth+1)
 	toReturn.EmptyRootsByHeight = make([]Hash, depth+1)
 
 	// create empty roots
@@ -56,16 +69,49 @@ func NewTree(depth uint) *Tree {
 	return toReturn
 }
 
+// Depth returns the depth of the tree
+func (tree *Tree) Depth() uint {
+	return tree.depth
+}
+
+// Capacity returns the maximum number of commitments in the tree
+func (tree *Tree) Capacity() uint {
+	return tree.maxElements
+}
+
+// Size returns the number of commitments in the tree
+func (tree *Tree) Size() uint {
+	return tree.nbCommitments
+}
+
 // Root computes and return the tree root value
 func (tree *Tree) Root() Hash {
-	return tree.computeSubTree(0, tree.depth)
+	return tree.node(tree.depth, 0, tree.nbCommitments)
+}
+
+// RootAt returns the root of the tree when it had its first size commitments, or ErrCommitmentNotFound if
+// size is greater than its number of commitments
+func (tree *Tree) RootAt(size uint) (Hash, error) {
+	if size > tree.nbCommitments {
+		return Hash{}, ErrCommitmentNotFound
+	}
+	return tree.node(tree.depth, 0, size), nil
 }
 
 // GetWitnesses return treeIndex and authPath from leaf to root
 func (tree *Tree) GetWitnesses(commitment Hash) (uint, [][]byte, error) {
 	treeIndex, ok := tree.commitmentsIndices[commitment]
 	if !ok {
-		return 0, nil, errors.New("commitment not found")
+		return 0, nil, ErrCommitmentNotFound
+	}
+	treePath, err := tree.GetWitnessesAt(treeIndex)
+	return treeIndex, treePath, err
+}
+
+// GetWitnessesAt returns the authPath from leaf to root of the commitment of index treeIndex
+func (tree *Tree) GetWitnessesAt(treeIndex uint) ([][]byte, error) {
+	if treeIndex >= tree.nbCommitments {
+		return nil, ErrCommitmentNotFound
 	}
 	treePath := make([][]byte, tree.depth)
 
@@ -73,45 +119,75 @@ func (tree *Tree) GetWitnesses(commitment Hash) (uint, [][]byte, error) {
 
 	// start at leaf and go up the tree
 	for height := uint(0); height < tree.depth; height++ {
-		sub := tree.computeSubTree(index^1, height)
+		sub := tree.node(height, index^1, tree.nbCommitments)
 		treePath[height] = make([]byte, 32)
 		copy(treePath[height], sub[:])
 		index >>= 1
 	}
 
-	return treeIndex, treePath, nil
+	return treePath, nil
+}
+
+// Commitment returns the commitment of index treeIndex
+func (tree *Tree) Commitment(treeIndex uint) (Hash, error) {
+	if treeIndex >= tree.nbCommitments {
+		return Hash{}, ErrCommitmentNotFound
+	}
+	return tree.nodes[0][treeIndex], nil
+}
+
+// Contains returns true if commitment is in the tree
+func (tree *Tree) Contains(commitment Hash) bool {
+	_, ok := tree.commitmentsIndices[commitment]
+	return ok
 }
 
 // AddCommitment adds a commitment to the tree, and return its index
 func (tree *Tree) AddCommitment(commitment Hash) (uint, error) {
 	if _, ok := tree.commitmentsIndices[commitment]; ok {
-		return 0, errors.New("commitment already exists")
+		return 0, ErrCommitmentExists
 	}
 	if tree.nbCommitments >= tree.maxElements {
-		return 0, errors.New("tree is full")
+		return 0, ErrTreeFull
 	}
 	tree.commitmentsIndices[commitment] = tree.nbCommitments
-	tree.commitments[tree.nbCommitments] = commitment
+	tree.nodes[0] = append(tree.nodes[0], commitment)
 	tree.nbCommitments++
+
+	// cache the subtrees the commitment completes
+	index := tree.nbCommitments - 1
+	for height := uint(0); height < tree.depth && index&1 == 1; height++ {
+		tree.nodes[height+1] = append(tree.nodes[height+1], shaCompress(tree.nodes[height][index-1], tree.nodes[height][index]))
+		index >>= 1
+	}
 	return tree.nbCommitments - 1, nil
 }
 
 // -------------------------------------------------------------------------------------------------
 // Private functions
 
-// recursively computes a subTree
-func (tree *Tree) computeSubTree(index, height uint) Hash {
-	if tree.nbCommitments <= (index << height) {
-		// if the other half of the tree is empty
-		return tree.EmptyRootsByHeight[height]
+// node returns the root of the subtree of height and index of the tree of the first size commitments
+func (tree *Tree) node(height, index, size uint) Hash {
+	if (index+1)<<height <= size {
+		// complete subtree, cached
+		return tree.nodes[height][index]
 	}
-	if height == 0 {
-		// we reached a leaf, return the leaf value (commitment)
-		return tree.commitments[index]
+	if index<<height >= size {
+		// empty subtree
+		return tree.EmptyRootsByHeight[height]
 	}
 
-	return shaCompress(tree.computeSubTree(index<<1, height-1),
-		tree.computeSubTree((index<<1)+1, height-1))
+	// the subtree holds the first empty leaf, of index size: starting from it, its left siblings are
+	// complete subtrees and its right siblings empty ones
+	node := tree.EmptyRootsByHeight[0]
+	for h := uint(0); h < height; h++ {
+		if (size>>h)&1 == 1 {
+			node = shaCompress(tree.nodes[h][(size>>h)-1], node)
+		} else {
+			node = shaCompress(node, tree.EmptyRootsByHeight[h])
+		}
+	}
+	return node
 }
 
 func shaCompress(left, right Hash) Hash {
@@ -114,3 +114,104 @@ func TestAddCommitment(t *testing.T) {
 		t.Fatal("shouldn't add a commitment to a full tree")
 	}
 }
+
+func TestRootAt(t *testing.T) {
+	tree := NewTree(3)
+
+	var roots []Hash
+	for i := 0; i < 5; i++ {
+		roots = append(roots, tree.Root())
+		if _, err := tree.AddCommitment(NewHash(RandomBytes(HashSize))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	roots = append(roots, tree.Root())
+
+	for size, root := range roots {
+		rootAt, err := tree.RootAt(uint(size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rootAt != root {
+			t.Fatalf("root at size %d doesn't match the root of the tree at that size", size)
+		}
+	}
+	if _, err := tree.RootAt(tree.Size() + 1); err != ErrCommitmentNotFound {
+		t.Fatal("expected ErrCommitmentNotFound, got", err)
+	}
+
+	// the witnesses of a commitment by index match the ones by commitment
+	cm, err := tree.Commitment(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, byCommitment, err := tree.GetWitnesses(cm)
+	if err != nil || index != 3 {
+		t.Fatal("unexpected witnesses", index, err)
+	}
+	byIndex, err := tree.GetWitnessesAt(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range byIndex {
+		if !bytes.Equal(byIndex[i], byCommitment[i]) {
+			t.Fatal("witnesses by index don't match witnesses by commitment")
+		}
+	}
+	if _, err := tree.GetWitnessesAt(tree.Size()); err != ErrCommitmentNotFound {
+		t.Fatal("expected ErrCommitmentNotFound, got", err)
+	}
+}
+
+// subTree recursively computes the root of the subtree of height and index of the first size commitments
+func subTree(tree *Tree, commitments []Hash, height, index, size uint) Hash {
+	if size <= index<<height {
+		return tree.EmptyRootsByHeight[height]
+	}
+	if height == 0 {
+		return commitments[index]
+	}
+	return shaCompress(subTree(tree, commitments, height-1, index<<1, size), subTree(tree, commitments, height-1, (index<<1)+1, size))
+}
+
+func TestCachedNodes(t *testing.T) {
+	const depth = 4
+	tree := NewTree(depth)
+	var commitments []Hash
+
+	// the roots and the witnesses of the tree match the ones recomputed from the commitments, at each size
+	for size := uint(0); size <= 1<<depth; size++ {
+		root := subTree(tree, commitments, depth, 0, size)
+		if tree.Root() != root {
+			t.Fatalf("root of a tree of %d commitments doesn't match", size)
+		}
+		for i := uint(0); i < size; i++ {
+			treePath, err := tree.GetWitnessesAt(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for height := uint(0); height < depth; height++ {
+				sibling := subTree(tree, commitments, height, (i>>height)^1, size)
+				if !bytes.Equal(treePath[height], sibling[:]) {
+					t.Fatalf("witness %d at height %d of a tree of %d commitments doesn't match", i, height, size)
+				}
+			}
+		}
+		for s := uint(0); s <= size; s++ {
+			if rootAt, _ := tree.RootAt(s); rootAt != subTree(tree, commitments, depth, 0, s) {
+				t.Fatalf("root at %d of a tree of %d commitments doesn't match", s, size)
+			}
+		}
+
+		if size < 1<<depth {
+			cm := NewHash(RandomBytes(HashSize))
+			commitments = append(commitments, cm)
+			if _, err := tree.AddCommitment(cm); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := tree.AddCommitment(NewHash(RandomBytes(HashSize))); err != ErrTreeFull {
+		t.Fatal("expected ErrTreeFull, got", err)
+	}
+}
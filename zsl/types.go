@@ -14,12 +14,25 @@
 
 package zsl
 
-import "crypto/rand"
+import (
+	"crypto/rand"
+	"errors"
+)
 
 const (
-	HashSize  = 32
+	HashSize = 32
+	// TreeDepth is the default depth of the commitment tree. Each key set has its own, recorded in its
+	// manifest (see VerifyingKey.TreeDepth): trees must be created with the depth of the keys.
 	TreeDepth = 29
-	ProofSize = 584
+
+	// ProofSize and Groth16ProofSize are the sizes of PPZKSNARK and GROTH16 proofs
+	ProofSize        = 584
+	Groth16ProofSize = 259
+
+	// CompressedProofSize and CompressedGroth16ProofSize are the sizes of compressed proofs, see
+	// Proof.Compress
+	CompressedProofSize        = 288
+	CompressedGroth16ProofSize = 128
 )
 
 // Hash is a type alias on a 32 byte array for clarity
@@ -36,6 +49,47 @@ func NewHash(from []byte) Hash {
 	return toReturn
 }
 
+var (
+	// ErrInvalidAsset is returned for an asset that is neither empty nor HashSize bytes
+	ErrInvalidAsset = errors.New("asset must be empty or 32 bytes")
+	// ErrInvalidBinding is returned for a binding that is neither empty nor HashSize bytes
+	ErrInvalidBinding = errors.New("binding must be empty or 32 bytes")
+)
+
+// CheckAsset returns ErrInvalidAsset if asset is neither empty nor HashSize bytes
+func CheckAsset(asset []byte) error {
+	if len(asset) != 0 && len(asset) != HashSize {
+		return ErrInvalidAsset
+	}
+	return nil
+}
+
+// CheckBinding returns ErrInvalidBinding if binding is neither empty nor HashSize bytes
+func CheckBinding(binding []byte) error {
+	if len(binding) != 0 && len(binding) != HashSize {
+		return ErrInvalidBinding
+	}
+	return nil
+}
+
+// NoteAsset returns the asset of a note: asset, or the default asset (HashSize zero bytes) if it is empty.
+// asset must have been checked with CheckAsset.
+func NoteAsset(asset []byte) []byte {
+	if len(asset) == 0 {
+		return make([]byte, HashSize)
+	}
+	return asset
+}
+
+// ProofBinding returns the binding of an unshielding or shielded transfer proof: binding, or HashSize zero
+// bytes if it is empty. binding must have been checked with CheckBinding.
+func ProofBinding(binding []byte) []byte {
+	if len(binding) == 0 {
+		return make([]byte, HashSize)
+	}
+	return binding
+}
+
 // RandomBytes returns a []byte filled with random bytes
 func RandomBytes(length uint) []byte {
 	toReturn := make([]byte, length)
@@ -44,3 +98,53 @@ func RandomBytes(length uint) []byte {
 	}
 	return toReturn
 }
+
+// transferCircuits are the shielded transfer circuits, by numbers of inputs and outputs, and
+// publicTransferCircuits those with public values in and out
+var transferCircuits = map[[2]int]Circuit{
+	{1, 1}: Circuit_TRANSFER_1X1,
+	{1, 2}: Circuit_TRANSFER_1X2,
+	{2, 2}: Circuit_TRANSFER,
+	{4, 2}: Circuit_TRANSFER_4X2,
+	{4, 4}: Circuit_TRANSFER_4X4,
+}
+var publicTransferCircuits = map[[2]int]Circuit{
+	{1, 1}: Circuit_PUBLIC_TRANSFER_1X1,
+	{1, 2}: Circuit_PUBLIC_TRANSFER_1X2,
+	{2, 2}: Circuit_PUBLIC_TRANSFER,
+	{4, 2}: Circuit_PUBLIC_TRANSFER_4X2,
+	{4, 4}: Circuit_PUBLIC_TRANSFER_4X4,
+}
+
+// TransferCircuit returns the shielded transfer circuit of nbInputs inputs and nbOutputs outputs, with
+// public values or not, false if there is none
+func TransferCircuit(nbInputs, nbOutputs int, publicValues bool) (Circuit, bool) {
+	if publicValues {
+		c, ok := publicTransferCircuits[[2]int{nbInputs, nbOutputs}]
+		return c, ok
+	}
+	c, ok := transferCircuits[[2]int{nbInputs, nbOutputs}]
+	return c, ok
+}
+
+// Arity returns the numbers of inputs and outputs of a shielded transfer circuit (0, 0 for the others)
+func (c Circuit) Arity() (nbInputs, nbOutputs int) {
+	for _, circuits := range []map[[2]int]Circuit{transferCircuits, publicTransferCircuits} {
+		for arity, circuit := range circuits {
+			if circuit == c {
+				return arity[0], arity[1]
+			}
+		}
+	}
+	return 0, 0
+}
+
+// PublicValues returns true for the shielded transfer circuits with public values in and out
+func (c Circuit) PublicValues() bool {
+	for _, circuit := range publicTransferCircuits {
+		if circuit == c {
+			return true
+		}
+	}
+	return false
+}
@@ -12,11 +12,30 @@ It has these top-level messages:
 	Note
 	ShieldedTransferRequest
 	VerifyShieldedTransferRequest
+	VerifyBatchRequest
+	VerifyBatchResult
 	ShieldedTransfer
 	VerifyShieldingRequest
 	Shielding
 	VerifyUnshieldingRequest
 	Unshielding
+	VerifyingKeyRequest
+	G1Point
+	G2Point
+	VerifyingKey
+	PublicInputsRequest
+	PublicInputs
+	KeySet
+	AddKeySetRequest
+	KeySetRequest
+	KeySetList
+	Nullifiers
+	NullifiersResult
+	Commitments
+	TreeState
+	RootAtRequest
+	WitnessRequest
+	Witness
 	ZAddress
 	Bytes
 	Result
@@ -44,14 +63,93 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// ProvingSystem of a proof, set by the server on the proofs it computes. A proof must be verified with
+// keys of its proving system, see GetVerifyingKey.
+type ProvingSystem int32
+
+const (
+	ProvingSystem_PPZKSNARK ProvingSystem = 0
+	ProvingSystem_GROTH16   ProvingSystem = 1
+)
+
+var ProvingSystem_name = map[int32]string{
+	0: "PPZKSNARK",
+	1: "GROTH16",
+}
+var ProvingSystem_value = map[string]int32{
+	"PPZKSNARK": 0,
+	"GROTH16":   1,
+}
+
+func (x ProvingSystem) String() string {
+	return proto.EnumName(ProvingSystem_name, int32(x))
+}
+func (ProvingSystem) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
 // -------------------------------------------------------------------------------------------------
-// Cross operation data structs
+// Verifying key data structs
+type Circuit int32
+
+const (
+	Circuit_SHIELDING           Circuit = 0
+	Circuit_UNSHIELDING         Circuit = 1
+	Circuit_TRANSFER            Circuit = 2
+	Circuit_TRANSFER_1X1        Circuit = 3
+	Circuit_TRANSFER_1X2        Circuit = 4
+	Circuit_TRANSFER_4X2        Circuit = 5
+	Circuit_TRANSFER_4X4        Circuit = 6
+	Circuit_PUBLIC_TRANSFER     Circuit = 7
+	Circuit_PUBLIC_TRANSFER_1X1 Circuit = 8
+	Circuit_PUBLIC_TRANSFER_1X2 Circuit = 9
+	Circuit_PUBLIC_TRANSFER_4X2 Circuit = 10
+	Circuit_PUBLIC_TRANSFER_4X4 Circuit = 11
+)
+
+var Circuit_name = map[int32]string{
+	0:  "SHIELDING",
+	1:  "UNSHIELDING",
+	2:  "TRANSFER",
+	3:  "TRANSFER_1X1",
+	4:  "TRANSFER_1X2",
+	5:  "TRANSFER_4X2",
+	6:  "TRANSFER_4X4",
+	7:  "PUBLIC_TRANSFER",
+	8:  "PUBLIC_TRANSFER_1X1",
+	9:  "PUBLIC_TRANSFER_1X2",
+	10: "PUBLIC_TRANSFER_4X2",
+	11: "PUBLIC_TRANSFER_4X4",
+}
+var Circuit_value = map[string]int32{
+	"SHIELDING":           0,
+	"UNSHIELDING":         1,
+	"TRANSFER":            2,
+	"TRANSFER_1X1":        3,
+	"TRANSFER_1X2":        4,
+	"TRANSFER_4X2":        5,
+	"TRANSFER_4X4":        6,
+	"PUBLIC_TRANSFER":     7,
+	"PUBLIC_TRANSFER_1X1": 8,
+	"PUBLIC_TRANSFER_1X2": 9,
+	"PUBLIC_TRANSFER_4X2": 10,
+	"PUBLIC_TRANSFER_4X4": 11,
+}
+
+func (x Circuit) String() string {
+	return proto.EnumName(Circuit_name, int32(x))
+}
+func (Circuit) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
 type ShieldedInput struct {
 	Sk        []byte   `protobuf:"bytes,1,opt,name=sk,proto3" json:"sk,omitempty"`
 	Rho       []byte   `protobuf:"bytes,2,opt,name=rho,proto3" json:"rho,omitempty"`
 	Value     uint64   `protobuf:"varint,3,opt,name=value" json:"value,omitempty"`
 	TreeIndex uint64   `protobuf:"varint,4,opt,name=treeIndex" json:"treeIndex,omitempty"`
 	TreePath  [][]byte `protobuf:"bytes,5,rep,name=treePath,proto3" json:"treePath,omitempty"`
+	Asset     []byte   `protobuf:"bytes,6,opt,name=asset,proto3" json:"asset,omitempty"`
+	// binding of the unshielding proof (ex: recipient address or transaction hash), 32 bytes or empty for 32
+	// zero bytes: the proof is only valid for it, so that it can't be replayed in another context (front-running).
+	// Ignored in the inputs of a shielded transfer (see ShieldedTransferRequest.binding) and by GetSpendNullifier.
+	Binding []byte `protobuf:"bytes,7,opt,name=binding,proto3" json:"binding,omitempty"`
 }
 
 func (m *ShieldedInput) Reset()                    { *m = ShieldedInput{} }
@@ -94,10 +192,27 @@ func (m *ShieldedInput) GetTreePath() [][]byte {
 	return nil
 }
 
+func (m *ShieldedInput) GetAsset() []byte {
+	if m != nil {
+		return m.Asset
+	}
+	return nil
+}
+
+func (m *ShieldedInput) GetBinding() []byte {
+	if m != nil {
+		return m.Binding
+	}
+	return nil
+}
+
 type Note struct {
 	Pk    []byte `protobuf:"bytes,1,opt,name=pk,proto3" json:"pk,omitempty"`
 	Rho   []byte `protobuf:"bytes,2,opt,name=rho,proto3" json:"rho,omitempty"`
 	Value uint64 `protobuf:"varint,3,opt,name=value" json:"value,omitempty"`
+	// asset of the note, 32 bytes or empty for the default asset (32 zero bytes). Notes of all assets share
+	// the same tree; the notes of a shielded transfer must be of the same asset.
+	Asset []byte `protobuf:"bytes,4,opt,name=asset,proto3" json:"asset,omitempty"`
 }
 
 func (m *Note) Reset()                    { *m = Note{} }
@@ -126,12 +241,26 @@ func (m *Note) GetValue() uint64 {
 	return 0
 }
 
+func (m *Note) GetAsset() []byte {
+	if m != nil {
+		return m.Asset
+	}
+	return nil
+}
+
 // -------------------------------------------------------------------------------------------------
 // ShieldedTransfer data structs
-// note: a shielded transfer has 2 inputs and 2 outputs (UTXO model)
+// note: a shielded transfer has N inputs and M outputs (UTXO model), see Circuit for the supported arities
 type ShieldedTransferRequest struct {
 	Inputs  []*ShieldedInput `protobuf:"bytes,1,rep,name=inputs" json:"inputs,omitempty"`
 	Outputs []*Note          `protobuf:"bytes,2,rep,name=outputs" json:"outputs,omitempty"`
+	// public values entering (ex: shielded from a transparent account) and leaving (unshielded) the
+	// shielded pool
+	VpubIn  uint64 `protobuf:"varint,3,opt,name=vpubIn" json:"vpubIn,omitempty"`
+	VpubOut uint64 `protobuf:"varint,4,opt,name=vpubOut" json:"vpubOut,omitempty"`
+	Binding []byte `protobuf:"bytes,5,opt,name=binding,proto3" json:"binding,omitempty"`
+	// public value paid to whoever submits the transfer (ex: a relayer), in the asset of the notes
+	Fee uint64 `protobuf:"varint,6,opt,name=fee" json:"fee,omitempty"`
 }
 
 func (m *ShieldedTransferRequest) Reset()                    { *m = ShieldedTransferRequest{} }
@@ -153,9 +282,42 @@ func (m *ShieldedTransferRequest) GetOutputs() []*Note {
 	return nil
 }
 
+func (m *ShieldedTransferRequest) GetVpubIn() uint64 {
+	if m != nil {
+		return m.VpubIn
+	}
+	return 0
+}
+
+func (m *ShieldedTransferRequest) GetVpubOut() uint64 {
+	if m != nil {
+		return m.VpubOut
+	}
+	return 0
+}
+
+func (m *ShieldedTransferRequest) GetBinding() []byte {
+	if m != nil {
+		return m.Binding
+	}
+	return nil
+}
+
+func (m *ShieldedTransferRequest) GetFee() uint64 {
+	if m != nil {
+		return m.Fee
+	}
+	return 0
+}
+
 type VerifyShieldedTransferRequest struct {
 	ShieldedTransfer *ShieldedTransfer `protobuf:"bytes,1,opt,name=shieldedTransfer" json:"shieldedTransfer,omitempty"`
 	TreeRoot         []byte            `protobuf:"bytes,2,opt,name=treeRoot,proto3" json:"treeRoot,omitempty"`
+	VpubIn           uint64            `protobuf:"varint,3,opt,name=vpubIn" json:"vpubIn,omitempty"`
+	VpubOut          uint64            `protobuf:"varint,4,opt,name=vpubOut" json:"vpubOut,omitempty"`
+	Asset            []byte            `protobuf:"bytes,5,opt,name=asset,proto3" json:"asset,omitempty"`
+	Binding          []byte            `protobuf:"bytes,6,opt,name=binding,proto3" json:"binding,omitempty"`
+	Fee              uint64            `protobuf:"varint,7,opt,name=fee" json:"fee,omitempty"`
 }
 
 func (m *VerifyShieldedTransferRequest) Reset()                    { *m = VerifyShieldedTransferRequest{} }
@@ -177,19 +339,121 @@ func (m *VerifyShieldedTransferRequest) GetTreeRoot() []byte {
 	return nil
 }
 
+func (m *VerifyShieldedTransferRequest) GetVpubIn() uint64 {
+	if m != nil {
+		return m.VpubIn
+	}
+	return 0
+}
+
+func (m *VerifyShieldedTransferRequest) GetVpubOut() uint64 {
+	if m != nil {
+		return m.VpubOut
+	}
+	return 0
+}
+
+func (m *VerifyShieldedTransferRequest) GetAsset() []byte {
+	if m != nil {
+		return m.Asset
+	}
+	return nil
+}
+
+func (m *VerifyShieldedTransferRequest) GetBinding() []byte {
+	if m != nil {
+		return m.Binding
+	}
+	return nil
+}
+
+func (m *VerifyShieldedTransferRequest) GetFee() uint64 {
+	if m != nil {
+		return m.Fee
+	}
+	return 0
+}
+
+type VerifyBatchRequest struct {
+	Shieldings        []*VerifyShieldingRequest        `protobuf:"bytes,1,rep,name=shieldings" json:"shieldings,omitempty"`
+	Unshieldings      []*VerifyUnshieldingRequest      `protobuf:"bytes,2,rep,name=unshieldings" json:"unshieldings,omitempty"`
+	ShieldedTransfers []*VerifyShieldedTransferRequest `protobuf:"bytes,3,rep,name=shieldedTransfers" json:"shieldedTransfers,omitempty"`
+}
+
+func (m *VerifyBatchRequest) Reset()                    { *m = VerifyBatchRequest{} }
+func (m *VerifyBatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*VerifyBatchRequest) ProtoMessage()               {}
+func (*VerifyBatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *VerifyBatchRequest) GetShieldings() []*VerifyShieldingRequest {
+	if m != nil {
+		return m.Shieldings
+	}
+	return nil
+}
+
+func (m *VerifyBatchRequest) GetUnshieldings() []*VerifyUnshieldingRequest {
+	if m != nil {
+		return m.Unshieldings
+	}
+	return nil
+}
+
+func (m *VerifyBatchRequest) GetShieldedTransfers() []*VerifyShieldedTransferRequest {
+	if m != nil {
+		return m.ShieldedTransfers
+	}
+	return nil
+}
+
+type VerifyBatchResult struct {
+	// shieldings[i] is the result of VerifyBatchRequest.shieldings[i], and so on
+	Shieldings        []bool `protobuf:"varint,1,rep,packed,name=shieldings" json:"shieldings,omitempty"`
+	Unshieldings      []bool `protobuf:"varint,2,rep,packed,name=unshieldings" json:"unshieldings,omitempty"`
+	ShieldedTransfers []bool `protobuf:"varint,3,rep,packed,name=shieldedTransfers" json:"shieldedTransfers,omitempty"`
+}
+
+func (m *VerifyBatchResult) Reset()                    { *m = VerifyBatchResult{} }
+func (m *VerifyBatchResult) String() string            { return proto.CompactTextString(m) }
+func (*VerifyBatchResult) ProtoMessage()               {}
+func (*VerifyBatchResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *VerifyBatchResult) GetShieldings() []bool {
+	if m != nil {
+		return m.Shieldings
+	}
+	return nil
+}
+
+func (m *VerifyBatchResult) GetUnshieldings() []bool {
+	if m != nil {
+		return m.Unshieldings
+	}
+	return nil
+}
+
+func (m *VerifyBatchResult) GetShieldedTransfers() []bool {
+	if m != nil {
+		return m.ShieldedTransfers
+	}
+	return nil
+}
+
 type ShieldedTransfer struct {
 	Snark []byte `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
 	// input spend nullifiers
 	SpendNullifiers [][]byte `protobuf:"bytes,2,rep,name=spendNullifiers,proto3" json:"spendNullifiers,omitempty"`
 	// output send nullifiers & commitments
-	SendNullifiers [][]byte `protobuf:"bytes,3,rep,name=sendNullifiers,proto3" json:"sendNullifiers,omitempty"`
-	Commitments    [][]byte `protobuf:"bytes,4,rep,name=commitments,proto3" json:"commitments,omitempty"`
+	SendNullifiers [][]byte      `protobuf:"bytes,3,rep,name=sendNullifiers,proto3" json:"sendNullifiers,omitempty"`
+	Commitments    [][]byte      `protobuf:"bytes,4,rep,name=commitments,proto3" json:"commitments,omitempty"`
+	ProvingSystem  ProvingSystem `protobuf:"varint,5,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	KeyId          string        `protobuf:"bytes,6,opt,name=keyId" json:"keyId,omitempty"`
 }
 
 func (m *ShieldedTransfer) Reset()                    { *m = ShieldedTransfer{} }
 func (m *ShieldedTransfer) String() string            { return proto.CompactTextString(m) }
 func (*ShieldedTransfer) ProtoMessage()               {}
-func (*ShieldedTransfer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*ShieldedTransfer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *ShieldedTransfer) GetSnark() []byte {
 	if m != nil {
@@ -219,17 +483,32 @@ func (m *ShieldedTransfer) GetCommitments() [][]byte {
 	return nil
 }
 
+func (m *ShieldedTransfer) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
+}
+
+func (m *ShieldedTransfer) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
 // -------------------------------------------------------------------------------------------------
 // Shielding data structs
 type VerifyShieldingRequest struct {
 	Shielding *Shielding `protobuf:"bytes,1,opt,name=shielding" json:"shielding,omitempty"`
 	Value     uint64     `protobuf:"varint,2,opt,name=value" json:"value,omitempty"`
+	Asset     []byte     `protobuf:"bytes,3,opt,name=asset,proto3" json:"asset,omitempty"`
 }
 
 func (m *VerifyShieldingRequest) Reset()                    { *m = VerifyShieldingRequest{} }
 func (m *VerifyShieldingRequest) String() string            { return proto.CompactTextString(m) }
 func (*VerifyShieldingRequest) ProtoMessage()               {}
-func (*VerifyShieldingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*VerifyShieldingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *VerifyShieldingRequest) GetShielding() *Shielding {
 	if m != nil {
@@ -245,16 +524,25 @@ func (m *VerifyShieldingRequest) GetValue() uint64 {
 	return 0
 }
 
+func (m *VerifyShieldingRequest) GetAsset() []byte {
+	if m != nil {
+		return m.Asset
+	}
+	return nil
+}
+
 type Shielding struct {
-	Snark         []byte `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
-	Commitment    []byte `protobuf:"bytes,2,opt,name=commitment,proto3" json:"commitment,omitempty"`
-	SendNullifier []byte `protobuf:"bytes,3,opt,name=sendNullifier,proto3" json:"sendNullifier,omitempty"`
+	Snark         []byte        `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
+	Commitment    []byte        `protobuf:"bytes,2,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	SendNullifier []byte        `protobuf:"bytes,3,opt,name=sendNullifier,proto3" json:"sendNullifier,omitempty"`
+	ProvingSystem ProvingSystem `protobuf:"varint,4,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	KeyId         string        `protobuf:"bytes,5,opt,name=keyId" json:"keyId,omitempty"`
 }
 
 func (m *Shielding) Reset()                    { *m = Shielding{} }
 func (m *Shielding) String() string            { return proto.CompactTextString(m) }
 func (*Shielding) ProtoMessage()               {}
-func (*Shielding) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*Shielding) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *Shielding) GetSnark() []byte {
 	if m != nil {
@@ -277,19 +565,37 @@ func (m *Shielding) GetSendNullifier() []byte {
 	return nil
 }
 
+func (m *Shielding) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
+}
+
+func (m *Shielding) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
 // -------------------------------------------------------------------------------------------------
 // Unshielding data structs
 type VerifyUnshieldingRequest struct {
-	Snark          []byte `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
-	SpendNullifier []byte `protobuf:"bytes,2,opt,name=spendNullifier,proto3" json:"spendNullifier,omitempty"`
-	TreeRoot       []byte `protobuf:"bytes,3,opt,name=treeRoot,proto3" json:"treeRoot,omitempty"`
-	Value          uint64 `protobuf:"varint,4,opt,name=value" json:"value,omitempty"`
+	Snark          []byte        `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
+	SpendNullifier []byte        `protobuf:"bytes,2,opt,name=spendNullifier,proto3" json:"spendNullifier,omitempty"`
+	TreeRoot       []byte        `protobuf:"bytes,3,opt,name=treeRoot,proto3" json:"treeRoot,omitempty"`
+	Value          uint64        `protobuf:"varint,4,opt,name=value" json:"value,omitempty"`
+	ProvingSystem  ProvingSystem `protobuf:"varint,5,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	KeyId          string        `protobuf:"bytes,6,opt,name=keyId" json:"keyId,omitempty"`
+	Asset          []byte        `protobuf:"bytes,7,opt,name=asset,proto3" json:"asset,omitempty"`
+	Binding        []byte        `protobuf:"bytes,8,opt,name=binding,proto3" json:"binding,omitempty"`
 }
 
 func (m *VerifyUnshieldingRequest) Reset()                    { *m = VerifyUnshieldingRequest{} }
 func (m *VerifyUnshieldingRequest) String() string            { return proto.CompactTextString(m) }
 func (*VerifyUnshieldingRequest) ProtoMessage()               {}
-func (*VerifyUnshieldingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*VerifyUnshieldingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *VerifyUnshieldingRequest) GetSnark() []byte {
 	if m != nil {
@@ -319,16 +625,46 @@ func (m *VerifyUnshieldingRequest) GetValue() uint64 {
 	return 0
 }
 
+func (m *VerifyUnshieldingRequest) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
+}
+
+func (m *VerifyUnshieldingRequest) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+func (m *VerifyUnshieldingRequest) GetAsset() []byte {
+	if m != nil {
+		return m.Asset
+	}
+	return nil
+}
+
+func (m *VerifyUnshieldingRequest) GetBinding() []byte {
+	if m != nil {
+		return m.Binding
+	}
+	return nil
+}
+
 type Unshielding struct {
-	Snark          []byte `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
-	SpendNullifier []byte `protobuf:"bytes,2,opt,name=spendNullifier,proto3" json:"spendNullifier,omitempty"`
-	SendNullifier  []byte `protobuf:"bytes,3,opt,name=sendNullifier,proto3" json:"sendNullifier,omitempty"`
+	Snark          []byte        `protobuf:"bytes,1,opt,name=snark,proto3" json:"snark,omitempty"`
+	SpendNullifier []byte        `protobuf:"bytes,2,opt,name=spendNullifier,proto3" json:"spendNullifier,omitempty"`
+	SendNullifier  []byte        `protobuf:"bytes,3,opt,name=sendNullifier,proto3" json:"sendNullifier,omitempty"`
+	ProvingSystem  ProvingSystem `protobuf:"varint,4,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	KeyId          string        `protobuf:"bytes,5,opt,name=keyId" json:"keyId,omitempty"`
 }
 
 func (m *Unshielding) Reset()                    { *m = Unshielding{} }
 func (m *Unshielding) String() string            { return proto.CompactTextString(m) }
 func (*Unshielding) ProtoMessage()               {}
-func (*Unshielding) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*Unshielding) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *Unshielding) GetSnark() []byte {
 	if m != nil {
@@ -351,114 +687,762 @@ func (m *Unshielding) GetSendNullifier() []byte {
 	return nil
 }
 
-// -------------------------------------------------------------------------------------------------
-// Other
-type ZAddress struct {
-	Sk []byte `protobuf:"bytes,1,opt,name=sk,proto3" json:"sk,omitempty"`
-	Pk []byte `protobuf:"bytes,2,opt,name=pk,proto3" json:"pk,omitempty"`
+func (m *Unshielding) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
 }
 
-func (m *ZAddress) Reset()                    { *m = ZAddress{} }
-func (m *ZAddress) String() string            { return proto.CompactTextString(m) }
-func (*ZAddress) ProtoMessage()               {}
-func (*ZAddress) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (m *Unshielding) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
 
-func (m *ZAddress) GetSk() []byte {
+type VerifyingKeyRequest struct {
+	Circuit Circuit `protobuf:"varint,1,opt,name=circuit,enum=zsl.Circuit" json:"circuit,omitempty"`
+	KeyId   string  `protobuf:"bytes,2,opt,name=keyId" json:"keyId,omitempty"`
+}
+
+func (m *VerifyingKeyRequest) Reset()                    { *m = VerifyingKeyRequest{} }
+func (m *VerifyingKeyRequest) String() string            { return proto.CompactTextString(m) }
+func (*VerifyingKeyRequest) ProtoMessage()               {}
+func (*VerifyingKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *VerifyingKeyRequest) GetCircuit() Circuit {
 	if m != nil {
-		return m.Sk
+		return m.Circuit
 	}
-	return nil
+	return Circuit_SHIELDING
 }
 
-func (m *ZAddress) GetPk() []byte {
+func (m *VerifyingKeyRequest) GetKeyId() string {
 	if m != nil {
-		return m.Pk
+		return m.KeyId
 	}
-	return nil
+	return ""
 }
 
-type Bytes struct {
-	Bytes []byte `protobuf:"bytes,1,opt,name=bytes,proto3" json:"bytes,omitempty"`
+// alt_bn128 G1 point, in affine coordinates. Coordinates are field elements, hex encoded
+// (0x prefixed, 32 bytes, big endian). The point at infinity is (0, 0).
+type G1Point struct {
+	X string `protobuf:"bytes,1,opt,name=x" json:"x,omitempty"`
+	Y string `protobuf:"bytes,2,opt,name=y" json:"y,omitempty"`
 }
 
-func (m *Bytes) Reset()                    { *m = Bytes{} }
-func (m *Bytes) String() string            { return proto.CompactTextString(m) }
-func (*Bytes) ProtoMessage()               {}
-func (*Bytes) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (m *G1Point) Reset()                    { *m = G1Point{} }
+func (m *G1Point) String() string            { return proto.CompactTextString(m) }
+func (*G1Point) ProtoMessage()               {}
+func (*G1Point) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
-func (m *Bytes) GetBytes() []byte {
+func (m *G1Point) GetX() string {
 	if m != nil {
-		return m.Bytes
+		return m.X
+	}
+	return ""
+}
+
+func (m *G1Point) GetY() string {
+	if m != nil {
+		return m.Y
+	}
+	return ""
+}
+
+// alt_bn128 G2 point, in affine coordinates over Fp2 = Fp[i]/(i^2 + 1). A coordinate is [c0, c1]
+// for c0 + c1 * i, encoded as G1Point coordinates. The point at infinity is ([0, 0], [0, 0]).
+type G2Point struct {
+	X []string `protobuf:"bytes,1,rep,name=x" json:"x,omitempty"`
+	Y []string `protobuf:"bytes,2,rep,name=y" json:"y,omitempty"`
+}
+
+func (m *G2Point) Reset()                    { *m = G2Point{} }
+func (m *G2Point) String() string            { return proto.CompactTextString(m) }
+func (*G2Point) ProtoMessage()               {}
+func (*G2Point) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *G2Point) GetX() []string {
+	if m != nil {
+		return m.X
 	}
 	return nil
 }
 
-type Result struct {
-	Result  bool   `protobuf:"varint,1,opt,name=result" json:"result,omitempty"`
-	Message string `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+func (m *G2Point) GetY() []string {
+	if m != nil {
+		return m.Y
+	}
+	return nil
 }
 
-func (m *Result) Reset()                    { *m = Result{} }
-func (m *Result) String() string            { return proto.CompactTextString(m) }
-func (*Result) ProtoMessage()               {}
-func (*Result) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+// Verifying key, as libsnark's r1cs_ppzksnark_verification_key or r1cs_gg_ppzksnark_verification_key,
+// depending on its proving system
+type VerifyingKey struct {
+	Circuit       Circuit       `protobuf:"varint,1,opt,name=circuit,enum=zsl.Circuit" json:"circuit,omitempty"`
+	Raw           []byte        `protobuf:"bytes,2,opt,name=raw,proto3" json:"raw,omitempty"`
+	Fingerprint   string        `protobuf:"bytes,3,opt,name=fingerprint" json:"fingerprint,omitempty"`
+	ProvingSystem ProvingSystem `protobuf:"varint,12,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	KeyId         string        `protobuf:"bytes,17,opt,name=keyId" json:"keyId,omitempty"`
+	TreeDepth     uint32        `protobuf:"varint,18,opt,name=treeDepth" json:"treeDepth,omitempty"`
+	// PPZKSNARK and GROTH16
+	Gamma *G2Point   `protobuf:"bytes,7,opt,name=gamma" json:"gamma,omitempty"`
+	Ic    []*G1Point `protobuf:"bytes,11,rep,name=ic" json:"ic,omitempty"`
+	// PPZKSNARK
+	AlphaA     *G2Point `protobuf:"bytes,4,opt,name=alphaA" json:"alphaA,omitempty"`
+	AlphaB     *G1Point `protobuf:"bytes,5,opt,name=alphaB" json:"alphaB,omitempty"`
+	AlphaC     *G2Point `protobuf:"bytes,6,opt,name=alphaC" json:"alphaC,omitempty"`
+	GammaBeta1 *G1Point `protobuf:"bytes,8,opt,name=gammaBeta1" json:"gammaBeta1,omitempty"`
+	GammaBeta2 *G2Point `protobuf:"bytes,9,opt,name=gammaBeta2" json:"gammaBeta2,omitempty"`
+	RCZ        *G2Point `protobuf:"bytes,10,opt,name=rCZ" json:"rCZ,omitempty"`
+	// GROTH16
+	AlphaBeta []string `protobuf:"bytes,13,rep,name=alphaBeta" json:"alphaBeta,omitempty"`
+	Delta     *G2Point `protobuf:"bytes,14,opt,name=delta" json:"delta,omitempty"`
+	// alpha_g1 and beta_g2 of the proving key, for verifiers that can't compare GT elements (EVM precompiles).
+	// Not set by GetVerifyingKey, see zslsolidity.
+	Alpha *G1Point `protobuf:"bytes,15,opt,name=alpha" json:"alpha,omitempty"`
+	Beta  *G2Point `protobuf:"bytes,16,opt,name=beta" json:"beta,omitempty"`
+}
+
+func (m *VerifyingKey) Reset()                    { *m = VerifyingKey{} }
+func (m *VerifyingKey) String() string            { return proto.CompactTextString(m) }
+func (*VerifyingKey) ProtoMessage()               {}
+func (*VerifyingKey) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *VerifyingKey) GetCircuit() Circuit {
+	if m != nil {
+		return m.Circuit
+	}
+	return Circuit_SHIELDING
+}
 
-func (m *Result) GetResult() bool {
+func (m *VerifyingKey) GetRaw() []byte {
 	if m != nil {
-		return m.Result
+		return m.Raw
 	}
-	return false
+	return nil
 }
 
-func (m *Result) GetMessage() string {
+func (m *VerifyingKey) GetFingerprint() string {
 	if m != nil {
-		return m.Message
+		return m.Fingerprint
 	}
 	return ""
 }
 
-type Void struct {
+func (m *VerifyingKey) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
 }
 
-func (m *Void) Reset()                    { *m = Void{} }
-func (m *Void) String() string            { return proto.CompactTextString(m) }
-func (*Void) ProtoMessage()               {}
-func (*Void) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
-
-func init() {
-	proto.RegisterType((*ShieldedInput)(nil), "zsl.ShieldedInput")
-	proto.RegisterType((*Note)(nil), "zsl.Note")
-	proto.RegisterType((*ShieldedTransferRequest)(nil), "zsl.ShieldedTransferRequest")
-	proto.RegisterType((*VerifyShieldedTransferRequest)(nil), "zsl.VerifyShieldedTransferRequest")
-	proto.RegisterType((*ShieldedTransfer)(nil), "zsl.ShieldedTransfer")
-	proto.RegisterType((*VerifyShieldingRequest)(nil), "zsl.VerifyShieldingRequest")
-	proto.RegisterType((*Shielding)(nil), "zsl.Shielding")
-	proto.RegisterType((*VerifyUnshieldingRequest)(nil), "zsl.VerifyUnshieldingRequest")
-	proto.RegisterType((*Unshielding)(nil), "zsl.Unshielding")
-	proto.RegisterType((*ZAddress)(nil), "zsl.ZAddress")
-	proto.RegisterType((*Bytes)(nil), "zsl.Bytes")
-	proto.RegisterType((*Result)(nil), "zsl.Result")
-	proto.RegisterType((*Void)(nil), "zsl.Void")
+func (m *VerifyingKey) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *VerifyingKey) GetTreeDepth() uint32 {
+	if m != nil {
+		return m.TreeDepth
+	}
+	return 0
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *VerifyingKey) GetGamma() *G2Point {
+	if m != nil {
+		return m.Gamma
+	}
+	return nil
+}
 
-// Client API for ZSLBox service
+func (m *VerifyingKey) GetIc() []*G1Point {
+	if m != nil {
+		return m.Ic
+	}
+	return nil
+}
 
-type ZSLBoxClient interface {
-	// CreateShielding computes a zkSNARK and a note commitment for given note.
+func (m *VerifyingKey) GetAlphaA() *G2Point {
+	if m != nil {
+		return m.AlphaA
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetAlphaB() *G1Point {
+	if m != nil {
+		return m.AlphaB
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetAlphaC() *G2Point {
+	if m != nil {
+		return m.AlphaC
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetGammaBeta1() *G1Point {
+	if m != nil {
+		return m.GammaBeta1
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetGammaBeta2() *G2Point {
+	if m != nil {
+		return m.GammaBeta2
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetRCZ() *G2Point {
+	if m != nil {
+		return m.RCZ
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetAlphaBeta() []string {
+	if m != nil {
+		return m.AlphaBeta
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetDelta() *G2Point {
+	if m != nil {
+		return m.Delta
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetAlpha() *G1Point {
+	if m != nil {
+		return m.Alpha
+	}
+	return nil
+}
+
+func (m *VerifyingKey) GetBeta() *G2Point {
+	if m != nil {
+		return m.Beta
+	}
+	return nil
+}
+
+// Exactly one operation must be set; its snark is ignored
+type PublicInputsRequest struct {
+	Shielding        *VerifyShieldingRequest        `protobuf:"bytes,1,opt,name=shielding" json:"shielding,omitempty"`
+	Unshielding      *VerifyUnshieldingRequest      `protobuf:"bytes,2,opt,name=unshielding" json:"unshielding,omitempty"`
+	ShieldedTransfer *VerifyShieldedTransferRequest `protobuf:"bytes,3,opt,name=shieldedTransfer" json:"shieldedTransfer,omitempty"`
+}
+
+func (m *PublicInputsRequest) Reset()                    { *m = PublicInputsRequest{} }
+func (m *PublicInputsRequest) String() string            { return proto.CompactTextString(m) }
+func (*PublicInputsRequest) ProtoMessage()               {}
+func (*PublicInputsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *PublicInputsRequest) GetShielding() *VerifyShieldingRequest {
+	if m != nil {
+		return m.Shielding
+	}
+	return nil
+}
+
+func (m *PublicInputsRequest) GetUnshielding() *VerifyUnshieldingRequest {
+	if m != nil {
+		return m.Unshielding
+	}
+	return nil
+}
+
+func (m *PublicInputsRequest) GetShieldedTransfer() *VerifyShieldedTransferRequest {
+	if m != nil {
+		return m.ShieldedTransfer
+	}
+	return nil
+}
+
+type PublicInputs struct {
+	Circuit Circuit `protobuf:"varint,1,opt,name=circuit,enum=zsl.Circuit" json:"circuit,omitempty"`
+	// field elements (0x prefixed, 32 bytes big endian hex), one per ic element of the verifying key but
+	// the first: the bits of the nullifiers, commitments, tree root, values (8 bytes, little endian), asset and
+	// binding, each byte most significant bit first, packed in 253 bits, least significant bit first
+	Inputs []string `protobuf:"bytes,2,rep,name=inputs" json:"inputs,omitempty"`
+}
+
+func (m *PublicInputs) Reset()                    { *m = PublicInputs{} }
+func (m *PublicInputs) String() string            { return proto.CompactTextString(m) }
+func (*PublicInputs) ProtoMessage()               {}
+func (*PublicInputs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *PublicInputs) GetCircuit() Circuit {
+	if m != nil {
+		return m.Circuit
+	}
+	return Circuit_SHIELDING
+}
+
+func (m *PublicInputs) GetInputs() []string {
+	if m != nil {
+		return m.Inputs
+	}
+	return nil
+}
+
+// KeySet is a set of proving and verifying keys for the circuits. Its key ID is the hex encoded
+// SHA256(SHA256(shielding vk) || SHA256(unshielding vk) || SHA256(transfer vk) || ...), vk being the raw
+// verifying keys of all the circuits, in the order of the Circuit enum. Proofs carry the key ID of their key set, and are verified with the key set of their key ID
+// (the active key set if empty).
+type KeySet struct {
+	KeyId         string        `protobuf:"bytes,1,opt,name=keyId" json:"keyId,omitempty"`
+	KeyDir        string        `protobuf:"bytes,2,opt,name=keyDir" json:"keyDir,omitempty"`
+	ProvingSystem ProvingSystem `protobuf:"varint,3,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	Active        bool          `protobuf:"varint,4,opt,name=active" json:"active,omitempty"`
+	TreeDepth     uint32        `protobuf:"varint,5,opt,name=treeDepth" json:"treeDepth,omitempty"`
+}
+
+func (m *KeySet) Reset()                    { *m = KeySet{} }
+func (m *KeySet) String() string            { return proto.CompactTextString(m) }
+func (*KeySet) ProtoMessage()               {}
+func (*KeySet) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *KeySet) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+func (m *KeySet) GetKeyDir() string {
+	if m != nil {
+		return m.KeyDir
+	}
+	return ""
+}
+
+func (m *KeySet) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
+}
+
+func (m *KeySet) GetActive() bool {
+	if m != nil {
+		return m.Active
+	}
+	return false
+}
+
+func (m *KeySet) GetTreeDepth() uint32 {
+	if m != nil {
+		return m.TreeDepth
+	}
+	return 0
+}
+
+type AddKeySetRequest struct {
+	KeyDir        string        `protobuf:"bytes,1,opt,name=keyDir" json:"keyDir,omitempty"`
+	ProvingSystem ProvingSystem `protobuf:"varint,2,opt,name=provingSystem,enum=zsl.ProvingSystem" json:"provingSystem,omitempty"`
+	// Merkle tree depth of the keys, checked against their manifest if not 0
+	TreeDepth uint32 `protobuf:"varint,3,opt,name=treeDepth" json:"treeDepth,omitempty"`
+}
+
+func (m *AddKeySetRequest) Reset()                    { *m = AddKeySetRequest{} }
+func (m *AddKeySetRequest) String() string            { return proto.CompactTextString(m) }
+func (*AddKeySetRequest) ProtoMessage()               {}
+func (*AddKeySetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *AddKeySetRequest) GetKeyDir() string {
+	if m != nil {
+		return m.KeyDir
+	}
+	return ""
+}
+
+func (m *AddKeySetRequest) GetProvingSystem() ProvingSystem {
+	if m != nil {
+		return m.ProvingSystem
+	}
+	return ProvingSystem_PPZKSNARK
+}
+
+func (m *AddKeySetRequest) GetTreeDepth() uint32 {
+	if m != nil {
+		return m.TreeDepth
+	}
+	return 0
+}
+
+type KeySetRequest struct {
+	KeyId string `protobuf:"bytes,1,opt,name=keyId" json:"keyId,omitempty"`
+}
+
+func (m *KeySetRequest) Reset()                    { *m = KeySetRequest{} }
+func (m *KeySetRequest) String() string            { return proto.CompactTextString(m) }
+func (*KeySetRequest) ProtoMessage()               {}
+func (*KeySetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *KeySetRequest) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+type KeySetList struct {
+	KeySets []*KeySet `protobuf:"bytes,1,rep,name=keySets" json:"keySets,omitempty"`
+}
+
+func (m *KeySetList) Reset()                    { *m = KeySetList{} }
+func (m *KeySetList) String() string            { return proto.CompactTextString(m) }
+func (*KeySetList) ProtoMessage()               {}
+func (*KeySetList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *KeySetList) GetKeySets() []*KeySet {
+	if m != nil {
+		return m.KeySets
+	}
+	return nil
+}
+
+// Nullifiers are 32 bytes each
+type Nullifiers struct {
+	SpendNullifiers [][]byte `protobuf:"bytes,1,rep,name=spendNullifiers,proto3" json:"spendNullifiers,omitempty"`
+	SendNullifiers  [][]byte `protobuf:"bytes,2,rep,name=sendNullifiers,proto3" json:"sendNullifiers,omitempty"`
+}
+
+func (m *Nullifiers) Reset()                    { *m = Nullifiers{} }
+func (m *Nullifiers) String() string            { return proto.CompactTextString(m) }
+func (*Nullifiers) ProtoMessage()               {}
+func (*Nullifiers) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *Nullifiers) GetSpendNullifiers() [][]byte {
+	if m != nil {
+		return m.SpendNullifiers
+	}
+	return nil
+}
+
+func (m *Nullifiers) GetSendNullifiers() [][]byte {
+	if m != nil {
+		return m.SendNullifiers
+	}
+	return nil
+}
+
+type NullifiersResult struct {
+	// spent[i] is true if spendNullifiers[i] was already seen (before the commit, or earlier in the
+	// request), used[i] if sendNullifiers[i] was. CommitNullifiers checks them atomically with the commit.
+	Spent     []bool `protobuf:"varint,1,rep,packed,name=spent" json:"spent,omitempty"`
+	Used      []bool `protobuf:"varint,2,rep,packed,name=used" json:"used,omitempty"`
+	Committed bool   `protobuf:"varint,3,opt,name=committed" json:"committed,omitempty"`
+}
+
+func (m *NullifiersResult) Reset()                    { *m = NullifiersResult{} }
+func (m *NullifiersResult) String() string            { return proto.CompactTextString(m) }
+func (*NullifiersResult) ProtoMessage()               {}
+func (*NullifiersResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *NullifiersResult) GetSpent() []bool {
+	if m != nil {
+		return m.Spent
+	}
+	return nil
+}
+
+func (m *NullifiersResult) GetUsed() []bool {
+	if m != nil {
+		return m.Used
+	}
+	return nil
+}
+
+func (m *NullifiersResult) GetCommitted() bool {
+	if m != nil {
+		return m.Committed
+	}
+	return false
+}
+
+// Commitments are 32 bytes each
+type Commitments struct {
+	Commitments [][]byte `protobuf:"bytes,1,rep,name=commitments,proto3" json:"commitments,omitempty"`
+}
+
+func (m *Commitments) Reset()                    { *m = Commitments{} }
+func (m *Commitments) String() string            { return proto.CompactTextString(m) }
+func (*Commitments) ProtoMessage()               {}
+func (*Commitments) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *Commitments) GetCommitments() [][]byte {
+	if m != nil {
+		return m.Commitments
+	}
+	return nil
+}
+
+type TreeState struct {
+	Root  []byte `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Size  uint64 `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+	Depth uint64 `protobuf:"varint,3,opt,name=depth" json:"depth,omitempty"`
+}
+
+func (m *TreeState) Reset()                    { *m = TreeState{} }
+func (m *TreeState) String() string            { return proto.CompactTextString(m) }
+func (*TreeState) ProtoMessage()               {}
+func (*TreeState) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *TreeState) GetRoot() []byte {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
+func (m *TreeState) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *TreeState) GetDepth() uint64 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+type RootAtRequest struct {
+	Size uint64 `protobuf:"varint,1,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *RootAtRequest) Reset()                    { *m = RootAtRequest{} }
+func (m *RootAtRequest) String() string            { return proto.CompactTextString(m) }
+func (*RootAtRequest) ProtoMessage()               {}
+func (*RootAtRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *RootAtRequest) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+// WitnessRequest is by commitment if set, by treeIndex otherwise
+type WitnessRequest struct {
+	Commitment []byte `protobuf:"bytes,1,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	TreeIndex  uint64 `protobuf:"varint,2,opt,name=treeIndex" json:"treeIndex,omitempty"`
+}
+
+func (m *WitnessRequest) Reset()                    { *m = WitnessRequest{} }
+func (m *WitnessRequest) String() string            { return proto.CompactTextString(m) }
+func (*WitnessRequest) ProtoMessage()               {}
+func (*WitnessRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *WitnessRequest) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *WitnessRequest) GetTreeIndex() uint64 {
+	if m != nil {
+		return m.TreeIndex
+	}
+	return 0
+}
+
+type Witness struct {
+	TreeIndex  uint64   `protobuf:"varint,1,opt,name=treeIndex" json:"treeIndex,omitempty"`
+	TreePath   [][]byte `protobuf:"bytes,2,rep,name=treePath,proto3" json:"treePath,omitempty"`
+	Commitment []byte   `protobuf:"bytes,3,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	Root       []byte   `protobuf:"bytes,4,opt,name=root,proto3" json:"root,omitempty"`
+	Size       uint64   `protobuf:"varint,5,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *Witness) Reset()                    { *m = Witness{} }
+func (m *Witness) String() string            { return proto.CompactTextString(m) }
+func (*Witness) ProtoMessage()               {}
+func (*Witness) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *Witness) GetTreeIndex() uint64 {
+	if m != nil {
+		return m.TreeIndex
+	}
+	return 0
+}
+
+func (m *Witness) GetTreePath() [][]byte {
+	if m != nil {
+		return m.TreePath
+	}
+	return nil
+}
+
+func (m *Witness) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *Witness) GetRoot() []byte {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
+func (m *Witness) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+// -------------------------------------------------------------------------------------------------
+// Other
+type ZAddress struct {
+	Sk []byte `protobuf:"bytes,1,opt,name=sk,proto3" json:"sk,omitempty"`
+	Pk []byte `protobuf:"bytes,2,opt,name=pk,proto3" json:"pk,omitempty"`
+}
+
+func (m *ZAddress) Reset()                    { *m = ZAddress{} }
+func (m *ZAddress) String() string            { return proto.CompactTextString(m) }
+func (*ZAddress) ProtoMessage()               {}
+func (*ZAddress) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *ZAddress) GetSk() []byte {
+	if m != nil {
+		return m.Sk
+	}
+	return nil
+}
+
+func (m *ZAddress) GetPk() []byte {
+	if m != nil {
+		return m.Pk
+	}
+	return nil
+}
+
+type Bytes struct {
+	Bytes []byte `protobuf:"bytes,1,opt,name=bytes,proto3" json:"bytes,omitempty"`
+}
+
+func (m *Bytes) Reset()                    { *m = Bytes{} }
+func (m *Bytes) String() string            { return proto.CompactTextString(m) }
+func (*Bytes) ProtoMessage()               {}
+func (*Bytes) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *Bytes) GetBytes() []byte {
+	if m != nil {
+		return m.Bytes
+	}
+	return nil
+}
+
+type Result struct {
+	Result  bool   `protobuf:"varint,1,opt,name=result" json:"result,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *Result) Reset()                    { *m = Result{} }
+func (m *Result) String() string            { return proto.CompactTextString(m) }
+func (*Result) ProtoMessage()               {}
+func (*Result) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *Result) GetResult() bool {
+	if m != nil {
+		return m.Result
+	}
+	return false
+}
+
+func (m *Result) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type Void struct {
+}
+
+func (m *Void) Reset()                    { *m = Void{} }
+func (m *Void) String() string            { return proto.CompactTextString(m) }
+func (*Void) ProtoMessage()               {}
+func (*Void) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func init() {
+	proto.RegisterType((*ShieldedInput)(nil), "zsl.ShieldedInput")
+	proto.RegisterType((*Note)(nil), "zsl.Note")
+	proto.RegisterType((*ShieldedTransferRequest)(nil), "zsl.ShieldedTransferRequest")
+	proto.RegisterType((*VerifyShieldedTransferRequest)(nil), "zsl.VerifyShieldedTransferRequest")
+	proto.RegisterType((*VerifyBatchRequest)(nil), "zsl.VerifyBatchRequest")
+	proto.RegisterType((*VerifyBatchResult)(nil), "zsl.VerifyBatchResult")
+	proto.RegisterType((*ShieldedTransfer)(nil), "zsl.ShieldedTransfer")
+	proto.RegisterType((*VerifyShieldingRequest)(nil), "zsl.VerifyShieldingRequest")
+	proto.RegisterType((*Shielding)(nil), "zsl.Shielding")
+	proto.RegisterType((*VerifyUnshieldingRequest)(nil), "zsl.VerifyUnshieldingRequest")
+	proto.RegisterType((*Unshielding)(nil), "zsl.Unshielding")
+	proto.RegisterType((*VerifyingKeyRequest)(nil), "zsl.VerifyingKeyRequest")
+	proto.RegisterType((*G1Point)(nil), "zsl.G1Point")
+	proto.RegisterType((*G2Point)(nil), "zsl.G2Point")
+	proto.RegisterType((*VerifyingKey)(nil), "zsl.VerifyingKey")
+	proto.RegisterType((*PublicInputsRequest)(nil), "zsl.PublicInputsRequest")
+	proto.RegisterType((*PublicInputs)(nil), "zsl.PublicInputs")
+	proto.RegisterType((*KeySet)(nil), "zsl.KeySet")
+	proto.RegisterType((*AddKeySetRequest)(nil), "zsl.AddKeySetRequest")
+	proto.RegisterType((*KeySetRequest)(nil), "zsl.KeySetRequest")
+	proto.RegisterType((*KeySetList)(nil), "zsl.KeySetList")
+	proto.RegisterType((*Nullifiers)(nil), "zsl.Nullifiers")
+	proto.RegisterType((*NullifiersResult)(nil), "zsl.NullifiersResult")
+	proto.RegisterType((*Commitments)(nil), "zsl.Commitments")
+	proto.RegisterType((*TreeState)(nil), "zsl.TreeState")
+	proto.RegisterType((*RootAtRequest)(nil), "zsl.RootAtRequest")
+	proto.RegisterType((*WitnessRequest)(nil), "zsl.WitnessRequest")
+	proto.RegisterType((*Witness)(nil), "zsl.Witness")
+	proto.RegisterType((*ZAddress)(nil), "zsl.ZAddress")
+	proto.RegisterType((*Bytes)(nil), "zsl.Bytes")
+	proto.RegisterType((*Result)(nil), "zsl.Result")
+	proto.RegisterType((*Void)(nil), "zsl.Void")
+	proto.RegisterEnum("zsl.ProvingSystem", ProvingSystem_name, ProvingSystem_value)
+	proto.RegisterEnum("zsl.Circuit", Circuit_name, Circuit_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for ZSLBox service
+
+type ZSLBoxClient interface {
+	// CreateShielding computes a zkSNARK and a note commitment for given note.
 	// Also returns a sendNullifier to ensure note.Rho (random) is unique
 	CreateShielding(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Shielding, error)
-	// CreateUnshielding computes a zkSNARK, nullifiers for given input, using Unshielding circuit
+	// CreateUnshielding computes a zkSNARK, nullifiers for given input, using Unshielding circuit.
+	// The zkSNARK is bound to input.Binding: it only verifies with the same binding.
 	CreateUnshielding(ctx context.Context, in *ShieldedInput, opts ...grpc.CallOption) (*Unshielding, error)
-	// CreateShieldedTransfer takes 2 notes as inputs (known Sk) and 2 desired output notes.
-	// It returns the zkSNARK, the spend nullifiers for the inputs, and the commitments & send nullifiers for outputs
+	// CreateShieldedTransfer takes N notes as inputs (known Sk) and M desired output notes, with the
+	// circuit of their arity: 1x1, 1x2, 2x2, 4x2 or 4x4 (see Circuit), and public values in and out
+	// and a public fee (inputs + vpubIn = outputs + vpubOut + fee) if any isn't 0.
+	// It returns the zkSNARK, bound to request.Binding, the spend nullifiers for the inputs, and the commitments &
+	// send nullifiers for outputs
 	CreateShieldedTransfer(ctx context.Context, in *ShieldedTransferRequest, opts ...grpc.CallOption) (*ShieldedTransfer, error)
 	// VerifyShielding ensures that the provided Shielding proof is valid. It takes as input the zkSNARK,
 	// the send nullifier, commitment and value of the shielded note.
@@ -467,11 +1451,15 @@ type ZSLBoxClient interface {
 	// the spend nullifier, the tree root and value of the shielded note.
 	VerifyUnshielding(ctx context.Context, in *VerifyUnshieldingRequest, opts ...grpc.CallOption) (*Result, error)
 	// VerifyShieldedTransfer ensures that the provided shielded transfer proof is valid.
-	// It takes as input the zkSNARK, treeRoot, spend nullifiers for inputs and send nullifiers & commitments
-	// for outputs
+	// It takes as input the zkSNARK, treeRoot, spend nullifiers for inputs, send nullifiers & commitments
+	// for outputs and the public values: their numbers, and whether a public value isn't 0, select the circuit
 	VerifyShieldedTransfer(ctx context.Context, in *VerifyShieldedTransferRequest, opts ...grpc.CallOption) (*Result, error)
-	// GetCommitment returns SHA256(note.Rho || note.Pk || note.Value)
-	// where note.Value is in little endian byte order
+	// VerifyBatch verifies many shielding, unshielding and shielded transfer proofs (ex: all the shielded
+	// transactions of a block) in parallel. It returns one result per request, in the same order;
+	// malformed requests are invalid.
+	VerifyBatch(ctx context.Context, in *VerifyBatchRequest, opts ...grpc.CallOption) (*VerifyBatchResult, error)
+	// GetCommitment returns SHA256(note.Rho || note.Pk || note.Value || note.Asset)
+	// where note.Value is in little endian byte order and note.Asset is 32 bytes (32 zero bytes if empty)
 	GetCommitment(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Bytes, error)
 	// GetSendNullifier returns SHA256(0x00 || note.Rho)
 	GetSendNullifier(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Bytes, error)
@@ -481,398 +1469,935 @@ type ZSLBoxClient interface {
 	GetNewAddress(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ZAddress, error)
 	// Sha256Compress applies SHA-256 to one input block, excluding the padding step specified in [NIST2015, Section 5.1]
 	Sha256Compress(ctx context.Context, in *Bytes, opts ...grpc.CallOption) (*Bytes, error)
+	// GetVerifyingKey returns the verifying key of a circuit, raw (libsnark serialization) and structured,
+	// along with its fingerprint
+	GetVerifyingKey(ctx context.Context, in *VerifyingKeyRequest, opts ...grpc.CallOption) (*VerifyingKey, error)
+	// GetPublicInputs returns the public inputs of a shielding, unshielding or shielded transfer, packed in
+	// field elements as the circuit verifiers expect them
+	GetPublicInputs(ctx context.Context, in *PublicInputsRequest, opts ...grpc.CallOption) (*PublicInputs, error)
+}
+
+type zSLBoxClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewZSLBoxClient(cc *grpc.ClientConn) ZSLBoxClient {
+	return &zSLBoxClient{cc}
+}
+
+func (c *zSLBoxClient) CreateShielding(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Shielding, error) {
+	out := new(Shielding)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/CreateShielding", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) CreateUnshielding(ctx context.Context, in *ShieldedInput, opts ...grpc.CallOption) (*Unshielding, error) {
+	out := new(Unshielding)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/CreateUnshielding", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) CreateShieldedTransfer(ctx context.Context, in *ShieldedTransferRequest, opts ...grpc.CallOption) (*ShieldedTransfer, error) {
+	out := new(ShieldedTransfer)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/CreateShieldedTransfer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) VerifyShielding(ctx context.Context, in *VerifyShieldingRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyShielding", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) VerifyUnshielding(ctx context.Context, in *VerifyUnshieldingRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyUnshielding", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) VerifyShieldedTransfer(ctx context.Context, in *VerifyShieldedTransferRequest, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyShieldedTransfer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) VerifyBatch(ctx context.Context, in *VerifyBatchRequest, opts ...grpc.CallOption) (*VerifyBatchResult, error) {
+	out := new(VerifyBatchResult)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyBatch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) GetCommitment(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Bytes, error) {
+	out := new(Bytes)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetCommitment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) GetSendNullifier(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Bytes, error) {
+	out := new(Bytes)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetSendNullifier", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) GetSpendNullifier(ctx context.Context, in *ShieldedInput, opts ...grpc.CallOption) (*Bytes, error) {
+	out := new(Bytes)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetSpendNullifier", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) GetNewAddress(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ZAddress, error) {
+	out := new(ZAddress)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetNewAddress", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) Sha256Compress(ctx context.Context, in *Bytes, opts ...grpc.CallOption) (*Bytes, error) {
+	out := new(Bytes)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/Sha256Compress", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) GetVerifyingKey(ctx context.Context, in *VerifyingKeyRequest, opts ...grpc.CallOption) (*VerifyingKey, error) {
+	out := new(VerifyingKey)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetVerifyingKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zSLBoxClient) GetPublicInputs(ctx context.Context, in *PublicInputsRequest, opts ...grpc.CallOption) (*PublicInputs, error) {
+	out := new(PublicInputs)
+	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetPublicInputs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for ZSLBox service
+
+type ZSLBoxServer interface {
+	// CreateShielding computes a zkSNARK and a note commitment for given note.
+	// Also returns a sendNullifier to ensure note.Rho (random) is unique
+	CreateShielding(context.Context, *Note) (*Shielding, error)
+	// CreateUnshielding computes a zkSNARK, nullifiers for given input, using Unshielding circuit.
+	// The zkSNARK is bound to input.Binding: it only verifies with the same binding.
+	CreateUnshielding(context.Context, *ShieldedInput) (*Unshielding, error)
+	// CreateShieldedTransfer takes N notes as inputs (known Sk) and M desired output notes, with the
+	// circuit of their arity: 1x1, 1x2, 2x2, 4x2 or 4x4 (see Circuit), and public values in and out
+	// and a public fee (inputs + vpubIn = outputs + vpubOut + fee) if any isn't 0.
+	// It returns the zkSNARK, bound to request.Binding, the spend nullifiers for the inputs, and the commitments &
+	// send nullifiers for outputs
+	CreateShieldedTransfer(context.Context, *ShieldedTransferRequest) (*ShieldedTransfer, error)
+	// VerifyShielding ensures that the provided Shielding proof is valid. It takes as input the zkSNARK,
+	// the send nullifier, commitment and value of the shielded note.
+	VerifyShielding(context.Context, *VerifyShieldingRequest) (*Result, error)
+	// VerifyUnshielding ensures that the provided Unshielding proof is valid. It takes as input the zkSNARK,
+	// the spend nullifier, the tree root and value of the shielded note.
+	VerifyUnshielding(context.Context, *VerifyUnshieldingRequest) (*Result, error)
+	// VerifyShieldedTransfer ensures that the provided shielded transfer proof is valid.
+	// It takes as input the zkSNARK, treeRoot, spend nullifiers for inputs, send nullifiers & commitments
+	// for outputs and the public values: their numbers, and whether a public value isn't 0, select the circuit
+	VerifyShieldedTransfer(context.Context, *VerifyShieldedTransferRequest) (*Result, error)
+	// VerifyBatch verifies many shielding, unshielding and shielded transfer proofs (ex: all the shielded
+	// transactions of a block) in parallel. It returns one result per request, in the same order;
+	// malformed requests are invalid.
+	VerifyBatch(context.Context, *VerifyBatchRequest) (*VerifyBatchResult, error)
+	// GetCommitment returns SHA256(note.Rho || note.Pk || note.Value || note.Asset)
+	// where note.Value is in little endian byte order and note.Asset is 32 bytes (32 zero bytes if empty)
+	GetCommitment(context.Context, *Note) (*Bytes, error)
+	// GetSendNullifier returns SHA256(0x00 || note.Rho)
+	GetSendNullifier(context.Context, *Note) (*Bytes, error)
+	// GetSpendNullifier returns SHA256(0x01 || shieldedInput.Rho || shieldedInput.Sk)
+	GetSpendNullifier(context.Context, *ShieldedInput) (*Bytes, error)
+	// GetNewAddress returns a tuple (Pk, Sk) where Pk is the paying (public) key and Sk is the secret key
+	GetNewAddress(context.Context, *Void) (*ZAddress, error)
+	// Sha256Compress applies SHA-256 to one input block, excluding the padding step specified in [NIST2015, Section 5.1]
+	Sha256Compress(context.Context, *Bytes) (*Bytes, error)
+	// GetVerifyingKey returns the verifying key of a circuit, raw (libsnark serialization) and structured,
+	// along with its fingerprint
+	GetVerifyingKey(context.Context, *VerifyingKeyRequest) (*VerifyingKey, error)
+	// GetPublicInputs returns the public inputs of a shielding, unshielding or shielded transfer, packed in
+	// field elements as the circuit verifiers expect them
+	GetPublicInputs(context.Context, *PublicInputsRequest) (*PublicInputs, error)
+}
+
+func RegisterZSLBoxServer(s *grpc.Server, srv ZSLBoxServer) {
+	s.RegisterService(&_ZSLBox_serviceDesc, srv)
+}
+
+func _ZSLBox_CreateShielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Note)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).CreateShielding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/CreateShielding",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).CreateShielding(ctx, req.(*Note))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_CreateUnshielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShieldedInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).CreateUnshielding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/CreateUnshielding",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).CreateUnshielding(ctx, req.(*ShieldedInput))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type zSLBoxClient struct {
-	cc *grpc.ClientConn
+func _ZSLBox_CreateShieldedTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShieldedTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).CreateShieldedTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/CreateShieldedTransfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).CreateShieldedTransfer(ctx, req.(*ShieldedTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func NewZSLBoxClient(cc *grpc.ClientConn) ZSLBoxClient {
-	return &zSLBoxClient{cc}
+func _ZSLBox_VerifyShielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyShieldingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).VerifyShielding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/VerifyShielding",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).VerifyShielding(ctx, req.(*VerifyShieldingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *zSLBoxClient) CreateShielding(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Shielding, error) {
-	out := new(Shielding)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/CreateShielding", in, out, c.cc, opts...)
-	if err != nil {
+func _ZSLBox_VerifyUnshielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyUnshieldingRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).VerifyUnshielding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/VerifyUnshielding",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).VerifyUnshielding(ctx, req.(*VerifyUnshieldingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *zSLBoxClient) CreateUnshielding(ctx context.Context, in *ShieldedInput, opts ...grpc.CallOption) (*Unshielding, error) {
-	out := new(Unshielding)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/CreateUnshielding", in, out, c.cc, opts...)
-	if err != nil {
+func _ZSLBox_VerifyShieldedTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyShieldedTransferRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).VerifyShieldedTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/VerifyShieldedTransfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).VerifyShieldedTransfer(ctx, req.(*VerifyShieldedTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *zSLBoxClient) CreateShieldedTransfer(ctx context.Context, in *ShieldedTransferRequest, opts ...grpc.CallOption) (*ShieldedTransfer, error) {
-	out := new(ShieldedTransfer)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/CreateShieldedTransfer", in, out, c.cc, opts...)
-	if err != nil {
+func _ZSLBox_VerifyBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyBatchRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).VerifyBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/VerifyBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).VerifyBatch(ctx, req.(*VerifyBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *zSLBoxClient) VerifyShielding(ctx context.Context, in *VerifyShieldingRequest, opts ...grpc.CallOption) (*Result, error) {
-	out := new(Result)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyShielding", in, out, c.cc, opts...)
-	if err != nil {
+func _ZSLBox_GetCommitment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Note)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).GetCommitment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/GetCommitment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).GetCommitment(ctx, req.(*Note))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_GetSendNullifier_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Note)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).GetSendNullifier(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/GetSendNullifier",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).GetSendNullifier(ctx, req.(*Note))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_GetSpendNullifier_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShieldedInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).GetSpendNullifier(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/GetSpendNullifier",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).GetSpendNullifier(ctx, req.(*ShieldedInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_GetNewAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).GetNewAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/GetNewAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).GetNewAddress(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_Sha256Compress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Bytes)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).Sha256Compress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/Sha256Compress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).Sha256Compress(ctx, req.(*Bytes))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_GetVerifyingKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyingKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).GetVerifyingKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/GetVerifyingKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).GetVerifyingKey(ctx, req.(*VerifyingKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZSLBox_GetPublicInputs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublicInputsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZSLBoxServer).GetPublicInputs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zsl.ZSLBox/GetPublicInputs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZSLBoxServer).GetPublicInputs(ctx, req.(*PublicInputsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ZSLBox_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "zsl.ZSLBox",
+	HandlerType: (*ZSLBoxServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateShielding",
+			Handler:    _ZSLBox_CreateShielding_Handler,
+		},
+		{
+			MethodName: "CreateUnshielding",
+			Handler:    _ZSLBox_CreateUnshielding_Handler,
+		},
+		{
+			MethodName: "CreateShieldedTransfer",
+			Handler:    _ZSLBox_CreateShieldedTransfer_Handler,
+		},
+		{
+			MethodName: "VerifyShielding",
+			Handler:    _ZSLBox_VerifyShielding_Handler,
+		},
+		{
+			MethodName: "VerifyUnshielding",
+			Handler:    _ZSLBox_VerifyUnshielding_Handler,
+		},
+		{
+			MethodName: "VerifyShieldedTransfer",
+			Handler:    _ZSLBox_VerifyShieldedTransfer_Handler,
+		},
+		{
+			MethodName: "VerifyBatch",
+			Handler:    _ZSLBox_VerifyBatch_Handler,
+		},
+		{
+			MethodName: "GetCommitment",
+			Handler:    _ZSLBox_GetCommitment_Handler,
+		},
+		{
+			MethodName: "GetSendNullifier",
+			Handler:    _ZSLBox_GetSendNullifier_Handler,
+		},
+		{
+			MethodName: "GetSpendNullifier",
+			Handler:    _ZSLBox_GetSpendNullifier_Handler,
+		},
+		{
+			MethodName: "GetNewAddress",
+			Handler:    _ZSLBox_GetNewAddress_Handler,
+		},
+		{
+			MethodName: "Sha256Compress",
+			Handler:    _ZSLBox_Sha256Compress_Handler,
+		},
+		{
+			MethodName: "GetVerifyingKey",
+			Handler:    _ZSLBox_GetVerifyingKey_Handler,
+		},
+		{
+			MethodName: "GetPublicInputs",
+			Handler:    _ZSLBox_GetPublicInputs_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "zslbox.proto",
 }
 
-func (c *zSLBoxClient) VerifyUnshielding(ctx context.Context, in *VerifyUnshieldingRequest, opts ...grpc.CallOption) (*Result, error) {
-	out := new(Result)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyUnshielding", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
+// Client API for KeySetAdmin service
 
-func (c *zSLBoxClient) VerifyShieldedTransfer(ctx context.Context, in *VerifyShieldedTransferRequest, opts ...grpc.CallOption) (*Result, error) {
-	out := new(Result)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/VerifyShieldedTransfer", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+type KeySetAdminClient interface {
+	// AddKeySet loads the existing key set of a key directory of the server and makes it the active key set
+	// once its keys are loaded: new proofs use it. Proofs of the other loaded key sets can still be verified.
+	// It fails with FailedPrecondition if the directory doesn't have the keys of all circuits and their
+	// manifest: keys are only generated at startup.
+	AddKeySet(ctx context.Context, in *AddKeySetRequest, opts ...grpc.CallOption) (*KeySet, error)
+	// RetireKeySet unloads a key set: its proofs can't be verified anymore. The active key set can't be retired.
+	RetireKeySet(ctx context.Context, in *KeySetRequest, opts ...grpc.CallOption) (*KeySet, error)
+	// ListKeySets returns the loaded key sets, the active one last
+	ListKeySets(ctx context.Context, in *Void, opts ...grpc.CallOption) (*KeySetList, error)
 }
 
-func (c *zSLBoxClient) GetCommitment(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Bytes, error) {
-	out := new(Bytes)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetCommitment", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+type keySetAdminClient struct {
+	cc *grpc.ClientConn
 }
 
-func (c *zSLBoxClient) GetSendNullifier(ctx context.Context, in *Note, opts ...grpc.CallOption) (*Bytes, error) {
-	out := new(Bytes)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetSendNullifier", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func NewKeySetAdminClient(cc *grpc.ClientConn) KeySetAdminClient {
+	return &keySetAdminClient{cc}
 }
 
-func (c *zSLBoxClient) GetSpendNullifier(ctx context.Context, in *ShieldedInput, opts ...grpc.CallOption) (*Bytes, error) {
-	out := new(Bytes)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetSpendNullifier", in, out, c.cc, opts...)
+func (c *keySetAdminClient) AddKeySet(ctx context.Context, in *AddKeySetRequest, opts ...grpc.CallOption) (*KeySet, error) {
+	out := new(KeySet)
+	err := grpc.Invoke(ctx, "/zsl.KeySetAdmin/AddKeySet", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *zSLBoxClient) GetNewAddress(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ZAddress, error) {
-	out := new(ZAddress)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/GetNewAddress", in, out, c.cc, opts...)
+func (c *keySetAdminClient) RetireKeySet(ctx context.Context, in *KeySetRequest, opts ...grpc.CallOption) (*KeySet, error) {
+	out := new(KeySet)
+	err := grpc.Invoke(ctx, "/zsl.KeySetAdmin/RetireKeySet", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *zSLBoxClient) Sha256Compress(ctx context.Context, in *Bytes, opts ...grpc.CallOption) (*Bytes, error) {
-	out := new(Bytes)
-	err := grpc.Invoke(ctx, "/zsl.ZSLBox/Sha256Compress", in, out, c.cc, opts...)
+func (c *keySetAdminClient) ListKeySets(ctx context.Context, in *Void, opts ...grpc.CallOption) (*KeySetList, error) {
+	out := new(KeySetList)
+	err := grpc.Invoke(ctx, "/zsl.KeySetAdmin/ListKeySets", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// Server API for ZSLBox service
+// Server API for KeySetAdmin service
 
-type ZSLBoxServer interface {
-	// CreateShielding computes a zkSNARK and a note commitment for given note.
-	// Also returns a sendNullifier to ensure note.Rho (random) is unique
-	CreateShielding(context.Context, *Note) (*Shielding, error)
-	// CreateUnshielding computes a zkSNARK, nullifiers for given input, using Unshielding circuit
-	CreateUnshielding(context.Context, *ShieldedInput) (*Unshielding, error)
-	// CreateShieldedTransfer takes 2 notes as inputs (known Sk) and 2 desired output notes.
-	// It returns the zkSNARK, the spend nullifiers for the inputs, and the commitments & send nullifiers for outputs
-	CreateShieldedTransfer(context.Context, *ShieldedTransferRequest) (*ShieldedTransfer, error)
-	// VerifyShielding ensures that the provided Shielding proof is valid. It takes as input the zkSNARK,
-	// the send nullifier, commitment and value of the shielded note.
-	VerifyShielding(context.Context, *VerifyShieldingRequest) (*Result, error)
-	// VerifyUnshielding ensures that the provided Unshielding proof is valid. It takes as input the zkSNARK,
-	// the spend nullifier, the tree root and value of the shielded note.
-	VerifyUnshielding(context.Context, *VerifyUnshieldingRequest) (*Result, error)
-	// VerifyShieldedTransfer ensures that the provided shielded transfer proof is valid.
-	// It takes as input the zkSNARK, treeRoot, spend nullifiers for inputs and send nullifiers & commitments
-	// for outputs
-	VerifyShieldedTransfer(context.Context, *VerifyShieldedTransferRequest) (*Result, error)
-	// GetCommitment returns SHA256(note.Rho || note.Pk || note.Value)
-	// where note.Value is in little endian byte order
-	GetCommitment(context.Context, *Note) (*Bytes, error)
-	// GetSendNullifier returns SHA256(0x00 || note.Rho)
-	GetSendNullifier(context.Context, *Note) (*Bytes, error)
-	// GetSpendNullifier returns SHA256(0x01 || shieldedInput.Rho || shieldedInput.Sk)
-	GetSpendNullifier(context.Context, *ShieldedInput) (*Bytes, error)
-	// GetNewAddress returns a tuple (Pk, Sk) where Pk is the paying (public) key and Sk is the secret key
-	GetNewAddress(context.Context, *Void) (*ZAddress, error)
-	// Sha256Compress applies SHA-256 to one input block, excluding the padding step specified in [NIST2015, Section 5.1]
-	Sha256Compress(context.Context, *Bytes) (*Bytes, error)
+type KeySetAdminServer interface {
+	// AddKeySet loads the existing key set of a key directory of the server and makes it the active key set
+	// once its keys are loaded: new proofs use it. Proofs of the other loaded key sets can still be verified.
+	// It fails with FailedPrecondition if the directory doesn't have the keys of all circuits and their
+	// manifest: keys are only generated at startup.
+	AddKeySet(context.Context, *AddKeySetRequest) (*KeySet, error)
+	// RetireKeySet unloads a key set: its proofs can't be verified anymore. The active key set can't be retired.
+	RetireKeySet(context.Context, *KeySetRequest) (*KeySet, error)
+	// ListKeySets returns the loaded key sets, the active one last
+	ListKeySets(context.Context, *Void) (*KeySetList, error)
 }
 
-func RegisterZSLBoxServer(s *grpc.Server, srv ZSLBoxServer) {
-	s.RegisterService(&_ZSLBox_serviceDesc, srv)
+func RegisterKeySetAdminServer(s *grpc.Server, srv KeySetAdminServer) {
+	s.RegisterService(&_KeySetAdmin_serviceDesc, srv)
 }
 
-func _ZSLBox_CreateShielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Note)
+func _KeySetAdmin_AddKeySet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddKeySetRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).CreateShielding(ctx, in)
+		return srv.(KeySetAdminServer).AddKeySet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/CreateShielding",
+		FullMethod: "/zsl.KeySetAdmin/AddKeySet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).CreateShielding(ctx, req.(*Note))
+		return srv.(KeySetAdminServer).AddKeySet(ctx, req.(*AddKeySetRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_CreateUnshielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ShieldedInput)
+func _KeySetAdmin_RetireKeySet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeySetRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).CreateUnshielding(ctx, in)
+		return srv.(KeySetAdminServer).RetireKeySet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/CreateUnshielding",
+		FullMethod: "/zsl.KeySetAdmin/RetireKeySet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).CreateUnshielding(ctx, req.(*ShieldedInput))
+		return srv.(KeySetAdminServer).RetireKeySet(ctx, req.(*KeySetRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_CreateShieldedTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ShieldedTransferRequest)
+func _KeySetAdmin_ListKeySets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).CreateShieldedTransfer(ctx, in)
+		return srv.(KeySetAdminServer).ListKeySets(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/CreateShieldedTransfer",
+		FullMethod: "/zsl.KeySetAdmin/ListKeySets",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).CreateShieldedTransfer(ctx, req.(*ShieldedTransferRequest))
+		return srv.(KeySetAdminServer).ListKeySets(ctx, req.(*Void))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_VerifyShielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VerifyShieldingRequest)
-	if err := dec(in); err != nil {
+var _KeySetAdmin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "zsl.KeySetAdmin",
+	HandlerType: (*KeySetAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddKeySet",
+			Handler:    _KeySetAdmin_AddKeySet_Handler,
+		},
+		{
+			MethodName: "RetireKeySet",
+			Handler:    _KeySetAdmin_RetireKeySet_Handler,
+		},
+		{
+			MethodName: "ListKeySets",
+			Handler:    _KeySetAdmin_ListKeySets_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "zslbox.proto",
+}
+
+// Client API for NullifierRegistry service
+
+type NullifierRegistryClient interface {
+	// CheckNullifiers returns, for each nullifier, whether it was already seen
+	CheckNullifiers(ctx context.Context, in *Nullifiers, opts ...grpc.CallOption) (*NullifiersResult, error)
+	// CommitNullifiers records the nullifiers, all or none: if one was already seen (or is twice in the
+	// request), it records none and committed is false
+	CommitNullifiers(ctx context.Context, in *Nullifiers, opts ...grpc.CallOption) (*NullifiersResult, error)
+}
+
+type nullifierRegistryClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewNullifierRegistryClient(cc *grpc.ClientConn) NullifierRegistryClient {
+	return &nullifierRegistryClient{cc}
+}
+
+func (c *nullifierRegistryClient) CheckNullifiers(ctx context.Context, in *Nullifiers, opts ...grpc.CallOption) (*NullifiersResult, error) {
+	out := new(NullifiersResult)
+	err := grpc.Invoke(ctx, "/zsl.NullifierRegistry/CheckNullifiers", in, out, c.cc, opts...)
+	if err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(ZSLBoxServer).VerifyShielding(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/VerifyShielding",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).VerifyShielding(ctx, req.(*VerifyShieldingRequest))
+	return out, nil
+}
+
+func (c *nullifierRegistryClient) CommitNullifiers(ctx context.Context, in *Nullifiers, opts ...grpc.CallOption) (*NullifiersResult, error) {
+	out := new(NullifiersResult)
+	err := grpc.Invoke(ctx, "/zsl.NullifierRegistry/CommitNullifiers", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return interceptor(ctx, in, info, handler)
+	return out, nil
 }
 
-func _ZSLBox_VerifyUnshielding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VerifyUnshieldingRequest)
+// Server API for NullifierRegistry service
+
+type NullifierRegistryServer interface {
+	// CheckNullifiers returns, for each nullifier, whether it was already seen
+	CheckNullifiers(context.Context, *Nullifiers) (*NullifiersResult, error)
+	// CommitNullifiers records the nullifiers, all or none: if one was already seen (or is twice in the
+	// request), it records none and committed is false
+	CommitNullifiers(context.Context, *Nullifiers) (*NullifiersResult, error)
+}
+
+func RegisterNullifierRegistryServer(s *grpc.Server, srv NullifierRegistryServer) {
+	s.RegisterService(&_NullifierRegistry_serviceDesc, srv)
+}
+
+func _NullifierRegistry_CheckNullifiers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Nullifiers)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).VerifyUnshielding(ctx, in)
+		return srv.(NullifierRegistryServer).CheckNullifiers(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/VerifyUnshielding",
+		FullMethod: "/zsl.NullifierRegistry/CheckNullifiers",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).VerifyUnshielding(ctx, req.(*VerifyUnshieldingRequest))
+		return srv.(NullifierRegistryServer).CheckNullifiers(ctx, req.(*Nullifiers))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_VerifyShieldedTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VerifyShieldedTransferRequest)
+func _NullifierRegistry_CommitNullifiers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Nullifiers)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).VerifyShieldedTransfer(ctx, in)
+		return srv.(NullifierRegistryServer).CommitNullifiers(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/VerifyShieldedTransfer",
+		FullMethod: "/zsl.NullifierRegistry/CommitNullifiers",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).VerifyShieldedTransfer(ctx, req.(*VerifyShieldedTransferRequest))
+		return srv.(NullifierRegistryServer).CommitNullifiers(ctx, req.(*Nullifiers))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_GetCommitment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Note)
-	if err := dec(in); err != nil {
+var _NullifierRegistry_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "zsl.NullifierRegistry",
+	HandlerType: (*NullifierRegistryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckNullifiers",
+			Handler:    _NullifierRegistry_CheckNullifiers_Handler,
+		},
+		{
+			MethodName: "CommitNullifiers",
+			Handler:    _NullifierRegistry_CommitNullifiers_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "zslbox.proto",
+}
+
+// Client API for CommitmentTree service
+
+type CommitmentTreeClient interface {
+	// AppendCommitments appends commitments to the tree, all or none: fails with AlreadyExists if one is
+	// already in the tree (or twice in the request), with ResourceExhausted if they don't fit in it. The first
+	// commitment has index size - len(commitments) in the returned tree. Only served on -admin_addr with
+	// -admin (PermissionDenied otherwise).
+	AppendCommitments(ctx context.Context, in *Commitments, opts ...grpc.CallOption) (*TreeState, error)
+	// GetRoot returns the root of the tree
+	GetRoot(ctx context.Context, in *Void, opts ...grpc.CallOption) (*TreeState, error)
+	// GetRootAt returns the root of the tree when it had its first size commitments (NotFound if it has less)
+	GetRootAt(ctx context.Context, in *RootAtRequest, opts ...grpc.CallOption) (*TreeState, error)
+	// GetWitness returns the tree index and tree path of a commitment (NotFound if it isn't in the tree),
+	// for a ShieldedInput
+	GetWitness(ctx context.Context, in *WitnessRequest, opts ...grpc.CallOption) (*Witness, error)
+}
+
+type commitmentTreeClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewCommitmentTreeClient(cc *grpc.ClientConn) CommitmentTreeClient {
+	return &commitmentTreeClient{cc}
+}
+
+func (c *commitmentTreeClient) AppendCommitments(ctx context.Context, in *Commitments, opts ...grpc.CallOption) (*TreeState, error) {
+	out := new(TreeState)
+	err := grpc.Invoke(ctx, "/zsl.CommitmentTree/AppendCommitments", in, out, c.cc, opts...)
+	if err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(ZSLBoxServer).GetCommitment(ctx, in)
+	return out, nil
+}
+
+func (c *commitmentTreeClient) GetRoot(ctx context.Context, in *Void, opts ...grpc.CallOption) (*TreeState, error) {
+	out := new(TreeState)
+	err := grpc.Invoke(ctx, "/zsl.CommitmentTree/GetRoot", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/GetCommitment",
+	return out, nil
+}
+
+func (c *commitmentTreeClient) GetRootAt(ctx context.Context, in *RootAtRequest, opts ...grpc.CallOption) (*TreeState, error) {
+	out := new(TreeState)
+	err := grpc.Invoke(ctx, "/zsl.CommitmentTree/GetRootAt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).GetCommitment(ctx, req.(*Note))
+	return out, nil
+}
+
+func (c *commitmentTreeClient) GetWitness(ctx context.Context, in *WitnessRequest, opts ...grpc.CallOption) (*Witness, error) {
+	out := new(Witness)
+	err := grpc.Invoke(ctx, "/zsl.CommitmentTree/GetWitness", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return interceptor(ctx, in, info, handler)
+	return out, nil
 }
 
-func _ZSLBox_GetSendNullifier_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Note)
+// Server API for CommitmentTree service
+
+type CommitmentTreeServer interface {
+	// AppendCommitments appends commitments to the tree, all or none: fails with AlreadyExists if one is
+	// already in the tree (or twice in the request), with ResourceExhausted if they don't fit in it. The first
+	// commitment has index size - len(commitments) in the returned tree. Only served on -admin_addr with
+	// -admin (PermissionDenied otherwise).
+	AppendCommitments(context.Context, *Commitments) (*TreeState, error)
+	// GetRoot returns the root of the tree
+	GetRoot(context.Context, *Void) (*TreeState, error)
+	// GetRootAt returns the root of the tree when it had its first size commitments (NotFound if it has less)
+	GetRootAt(context.Context, *RootAtRequest) (*TreeState, error)
+	// GetWitness returns the tree index and tree path of a commitment (NotFound if it isn't in the tree),
+	// for a ShieldedInput
+	GetWitness(context.Context, *WitnessRequest) (*Witness, error)
+}
+
+func RegisterCommitmentTreeServer(s *grpc.Server, srv CommitmentTreeServer) {
+	s.RegisterService(&_CommitmentTree_serviceDesc, srv)
+}
+
+func _CommitmentTree_AppendCommitments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Commitments)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).GetSendNullifier(ctx, in)
+		return srv.(CommitmentTreeServer).AppendCommitments(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/GetSendNullifier",
+		FullMethod: "/zsl.CommitmentTree/AppendCommitments",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).GetSendNullifier(ctx, req.(*Note))
+		return srv.(CommitmentTreeServer).AppendCommitments(ctx, req.(*Commitments))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_GetSpendNullifier_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ShieldedInput)
+func _CommitmentTree_GetRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).GetSpendNullifier(ctx, in)
+		return srv.(CommitmentTreeServer).GetRoot(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/GetSpendNullifier",
+		FullMethod: "/zsl.CommitmentTree/GetRoot",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).GetSpendNullifier(ctx, req.(*ShieldedInput))
+		return srv.(CommitmentTreeServer).GetRoot(ctx, req.(*Void))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_GetNewAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Void)
+func _CommitmentTree_GetRootAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RootAtRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).GetNewAddress(ctx, in)
+		return srv.(CommitmentTreeServer).GetRootAt(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/GetNewAddress",
+		FullMethod: "/zsl.CommitmentTree/GetRootAt",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).GetNewAddress(ctx, req.(*Void))
+		return srv.(CommitmentTreeServer).GetRootAt(ctx, req.(*RootAtRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ZSLBox_Sha256Compress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Bytes)
+func _CommitmentTree_GetWitness_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WitnessRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ZSLBoxServer).Sha256Compress(ctx, in)
+		return srv.(CommitmentTreeServer).GetWitness(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/zsl.ZSLBox/Sha256Compress",
+		FullMethod: "/zsl.CommitmentTree/GetWitness",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZSLBoxServer).Sha256Compress(ctx, req.(*Bytes))
+		return srv.(CommitmentTreeServer).GetWitness(ctx, req.(*WitnessRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _ZSLBox_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "zsl.ZSLBox",
-	HandlerType: (*ZSLBoxServer)(nil),
+var _CommitmentTree_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "zsl.CommitmentTree",
+	HandlerType: (*CommitmentTreeServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "CreateShielding",
-			Handler:    _ZSLBox_CreateShielding_Handler,
-		},
-		{
-			MethodName: "CreateUnshielding",
-			Handler:    _ZSLBox_CreateUnshielding_Handler,
-		},
-		{
-			MethodName: "CreateShieldedTransfer",
-			Handler:    _ZSLBox_CreateShieldedTransfer_Handler,
-		},
-		{
-			MethodName: "VerifyShielding",
-			Handler:    _ZSLBox_VerifyShielding_Handler,
-		},
-		{
-			MethodName: "VerifyUnshielding",
-			Handler:    _ZSLBox_VerifyUnshielding_Handler,
-		},
-		{
-			MethodName: "VerifyShieldedTransfer",
-			Handler:    _ZSLBox_VerifyShieldedTransfer_Handler,
-		},
-		{
-			MethodName: "GetCommitment",
-			Handler:    _ZSLBox_GetCommitment_Handler,
-		},
-		{
-			MethodName: "GetSendNullifier",
-			Handler:    _ZSLBox_GetSendNullifier_Handler,
+			MethodName: "AppendCommitments",
+			Handler:    _CommitmentTree_AppendCommitments_Handler,
 		},
 		{
-			MethodName: "GetSpendNullifier",
-			Handler:    _ZSLBox_GetSpendNullifier_Handler,
+			MethodName: "GetRoot",
+			Handler:    _CommitmentTree_GetRoot_Handler,
 		},
 		{
-			MethodName: "GetNewAddress",
-			Handler:    _ZSLBox_GetNewAddress_Handler,
+			MethodName: "GetRootAt",
+			Handler:    _CommitmentTree_GetRootAt_Handler,
 		},
 		{
-			MethodName: "Sha256Compress",
-			Handler:    _ZSLBox_Sha256Compress_Handler,
+			MethodName: "GetWitness",
+			Handler:    _CommitmentTree_GetWitness_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -882,48 +2407,122 @@ var _ZSLBox_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("zslbox.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 681 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x5d, 0x4f, 0xd4, 0x40,
-	0x14, 0x4d, 0xb7, 0x65, 0x61, 0xef, 0x7e, 0xb0, 0x4c, 0x14, 0x9b, 0x15, 0xcc, 0xa6, 0x1a, 0xb2,
-	0x12, 0xc2, 0xc3, 0x12, 0x4d, 0xd4, 0x44, 0x03, 0x3c, 0x10, 0x12, 0x25, 0x66, 0x56, 0x79, 0x20,
-	0xbe, 0x14, 0x3b, 0xb0, 0x95, 0x6e, 0xa7, 0x74, 0xa6, 0x0a, 0xfb, 0x60, 0xe2, 0xab, 0xbf, 0xc1,
-	0xdf, 0xe7, 0xef, 0x30, 0x33, 0x6d, 0xb7, 0x33, 0x6d, 0x49, 0x48, 0x7c, 0x9b, 0x7b, 0xe7, 0xec,
-	0xbd, 0xf7, 0x9c, 0x39, 0xb7, 0x0b, 0x9d, 0x39, 0x0b, 0xce, 0xe9, 0xcd, 0x6e, 0x14, 0x53, 0x4e,
-	0x91, 0x39, 0x67, 0x81, 0xf3, 0xcb, 0x80, 0xee, 0x64, 0xea, 0x93, 0xc0, 0x23, 0xde, 0x71, 0x18,
-	0x25, 0x1c, 0xf5, 0xa0, 0xc1, 0xae, 0x6c, 0x63, 0x68, 0x8c, 0x3a, 0xb8, 0xc1, 0xae, 0x50, 0x1f,
-	0xcc, 0x78, 0x4a, 0xed, 0x86, 0x4c, 0x88, 0x23, 0x7a, 0x00, 0x4b, 0xdf, 0xdd, 0x20, 0x21, 0xb6,
-	0x39, 0x34, 0x46, 0x16, 0x4e, 0x03, 0xb4, 0x01, 0x2d, 0x1e, 0x13, 0x72, 0x1c, 0x7a, 0xe4, 0xc6,
-	0xb6, 0xe4, 0x4d, 0x91, 0x40, 0x03, 0x58, 0x11, 0xc1, 0x47, 0x97, 0x4f, 0xed, 0xa5, 0xa1, 0x39,
-	0xea, 0xe0, 0x45, 0xec, 0xbc, 0x05, 0xeb, 0x84, 0x72, 0x22, 0x3a, 0x47, 0x8b, 0xce, 0xd1, 0xbd,
-	0x3b, 0x3b, 0xdf, 0xe0, 0x51, 0x4e, 0xe1, 0x53, 0xec, 0x86, 0xec, 0x82, 0xc4, 0x98, 0x5c, 0x27,
-	0x84, 0x71, 0xb4, 0x0d, 0x4d, 0x5f, 0xb0, 0x62, 0xb6, 0x31, 0x34, 0x47, 0xed, 0x31, 0xda, 0x9d,
-	0xb3, 0x60, 0x57, 0x23, 0x8c, 0x33, 0x04, 0x7a, 0x0a, 0xcb, 0x34, 0xe1, 0x12, 0xdc, 0x90, 0xe0,
-	0x96, 0x04, 0x8b, 0xd1, 0x70, 0x7e, 0xe3, 0xfc, 0x84, 0xcd, 0x53, 0x12, 0xfb, 0x17, 0xb7, 0x77,
-	0x75, 0xdc, 0x87, 0x3e, 0x2b, 0x5d, 0x49, 0x4a, 0xed, 0xf1, 0x43, 0xad, 0xf7, 0xe2, 0x77, 0x15,
-	0x78, 0xae, 0x15, 0xa6, 0x94, 0x67, 0xe4, 0x17, 0xb1, 0xf3, 0xc7, 0x80, 0x7e, 0xb9, 0x84, 0x90,
-	0x85, 0x85, 0x6e, 0x9c, 0x6b, 0x97, 0x06, 0x68, 0x04, 0xab, 0x2c, 0x22, 0xa1, 0x77, 0x92, 0x04,
-	0x81, 0x7f, 0xe1, 0x93, 0x38, 0xe5, 0xd5, 0xc1, 0xe5, 0x34, 0xda, 0x82, 0x1e, 0xd3, 0x81, 0xa6,
-	0x04, 0x96, 0xb2, 0x68, 0x08, 0xed, 0xaf, 0x74, 0x36, 0xf3, 0xf9, 0x8c, 0x84, 0x9c, 0xd9, 0x96,
-	0x04, 0xa9, 0x29, 0xe7, 0x0b, 0xac, 0xab, 0xf2, 0xf8, 0xe1, 0x65, 0xae, 0xcb, 0x0e, 0xb4, 0x58,
-	0x9e, 0xcb, 0x04, 0xe9, 0x29, 0x82, 0x08, 0x64, 0x01, 0x28, 0x1e, 0xba, 0xa1, 0x3e, 0xf4, 0x25,
-	0xb4, 0x26, 0x2a, 0xa4, 0x86, 0xf4, 0x13, 0x80, 0x62, 0x9e, 0x4c, 0x3d, 0x25, 0x83, 0x9e, 0x41,
-	0x57, 0x23, 0x25, 0x9d, 0xd4, 0xc1, 0x7a, 0xd2, 0xf9, 0x6d, 0x80, 0x9d, 0xf2, 0xf8, 0x1c, 0xb2,
-	0x32, 0x93, 0xfa, 0xc6, 0x42, 0x43, 0x4d, 0xd6, 0xac, 0x79, 0x29, 0xab, 0x3d, 0xae, 0xa9, 0x3f,
-	0x6e, 0xc1, 0xda, 0x52, 0x59, 0x5f, 0x43, 0x5b, 0x99, 0xe2, 0x3f, 0xdb, 0xdf, 0x8f, 0xff, 0x36,
-	0xac, 0x9c, 0xed, 0x7b, 0x5e, 0x4c, 0x18, 0xab, 0x7c, 0x0f, 0xd2, 0x2d, 0x6d, 0xe4, 0x5b, 0xea,
-	0x6c, 0xc2, 0xd2, 0xc1, 0x2d, 0x27, 0x4c, 0x0c, 0x76, 0x2e, 0x0e, 0xf9, 0x60, 0x32, 0x70, 0x5e,
-	0x43, 0x13, 0x13, 0x96, 0x04, 0x1c, 0xad, 0x43, 0x33, 0x96, 0x27, 0x09, 0x58, 0xc1, 0x59, 0x84,
-	0x6c, 0x58, 0x9e, 0x11, 0xc6, 0xdc, 0xcb, 0xf4, 0xb5, 0x5b, 0x38, 0x0f, 0x9d, 0x26, 0x58, 0xa7,
-	0xd4, 0xf7, 0xc6, 0x7f, 0x2d, 0x68, 0x9e, 0x4d, 0xde, 0x1f, 0xd0, 0x1b, 0xb4, 0x03, 0xab, 0x87,
-	0x31, 0x71, 0x39, 0x29, 0x8c, 0x50, 0xac, 0xe9, 0xa0, 0xe4, 0x28, 0xf4, 0x0a, 0xd6, 0x52, 0xb4,
-	0x2a, 0x60, 0xcd, 0x37, 0x60, 0xd0, 0x97, 0x39, 0x15, 0xf5, 0x01, 0xd6, 0xd5, 0x46, 0xca, 0xb6,
-	0x6d, 0xd4, 0xef, 0x71, 0xea, 0x8e, 0x41, 0xfd, 0x96, 0xa3, 0x37, 0xb0, 0x5a, 0x5a, 0x0c, 0xf4,
-	0x58, 0x22, 0xeb, 0xd7, 0x65, 0xd0, 0x96, 0x97, 0x99, 0x72, 0xef, 0x60, 0xad, 0xe2, 0x46, 0xb4,
-	0xa9, 0xfc, 0xbc, 0xea, 0x52, 0xbd, 0xc0, 0xb1, 0xbe, 0x96, 0xca, 0x5c, 0x4e, 0x65, 0x88, 0x2a,
-	0x25, 0xad, 0xd4, 0x16, 0x74, 0x8f, 0x08, 0x3f, 0x2c, 0x36, 0x4a, 0x91, 0x1f, 0xe4, 0x31, 0x75,
-	0xc3, 0x73, 0xe8, 0x1f, 0x11, 0x3e, 0xd1, 0xcc, 0x77, 0x07, 0x74, 0x0f, 0xd6, 0x04, 0x54, 0x37,
-	0x6a, 0xdd, 0x2b, 0xe9, 0xf5, 0xc5, 0x1c, 0x27, 0xe4, 0x47, 0xee, 0xd3, 0xb4, 0xb8, 0xf0, 0xcb,
-	0xa0, 0x2b, 0x8f, 0x0b, 0x07, 0x8f, 0xa0, 0x37, 0x99, 0xba, 0xe3, 0x17, 0x2f, 0x0f, 0xe9, 0x2c,
-	0x92, 0x19, 0xa5, 0x90, 0x5a, 0xf4, 0xbc, 0x29, 0xff, 0x19, 0xf7, 0xfe, 0x05, 0x00, 0x00, 0xff,
-	0xff, 0x45, 0xb5, 0xba, 0x73, 0x29, 0x07, 0x00, 0x00,
+	// 1861 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x58, 0x4b, 0x8f, 0x1b, 0xc7,
+	0x11, 0x76, 0x73, 0xf8, 0x2c, 0xbe, 0x86, 0xbd, 0xf1, 0x6a, 0xc0, 0x48, 0x02, 0x31, 0x96, 0x84,
+	0xb5, 0x22, 0x48, 0x58, 0x4a, 0x36, 0x22, 0x1b, 0xb0, 0xc1, 0xa5, 0x9c, 0x35, 0x21, 0x85, 0x5e,
+	0x0c, 0x57, 0x8e, 0xb1, 0x87, 0x18, 0xb3, 0x64, 0x2f, 0x77, 0xb0, 0xe4, 0x90, 0x99, 0x69, 0x4a,
+	0xcb, 0x3d, 0x06, 0xc8, 0xc5, 0xb9, 0xe4, 0x9c, 0x1f, 0xa0, 0x7b, 0x4e, 0x39, 0xe7, 0x96, 0x5b,
+	0x7e, 0x43, 0x2e, 0x39, 0xe5, 0x94, 0x53, 0x8e, 0x41, 0x3f, 0x66, 0xa6, 0x7b, 0x38, 0xbb, 0xa1,
+	0x92, 0x00, 0xb9, 0x4d, 0x55, 0x7d, 0x55, 0x5d, 0x55, 0x5d, 0xdd, 0x55, 0x3d, 0x50, 0xbb, 0x0a,
+	0x67, 0xa7, 0x8b, 0xcb, 0xc7, 0xcb, 0x60, 0x41, 0x17, 0xd8, 0xb8, 0x0a, 0x67, 0xf6, 0x1f, 0x10,
+	0xd4, 0x47, 0xe7, 0x1e, 0x99, 0x4d, 0xc8, 0x64, 0xe0, 0x2f, 0x57, 0x14, 0x37, 0x20, 0x17, 0x5e,
+	0x58, 0xa8, 0x83, 0xf6, 0x6a, 0x4e, 0x2e, 0xbc, 0xc0, 0x26, 0x18, 0xc1, 0xf9, 0xc2, 0xca, 0x71,
+	0x06, 0xfb, 0xc4, 0x3f, 0x82, 0xc2, 0x1b, 0x77, 0xb6, 0x22, 0x96, 0xd1, 0x41, 0x7b, 0x79, 0x47,
+	0x10, 0xf8, 0x36, 0x54, 0x68, 0x40, 0xc8, 0xc0, 0x9f, 0x90, 0x4b, 0x2b, 0xcf, 0x25, 0x09, 0x03,
+	0xb7, 0xa1, 0xcc, 0x88, 0x23, 0x97, 0x9e, 0x5b, 0x85, 0x8e, 0xb1, 0x57, 0x73, 0x62, 0x9a, 0xd9,
+	0x73, 0xc3, 0x90, 0x50, 0xab, 0xc8, 0xd7, 0x10, 0x04, 0xb6, 0xa0, 0x74, 0xea, 0xf9, 0x13, 0xcf,
+	0x9f, 0x5a, 0x25, 0xce, 0x8f, 0x48, 0xfb, 0x18, 0xf2, 0xc3, 0x05, 0x25, 0xcc, 0xd3, 0x65, 0xec,
+	0xe9, 0x72, 0x7b, 0x4f, 0xe3, 0xf5, 0xf2, 0xca, 0x7a, 0xf6, 0x9f, 0x11, 0xdc, 0x8a, 0x32, 0x71,
+	0x1c, 0xb8, 0x7e, 0x78, 0x46, 0x02, 0x87, 0xfc, 0x6a, 0x45, 0x42, 0x8a, 0x1f, 0x42, 0xd1, 0x63,
+	0xc9, 0x09, 0x2d, 0xd4, 0x31, 0xf6, 0xaa, 0x5d, 0xfc, 0xf8, 0x2a, 0x9c, 0x3d, 0xd6, 0xf2, 0xe6,
+	0x48, 0x04, 0xfe, 0x08, 0x4a, 0x8b, 0x15, 0xe5, 0xe0, 0x1c, 0x07, 0x57, 0x38, 0x98, 0x79, 0xec,
+	0x44, 0x12, 0xbc, 0x0b, 0xc5, 0x37, 0xcb, 0xd5, 0xe9, 0xc0, 0x97, 0x9e, 0x49, 0x8a, 0x05, 0xcd,
+	0xbe, 0xbe, 0x59, 0x51, 0x99, 0xc2, 0x88, 0x54, 0xd3, 0x51, 0xd0, 0xd2, 0xc1, 0xc2, 0x3e, 0x23,
+	0x84, 0x27, 0x2f, 0xef, 0xb0, 0x4f, 0xfb, 0x1f, 0x08, 0xee, 0x7c, 0x4b, 0x02, 0xef, 0x6c, 0x7d,
+	0x5d, 0x40, 0x3d, 0x30, 0xc3, 0x94, 0x88, 0x27, 0xb2, 0xda, 0xfd, 0x50, 0x0b, 0x2d, 0xd6, 0xdb,
+	0x80, 0x47, 0x3b, 0xea, 0x2c, 0x16, 0x54, 0xa6, 0x3c, 0xa6, 0xff, 0x83, 0xf0, 0xe2, 0x3d, 0x29,
+	0x5c, 0x53, 0x03, 0xc5, 0xcc, 0xa0, 0x4b, 0x49, 0xd0, 0x7f, 0x43, 0x80, 0x45, 0xd0, 0x07, 0x2e,
+	0x1d, 0x9f, 0x47, 0x91, 0x7e, 0x0e, 0x20, 0x5c, 0xf7, 0xfc, 0x69, 0xb4, 0x7d, 0x3f, 0xe6, 0x31,
+	0xaa, 0x19, 0xf2, 0xfc, 0xa9, 0x54, 0x70, 0x14, 0x38, 0xee, 0x41, 0x6d, 0xe5, 0x2b, 0xea, 0x62,
+	0x43, 0xef, 0x28, 0xea, 0xaf, 0xfd, 0x30, 0x6d, 0x40, 0x53, 0xc1, 0x47, 0xd0, 0x4a, 0xa7, 0x2e,
+	0xb4, 0x0c, 0x6e, 0xc7, 0xde, 0x70, 0x63, 0x63, 0xa3, 0x9c, 0x4d, 0x65, 0xfb, 0x37, 0x08, 0x5a,
+	0x5a, 0xa0, 0xe1, 0x6a, 0x46, 0xf1, 0xdd, 0x8d, 0x38, 0xcb, 0x5a, 0x28, 0x76, 0x46, 0x28, 0xe5,
+	0x94, 0xaf, 0x8f, 0xae, 0xf3, 0xb5, 0x9c, 0xe5, 0xc7, 0xdf, 0x11, 0x98, 0x69, 0xb7, 0xd9, 0x3e,
+	0x86, 0xbe, 0x1b, 0x44, 0xc7, 0x52, 0x10, 0x78, 0x0f, 0x9a, 0xe1, 0x92, 0xf8, 0x93, 0xe1, 0x6a,
+	0x36, 0xf3, 0xce, 0x3c, 0x12, 0x88, 0xf5, 0x6b, 0x4e, 0x9a, 0x8d, 0x1f, 0x40, 0x23, 0xd4, 0x81,
+	0x06, 0x07, 0xa6, 0xb8, 0xb8, 0x03, 0xd5, 0xf1, 0x62, 0x3e, 0xf7, 0xe8, 0x9c, 0xf8, 0x34, 0xb4,
+	0xf2, 0x1c, 0xa4, 0xb2, 0xf0, 0x4f, 0xa1, 0xbe, 0x0c, 0x16, 0x6f, 0x3c, 0x7f, 0x3a, 0x5a, 0x87,
+	0x94, 0xcc, 0x79, 0x65, 0x35, 0xe4, 0xd1, 0x3d, 0x52, 0x25, 0x8e, 0x0e, 0x64, 0x31, 0x5c, 0x90,
+	0xf5, 0x60, 0xc2, 0x6b, 0xae, 0xe2, 0x08, 0xc2, 0x0e, 0x60, 0x37, 0xbb, 0x62, 0xf0, 0x23, 0xa8,
+	0xc4, 0x49, 0x94, 0xa7, 0xa8, 0xa1, 0x9c, 0x22, 0x86, 0x4c, 0x00, 0xc9, 0x9d, 0x94, 0xcb, 0xbc,
+	0x93, 0x0c, 0xf5, 0x4e, 0xfa, 0x23, 0x82, 0xca, 0x48, 0xd5, 0xcc, 0xc8, 0xed, 0x5d, 0x80, 0x24,
+	0x6c, 0x79, 0x12, 0x15, 0x0e, 0xbe, 0x07, 0x75, 0x2d, 0x77, 0x72, 0x05, 0x9d, 0xb9, 0x99, 0xad,
+	0xfc, 0x7b, 0x67, 0xab, 0xa0, 0x66, 0xeb, 0x87, 0x1c, 0x58, 0xd7, 0x9d, 0x90, 0x6b, 0x02, 0x61,
+	0x5b, 0xaf, 0x55, 0x83, 0x0c, 0x26, 0xc5, 0xd5, 0x2e, 0x1e, 0x23, 0x75, 0xf1, 0xc4, 0xc9, 0xcd,
+	0xab, 0xc9, 0xfd, 0x1f, 0x97, 0x42, 0xb2, 0x59, 0xa5, 0x6b, 0x2e, 0xab, 0xb2, 0xde, 0xb0, 0xfe,
+	0x84, 0xa0, 0xaa, 0xa4, 0xe1, 0xbf, 0x8c, 0xff, 0xff, 0xb3, 0xa1, 0x23, 0xd8, 0x11, 0xfb, 0xe9,
+	0xf9, 0xd3, 0x97, 0x64, 0x1d, 0x6d, 0xe5, 0x03, 0x28, 0x8d, 0xbd, 0x60, 0xbc, 0xf2, 0x28, 0x0f,
+	0xa6, 0xd1, 0xad, 0xf1, 0x05, 0xfa, 0x82, 0xe7, 0x44, 0xc2, 0xc4, 0x68, 0x4e, 0x35, 0x7a, 0x1f,
+	0x4a, 0x87, 0xfb, 0x47, 0x0b, 0xcf, 0xa7, 0xb8, 0x06, 0xe8, 0x92, 0x9b, 0xa8, 0x38, 0xe8, 0x92,
+	0x51, 0x6b, 0x09, 0x45, 0x6b, 0x0e, 0xeb, 0x6a, 0x30, 0x43, 0x83, 0x19, 0x02, 0xf6, 0xbb, 0x02,
+	0xd4, 0x54, 0x1f, 0xb7, 0x76, 0x8e, 0x0d, 0x0e, 0xee, 0xdb, 0x78, 0x70, 0x70, 0xdf, 0xb2, 0xeb,
+	0xe5, 0xcc, 0xf3, 0xa7, 0x24, 0x58, 0x06, 0x9e, 0x2f, 0xca, 0xac, 0xe2, 0xa8, 0xac, 0xcd, 0xfc,
+	0xd6, 0xde, 0x3b, 0xbf, 0x2d, 0xb5, 0xa6, 0xe4, 0xf8, 0xf4, 0x82, 0x2c, 0xe9, 0xb9, 0x85, 0x3b,
+	0x68, 0xaf, 0xee, 0x24, 0x0c, 0x6c, 0x43, 0x61, 0xea, 0xce, 0xe7, 0x2e, 0xaf, 0xb8, 0xaa, 0x8c,
+	0x43, 0xe6, 0xc4, 0x11, 0x22, 0x7c, 0x1b, 0x72, 0xde, 0xd8, 0xaa, 0x76, 0x8c, 0x04, 0x20, 0x72,
+	0xeb, 0xe4, 0xbc, 0x31, 0xbe, 0x07, 0x45, 0x77, 0xb6, 0x3c, 0x77, 0x7b, 0xbc, 0x10, 0xd2, 0x26,
+	0xa4, 0x2c, 0x46, 0x1d, 0x58, 0x05, 0x15, 0xb5, 0xaf, 0xa2, 0x0e, 0x62, 0x54, 0xdf, 0x2a, 0xaa,
+	0x28, 0xcd, 0x56, 0x1f, 0x3f, 0x02, 0xe0, 0x8e, 0x1d, 0x10, 0xea, 0xee, 0x5b, 0x65, 0x15, 0x29,
+	0xed, 0x29, 0x72, 0x0d, 0xdd, 0xb5, 0x2a, 0x19, 0x76, 0x15, 0x39, 0xbe, 0x0b, 0x46, 0xd0, 0x3f,
+	0xb1, 0x20, 0x03, 0xc6, 0x04, 0x2c, 0x9b, 0xc2, 0x57, 0x42, 0x5d, 0xab, 0xce, 0x0b, 0x24, 0x61,
+	0xb0, 0x6c, 0x4e, 0xc8, 0x8c, 0xba, 0x56, 0x23, 0x2b, 0x9b, 0x5c, 0xc4, 0x30, 0x5c, 0xc1, 0x6a,
+	0x66, 0x38, 0x2e, 0x44, 0xb8, 0x03, 0xf9, 0x53, 0xb6, 0x80, 0x99, 0x61, 0x86, 0x4b, 0xec, 0xbf,
+	0x22, 0xd8, 0x39, 0x5a, 0x9d, 0xce, 0xbc, 0x31, 0x1f, 0x12, 0xc3, 0xe8, 0xd8, 0x3c, 0xdf, 0x6c,
+	0x19, 0x37, 0x0e, 0x25, 0x09, 0x1a, 0x7f, 0x09, 0x55, 0xa5, 0x69, 0xf3, 0xa2, 0xfd, 0xb7, 0x23,
+	0x89, 0xaa, 0x81, 0x87, 0x19, 0xb3, 0x9f, 0xd1, 0x41, 0x5b, 0x0e, 0x24, 0x1b, 0xba, 0xf6, 0x10,
+	0x6a, 0x6a, 0x88, 0x5b, 0x9f, 0xba, 0xdd, 0x78, 0xa8, 0x16, 0x27, 0x58, 0x52, 0xf6, 0x3b, 0x04,
+	0xc5, 0x97, 0x64, 0x3d, 0x22, 0xca, 0xad, 0x81, 0xd4, 0xa3, 0xb2, 0x0b, 0xc5, 0x0b, 0xb2, 0x7e,
+	0xe1, 0x05, 0xf2, 0x86, 0x90, 0xd4, 0xe6, 0x91, 0x34, 0xb6, 0x3d, 0x92, 0xbb, 0x50, 0x74, 0xc7,
+	0xd4, 0x7b, 0x23, 0xfa, 0x46, 0xd9, 0x91, 0x94, 0x7e, 0x28, 0x0b, 0xa9, 0x43, 0x69, 0xff, 0x1a,
+	0x81, 0xd9, 0x9b, 0x4c, 0x84, 0xaf, 0xd1, 0xce, 0x26, 0xce, 0xa1, 0x9b, 0x9d, 0xcb, 0x6d, 0xeb,
+	0x9c, 0xe6, 0x84, 0x91, 0x76, 0xe2, 0x3e, 0xd4, 0x75, 0x07, 0x32, 0x73, 0x66, 0x3f, 0x05, 0x10,
+	0xb0, 0x57, 0x5e, 0x48, 0xf1, 0x7d, 0x28, 0x5d, 0x70, 0x2a, 0x9a, 0x88, 0xab, 0xdc, 0x0d, 0x69,
+	0x28, 0x92, 0xd9, 0xbf, 0x04, 0x50, 0x46, 0xae, 0x8c, 0x21, 0x0e, 0x6d, 0x3b, 0xc4, 0xe5, 0xb2,
+	0x86, 0x38, 0xfb, 0x04, 0xcc, 0x84, 0x92, 0x73, 0x2c, 0xeb, 0x8d, 0x4b, 0x36, 0xc9, 0x88, 0x11,
+	0x56, 0x10, 0x18, 0x43, 0x7e, 0x15, 0x92, 0x89, 0x9c, 0x5a, 0xf9, 0x37, 0xcb, 0x8b, 0x18, 0x73,
+	0x28, 0x99, 0xf0, 0xbc, 0x94, 0x9d, 0x84, 0x61, 0x3f, 0x81, 0x6a, 0x5f, 0x99, 0x06, 0x53, 0xf3,
+	0x22, 0xda, 0x98, 0x17, 0xed, 0x01, 0x54, 0x8e, 0x03, 0x42, 0x46, 0xd4, 0xa5, 0x84, 0xad, 0x17,
+	0xb0, 0xf9, 0x42, 0x34, 0x68, 0xfe, 0xcd, 0x78, 0xa1, 0x77, 0x15, 0xcd, 0x6d, 0xfc, 0x9b, 0x79,
+	0x3b, 0x89, 0xf7, 0x25, 0xef, 0x08, 0xc2, 0xfe, 0x08, 0xea, 0x6c, 0x1a, 0xe9, 0xc5, 0x7b, 0x12,
+	0xa9, 0xa2, 0x44, 0xd5, 0x1e, 0x42, 0xe3, 0x17, 0x1e, 0xf5, 0x49, 0x18, 0x5f, 0x0a, 0xfa, 0x24,
+	0x87, 0x36, 0x26, 0x39, 0xed, 0x85, 0x9d, 0x4b, 0xbd, 0xb0, 0xed, 0xdf, 0x22, 0x28, 0x49, 0x83,
+	0x3a, 0x12, 0xdd, 0xf4, 0x16, 0xcf, 0xa5, 0xde, 0xe2, 0xba, 0x0f, 0xc6, 0x86, 0x0f, 0x51, 0x62,
+	0xf2, 0x19, 0x89, 0x29, 0x28, 0xd1, 0x3d, 0x84, 0xf2, 0x49, 0x6f, 0x32, 0x09, 0x98, 0x37, 0xe9,
+	0x3f, 0x0a, 0xe2, 0xdd, 0x9e, 0x8b, 0xde, 0xed, 0xf6, 0x1d, 0x28, 0x1c, 0xac, 0x29, 0x09, 0x59,
+	0x36, 0x4f, 0xd9, 0x47, 0x34, 0x17, 0x71, 0xc2, 0xfe, 0x0c, 0x8a, 0xb2, 0x36, 0x76, 0xa1, 0x18,
+	0xf0, 0x2f, 0x0e, 0x28, 0x3b, 0x92, 0x62, 0x93, 0xd7, 0x9c, 0x84, 0xa1, 0x3b, 0x25, 0xf2, 0x46,
+	0x88, 0x48, 0xbb, 0x08, 0xf9, 0x6f, 0x17, 0xde, 0xe4, 0xe1, 0x4f, 0xa0, 0xae, 0x9d, 0x31, 0x5c,
+	0x87, 0xca, 0xd1, 0xd1, 0xc9, 0xcb, 0xd1, 0xb0, 0xe7, 0xbc, 0x34, 0x3f, 0xc0, 0x55, 0x28, 0x1d,
+	0x3a, 0xdf, 0x1c, 0x7f, 0xbd, 0xff, 0xa9, 0x89, 0x1e, 0xfe, 0x13, 0x41, 0x49, 0xde, 0x56, 0x0c,
+	0x37, 0xfa, 0x7a, 0xf0, 0xd5, 0xab, 0x17, 0x83, 0xe1, 0xa1, 0xf9, 0x01, 0x6e, 0x42, 0xf5, 0xf5,
+	0x30, 0x61, 0x20, 0x5c, 0x83, 0xf2, 0xb1, 0xd3, 0x1b, 0x8e, 0x7e, 0xf6, 0x95, 0x63, 0xe6, 0xb0,
+	0x09, 0xb5, 0x88, 0xfa, 0x7e, 0xff, 0xbb, 0x7d, 0xd3, 0x48, 0x71, 0xba, 0x66, 0x5e, 0xe3, 0x3c,
+	0xfb, 0xae, 0x6b, 0x16, 0x52, 0x9c, 0x67, 0x66, 0x11, 0xef, 0x40, 0xf3, 0xe8, 0xf5, 0xc1, 0xab,
+	0x41, 0xff, 0xfb, 0xd8, 0x78, 0x09, 0xdf, 0x82, 0x9d, 0x14, 0x93, 0xaf, 0x51, 0xce, 0x16, 0x74,
+	0xcd, 0x4a, 0x96, 0x80, 0xad, 0x08, 0xd9, 0x82, 0x67, 0x66, 0xb5, 0xfb, 0xae, 0x08, 0xc5, 0x93,
+	0xd1, 0xab, 0x83, 0xc5, 0x25, 0x7e, 0x04, 0xcd, 0x7e, 0x40, 0x5c, 0x4a, 0x92, 0x07, 0x48, 0xf2,
+	0x27, 0xa3, 0x9d, 0x7a, 0xe0, 0xe0, 0xe7, 0xd0, 0x12, 0x68, 0x75, 0xce, 0xcd, 0xf8, 0x4d, 0xd2,
+	0x36, 0x39, 0x4f, 0x45, 0xfd, 0x1c, 0x76, 0xd5, 0x85, 0x94, 0xc7, 0xe4, 0xed, 0xec, 0x7f, 0x11,
+	0xe2, 0xb8, 0xb4, 0xb3, 0xff, 0x54, 0xe0, 0xcf, 0xa1, 0x99, 0x6a, 0xa2, 0xf8, 0xa6, 0xd6, 0xda,
+	0x16, 0x57, 0x9f, 0xac, 0xb0, 0x2f, 0xa3, 0xa7, 0xb5, 0xea, 0xe0, 0xcd, 0xcd, 0x55, 0x37, 0x30,
+	0xd0, 0x5f, 0x89, 0x8a, 0x5f, 0x5b, 0x34, 0x57, 0xdd, 0xd4, 0x17, 0x50, 0x55, 0x9e, 0xf9, 0xf8,
+	0x96, 0xa2, 0xaf, 0xfe, 0xe1, 0x68, 0xef, 0x6e, 0x0a, 0xb8, 0xfe, 0x03, 0xa8, 0x1f, 0x12, 0x9a,
+	0x5c, 0x82, 0xea, 0xf6, 0x01, 0xff, 0x14, 0xa7, 0xee, 0x63, 0x30, 0x0f, 0x09, 0x1d, 0x69, 0xaf,
+	0x87, 0x6b, 0xa0, 0x4f, 0xa1, 0xc5, 0xa0, 0xfa, 0x7b, 0x24, 0x6b, 0x97, 0x75, 0xfb, 0xcc, 0x8f,
+	0x21, 0x79, 0x1b, 0xdd, 0x07, 0xc2, 0x38, 0x3b, 0x97, 0xed, 0x3a, 0xff, 0x8c, 0x6f, 0x8a, 0x3d,
+	0x68, 0x8c, 0xce, 0xdd, 0xee, 0x27, 0x9f, 0xf6, 0x17, 0xf3, 0x25, 0xe7, 0x28, 0x86, 0x34, 0xa3,
+	0x5f, 0x40, 0xf3, 0x90, 0x50, 0x6d, 0xda, 0xb7, 0x94, 0x3c, 0x68, 0x8f, 0x94, 0x76, 0x6b, 0x43,
+	0x22, 0xf5, 0xb5, 0xb9, 0x45, 0xe8, 0x67, 0x4c, 0x6b, 0xed, 0xd6, 0x86, 0xa4, 0xfb, 0x7b, 0x04,
+	0x55, 0xd1, 0x2e, 0x7b, 0x93, 0xb9, 0xe7, 0xe3, 0x27, 0x50, 0x89, 0x47, 0x01, 0x2c, 0x2a, 0x33,
+	0x3d, 0x1a, 0xb4, 0xd5, 0x26, 0x8b, 0x9f, 0x40, 0xcd, 0x21, 0xd4, 0x0b, 0x88, 0xa4, 0xb1, 0x22,
+	0xcc, 0x54, 0xf8, 0x18, 0xaa, 0xac, 0x77, 0x0b, 0x4a, 0x4b, 0x62, 0x53, 0x81, 0x31, 0x48, 0xf7,
+	0x07, 0x04, 0xad, 0x78, 0x7f, 0x1c, 0x32, 0xf5, 0x42, 0x1a, 0xac, 0xf1, 0x73, 0x68, 0xf6, 0xcf,
+	0xc9, 0xf8, 0x42, 0x69, 0xd4, 0x42, 0x33, 0x61, 0xb4, 0x3f, 0x4c, 0x31, 0x64, 0x29, 0x7d, 0x06,
+	0xa6, 0xa8, 0xa3, 0xf7, 0xd7, 0xed, 0xfe, 0x05, 0x41, 0x23, 0x29, 0x42, 0xd6, 0x62, 0xf1, 0x27,
+	0xd0, 0xea, 0x2d, 0x59, 0x11, 0xa9, 0x1d, 0x5a, 0x5c, 0x0c, 0x0a, 0x47, 0xde, 0x31, 0x49, 0x53,
+	0xbe, 0x07, 0xa5, 0x43, 0x42, 0xf9, 0x3b, 0x5f, 0x89, 0x3e, 0x8d, 0x7a, 0x02, 0x15, 0x89, 0xea,
+	0x45, 0x59, 0xd5, 0x9a, 0x71, 0x86, 0x02, 0x1c, 0x12, 0x1a, 0xb5, 0xce, 0x1d, 0x2e, 0xd5, 0x3b,
+	0x73, 0xbb, 0xa6, 0x32, 0x4f, 0x8b, 0xfc, 0xff, 0xf9, 0xd3, 0x7f, 0x0d, 0x00, 0x4d, 0x6c, 0xe1,
+	0x32, 0x4f, 0x17, 0x00, 0x00,
 }